@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/alecthomas/kingpin"
+
+	"github.com/bojand/ghz/distributed"
+	"github.com/bojand/ghz/runner"
+)
+
+// agentCommand is the first argument to run ghz as an agent of a distributed test
+const agentCommand = "agent"
+
+// runAgent runs ghz as an agent serving the tests of a coordinator, until it fails
+func runAgent(args []string) {
+	app := kingpin.New("ghz agent", "Runs ghz as an agent of a distributed test. The coordinator is a ghz run with the --agents option.")
+	app.HelpFlag.Short('h')
+
+	listen := app.Flag("listen", "Address to listen on for the coordinator. Default is localhost:50060, use for example :50060 to listen on all interfaces, which requires --secret, --cert and --key.").
+		Default("localhost:50060").String()
+	secret := app.Flag("secret", "Shared secret the coordinator must send with --agents-secret. Can also be set with the GHZ_AGENT_SECRET environment variable.").
+		Envar("GHZ_AGENT_SECRET").PlaceHolder(" ").String()
+	cert := app.Flag("cert", "File containing the TLS certificate the agent serves with.").
+		PlaceHolder(" ").String()
+	key := app.Flag("key", "File containing the private key of the TLS certificate.").
+		PlaceHolder(" ").String()
+	debug := app.Flag("debug", "The path to debug log file.").PlaceHolder(" ").String()
+
+	kingpin.MustParse(app.Parse(args))
+
+	var logger runner.Logger
+	if *debug != "" {
+		l, err := createLogger(*debug)
+		app.FatalIfError(err, "")
+
+		defer func() {
+			_ = l.Sync()
+		}()
+
+		logger = l
+	}
+
+	var options []distributed.AgentOption
+	if *secret != "" {
+		options = append(options, distributed.WithAgentSecret(*secret))
+	}
+
+	if *cert != "" || *key != "" {
+		options = append(options, distributed.WithAgentCertificate(*cert, *key))
+	}
+
+	agent := distributed.NewAgent(logger, options...)
+
+	app.FatalIfError(agent.ListenAndServe(*listen), "")
+}
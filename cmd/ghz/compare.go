@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+
+	"github.com/bojand/ghz/printer"
+	"github.com/bojand/ghz/runner"
+)
+
+// compareCommand is the first argument to compare the reports of two runs
+const compareCommand = "compare"
+
+// runCompare compares the current report against the baseline report and prints the comparison.
+// It exits with the regression exit code if any of the metrics regressed.
+func runCompare(args []string) {
+	app := kingpin.New("ghz compare", "Compares the JSON report of a run against the JSON report of a baseline run.")
+	app.HelpFlag.Short('h')
+
+	baselinePath := app.Arg("baseline", "Path to the JSON report of the baseline run.").Required().String()
+	currentPath := app.Arg("current", "Path to the JSON report of the current run.").Required().String()
+	tolerances := app.Flag("tolerance", `Tolerated change of a metric in the form "<metric>=<value>". Can be repeated. Examples: --tolerance "p99=5%" --tolerance "latency=20%" --tolerance "rps=10%" --tolerance "error-rate=0.5".`).
+		PlaceHolder(" ").Strings()
+	format := app.Flag("format", "Output format. One of: summary, markdown, json, pretty, html. Default is summary.").
+		Short('O').Default("summary").PlaceHolder(" ").Enum("summary", "markdown", "json", "pretty", "html")
+	output := app.Flag("output", "Output path. If none provided stdout is used.").
+		Short('o').PlaceHolder(" ").String()
+
+	kingpin.MustParse(app.Parse(args))
+
+	baseline, err := readReportFile(*baselinePath)
+	app.FatalIfError(err, "")
+
+	current, err := readReportFile(*currentPath)
+	app.FatalIfError(err, "")
+
+	comparison, err := runner.CompareReports(baseline, current, *tolerances...)
+	app.FatalIfError(err, "")
+
+	out := os.Stdout
+	if path := strings.TrimSpace(*output); path != "" {
+		f, err := os.Create(path)
+		app.FatalIfError(err, "")
+
+		defer func() {
+			handleError(f.Close())
+		}()
+
+		out = f
+	}
+
+	p := printer.ComparisonPrinter{Out: out, Comparison: comparison}
+	app.FatalIfError(p.Print(*format), "")
+
+	if comparison.Regressed {
+		// close the output before exiting
+		if out != os.Stdout {
+			handleError(out.Close())
+		}
+
+		os.Exit(regressionExitCode)
+	}
+}
+
+// readReportFile reads the JSON report at the path
+func readReportFile(path string) (*runner.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return runner.ReadReport(f)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/runner"
+)
+
+func writeReportFile(t *testing.T, path string, r *runner.Report) {
+	b, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, b, 0600))
+}
+
+func TestRunCompare(t *testing.T) {
+	// runCompare exits the process, so it is run in a subprocess of the test
+	if args := os.Getenv("GHZ_TEST_COMPARE_ARGS"); args != "" {
+		var compareArgs []string
+		if err := json.Unmarshal([]byte(args), &compareArgs); err != nil {
+			t.Fatal(err)
+		}
+
+		runCompare(compareArgs)
+		return
+	}
+
+	newReport := func(p99 time.Duration) *runner.Report {
+		r := &runner.Report{
+			Count:          100,
+			Average:        10 * time.Millisecond,
+			Fastest:        5 * time.Millisecond,
+			Slowest:        p99,
+			Rps:            100,
+			StatusCodeDist: map[string]int{"OK": 100},
+			LatencyDistribution: []runner.LatencyDistribution{
+				{Percentage: 50, Latency: 10 * time.Millisecond},
+				{Percentage: 99, Latency: p99},
+			},
+		}
+
+		// the same median latencies in both runs
+		for i := 0; i < 100; i++ {
+			d := time.Duration(5+i%10) * time.Millisecond
+			if i == 99 {
+				d = p99
+			}
+			r.Details = append(r.Details, runner.ResultDetail{Latency: d, Status: "OK"})
+		}
+
+		return r
+	}
+
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "baseline.json")
+	current := filepath.Join(dir, "current.json")
+	writeReportFile(t, baseline, newReport(20*time.Millisecond))
+
+	compare := func(t *testing.T) (int, string) {
+		args, err := json.Marshal([]string{"-O", "json", "-o", filepath.Join(dir, "comparison.json"), baseline, current})
+		assert.NoError(t, err)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRunCompare$")
+		cmd.Env = append(os.Environ(), "GHZ_TEST_COMPARE_ARGS="+string(args))
+		out, err := cmd.CombinedOutput()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), string(out)
+		}
+
+		assert.NoError(t, err)
+		return 0, string(out)
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		writeReportFile(t, current, newReport(20*time.Millisecond))
+
+		code, out := compare(t)
+		assert.Equal(t, 0, code, out)
+	})
+
+	t.Run("p99 regressed", func(t *testing.T) {
+		writeReportFile(t, current, newReport(40*time.Millisecond))
+
+		code, out := compare(t)
+		assert.Equal(t, regressionExitCode, code, out)
+
+		f, err := os.Open(filepath.Join(dir, "comparison.json"))
+		assert.NoError(t, err)
+		defer f.Close()
+
+		comparison := &runner.Comparison{}
+		assert.NoError(t, json.NewDecoder(f).Decode(comparison))
+		assert.True(t, comparison.Regressed)
+		assert.NotNil(t, comparison.Significance)
+		assert.False(t, comparison.Significance.Significant)
+
+		for _, m := range comparison.Metrics {
+			switch m.Metric {
+			case "slowest", "p99":
+				assert.Equal(t, runner.CompareRegressed, m.Status, m.Metric)
+			default:
+				assert.Equal(t, runner.CompareUnchanged, m.Status, m.Metric)
+			}
+		}
+	})
+}
@@ -1,24 +1,34 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/dustin/go-humanize"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/bojand/ghz/distributed"
 	"github.com/bojand/ghz/printer"
 	"github.com/bojand/ghz/runner"
 )
 
+// exit code used when any of the assertions fail
+const assertionsFailedExitCode = 2
+
+// exit code used when the comparison against the baseline regressed
+const regressionExitCode = 3
+
 var (
 	// set by goreleaser with -ldflags="-X main.version=..."
 	version = "dev"
@@ -40,6 +50,10 @@ var (
 	call      = kingpin.Flag("call", `A fully-qualified method name in 'package.Service/method' or 'package.Service.Method' format.`).
 			PlaceHolder(" ").IsSetByUser(&isCallSet).String()
 
+	isScenarioSet = false
+	scenario      = kingpin.Flag("scenario", "File path for the scenario JSON, TOML or YAML file with a weighted mix of calls or an ordered list of steps to make. Alternative to call.").
+			PlaceHolder(" ").IsSetByUser(&isScenarioSet).String()
+
 	isImportSet = false
 	paths       = kingpin.Flag("import-paths", "Comma separated list of proto import paths. The current working directory and the directory of the protocol buffer file are automatically added to the import list.").
 			Short('i').PlaceHolder(" ").IsSetByUser(&isImportSet).String()
@@ -83,29 +97,49 @@ var (
 			Default("0").Short('r').IsSetByUser(&isRPSSet).Uint()
 
 	isScheduleSet = false
-	schedule      = kingpin.Flag("load-schedule", "Specifies the load schedule. Options are const, step, or line. Default is const.").
+	schedule      = kingpin.Flag("load-schedule", "Specifies the load schedule. Options are const, step, line, poisson, sine, spike, file, or search. Default is const.").
 			Default("const").IsSetByUser(&isScheduleSet).String()
 
 	isLoadStartSet = false
-	loadStart      = kingpin.Flag("load-start", "Specifies the RPS load start value for step or line schedules.").
+	loadStart      = kingpin.Flag("load-start", "Specifies the RPS load start value for step, line, or search schedules.").
 			Default("0").IsSetByUser(&isLoadStartSet).Uint()
 
 	isLoadStepSet = false
-	loadStep      = kingpin.Flag("load-step", "Specifies the load step value or slope value.").
+	loadStep      = kingpin.Flag("load-step", "Specifies the load step value or slope value, or the RPS resolution of the search schedule.").
 			Default("0").IsSetByUser(&isLoadStepSet).Int()
 
 	isLoadEndSet = false
-	loadEnd      = kingpin.Flag("load-end", "Specifies the load end value for step or line load schedules.").
+	loadEnd      = kingpin.Flag("load-end", "Specifies the load end value for step or line load schedules, or the max RPS of the search schedule.").
 			Default("0").IsSetByUser(&isLoadEndSet).Uint()
 
 	isLoadStepDurSet = false
-	loadStepDuration = kingpin.Flag("load-step-duration", "Specifies the load step duration value for step load schedule.").
+	loadStepDuration = kingpin.Flag("load-step-duration", "Specifies the load step duration value for step load schedule, or the duration of each probe of the search schedule.").
 				Default("0").IsSetByUser(&isLoadStepDurSet).Duration()
 
 	isLoadMaxDurSet = false
 	loadMaxDuration = kingpin.Flag("load-max-duration", "Specifies the max load duration value for step or line load schedule.").
 			Default("0").IsSetByUser(&isLoadMaxDurSet).Duration()
 
+	isLoadAmplitudeSet = false
+	loadAmplitude      = kingpin.Flag("load-amplitude", "Specifies the RPS added to the rps at the peak of the sine load schedule or during the spikes of the spike load schedule.").
+				Default("0").IsSetByUser(&isLoadAmplitudeSet).Uint()
+
+	isLoadPeriodSet = false
+	loadPeriod      = kingpin.Flag("load-period", "Specifies the period of the sine load schedule or the interval between the spikes of the spike load schedule.").
+			Default("0").IsSetByUser(&isLoadPeriodSet).Duration()
+
+	isLoadSpikeDurSet = false
+	loadSpikeDuration = kingpin.Flag("load-spike-duration", "Specifies the duration of each spike of the spike load schedule.").
+				Default("0").IsSetByUser(&isLoadSpikeDurSet).Duration()
+
+	isLoadScheduleFileSet = false
+	loadScheduleFile      = kingpin.Flag("load-schedule-file", "Path of the file with the elapsed duration and RPS points of the file load schedule.").
+				PlaceHolder(" ").IsSetByUser(&isLoadScheduleFileSet).String()
+
+	isSearchSLOSet = false
+	searchSLOs     = kingpin.Flag("search-slo", `SLO each probe of the search load schedule must meet, in the form of an assertion "<metric> <op> <value>". Can be repeated. Example: --search-slo "p99 < 250ms" --search-slo "error-rate < 1%".`).
+			PlaceHolder(" ").IsSetByUser(&isSearchSLOSet).Strings()
+
 	// Concurrency
 	isCSet = false
 	c      = kingpin.Flag("concurrency", "Number of request workers to run concurrently for const concurrency schedule. Default is 50.").
@@ -207,8 +241,8 @@ var (
 			Short('o').PlaceHolder(" ").IsSetByUser(&isOutputSet).String()
 
 	isFormatSet = false
-	format      = kingpin.Flag("format", "Output format. One of: summary, csv, json, pretty, html, influx-summary, influx-details. Default is summary.").
-			Short('O').Default("summary").PlaceHolder(" ").IsSetByUser(&isFormatSet).Enum("summary", "csv", "json", "pretty", "html", "influx-summary", "influx-details", "prometheus")
+	format      = kingpin.Flag("format", "Output format. One of: summary, csv, json, pretty, html, influx-summary, influx-details, prometheus, markdown, junit. Default is summary.").
+			Short('O').Default("summary").PlaceHolder(" ").IsSetByUser(&isFormatSet).Enum("summary", "csv", "json", "pretty", "html", "influx-summary", "influx-details", "prometheus", "markdown", "junit")
 
 	isSkipFirstSet = false
 	skipFirst      = kingpin.Flag("skipFirst", "Skip the first X requests when doing the results tally.").
@@ -218,6 +252,110 @@ var (
 	countErrors = kingpin.Flag("count-errors", "Count erroneous (non-OK) resoponses in stats calculations.").
 			Default("false").IsSetByUser(&isCESet).Bool()
 
+	isHistPrecisionSet = false
+	histPrecision      = kingpin.Flag("histogram-precision", "Number of significant value digits maintained by the latency histogram. Between 1 and 5. Default is 3.").
+				Default("3").IsSetByUser(&isHistPrecisionSet).Uint()
+
+	isDetailsSampleSizeSet = false
+	detailsSampleSize      = kingpin.Flag("details-sample-size", "Maximum number of result details kept in the report. Past the limit details are a random sample of all results. Default is 1000000.").
+				Default("1000000").IsSetByUser(&isDetailsSampleSizeSet).Uint()
+
+	isProgressSet = false
+	progress      = kingpin.Flag("progress", "Print live progress of the run to stderr.").
+			Default("false").IsSetByUser(&isProgressSet).Bool()
+
+	isProgressIntervalSet = false
+	progressInterval      = kingpin.Flag("progress-interval", "Interval between progress updates. Default is 1s.").
+				Default("1s").IsSetByUser(&isProgressIntervalSet).Duration()
+
+	isTimelineIntervalSet = false
+	timelineInterval      = kingpin.Flag("timeline-interval", "Interval of the windows of the timeline breakdown of the results included in the report. Default is no timeline.").
+				Default("0").IsSetByUser(&isTimelineIntervalSet).Duration()
+
+	isOTLPEndpointSet = false
+	otlpEndpoint      = kingpin.Flag("otlp-endpoint", "Base URL of an OpenTelemetry collector receiving OTLP over HTTP to push the live metrics of the run to. For example http://localhost:4318.").
+				PlaceHolder(" ").IsSetByUser(&isOTLPEndpointSet).String()
+
+	isOTLPHeaderSet = false
+	otlpHeaders     = kingpin.Flag("otlp-header", `HTTP header sent with the OTLP exports in the form "<name>=<value>". Can be repeated.`).
+			PlaceHolder(" ").IsSetByUser(&isOTLPHeaderSet).StringMap()
+
+	isOTLPIntervalSet = false
+	otlpInterval      = kingpin.Flag("otlp-interval", "Interval of the OTLP metrics exports. Default is 10s.").
+				Default("10s").IsSetByUser(&isOTLPIntervalSet).Duration()
+
+	isOTLPTracesSet = false
+	otlpTraces      = kingpin.Flag("otlp-traces", "Export a client span for each call to the OTLP endpoint and propagate its trace context in the traceparent metadata of the call.").
+			Default("false").IsSetByUser(&isOTLPTracesSet).Bool()
+
+	isMetricsAddressSet = false
+	metricsAddress      = kingpin.Flag("metrics-address", "Address to serve the live Prometheus metrics of the run on the /metrics path while the run is in progress. For example :9100.").
+				PlaceHolder(" ").IsSetByUser(&isMetricsAddressSet).String()
+
+	isPushgatewaySet = false
+	pushgateway      = kingpin.Flag("pushgateway", "URL of a Prometheus Pushgateway to push the final metrics of the report to. The job is the name of the run and the tags are the grouping labels.").
+				PlaceHolder(" ").IsSetByUser(&isPushgatewaySet).String()
+
+	isResultsFileSet = false
+	resultsFile      = kingpin.Flag("results-file", "Path to a file to continuously append each call result to in a compact binary format. The report can be regenerated from the file using the report command.").
+				PlaceHolder(" ").IsSetByUser(&isResultsFileSet).String()
+
+	isInfluxURLSet = false
+	influxURL      = kingpin.Flag("influx-url", "Base URL of an InfluxDB server to write the report to using the HTTP write API. For example http://localhost:8086.").
+			PlaceHolder(" ").IsSetByUser(&isInfluxURLSet).String()
+
+	isInfluxDBSet = false
+	influxDB      = kingpin.Flag("influx-db", "InfluxDB 1.x database to write to.").
+			PlaceHolder(" ").IsSetByUser(&isInfluxDBSet).String()
+
+	isInfluxRPSet = false
+	influxRP      = kingpin.Flag("influx-rp", "InfluxDB 1.x retention policy to write to.").
+			PlaceHolder(" ").IsSetByUser(&isInfluxRPSet).String()
+
+	isInfluxUsernameSet = false
+	influxUsername      = kingpin.Flag("influx-username", "InfluxDB 1.x username.").
+				PlaceHolder(" ").IsSetByUser(&isInfluxUsernameSet).String()
+
+	isInfluxPasswordSet = false
+	influxPassword      = kingpin.Flag("influx-password", "InfluxDB 1.x password.").
+				PlaceHolder(" ").IsSetByUser(&isInfluxPasswordSet).String()
+
+	isInfluxOrgSet = false
+	influxOrg      = kingpin.Flag("influx-org", "InfluxDB 2.x organization to write to.").
+			PlaceHolder(" ").IsSetByUser(&isInfluxOrgSet).String()
+
+	isInfluxBucketSet = false
+	influxBucket      = kingpin.Flag("influx-bucket", "InfluxDB 2.x bucket to write to.").
+				PlaceHolder(" ").IsSetByUser(&isInfluxBucketSet).String()
+
+	isInfluxTokenSet = false
+	influxToken      = kingpin.Flag("influx-token", "InfluxDB 2.x API token.").
+				PlaceHolder(" ").IsSetByUser(&isInfluxTokenSet).String()
+
+	isInfluxStreamDetailsSet = false
+	influxStreamDetails      = kingpin.Flag("influx-stream-details", "Stream a detail point for each result to InfluxDB during the run instead of writing the details at the end.").
+					Default("false").IsSetByUser(&isInfluxStreamDetailsSet).Bool()
+
+	isAssertSet = false
+	assertions  = kingpin.Flag("assert", `Assertion to evaluate against the final report in the form "<metric> <op> <value>". Can be repeated. Examples: --assert "p99 < 250ms" --assert "error-rate < 1%" --assert "rps >= 500" --assert "status:Unavailable == 0".`).
+			PlaceHolder(" ").IsSetByUser(&isAssertSet).Strings()
+
+	isBaselineSet = false
+	baseline      = kingpin.Flag("baseline", "Path to the JSON report of a baseline run to compare the report against. The comparison summary is printed to stderr.").
+			PlaceHolder(" ").IsSetByUser(&isBaselineSet).String()
+
+	isToleranceSet = false
+	tolerances     = kingpin.Flag("tolerance", `Tolerated change of a metric compared to the baseline in the form "<metric>=<value>". Can be repeated. Examples: --tolerance "p99=5%" --tolerance "rps=10%" --tolerance "error-rate=0.5".`).
+			PlaceHolder(" ").IsSetByUser(&isToleranceSet).Strings()
+
+	isValidateSet = false
+	validations   = kingpin.Flag("validate", `Validation of the received response messages in the form "<path> <op> <value>". Can be repeated. Responses failing validation are counted as errors. Examples: --validate "status == ACTIVE" --validate "token =~ ^[a-f0-9]{32}$" --validate "len(items) >= 1".`).
+			PlaceHolder(" ").IsSetByUser(&isValidateSet).Strings()
+
+	isSkipDetailsSet = false
+	skipDetails      = kingpin.Flag("skip-details", "Do not keep any result details in the report.").
+				Default("false").IsSetByUser(&isSkipDetailsSet).Bool()
+
 	// Connection
 	isConnSet = false
 	conns     = kingpin.Flag("connections", "Number of connections to use. Concurrency is distributed evenly among all the connections. Default is 1.").
@@ -274,17 +412,52 @@ var (
 	disableTemplateData      = kingpin.Flag("disable-template-data", "Do not use and execute any call template data. Useful for better performance.").
 					Default("false").IsSetByUser(&isDisableTemplateDataSet).Bool()
 
+	isAgentsSet = false
+	agents      = kingpin.Flag("agents", "Comma separated list of ghz agent addresses to distribute the load across. The total requests, rate and concurrency are split evenly across the agents.").
+			PlaceHolder(" ").IsSetByUser(&isAgentsSet).String()
+
+	isAgentsSecretSet = false
+	agentsSecret      = kingpin.Flag("agents-secret", "Shared secret sent to the ghz agents, which must match the --secret of the agents. Can also be set with the GHZ_AGENTS_SECRET environment variable.").
+				Envar("GHZ_AGENTS_SECRET").PlaceHolder(" ").IsSetByUser(&isAgentsSecretSet).String()
+
+	isAgentsCACertSet = false
+	agentsCACert      = kingpin.Flag("agents-cacert", "File containing trusted root certificates for verifying the TLS certificates of the ghz agents. Required for agents on other hosts.").
+				PlaceHolder(" ").IsSetByUser(&isAgentsCACertSet).String()
+
 	// host main argument
 	isHostSet = false
 	host      = kingpin.Arg("host", "Host and port to test.").String()
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == agentCommand {
+		runAgent(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == compareCommand {
+		runCompare(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == reportCommand {
+		runReport(os.Args[2:])
+		return
+	}
+
 	kingpin.Version(version)
 	kingpin.CommandLine.HelpFlag.Short('h')
 	kingpin.CommandLine.VersionFlag.Short('v')
 	kingpin.Parse()
 
+	// exit with a non-zero code after all the other deferred cleanup is done
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
 	isHostSet = *host != ""
 
 	cfgPath := strings.TrimSpace(*cPath)
@@ -310,6 +483,10 @@ func main() {
 		kingpin.FatalIfError(err, "")
 	}
 
+	if len(cfg.Agents) > 0 {
+		kingpin.FatalIfError(checkAgentsConfig(&cfg), "")
+	}
+
 	var logger *zap.SugaredLogger
 
 	options := []runner.Option{runner.WithConfig(&cfg)}
@@ -327,11 +504,68 @@ func main() {
 		logger.Warnw("Load balancing strategy set without using DNS (dns:///) scheme", "strategy", cfg.LBStrategy, "host", cfg.Host)
 	}
 
+	var progressPrinter *printer.ProgressPrinter
+	if cfg.Progress {
+		progressPrinter = printer.NewProgressPrinter(os.Stderr)
+
+		options = append(options, runner.WithProgressCallback(time.Duration(cfg.ProgressInterval), progressPrinter.Print))
+	}
+
 	if logger != nil {
 		logger.Debugw("Start Run", "config", cfg)
 	}
 
-	report, err := runner.Run(cfg.Call, cfg.Host, options...)
+	var influxWriter *runner.InfluxWriter
+	if strings.TrimSpace(cfg.InfluxURL) != "" {
+		var err error
+		influxWriter, err = runner.NewInfluxWriter(runner.InfluxConfig{
+			URL:             cfg.InfluxURL,
+			Database:        cfg.InfluxDatabase,
+			RetentionPolicy: cfg.InfluxRetention,
+			Username:        cfg.InfluxUsername,
+			Password:        cfg.InfluxPassword,
+			Org:             cfg.InfluxOrg,
+			Bucket:          cfg.InfluxBucket,
+			Token:           cfg.InfluxToken,
+		})
+		kingpin.FatalIfError(err, "")
+
+		if cfg.InfluxStreamDetails {
+			options = append(options, runner.WithInfluxDetails(influxWriter))
+		}
+	}
+
+	if resultsPath := strings.TrimSpace(cfg.ResultsFile); resultsPath != "" {
+		f, err := os.Create(resultsPath)
+		kingpin.FatalIfError(err, "")
+
+		defer func() {
+			handleError(f.Close())
+		}()
+
+		options = append(options, runner.WithResultSink(runner.NewResultsFileWriter(f)))
+	}
+
+	var baselineReport *runner.Report
+	if baselinePath := strings.TrimSpace(cfg.Baseline); baselinePath != "" {
+		var err error
+		baselineReport, err = readReportFile(baselinePath)
+		kingpin.FatalIfError(err, "")
+
+		kingpin.FatalIfError(runner.ValidateTolerances(cfg.Tolerances...), "")
+	}
+
+	var report *runner.Report
+	var err error
+	if len(cfg.Agents) > 0 {
+		report, err = runDistributed(&cfg, logger, progressPrinter)
+	} else {
+		report, err = runner.Run(cfg.Call, cfg.Host, options...)
+	}
+
+	if progressPrinter != nil {
+		progressPrinter.Done()
+	}
 	if err != nil {
 		if logger != nil {
 			logger.Errorf("Error from run: %+v", err.Error())
@@ -380,6 +614,99 @@ func main() {
 	}
 
 	handleError(p.Print(cfg.Format))
+
+	if gatewayURL := strings.TrimSpace(cfg.Pushgateway); gatewayURL != "" {
+		if logger != nil {
+			logger.Debugw("Pushing metrics to "+gatewayURL, "url", gatewayURL)
+		}
+
+		handleError(p.PushPrometheus(gatewayURL))
+	}
+
+	if influxWriter != nil {
+		if logger != nil {
+			logger.Debugw("Writing report to InfluxDB", "url", cfg.InfluxURL)
+		}
+
+		ip := printer.ReportPrinter{Report: report, Out: influxWriter}
+		err := ip.PrintInflux(!cfg.InfluxStreamDetails)
+		if closeErr := influxWriter.Close(); err == nil {
+			err = closeErr
+		}
+
+		handleError(err)
+	}
+
+	if report.Assertions != nil && !report.Assertions.Passed {
+		if logger != nil {
+			logger.Debug("Assertions failed")
+		}
+
+		exitCode = assertionsFailedExitCode
+	}
+
+	if baselineReport != nil {
+		comparison, err := runner.CompareReports(baselineReport, report, cfg.Tolerances...)
+		handleError(err)
+
+		cp := printer.ComparisonPrinter{Out: os.Stderr, Comparison: comparison}
+		handleError(cp.Print("summary"))
+
+		if comparison.Regressed && exitCode == 0 {
+			if logger != nil {
+				logger.Debug("Comparison against the baseline regressed")
+			}
+
+			exitCode = regressionExitCode
+		}
+	}
+}
+
+// runDistributed runs the test across the agents, the run is stopped on interrupt
+func runDistributed(cfg *runner.Config, logger *zap.SugaredLogger, progressPrinter *printer.ProgressPrinter) (*runner.Report, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var options []distributed.Option
+	if logger != nil {
+		options = append(options, distributed.WithLogger(logger))
+	}
+
+	if cfg.AgentsSecret != "" {
+		options = append(options, distributed.WithSecret(cfg.AgentsSecret))
+	}
+
+	if cfg.AgentsRootCert != "" {
+		options = append(options, distributed.WithRootCertificate(cfg.AgentsRootCert))
+	}
+
+	if progressPrinter != nil {
+		options = append(options, distributed.WithProgressCallback(time.Duration(cfg.ProgressInterval), progressPrinter.Print))
+	}
+
+	return distributed.Run(ctx, cfg, cfg.Agents, options...)
+}
+
+// checkAgentsConfig returns an error for the options that observe the calls during the run,
+// which are not supported when the run is distributed across agents
+func checkAgentsConfig(cfg *runner.Config) error {
+	options := []struct {
+		name string
+		set  bool
+	}{
+		{"results-file", strings.TrimSpace(cfg.ResultsFile) != ""},
+		{"influx-stream-details", cfg.InfluxStreamDetails},
+		{"otlp-endpoint", strings.TrimSpace(cfg.OTLPEndpoint) != ""},
+		{"metrics-address", strings.TrimSpace(cfg.MetricsAddress) != ""},
+	}
+
+	for _, o := range options {
+		if o.set {
+			return fmt.Errorf("--%s is not supported with --agents", o.name)
+		}
+	}
+
+	return nil
 }
 
 func handleError(err error) {
@@ -461,6 +788,7 @@ func createConfigFromArgs(cfg *runner.Config) error {
 	cfg.Proto = *proto
 	cfg.Protoset = *protoset
 	cfg.Call = *call
+	cfg.Scenario = *scenario
 	cfg.RootCert = *cacert
 	cfg.Cert = *cert
 	cfg.Key = *key
@@ -504,6 +832,11 @@ func createConfigFromArgs(cfg *runner.Config) error {
 	cfg.LoadEnd = *loadEnd
 	cfg.LoadStepDuration = runner.Duration(*loadStepDuration)
 	cfg.LoadMaxDuration = runner.Duration(*loadMaxDuration)
+	cfg.LoadAmplitude = *loadAmplitude
+	cfg.LoadPeriod = runner.Duration(*loadPeriod)
+	cfg.LoadSpikeDuration = runner.Duration(*loadSpikeDuration)
+	cfg.LoadScheduleFile = *loadScheduleFile
+	cfg.SearchSLOs = *searchSLOs
 	cfg.Async = *async
 	cfg.CSchedule = *cschdule
 	cfg.CStart = *cStart
@@ -517,6 +850,40 @@ func createConfigFromArgs(cfg *runner.Config) error {
 	cfg.MaxCallSendMsgSize = *maxSendMsgSize
 	cfg.DisableTemplateFuncs = *disableTemplateFuncs
 	cfg.DisableTemplateData = *disableTemplateData
+	cfg.HistogramPrecision = *histPrecision
+	cfg.DetailsSampleSize = *detailsSampleSize
+	cfg.SkipDetails = *skipDetails
+	cfg.Progress = *progress
+	cfg.ProgressInterval = runner.Duration(*progressInterval)
+	cfg.TimelineInterval = runner.Duration(*timelineInterval)
+	cfg.OTLPEndpoint = *otlpEndpoint
+	cfg.OTLPHeaders = *otlpHeaders
+	cfg.OTLPInterval = runner.Duration(*otlpInterval)
+	cfg.OTLPTraces = *otlpTraces
+	cfg.MetricsAddress = *metricsAddress
+	cfg.Pushgateway = *pushgateway
+	cfg.ResultsFile = *resultsFile
+	cfg.InfluxURL = *influxURL
+	cfg.InfluxDatabase = *influxDB
+	cfg.InfluxRetention = *influxRP
+	cfg.InfluxUsername = *influxUsername
+	cfg.InfluxPassword = *influxPassword
+	cfg.InfluxOrg = *influxOrg
+	cfg.InfluxBucket = *influxBucket
+	cfg.InfluxToken = *influxToken
+	cfg.InfluxStreamDetails = *influxStreamDetails
+	cfg.Assertions = *assertions
+	cfg.Validations = *validations
+	cfg.Baseline = *baseline
+	cfg.Tolerances = *tolerances
+
+	agentsTrimmed := strings.TrimSpace(*agents)
+	if agentsTrimmed != "" {
+		cfg.Agents = strings.Split(agentsTrimmed, ",")
+	}
+
+	cfg.AgentsSecret = *agentsSecret
+	cfg.AgentsRootCert = *agentsCACert
 
 	return nil
 }
@@ -540,6 +907,10 @@ func mergeConfig(dest *runner.Config, src *runner.Config) error {
 		dest.Call = src.Call
 	}
 
+	if isScenarioSet {
+		dest.Scenario = src.Scenario
+	}
+
 	// security
 
 	if isCACertSet {
@@ -578,6 +949,122 @@ func mergeConfig(dest *runner.Config, src *runner.Config) error {
 		dest.CountErrors = src.CountErrors
 	}
 
+	if isHistPrecisionSet {
+		dest.HistogramPrecision = src.HistogramPrecision
+	}
+
+	if isDetailsSampleSizeSet {
+		dest.DetailsSampleSize = src.DetailsSampleSize
+	}
+
+	if isSkipDetailsSet {
+		dest.SkipDetails = src.SkipDetails
+	}
+
+	if isAssertSet {
+		dest.Assertions = src.Assertions
+	}
+
+	if isValidateSet {
+		dest.Validations = src.Validations
+	}
+
+	if isBaselineSet {
+		dest.Baseline = src.Baseline
+	}
+
+	if isToleranceSet {
+		dest.Tolerances = src.Tolerances
+	}
+
+	if isAgentsSet {
+		dest.Agents = src.Agents
+	}
+
+	if isAgentsSecretSet || src.AgentsSecret != "" {
+		dest.AgentsSecret = src.AgentsSecret
+	}
+
+	if isAgentsCACertSet {
+		dest.AgentsRootCert = src.AgentsRootCert
+	}
+
+	if isProgressSet {
+		dest.Progress = src.Progress
+	}
+
+	if isProgressIntervalSet {
+		dest.ProgressInterval = src.ProgressInterval
+	}
+
+	if isTimelineIntervalSet {
+		dest.TimelineInterval = src.TimelineInterval
+	}
+
+	if isOTLPEndpointSet {
+		dest.OTLPEndpoint = src.OTLPEndpoint
+	}
+
+	if isOTLPHeaderSet {
+		dest.OTLPHeaders = src.OTLPHeaders
+	}
+
+	if isOTLPIntervalSet {
+		dest.OTLPInterval = src.OTLPInterval
+	}
+
+	if isOTLPTracesSet {
+		dest.OTLPTraces = src.OTLPTraces
+	}
+
+	if isMetricsAddressSet {
+		dest.MetricsAddress = src.MetricsAddress
+	}
+
+	if isPushgatewaySet {
+		dest.Pushgateway = src.Pushgateway
+	}
+
+	if isResultsFileSet {
+		dest.ResultsFile = src.ResultsFile
+	}
+
+	if isInfluxURLSet {
+		dest.InfluxURL = src.InfluxURL
+	}
+
+	if isInfluxDBSet {
+		dest.InfluxDatabase = src.InfluxDatabase
+	}
+
+	if isInfluxRPSet {
+		dest.InfluxRetention = src.InfluxRetention
+	}
+
+	if isInfluxUsernameSet {
+		dest.InfluxUsername = src.InfluxUsername
+	}
+
+	if isInfluxPasswordSet {
+		dest.InfluxPassword = src.InfluxPassword
+	}
+
+	if isInfluxOrgSet {
+		dest.InfluxOrg = src.InfluxOrg
+	}
+
+	if isInfluxBucketSet {
+		dest.InfluxBucket = src.InfluxBucket
+	}
+
+	if isInfluxTokenSet {
+		dest.InfluxToken = src.InfluxToken
+	}
+
+	if isInfluxStreamDetailsSet {
+		dest.InfluxStreamDetails = src.InfluxStreamDetails
+	}
+
 	// run
 
 	if isNSet {
@@ -730,6 +1217,26 @@ func mergeConfig(dest *runner.Config, src *runner.Config) error {
 		dest.LoadMaxDuration = src.LoadMaxDuration
 	}
 
+	if isLoadAmplitudeSet {
+		dest.LoadAmplitude = src.LoadAmplitude
+	}
+
+	if isLoadPeriodSet {
+		dest.LoadPeriod = src.LoadPeriod
+	}
+
+	if isLoadSpikeDurSet {
+		dest.LoadSpikeDuration = src.LoadSpikeDuration
+	}
+
+	if isLoadScheduleFileSet {
+		dest.LoadScheduleFile = src.LoadScheduleFile
+	}
+
+	if isSearchSLOSet {
+		dest.SearchSLOs = src.SearchSLOs
+	}
+
 	// concurrency
 
 	if isCSet {
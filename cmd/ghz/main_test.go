@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/runner"
+)
+
+func TestCheckAgentsConfig(t *testing.T) {
+	assert.NoError(t, checkAgentsConfig(&runner.Config{Agents: []string{"localhost:50060"}, Output: "report.json"}))
+
+	for _, tc := range []struct {
+		name   string
+		cfg    runner.Config
+		errStr string
+	}{
+		{"results file", runner.Config{ResultsFile: "results.bin"}, "--results-file is not supported with --agents"},
+		{"influx details", runner.Config{InfluxStreamDetails: true}, "--influx-stream-details is not supported with --agents"},
+		{"otlp", runner.Config{OTLPEndpoint: "http://localhost:4318"}, "--otlp-endpoint is not supported with --agents"},
+		{"metrics", runner.Config{MetricsAddress: ":9100"}, "--metrics-address is not supported with --agents"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Agents = []string{"localhost:50060"}
+			assert.EqualError(t, checkAgentsConfig(&tc.cfg), tc.errStr)
+		})
+	}
+}
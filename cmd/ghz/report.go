@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin"
+
+	"github.com/bojand/ghz/printer"
+	"github.com/bojand/ghz/runner"
+)
+
+// reportCommand is the first argument to regenerate the report of a run from its results file
+const reportCommand = "report"
+
+// runReport regenerates the report of a run from the results file written using --results-file
+// and prints it in any of the output formats.
+func runReport(args []string) {
+	app := kingpin.New("ghz report", "Regenerates the report of a run from the results file written using --results-file.")
+	app.HelpFlag.Short('h')
+
+	resultsPath := app.Arg("results", "Path to the results file.").Required().String()
+	format := app.Flag("format", "Output format. One of: summary, csv, json, pretty, html, influx-summary, influx-details, prometheus, markdown, junit. Default is summary.").
+		Short('O').Default("summary").PlaceHolder(" ").Enum("summary", "csv", "json", "pretty", "html", "influx-summary", "influx-details", "prometheus", "markdown", "junit")
+	output := app.Flag("output", "Output path. If none provided stdout is used.").
+		Short('o').PlaceHolder(" ").String()
+	from := app.Flag("from", `Only include the calls ending at or after this time. An RFC 3339 time, or a duration since the start of the run. Examples: "2021-06-01T10:00:00Z", "30s".`).
+		PlaceHolder(" ").String()
+	to := app.Flag("to", `Only include the calls ending at or before this time. An RFC 3339 time, or a duration since the start of the run. Examples: "2021-06-01T10:05:00Z", "5m".`).
+		PlaceHolder(" ").String()
+
+	kingpin.MustParse(app.Parse(args))
+
+	f, err := os.Open(*resultsPath)
+	app.FatalIfError(err, "")
+
+	defer f.Close()
+
+	var start time.Time
+	if isRelativeTime(*from) || isRelativeTime(*to) {
+		start, err = resultsStart(f)
+		app.FatalIfError(err, "")
+	}
+
+	fromTime, err := parseReportTime(*from, start)
+	app.FatalIfError(err, "--from")
+
+	toTime, err := parseReportTime(*to, start)
+	app.FatalIfError(err, "--to")
+
+	report, err := runner.ReplayResults(f, fromTime, toTime)
+	app.FatalIfError(err, "")
+
+	out := os.Stdout
+	if path := strings.TrimSpace(*output); path != "" {
+		f, err := os.Create(path)
+		app.FatalIfError(err, "")
+
+		defer func() {
+			handleError(f.Close())
+		}()
+
+		out = f
+	}
+
+	p := printer.ReportPrinter{Out: out, Report: report}
+	app.FatalIfError(p.Print(*format), "")
+}
+
+// isRelativeTime returns whether the report time is a duration since the start of the run
+func isRelativeTime(v string) bool {
+	_, err := time.ParseDuration(strings.TrimSpace(v))
+	return err == nil
+}
+
+// parseReportTime parses the RFC 3339 time or duration since the start of the run, an empty value is the zero time
+func parseReportTime(v string, start time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(v); err == nil {
+		return start.Add(d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: must be an RFC 3339 time or a duration", v)
+	}
+
+	return t, nil
+}
+
+// resultsStart returns the start of the first call in the results file and rewinds it
+func resultsStart(f *os.File) (time.Time, error) {
+	rr, err := runner.NewResultsFileReader(f)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var start time.Time
+	for {
+		res, err := rr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if s := res.Timestamp.Add(-res.Latency); start.IsZero() || s.Before(start) {
+			start = s
+		}
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+
+	return start, err
+}
@@ -0,0 +1,284 @@
+package distributed
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/local"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/bojand/ghz/runner"
+)
+
+// Agent runs its part of a distributed test on behalf of a coordinator.
+// An agent runs a single test at a time. The coordinator sends the contents of the files
+// of the test, such as the proto and data files, and the agent rejects test configs
+// referencing its own files. An agent serving on an address other than loopback requires
+// a secret and a TLS certificate.
+type Agent struct {
+	log    runner.Logger
+	secret string
+	cert   string
+	key    string
+
+	lock     sync.Mutex
+	prepared *prepareRequest
+	cleanup  func()
+	running  string
+	cancel   context.CancelFunc
+}
+
+// AgentOption controls some aspect of the agent
+type AgentOption func(*Agent)
+
+// WithAgentSecret specifies the shared secret the coordinator must send to use the agent
+//
+//	WithAgentSecret("s3cret")
+func WithAgentSecret(secret string) AgentOption {
+	return func(a *Agent) {
+		a.secret = secret
+	}
+}
+
+// WithAgentCertificate specifies the TLS certificate and key files the agent serves with
+//
+//	WithAgentCertificate("agent.crt", "agent.key")
+func WithAgentCertificate(cert, key string) AgentOption {
+	return func(a *Agent) {
+		a.cert = cert
+		a.key = key
+	}
+}
+
+// NewAgent creates a new agent. The logger is optional.
+func NewAgent(log runner.Logger, options ...AgentOption) *Agent {
+	a := &Agent{log: log}
+	for _, option := range options {
+		option(a)
+	}
+
+	return a
+}
+
+// Register registers the agent service with the gRPC server
+func (a *Agent) Register(s *grpc.Server) {
+	s.RegisterService(&agentServiceDesc, a)
+}
+
+// ListenAndServe listens on the TCP address and serves the agent service until it fails
+func (a *Agent) ListenAndServe(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return a.Serve(lis)
+}
+
+// Serve serves the agent service on the listener until it fails. Without a TLS certificate
+// only loopback connections are served.
+func (a *Agent) Serve(lis net.Listener) error {
+	addr := lis.Addr().String()
+
+	if !isLoopback(addr) && (a.secret == "" || a.cert == "") {
+		_ = lis.Close()
+		return fmt.Errorf("agent listening on %s requires a secret and a TLS certificate, "+
+			"only loopback addresses can be served without them", addr)
+	}
+
+	creds := local.NewCredentials()
+	if a.cert != "" {
+		tlsCreds, err := credentials.NewServerTLSFromFile(a.cert, a.key)
+		if err != nil {
+			_ = lis.Close()
+			return err
+		}
+
+		creds = tlsCreds
+	}
+
+	s := grpc.NewServer(grpc.Creds(creds))
+	a.Register(s)
+
+	if a.log != nil {
+		a.log.Debugw("Agent listening", "address", lis.Addr().String())
+	}
+
+	return s.Serve(lis)
+}
+
+// authorize checks the shared secret sent by the coordinator
+func (a *Agent) authorize(ctx context.Context) error {
+	if a.secret == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, secret := range md.Get(secretHeader) {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(a.secret)) == 1 {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid agent secret")
+}
+
+// runOptions returns the runner options of the prepared run
+func runOptions(req *prepareRequest, cfg *runner.Config) []runner.Option {
+	options := []runner.Option{runner.WithConfig(cfg)}
+
+	if len(req.Protoset) > 0 {
+		options = append(options, runner.WithProtosetBinary(req.Protoset))
+	}
+
+	if req.Scenario != nil {
+		options = append(options, runner.WithScenario(req.Scenario))
+	}
+
+	return options
+}
+
+func (a *Agent) prepare(ctx context.Context, req *prepareRequest) (*prepareResponse, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.RunID == "" || req.Config == nil {
+		return nil, status.Error(codes.InvalidArgument, "run id and config required")
+	}
+
+	if err := checkAgentFiles(req.Config, req.Scenario); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.running != "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "agent is busy with run %s", a.running)
+	}
+
+	req.Config.BinData = req.BinaryData
+
+	cleanup, err := writeFiles(req.Config, req.Files)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to write files: %v", err)
+	}
+
+	// validate a copy as the config options fix up the config
+	cfg := *req.Config
+	if _, err := runner.NewConfig(cfg.Call, cfg.Host, runOptions(req, &cfg)...); err != nil {
+		cleanup()
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+	}
+
+	// a prepared run that was never started is replaced
+	if a.cleanup != nil {
+		a.cleanup()
+	}
+
+	a.prepared = req
+	a.cleanup = cleanup
+
+	if a.log != nil {
+		a.log.Debugw("Prepared run", "runID", req.RunID, "config", req.Config)
+	}
+
+	return &prepareResponse{}, nil
+}
+
+func (a *Agent) start(req *startRequest, stream grpc.ServerStream) error {
+	if err := a.authorize(stream.Context()); err != nil {
+		return err
+	}
+
+	a.lock.Lock()
+
+	if a.running != "" {
+		a.lock.Unlock()
+		return status.Errorf(codes.FailedPrecondition, "agent is busy with run %s", a.running)
+	}
+
+	if a.prepared == nil || a.prepared.RunID != req.RunID {
+		a.lock.Unlock()
+		return status.Errorf(codes.NotFound, "run %s is not prepared", req.RunID)
+	}
+
+	prepared, cleanup := a.prepared, a.cleanup
+	cfg := prepared.Config
+	a.prepared = nil
+	a.cleanup = nil
+	a.running = req.RunID
+
+	// the run is stopped when the coordinator goes away
+	ctx, cancel := context.WithCancel(stream.Context())
+	a.cancel = cancel
+
+	a.lock.Unlock()
+
+	defer func() {
+		cancel()
+		cleanup()
+
+		a.lock.Lock()
+		a.running = ""
+		a.cancel = nil
+		a.lock.Unlock()
+	}()
+
+	options := append(runOptions(prepared, cfg), runner.WithLatencyHistogram(true))
+	if a.log != nil {
+		options = append(options, runner.WithLogger(a.log))
+	}
+
+	if cfg.Progress {
+		options = append(options, runner.WithProgressCallback(time.Duration(cfg.ProgressInterval), func(p *runner.Progress) {
+			// progress is best effort, a failed stream fails the final send as well
+			_ = stream.SendMsg(&runEvent{Progress: p})
+		}))
+	}
+
+	if a.log != nil {
+		a.log.Debugw("Starting run", "runID", req.RunID)
+	}
+
+	report, err := runner.RunContext(ctx, cfg.Call, cfg.Host, options...)
+	if err != nil {
+		if a.log != nil {
+			a.log.Errorw("Run failed", "runID", req.RunID, "error", err)
+		}
+
+		return status.Errorf(codes.Aborted, "run failed: %v", err)
+	}
+
+	if a.log != nil {
+		a.log.Debugw("Run finished", "runID", req.RunID, "count", report.Count)
+	}
+
+	return stream.SendMsg(&runEvent{Report: report})
+}
+
+func (a *Agent) stop(ctx context.Context, req *stopRequest) (*stopResponse, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.running != req.RunID || a.cancel == nil {
+		return nil, status.Errorf(codes.NotFound, "run %s is not running", req.RunID)
+	}
+
+	a.cancel()
+
+	return &stopResponse{}, nil
+}
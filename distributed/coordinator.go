@@ -0,0 +1,514 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/local"
+
+	"github.com/bojand/ghz/runner"
+)
+
+// coordinator holds the options of a distributed run
+type coordinator struct {
+	dialTimeout      time.Duration
+	secret           string
+	rootCert         string
+	log              runner.Logger
+	progressFunc     runner.ProgressFunc
+	progressInterval time.Duration
+}
+
+// Option controls some aspect of the distributed run
+type Option func(*coordinator) error
+
+// WithDialTimeout specifies the timeout for connecting to the agents. Default is 10s.
+//
+//	WithDialTimeout(time.Duration(20*time.Second))
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *coordinator) error {
+		c.dialTimeout = d
+
+		return nil
+	}
+}
+
+// WithSecret specifies the shared secret sent to the agents, which must match the secret of the agents
+//
+//	WithSecret("s3cret")
+func WithSecret(secret string) Option {
+	return func(c *coordinator) error {
+		c.secret = secret
+
+		return nil
+	}
+}
+
+// WithRootCertificate specifies the root certificate file to verify the TLS certificates of the agents.
+// The agents are connected to using TLS, which is required for agents on other hosts. Without it
+// only agents on a loopback address can be used.
+//
+//	WithRootCertificate("agents-ca.crt")
+func WithRootCertificate(cert string) Option {
+	return func(c *coordinator) error {
+		c.rootCert = cert
+
+		return nil
+	}
+}
+
+// WithLogger specifies the logging option
+func WithLogger(log runner.Logger) Option {
+	return func(c *coordinator) error {
+		c.log = log
+
+		return nil
+	}
+}
+
+// WithProgressCallback specifies a function to be called periodically with the combined progress of the agents.
+// The counts and rates are the sums of the latest agent snapshots and the latency
+// percentiles are the highest of the agent percentiles.
+//
+//	WithProgressCallback(time.Second, func(p *runner.Progress) {
+//		fmt.Printf("%d requests at %.2f rps\n", p.Count, p.Rps)
+//	})
+func WithProgressCallback(interval time.Duration, fn runner.ProgressFunc) Option {
+	return func(c *coordinator) error {
+		if interval <= 0 {
+			return errors.New("progress interval must be greater than 0")
+		}
+
+		c.progressInterval = interval
+		c.progressFunc = fn
+
+		return nil
+	}
+}
+
+// Run runs the test described by the config across the agents and returns the merged report.
+// The total requests, rate and concurrency of the config are split evenly across the agents,
+// which are all prepared before any of them is started. The files of the config are read by
+// the coordinator and their contents are sent to the agents. When the context is done the agents
+// are stopped and the report of the results so far is returned.
+//
+//	report, err := distributed.Run(ctx, &cfg, []string{"10.0.0.1:50060", "10.0.0.2:50060"})
+func Run(ctx context.Context, cfg *runner.Config, agents []string, options ...Option) (*runner.Report, error) {
+	c := &coordinator{dialTimeout: 10 * time.Second}
+	for _, option := range options {
+		if err := option(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg == nil {
+		return nil, errors.New("config required")
+	}
+
+	creds := local.NewCredentials()
+	if c.rootCert != "" {
+		tlsCreds, err := credentials.NewClientTLSFromFile(c.rootCert, "")
+		if err != nil {
+			return nil, err
+		}
+
+		creds = tlsCreds
+	} else {
+		for _, addr := range agents {
+			if !isLoopback(addr) {
+				return nil, fmt.Errorf("agent %s: a root certificate is required to connect to agents on other hosts using TLS", addr)
+			}
+		}
+	}
+
+	resolved, files, err := resolveFiles(cfg, os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs, err := splitConfig(resolved, len(agents))
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]*agentClient, len(agents))
+	defer func() {
+		for _, ac := range clients {
+			if ac != nil {
+				_ = ac.close()
+			}
+		}
+	}()
+
+	runID := uuid.New().String()
+
+	// connect to and prepare all the agents before starting any of them
+	g, gctx := errgroup.WithContext(ctx)
+	for i, addr := range agents {
+		i, addr := i, addr
+		g.Go(func() error {
+			ac, err := dialAgent(gctx, addr, c.dialTimeout, creds, c.secret)
+			if err != nil {
+				return fmt.Errorf("agent %s: %w", addr, err)
+			}
+			clients[i] = ac
+
+			acfg := cfgs[i]
+			if c.progressFunc != nil {
+				acfg.Progress = true
+				acfg.ProgressInterval = runner.Duration(c.progressInterval)
+			}
+
+			req := *files
+			req.RunID = runID
+			req.Config = acfg
+
+			err = ac.prepare(gctx, &req)
+			if err != nil {
+				return fmt.Errorf("agent %s: %w", addr, err)
+			}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if c.log != nil {
+		c.log.Debugw("Prepared agents", "runID", runID, "agents", agents)
+	}
+
+	// the agent streams are not bound to the context so that the
+	// agents can be stopped and still send the report of the results so far
+	streamCtx, cancelStreams := context.WithCancel(context.Background())
+	defer cancelStreams()
+
+	var progress *progressMerger
+	if c.progressFunc != nil {
+		progress = newProgressMerger(len(agents))
+	}
+
+	reports := make([]*runner.Report, len(agents))
+	errs := make([]error, len(agents))
+
+	var wg sync.WaitGroup
+	begin := make(chan struct{})
+	for i, ac := range clients {
+		wg.Add(1)
+		go func(i int, ac *agentClient) {
+			defer wg.Done()
+
+			<-begin
+
+			reports[i], errs[i] = c.runAgent(streamCtx, ac, runID, i, progress)
+		}(i, ac)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	// start all the agents together
+	close(begin)
+
+	var tick <-chan time.Time
+	if progress != nil {
+		ticker := time.NewTicker(c.progressInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	done := ctx.Done()
+	for running := true; running; {
+		select {
+		case <-finished:
+			running = false
+		case <-tick:
+			if p := progress.merge(); p != nil {
+				c.progressFunc(p)
+			}
+		case <-done:
+			done = nil
+
+			if c.log != nil {
+				c.log.Debugw("Stopping agents", "runID", runID)
+			}
+
+			for _, ac := range clients {
+				stopCtx, cancel := context.WithTimeout(context.Background(), c.dialTimeout)
+				if err := ac.stop(stopCtx, &stopRequest{RunID: runID}); err != nil && c.log != nil {
+					c.log.Errorw("Failed to stop agent", "agent", ac.addr, "error", err)
+				}
+				cancel()
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", agents[i], err)
+		}
+	}
+
+	return runner.MergeReports(reports, cfg.Assertions...)
+}
+
+// runAgent starts the run on the agent and waits for its report
+func (c *coordinator) runAgent(ctx context.Context, ac *agentClient, runID string, idx int, progress *progressMerger) (*runner.Report, error) {
+	stream, err := ac.start(ctx, &startRequest{RunID: runID})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		ev := &runEvent{}
+		err := stream.RecvMsg(ev)
+		if err == io.EOF {
+			return nil, errors.New("no report received")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ev.Report != nil {
+			if c.log != nil {
+				c.log.Debugw("Received agent report", "agent", ac.addr, "count", ev.Report.Count)
+			}
+
+			return ev.Report, nil
+		}
+
+		if ev.Progress != nil && progress != nil {
+			progress.record(idx, ev.Progress)
+		}
+	}
+}
+
+// splitConfig splits the config of the run into the configs of n agents
+func splitConfig(cfg *runner.Config, n int) ([]*runner.Config, error) {
+	if n == 0 {
+		return nil, errors.New("at least one agent required")
+	}
+
+	timed := cfg.Z > 0 || cfg.X > 0
+
+	if !timed && cfg.N < uint(n) {
+		return nil, fmt.Errorf("total requests %d cannot be less than the number of agents %d", cfg.N, n)
+	}
+
+	if cfg.C < uint(n) {
+		return nil, fmt.Errorf("concurrency %d cannot be less than the number of agents %d", cfg.C, n)
+	}
+
+	if cfg.LoadSchedule == runner.ScheduleFile {
+		return nil, errors.New("file load schedule cannot be split across agents")
+	}
+
+	if cfg.LoadSchedule == runner.ScheduleSearch {
+		return nil, errors.New("search load schedule cannot be split across agents")
+	}
+
+	if cfg.RPS > 0 && cfg.RPS < uint(n) {
+		return nil, fmt.Errorf("rps %d cannot be less than the number of agents %d", cfg.RPS, n)
+	}
+
+	res := make([]*runner.Config, n)
+	for i := 0; i < n; i++ {
+		c := agentConfig(cfg)
+
+		if !timed {
+			c.N = share(cfg.N, n, i)
+		}
+
+		c.C = share(cfg.C, n, i)
+		c.RPS = share(cfg.RPS, n, i)
+		c.SkipFirst = share(cfg.SkipFirst, n, i)
+		c.DetailsSampleSize = share(cfg.DetailsSampleSize, n, i)
+
+		c.Connections = share(cfg.Connections, n, i)
+		if c.Connections == 0 {
+			c.Connections = 1
+		}
+		if c.Connections > c.C {
+			c.Connections = c.C
+		}
+
+		c.LoadStart = share(cfg.LoadStart, n, i)
+		c.LoadEnd = share(cfg.LoadEnd, n, i)
+		c.LoadStep = shareInt(cfg.LoadStep, n, i)
+		c.LoadAmplitude = share(cfg.LoadAmplitude, n, i)
+		c.CStart = share(cfg.CStart, n, i)
+		c.CEnd = share(cfg.CEnd, n, i)
+		c.CStep = shareInt(cfg.CStep, n, i)
+
+		res[i] = &c
+	}
+
+	return res, nil
+}
+
+// agentConfig returns the part of the config sent to the agents. Only the options of the test
+// itself are sent: agents use all of their own CPUs, do not read or write their own files, and
+// only report back to the coordinator, which does the output, exports and assertions of the run.
+func agentConfig(cfg *runner.Config) runner.Config {
+	return runner.Config{
+		Call:                  cfg.Call,
+		Host:                  cfg.Host,
+		CountErrors:           cfg.CountErrors,
+		SkipTLSVerify:         cfg.SkipTLSVerify,
+		SkipFirst:             cfg.SkipFirst,
+		CName:                 cfg.CName,
+		Authority:             cfg.Authority,
+		Insecure:              cfg.Insecure,
+		N:                     cfg.N,
+		Async:                 cfg.Async,
+		C:                     cfg.C,
+		CSchedule:             cfg.CSchedule,
+		CStart:                cfg.CStart,
+		CEnd:                  cfg.CEnd,
+		CStep:                 cfg.CStep,
+		CStepDuration:         cfg.CStepDuration,
+		CMaxDuration:          cfg.CMaxDuration,
+		Connections:           cfg.Connections,
+		RPS:                   cfg.RPS,
+		Z:                     cfg.Z,
+		ZStop:                 cfg.ZStop,
+		X:                     cfg.X,
+		Timeout:               cfg.Timeout,
+		Data:                  cfg.Data,
+		Metadata:              cfg.Metadata,
+		SI:                    cfg.SI,
+		StreamCallDuration:    cfg.StreamCallDuration,
+		StreamCallCount:       cfg.StreamCallCount,
+		StreamDynamicMessages: cfg.StreamDynamicMessages,
+		DialTimeout:           cfg.DialTimeout,
+		KeepaliveTime:         cfg.KeepaliveTime,
+		Name:                  cfg.Name,
+		Tags:                  cfg.Tags,
+		ReflectMetadata:       cfg.ReflectMetadata,
+		EnableCompression:     cfg.EnableCompression,
+		LoadSchedule:          cfg.LoadSchedule,
+		LoadStart:             cfg.LoadStart,
+		LoadEnd:               cfg.LoadEnd,
+		LoadStep:              cfg.LoadStep,
+		LoadStepDuration:      cfg.LoadStepDuration,
+		LoadMaxDuration:       cfg.LoadMaxDuration,
+		LoadAmplitude:         cfg.LoadAmplitude,
+		LoadPeriod:            cfg.LoadPeriod,
+		LoadSpikeDuration:     cfg.LoadSpikeDuration,
+		LBStrategy:            cfg.LBStrategy,
+		MaxCallRecvMsgSize:    cfg.MaxCallRecvMsgSize,
+		MaxCallSendMsgSize:    cfg.MaxCallSendMsgSize,
+		DisableTemplateFuncs:  cfg.DisableTemplateFuncs,
+		DisableTemplateData:   cfg.DisableTemplateData,
+		HistogramPrecision:    cfg.HistogramPrecision,
+		DetailsSampleSize:     cfg.DetailsSampleSize,
+		SkipDetails:           cfg.SkipDetails,
+		ProgressInterval:      cfg.ProgressInterval,
+		TimelineInterval:      cfg.TimelineInterval,
+		Validations:           cfg.Validations,
+	}
+}
+
+// share returns the share of agent i of the value split across n agents
+func share(v uint, n, i int) uint {
+	s := v / uint(n)
+	if uint(i) < v%uint(n) {
+		s++
+	}
+
+	return s
+}
+
+func shareInt(v, n, i int) int {
+	if v < 0 {
+		return -int(share(uint(-v), n, i))
+	}
+
+	return int(share(uint(v), n, i))
+}
+
+// progressMerger combines the latest progress snapshots of the agents
+type progressMerger struct {
+	lock   sync.Mutex
+	latest []*runner.Progress
+}
+
+func newProgressMerger(n int) *progressMerger {
+	return &progressMerger{latest: make([]*runner.Progress, n)}
+}
+
+func (m *progressMerger) record(idx int, p *runner.Progress) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.latest[idx] = p
+}
+
+// merge returns the combined progress or nil if there is no progress yet
+func (m *progressMerger) merge() *runner.Progress {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var res *runner.Progress
+	for _, p := range m.latest {
+		if p == nil {
+			continue
+		}
+
+		if res == nil {
+			res = &runner.Progress{
+				ErrorDist:      make(map[string]int),
+				StatusCodeDist: make(map[string]int),
+			}
+		}
+
+		if p.Elapsed > res.Elapsed {
+			res.Elapsed = p.Elapsed
+		}
+
+		res.Count += p.Count
+		res.ErrorCount += p.ErrorCount
+		res.IntervalCount += p.IntervalCount
+		res.Rps += p.Rps
+
+		for k, v := range p.ErrorDist {
+			res.ErrorDist[k] += v
+		}
+
+		for k, v := range p.StatusCodeDist {
+			res.StatusCodeDist[k] += v
+		}
+
+		for _, ld := range p.LatencyDistribution {
+			found := false
+			for j := range res.LatencyDistribution {
+				if res.LatencyDistribution[j].Percentage == ld.Percentage {
+					found = true
+					if ld.Latency > res.LatencyDistribution[j].Latency {
+						res.LatencyDistribution[j].Latency = ld.Latency
+					}
+				}
+			}
+
+			if !found {
+				res.LatencyDistribution = append(res.LatencyDistribution, ld)
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,521 @@
+package distributed
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/local"
+	"google.golang.org/grpc/status"
+
+	"github.com/bojand/ghz/internal"
+	"github.com/bojand/ghz/internal/helloworld"
+	"github.com/bojand/ghz/runner"
+)
+
+// startAgents starts n agents on localhost and returns their addresses
+func startAgents(t *testing.T, n int, options ...AgentOption) []string {
+	t.Helper()
+
+	addrs := make([]string, n)
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			assert.FailNow(t, err.Error())
+		}
+
+		agent := NewAgent(nil, options...)
+
+		go func() {
+			_ = agent.Serve(lis)
+		}()
+
+		t.Cleanup(func() {
+			_ = lis.Close()
+		})
+
+		addrs[i] = lis.Addr().String()
+	}
+
+	return addrs
+}
+
+func newTestConfig() *runner.Config {
+	return &runner.Config{
+		Proto:              "../testdata/greeter.proto",
+		Call:               "helloworld.Greeter.SayHello",
+		Host:               internal.TestLocalhost,
+		Insecure:           true,
+		Data:               map[string]interface{}{"name": "bob"},
+		N:                  30,
+		C:                  6,
+		Connections:        1,
+		Timeout:            runner.Duration(20 * time.Second),
+		DialTimeout:        runner.Duration(10 * time.Second),
+		HistogramPrecision: 3,
+		DetailsSampleSize:  100,
+		LoadSchedule:       "const",
+		CSchedule:          "const",
+		CStart:             1,
+		ZStop:              "close",
+	}
+}
+
+func TestRun(t *testing.T) {
+	gs, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	agents := startAgents(t, 3)
+
+	t.Run("merges the agent reports", func(t *testing.T) {
+		gs.ResetCounters()
+
+		cfg := newTestConfig()
+		cfg.Assertions = []string{"count == 30", "errors == 0"}
+
+		report, err := Run(context.Background(), cfg, agents)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		assert.Equal(t, 30, int(report.Count))
+		assert.Equal(t, 30, gs.GetCount(helloworld.Unary))
+		assert.Equal(t, map[string]int{"OK": 30}, report.StatusCodeDist)
+		assert.Empty(t, report.ErrorDist)
+		assert.Equal(t, runner.ReasonNormalEnd, report.EndReason)
+
+		assert.Equal(t, 30, report.Options.Total)
+		assert.Equal(t, 6, report.Options.Concurrency)
+		assert.Equal(t, 3, report.Options.Connections)
+
+		assert.NotZero(t, report.Average)
+		assert.NotZero(t, report.Rps)
+		assert.True(t, report.Fastest <= report.Slowest)
+		assert.Len(t, report.LatencyDistribution, 7)
+		assert.NotEmpty(t, report.Histogram)
+		assert.Nil(t, report.LatencyHistogram)
+		assert.Len(t, report.Details, 30)
+
+		assert.NotNil(t, report.Assertions)
+		assert.True(t, report.Assertions.Passed)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		gs.ResetCounters()
+
+		cfg := newTestConfig()
+		cfg.N = 0
+		cfg.Z = runner.Duration(1200 * time.Millisecond)
+		cfg.RPS = 30
+
+		var lock sync.Mutex
+		var snapshots []*runner.Progress
+
+		report, err := Run(context.Background(), cfg, agents,
+			WithProgressCallback(300*time.Millisecond, func(p *runner.Progress) {
+				lock.Lock()
+				snapshots = append(snapshots, p)
+				lock.Unlock()
+			}))
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, runner.ReasonTimeout, report.EndReason)
+		assert.True(t, report.Count > 0)
+
+		// calls in flight at the end of the run may fail before reaching the server
+		assert.True(t, report.StatusCodeDist["OK"] <= gs.GetCount(helloworld.Unary))
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		assert.NotEmpty(t, snapshots)
+		last := snapshots[len(snapshots)-1]
+		assert.True(t, last.Count > 0)
+		assert.True(t, last.Count <= report.Count)
+	})
+
+	t.Run("stop on cancel", func(t *testing.T) {
+		gs.ResetCounters()
+
+		cfg := newTestConfig()
+		cfg.N = 0
+		cfg.Z = runner.Duration(time.Minute)
+		cfg.RPS = 30
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		report, err := Run(ctx, cfg, agents)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.True(t, time.Since(start) < 10*time.Second)
+		assert.Equal(t, runner.ReasonCancel, report.EndReason)
+		assert.True(t, report.Count > 0)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.Call = "helloworld.Greeter.Missing"
+
+		report, err := Run(context.Background(), cfg, agents)
+
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("files and scenario", func(t *testing.T) {
+		gs.ResetCounters()
+
+		cfg := newTestConfig()
+		cfg.Data = nil
+		cfg.DataPath = "../testdata/data.json"
+		cfg.MetadataPath = "../testdata/metadata.json"
+		cfg.Scenario = "../testdata/scenario.json"
+
+		report, err := Run(context.Background(), cfg, agents)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, report) {
+			assert.Equal(t, 30, int(report.Count))
+			assert.Equal(t, map[string]int{"OK": 30}, report.StatusCodeDist)
+			assert.Len(t, report.Calls, 2)
+		}
+	})
+
+	t.Run("agent files are rejected", func(t *testing.T) {
+		ac, err := dialAgent(context.Background(), agents[0], time.Second, local.NewCredentials(), "")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		defer ac.close()
+
+		cfg := newTestConfig()
+		cfg.DataPath = "/etc/passwd"
+
+		err = ac.prepare(context.Background(), &prepareRequest{RunID: "run", Config: cfg})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Contains(t, err.Error(), "config cannot reference agent files: proto")
+	})
+
+	t.Run("unreachable agent", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), []string{agents[0], "localhost:1"},
+			WithDialTimeout(200*time.Millisecond))
+
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+}
+
+func TestRun_secret(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	agents := startAgents(t, 2, WithAgentSecret("s3cret"))
+
+	t.Run("valid secret", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), agents, WithSecret("s3cret"))
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, report) {
+			assert.Equal(t, 30, int(report.Count))
+		}
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), agents)
+
+		assert.Nil(t, report)
+		assert.Equal(t, codes.Unauthenticated, status.Code(errors.Unwrap(err)))
+	})
+
+	t.Run("invalid secret", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), agents, WithSecret("guess"))
+
+		assert.Nil(t, report)
+		assert.Equal(t, codes.Unauthenticated, status.Code(errors.Unwrap(err)))
+	})
+}
+
+// writeTestCertificate writes a self-signed certificate for localhost and its key to the directory
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	certPath, keyPath := filepath.Join(dir, "agent.crt"), filepath.Join(dir, "agent.key")
+	assert.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certPath, keyPath
+}
+
+func TestRun_TLS(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	cert, key := writeTestCertificate(t, t.TempDir())
+	agents := startAgents(t, 2, WithAgentSecret("s3cret"), WithAgentCertificate(cert, key))
+
+	t.Run("valid certificate", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), agents,
+			WithSecret("s3cret"), WithRootCertificate(cert))
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, report) {
+			assert.Equal(t, 30, int(report.Count))
+		}
+	})
+
+	t.Run("plaintext to TLS agent", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), agents,
+			WithSecret("s3cret"), WithDialTimeout(200*time.Millisecond))
+
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("agents on other hosts require TLS", func(t *testing.T) {
+		report, err := Run(context.Background(), newTestConfig(), []string{"10.0.0.1:50060"}, WithSecret("s3cret"))
+
+		assert.EqualError(t, err, "agent 10.0.0.1:50060: a root certificate is required to connect to agents on other hosts using TLS")
+		assert.Nil(t, report)
+	})
+}
+
+func TestAgent_Serve(t *testing.T) {
+	cert, key := writeTestCertificate(t, t.TempDir())
+
+	for _, tc := range []struct {
+		name    string
+		options []AgentOption
+	}{
+		{"no secret or certificate", nil},
+		{"no certificate", []AgentOption{WithAgentSecret("s3cret")}},
+		{"no secret", []AgentOption{WithAgentCertificate(cert, key)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", ":0")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = NewAgent(nil, tc.options...).Serve(lis)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "requires a secret and a TLS certificate")
+			}
+		})
+	}
+}
+
+func TestSplitConfig(t *testing.T) {
+	t.Run("splits evenly", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.N = 100
+		cfg.C = 10
+		cfg.RPS = 50
+		cfg.Connections = 2
+		cfg.LoadStep = -5
+		cfg.CPUs = 8
+		cfg.Output = "report.html"
+
+		cfgs, err := splitConfig(cfg, 3)
+		assert.NoError(t, err)
+		assert.Len(t, cfgs, 3)
+
+		var n, c, rps uint
+		for i, ac := range cfgs {
+			n += ac.N
+			c += ac.C
+			rps += ac.RPS
+
+			assert.Equal(t, uint(1), ac.Connections)
+			assert.Zero(t, ac.CPUs)
+			assert.Empty(t, ac.Output)
+
+			if i < 2 {
+				assert.Equal(t, -2, ac.LoadStep)
+			} else {
+				assert.Equal(t, -1, ac.LoadStep)
+			}
+		}
+
+		assert.Equal(t, uint(100), n)
+		assert.Equal(t, uint(10), c)
+		assert.Equal(t, uint(50), rps)
+		assert.Equal(t, []uint{34, 33, 33}, []uint{cfgs[0].N, cfgs[1].N, cfgs[2].N})
+
+		// the original config is unchanged
+		assert.Equal(t, uint(100), cfg.N)
+		assert.Equal(t, "report.html", cfg.Output)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.N = 5
+		cfg.Z = runner.Duration(time.Second)
+
+		cfgs, err := splitConfig(cfg, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(5), cfgs[2].N)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cfg := newTestConfig()
+
+		_, err := splitConfig(cfg, 0)
+		assert.EqualError(t, err, "at least one agent required")
+
+		cfg.C = 2
+		_, err = splitConfig(cfg, 3)
+		assert.EqualError(t, err, "concurrency 2 cannot be less than the number of agents 3")
+
+		cfg.C = 3
+		cfg.N = 2
+		_, err = splitConfig(cfg, 3)
+		assert.EqualError(t, err, "total requests 2 cannot be less than the number of agents 3")
+
+		cfg.N = 3
+		cfg.RPS = 1
+		_, err = splitConfig(cfg, 3)
+		assert.EqualError(t, err, "rps 1 cannot be less than the number of agents 3")
+
+		cfg.LoadSchedule = runner.ScheduleFile
+		_, err = splitConfig(cfg, 3)
+		assert.EqualError(t, err, "file load schedule cannot be split across agents")
+
+		cfg.LoadSchedule = runner.ScheduleSearch
+		_, err = splitConfig(cfg, 3)
+		assert.EqualError(t, err, "search load schedule cannot be split across agents")
+	})
+	t.Run("only the test options reach the agents", func(t *testing.T) {
+		sent := map[string]bool{
+			"Call": true, "Host": true, "CountErrors": true, "SkipTLSVerify": true, "SkipFirst": true,
+			"CName": true, "Authority": true, "Insecure": true, "N": true, "Async": true, "C": true,
+			"CSchedule": true, "CStart": true, "CEnd": true, "CStep": true, "CStepDuration": true,
+			"CMaxDuration": true, "Connections": true, "RPS": true, "Z": true, "ZStop": true, "X": true,
+			"Timeout": true, "Data": true, "Metadata": true, "SI": true, "StreamCallDuration": true,
+			"StreamCallCount": true, "StreamDynamicMessages": true, "DialTimeout": true,
+			"KeepaliveTime": true, "Name": true, "Tags": true, "ReflectMetadata": true,
+			"EnableCompression": true, "LoadSchedule": true, "LoadStart": true, "LoadEnd": true,
+			"LoadStep": true, "LoadStepDuration": true, "LoadMaxDuration": true, "LoadAmplitude": true,
+			"LoadPeriod": true, "LoadSpikeDuration": true, "LBStrategy": true, "MaxCallRecvMsgSize": true,
+			"MaxCallSendMsgSize": true, "DisableTemplateFuncs": true, "DisableTemplateData": true,
+			"HistogramPrecision": true, "DetailsSampleSize": true, "SkipDetails": true,
+			"ProgressInterval": true, "TimelineInterval": true, "Validations": true,
+		}
+
+		// files are sent as contents, and outputs, exports and assertions are done by the coordinator
+		dropped := map[string]bool{
+			"Proto": true, "Protoset": true, "ImportPaths": true, "RootCert": true, "Cert": true,
+			"Key": true, "DataPath": true, "BinData": true, "BinDataPath": true, "MetadataPath": true,
+			"LoadScheduleFile": true, "Scenario": true, "Baseline": true, "Tolerances": true,
+			"Output": true, "Format": true, "Debug": true, "CPUs": true, "Progress": true,
+			"SearchSLOs": true, "Assertions": true, "Agents": true, "AgentsSecret": true, "AgentsRootCert": true,
+			"OTLPEndpoint": true, "OTLPHeaders": true, "OTLPInterval": true, "OTLPTraces": true,
+			"MetricsAddress": true, "Pushgateway": true, "ResultsFile": true, "InfluxURL": true,
+			"InfluxDatabase": true, "InfluxRetention": true, "InfluxUsername": true,
+			"InfluxPassword": true, "InfluxOrg": true, "InfluxBucket": true, "InfluxToken": true,
+			"InfluxStreamDetails": true,
+		}
+
+		cfg := &runner.Config{}
+		v := reflect.ValueOf(cfg).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			switch f.Kind() {
+			case reflect.String:
+				f.SetString("x")
+			case reflect.Bool:
+				f.SetBool(true)
+			case reflect.Uint:
+				f.SetUint(7)
+			case reflect.Int, reflect.Int64:
+				f.SetInt(7)
+			case reflect.Slice:
+				if f.Type().Elem().Kind() == reflect.Uint8 {
+					f.SetBytes([]byte("x"))
+				} else {
+					f.Set(reflect.ValueOf([]string{"x"}))
+				}
+			case reflect.Map:
+				f.Set(reflect.ValueOf(map[string]string{"k": "v"}))
+			case reflect.Interface:
+				f.Set(reflect.ValueOf("x"))
+			}
+		}
+
+		cfgs, err := splitConfig(cfg, 1)
+		assert.NoError(t, err)
+
+		ac := reflect.ValueOf(cfgs[0]).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+
+			switch {
+			case sent[name]:
+				assert.Equal(t, v.Field(i).Interface(), ac.Field(i).Interface(), name)
+			case dropped[name]:
+				assert.True(t, ac.Field(i).IsZero(), name)
+			default:
+				assert.Fail(t, "config field is neither sent to nor dropped for the agents", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package distributed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bojand/ghz/protodesc"
+	"github.com/bojand/ghz/runner"
+)
+
+// The TLS files of the config sent to the agents
+const (
+	fileRootCert = "cacert"
+	fileCert     = "cert"
+	fileKey      = "key"
+)
+
+// resolveFiles reads the files referenced by the config on the coordinator, so that the agents
+// never read their own files. It returns a copy of the config with the data, metadata and binary
+// data inlined and the file paths cleared, and the prepare request with the contents of the
+// proto, TLS and scenario files.
+func resolveFiles(cfg *runner.Config, stdin io.Reader) (*runner.Config, *prepareRequest, error) {
+	c := *cfg
+	req := &prepareRequest{}
+
+	var err error
+
+	switch {
+	case strings.TrimSpace(c.Proto) != "":
+		proto := strings.TrimSpace(c.Proto)
+
+		// the same import paths as the runner
+		imports := make([]string, 0, len(c.ImportPaths)+2)
+		if dir := filepath.Dir(proto); dir != "." {
+			imports = append(imports, dir)
+		}
+		imports = append(imports, ".")
+		imports = append(imports, c.ImportPaths...)
+
+		if req.Protoset, err = protodesc.GetProtoSetFromProto(proto, imports); err != nil {
+			return nil, nil, fmt.Errorf("proto: %w", err)
+		}
+	case strings.TrimSpace(c.Protoset) != "":
+		if req.Protoset, err = os.ReadFile(strings.TrimSpace(c.Protoset)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c.Proto = ""
+	c.Protoset = ""
+	c.ImportPaths = nil
+
+	if dataStr, ok := c.Data.(string); ok && dataStr == "@" {
+		if c.Data, err = decodeData(stdin); err != nil {
+			return nil, nil, fmt.Errorf("data: %w", err)
+		}
+	} else if path := strings.TrimSpace(c.DataPath); path != "" {
+		if c.Data, err = readData(path); err != nil {
+			return nil, nil, fmt.Errorf("data file: %w", err)
+		}
+	}
+
+	c.DataPath = ""
+
+	if path := strings.TrimSpace(c.MetadataPath); path != "" {
+		if c.Metadata, err = readMetadata(path); err != nil {
+			return nil, nil, fmt.Errorf("metadata file: %w", err)
+		}
+	}
+
+	c.MetadataPath = ""
+
+	if path := strings.TrimSpace(c.BinDataPath); path != "" {
+		if c.BinData, err = os.ReadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c.BinDataPath = ""
+	req.BinaryData = c.BinData
+
+	files := map[string]string{fileRootCert: c.RootCert, fileCert: c.Cert, fileKey: c.Key}
+	for name, path := range files {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+
+		if req.Files == nil {
+			req.Files = make(map[string][]byte, len(files))
+		}
+
+		if req.Files[name], err = os.ReadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c.RootCert = ""
+	c.Cert = ""
+	c.Key = ""
+
+	if path := strings.TrimSpace(c.Scenario); path != "" {
+		if req.Scenario, err = resolveScenario(path); err != nil {
+			return nil, nil, fmt.Errorf("scenario: %w", err)
+		}
+	}
+
+	c.Scenario = ""
+
+	return &c, req, nil
+}
+
+// resolveScenario loads the scenario and inlines the data and metadata files of its calls
+func resolveScenario(path string) (*runner.Scenario, error) {
+	s := &runner.Scenario{}
+	if err := runner.LoadScenario(path, s); err != nil {
+		return nil, err
+	}
+
+	for _, calls := range [][]runner.ScenarioCall{s.Calls, s.Steps} {
+		for i := range calls {
+			sc := &calls[i]
+
+			if strings.TrimSpace(sc.BinDataPath) != "" {
+				return nil, fmt.Errorf("call %q: binary data files cannot be sent to agents", sc.Call)
+			}
+
+			var err error
+
+			if p := strings.TrimSpace(sc.DataPath); p != "" {
+				if sc.Data, err = readData(p); err != nil {
+					return nil, fmt.Errorf("call %q: %w", sc.Call, err)
+				}
+			}
+
+			if p := strings.TrimSpace(sc.MetadataPath); p != "" {
+				if sc.Metadata, err = readMetadata(p); err != nil {
+					return nil, fmt.Errorf("call %q: %w", sc.Call, err)
+				}
+			}
+
+			sc.DataPath = ""
+			sc.MetadataPath = ""
+		}
+	}
+
+	return s, nil
+}
+
+func readData(path string) (interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return decodeData(f)
+}
+
+// decodeData decodes the JSON data keeping the numbers as they are
+func decodeData(r io.Reader) (interface{}, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func readMetadata(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	md := make(map[string]string)
+	if err := json.Unmarshal(b, &md); err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+// checkAgentFiles returns an error if the config or scenario sent to an agent references
+// files of the agent, writes files on the agent, or makes the agent export the results
+// or listen on an address
+func checkAgentFiles(cfg *runner.Config, s *runner.Scenario) error {
+	if dataStr, ok := cfg.Data.(string); ok && dataStr == "@" {
+		return errors.New("config cannot read the data from the agent stdin")
+	}
+
+	fields := []struct {
+		name string
+		set  bool
+	}{
+		{"proto", cfg.Proto != ""},
+		{"protoset", cfg.Protoset != ""},
+		{"import-paths", len(cfg.ImportPaths) > 0},
+		{"cacert", cfg.RootCert != ""},
+		{"cert", cfg.Cert != ""},
+		{"key", cfg.Key != ""},
+		{"data-file", cfg.DataPath != ""},
+		{"binary-file", cfg.BinDataPath != ""},
+		{"metadata-file", cfg.MetadataPath != ""},
+		{"load-schedule-file", cfg.LoadScheduleFile != ""},
+		{"scenario", cfg.Scenario != ""},
+		{"baseline", cfg.Baseline != ""},
+		{"output", cfg.Output != ""},
+		{"debug", cfg.Debug != ""},
+		{"results-file", cfg.ResultsFile != ""},
+	}
+
+	for _, f := range fields {
+		if f.set {
+			return fmt.Errorf("config cannot reference agent files: %s", f.name)
+		}
+	}
+
+	if cfg.OTLPEndpoint != "" {
+		return errors.New("config cannot export from the agent: otlp-endpoint")
+	}
+
+	if cfg.MetricsAddress != "" {
+		return errors.New("config cannot listen on the agent: metrics-address")
+	}
+
+	if s != nil {
+		for _, calls := range [][]runner.ScenarioCall{s.Calls, s.Steps} {
+			for _, sc := range calls {
+				if sc.DataPath != "" || sc.BinDataPath != "" || sc.MetadataPath != "" {
+					return fmt.Errorf("scenario call %q cannot reference agent files", sc.Call)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFiles writes the TLS files sent by the coordinator to a new temporary directory
+// and sets their paths in the config. The directory is removed by the returned function.
+func writeFiles(cfg *runner.Config, files map[string][]byte) (func(), error) {
+	if len(files) == 0 {
+		return func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "ghz-agent-")
+	if err != nil {
+		return nil, err
+	}
+
+	remove := func() {
+		_ = os.RemoveAll(dir)
+	}
+
+	paths := map[string]*string{fileRootCert: &cfg.RootCert, fileCert: &cfg.Cert, fileKey: &cfg.Key}
+	for name, content := range files {
+		path, ok := paths[name]
+		if !ok {
+			remove()
+			return nil, fmt.Errorf("unknown file %q", name)
+		}
+
+		*path = filepath.Join(dir, name)
+		if err := os.WriteFile(*path, content, 0600); err != nil {
+			remove()
+			return nil, err
+		}
+	}
+
+	return remove, nil
+}
@@ -0,0 +1,135 @@
+package distributed
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/protodesc"
+	"github.com/bojand/ghz/runner"
+)
+
+func TestResolveFiles(t *testing.T) {
+	t.Run("inlines the files", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.Data = nil
+		cfg.DataPath = "../testdata/data.json"
+		cfg.MetadataPath = "../testdata/metadata.json"
+		cfg.RootCert = "../testdata/localhost.crt"
+		cfg.Scenario = "../testdata/scenario.json"
+
+		resolved, req, err := resolveFiles(cfg, nil)
+		assert.NoError(t, err)
+
+		assert.Empty(t, resolved.Proto)
+		assert.Empty(t, resolved.DataPath)
+		assert.Empty(t, resolved.MetadataPath)
+		assert.Empty(t, resolved.RootCert)
+		assert.Empty(t, resolved.Scenario)
+		assert.NoError(t, checkAgentFiles(resolved, req.Scenario))
+
+		assert.Equal(t, map[string]interface{}{"name": "Some Name {{.TimestampUnix}}"}, resolved.Data)
+		assert.Equal(t, map[string]string{"request-id": "{{.RequestNumber}}"}, resolved.Metadata)
+
+		md, err := protodesc.GetMethodDescFromProtoSetBinary(cfg.Call, req.Protoset)
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+
+		cert, err := os.ReadFile("../testdata/localhost.crt")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"cacert": cert}, req.Files)
+
+		if assert.NotNil(t, req.Scenario) {
+			assert.Len(t, req.Scenario.Calls, 2)
+		}
+
+		// the original config is unchanged
+		assert.Equal(t, "../testdata/greeter.proto", cfg.Proto)
+		assert.Equal(t, "../testdata/data.json", cfg.DataPath)
+	})
+
+	t.Run("data from stdin keeps the numbers", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.Data = "@"
+
+		resolved, _, err := resolveFiles(cfg, strings.NewReader(`{"id": 12345678901234567890}`))
+		assert.NoError(t, err)
+
+		b, err := json.Marshal(resolved.Data)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"id":12345678901234567890}`, string(b))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.DataPath = "../testdata/missing.json"
+
+		_, _, err := resolveFiles(cfg, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckAgentFiles(t *testing.T) {
+	var tests = []struct {
+		name     string
+		cfg      runner.Config
+		expected string
+	}{
+		{"no files", runner.Config{Call: "helloworld.Greeter.SayHello"}, ""},
+		{"proto", runner.Config{Proto: "/etc/secret.proto"}, "config cannot reference agent files: proto"},
+		{"import paths", runner.Config{ImportPaths: []string{"/etc"}}, "config cannot reference agent files: import-paths"},
+		{"key", runner.Config{Key: "/etc/ssl/private/key.pem"}, "config cannot reference agent files: key"},
+		{"data file", runner.Config{DataPath: "/etc/passwd"}, "config cannot reference agent files: data-file"},
+		{"binary file", runner.Config{BinDataPath: "/etc/shadow"}, "config cannot reference agent files: binary-file"},
+		{"output", runner.Config{Output: "/tmp/report.html"}, "config cannot reference agent files: output"},
+		{"stdin", runner.Config{Data: "@"}, "config cannot read the data from the agent stdin"},
+		{"otlp", runner.Config{OTLPEndpoint: "http://collector:4318"}, "config cannot export from the agent: otlp-endpoint"},
+		{"metrics", runner.Config{MetricsAddress: ":9100"}, "config cannot listen on the agent: metrics-address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAgentFiles(&tt.cfg, nil)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("scenario", func(t *testing.T) {
+		s := &runner.Scenario{Calls: []runner.ScenarioCall{{Call: "helloworld.Greeter.SayHello", DataPath: "/etc/passwd"}}}
+
+		err := checkAgentFiles(&runner.Config{}, s)
+		assert.EqualError(t, err, `scenario call "helloworld.Greeter.SayHello" cannot reference agent files`)
+	})
+}
+
+func TestWriteFiles(t *testing.T) {
+	cfg := &runner.Config{}
+
+	cleanup, err := writeFiles(cfg, map[string][]byte{"cacert": []byte("ca"), "key": []byte("key")})
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(cfg.RootCert)
+	assert.NoError(t, err)
+	assert.Equal(t, "ca", string(b))
+
+	b, err = os.ReadFile(cfg.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "key", string(b))
+
+	assert.Empty(t, cfg.Cert)
+
+	cleanup()
+
+	_, err = os.Stat(cfg.RootCert)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = writeFiles(cfg, map[string][]byte{"../passwd": []byte("x")})
+	assert.EqualError(t, err, `unknown file "../passwd"`)
+}
@@ -0,0 +1,222 @@
+// Package distributed runs a ghz test across several agent processes.
+//
+// An Agent listens for work from a coordinator over gRPC. The coordinator splits the total requests,
+// the rate and the concurrency of the test across the agents, starts them together and merges
+// the reports of the agents into a single runner.Report using the mergeable latency histograms.
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/bojand/ghz/runner"
+)
+
+// codecName is the content subtype of the messages between the coordinator and the agents
+const codecName = "ghz-json"
+
+const serviceName = "ghz.Agent"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes the coordinator and agent messages as JSON so that
+// the reports and configs do not need their own protocol buffer definitions
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// prepareRequest asks the agent to validate and hold its part of the test
+type prepareRequest struct {
+	RunID  string         `json:"runId"`
+	Config *runner.Config `json:"config"`
+
+	// BinaryData is the binary data of the config, which is not included in the config JSON
+	BinaryData []byte `json:"binaryData,omitempty"`
+
+	// Protoset is the protoset binary of the proto or protoset file of the coordinator
+	Protoset []byte `json:"protoset,omitempty"`
+
+	// Files are the contents of the TLS files of the coordinator: cacert, cert and key
+	Files map[string][]byte `json:"files,omitempty"`
+
+	// Scenario is the scenario of the coordinator, with the data and metadata files inlined
+	Scenario *runner.Scenario `json:"scenario,omitempty"`
+}
+
+type prepareResponse struct{}
+
+// startRequest starts the prepared test
+type startRequest struct {
+	RunID string `json:"runId"`
+}
+
+// stopRequest stops the running test. The agent still sends the report of the stopped run.
+type stopRequest struct {
+	RunID string `json:"runId"`
+}
+
+type stopResponse struct{}
+
+// runEvent is streamed by the agent while running the test.
+// Either the progress snapshot or the final report is set.
+type runEvent struct {
+	Progress *runner.Progress `json:"progress,omitempty"`
+	Report   *runner.Report   `json:"report,omitempty"`
+}
+
+// agentServer is the server API of the agent service
+type agentServer interface {
+	prepare(context.Context, *prepareRequest) (*prepareResponse, error)
+	start(*startRequest, grpc.ServerStream) error
+	stop(context.Context, *stopRequest) (*stopResponse, error)
+}
+
+var agentServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*agentServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Prepare",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(prepareRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				return srv.(agentServer).prepare(ctx, in)
+			},
+		},
+		{
+			MethodName: "Stop",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(stopRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				return srv.(agentServer).stop(ctx, in)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Start",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := new(startRequest)
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+
+				return srv.(agentServer).start(in, stream)
+			},
+		},
+	},
+}
+
+// secretHeader is the metadata key of the shared secret of the coordinator and the agents
+const secretHeader = "ghz-agent-secret"
+
+// secretCredentials sends the shared secret with every call to the agent
+type secretCredentials string
+
+func (s secretCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{secretHeader: string(s)}, nil
+}
+
+// RequireTransportSecurity allows the secret to be sent over loopback connections without TLS,
+// the coordinator only connects to agents on other hosts using TLS
+func (s secretCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// isLoopback returns whether the host of the address is a loopback address or localhost
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// agentClient is the client of the agent service
+type agentClient struct {
+	addr string
+	cc   *grpc.ClientConn
+}
+
+func dialAgent(ctx context.Context, addr string, timeout time.Duration, creds credentials.TransportCredentials, secret string) (*agentClient, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithBlock(),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)),
+	}
+
+	if secret != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(secretCredentials(secret)))
+	}
+
+	cc, err := grpc.DialContext(dialCtx, addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentClient{addr: addr, cc: cc}, nil
+}
+
+func (c *agentClient) prepare(ctx context.Context, in *prepareRequest) error {
+	return c.cc.Invoke(ctx, "/"+serviceName+"/Prepare", in, new(prepareResponse))
+}
+
+func (c *agentClient) stop(ctx context.Context, in *stopRequest) error {
+	return c.cc.Invoke(ctx, "/"+serviceName+"/Stop", in, new(stopResponse))
+}
+
+func (c *agentClient) start(ctx context.Context, in *startRequest) (grpc.ClientStream, error) {
+	stream, err := c.cc.NewStream(ctx, &agentServiceDesc.Streams[0], "/"+serviceName+"/Start")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+func (c *agentClient) close() error {
+	return c.cc.Close()
+}
@@ -82,7 +82,7 @@ func (s *Greeter) SayHello(ctx context.Context, in *HelloRequest) (*HelloReply,
 		md, ok := metadata.FromIncomingContext(ctx)
 		if ok {
 			for k, v := range md {
-				if k == "token" {
+				if k == "token" || k == "traceparent" {
 					mdval = mdval + k + ":"
 					for _, vv := range v {
 						mdval = mdval + vv
@@ -1,10 +1,18 @@
 package load
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 // nano is the const for number of nanoseconds in a second
@@ -23,6 +31,17 @@ type Pacer interface {
 	Rate(elapsed time.Duration) float64
 }
 
+// ScheduledPacer is a Pacer that can tell when a hit is scheduled to be sent regardless
+// of when it is actually sent. It is used to measure the latencies from the intended
+// start of the hits, correcting them for coordinated omission.
+type ScheduledPacer interface {
+	Pacer
+
+	// Scheduled returns the elapsed duration at which the hit following the given
+	// number of completed hits is scheduled to be sent.
+	Scheduled(hits uint64) time.Duration
+}
+
 // A ConstantPacer defines a constant rate of hits.
 type ConstantPacer struct {
 	Freq uint64 // Frequency of hits per second
@@ -63,6 +82,15 @@ func (cp *ConstantPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration
 	return delta - elapsed, false
 }
 
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+func (cp *ConstantPacer) Scheduled(hits uint64) time.Duration {
+	if cp.Freq == 0 {
+		return 0
+	}
+
+	return time.Duration((hits + 1) * uint64(nano/int64(cp.Freq)))
+}
+
 // Rate returns a ConstantPacer's instantaneous hit rate (i.e. requests per second)
 // at the given elapsed duration of an attack. Since it's constant, the return
 // value is independent of the given elapsed duration.
@@ -192,6 +220,34 @@ func (p *StepPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, boo
 	return wait, false
 }
 
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+// The hits beyond the end of the load are scheduled at the end.
+func (p *StepPacer) Scheduled(hits uint64) time.Duration {
+	p.once.Do(p.initialize)
+
+	var end time.Duration
+	if p.constAt > 0 && p.Stop.Freq == 0 {
+		end = p.constAt
+	}
+
+	wait, stop := paceHits(0, hits, p.scheduledHits, end)
+	if stop {
+		return end
+	}
+
+	return wait
+}
+
+// scheduledHits returns the number of hits scheduled by elapsed duration t,
+// continuing at the constant stop rate once it is reached as Pace does.
+func (p *StepPacer) scheduledHits(t time.Duration) float64 {
+	if p.constAt > 0 && t >= p.constAt {
+		return p.hits(p.constAt) + (t-p.constAt).Seconds()*float64(p.Stop.Freq)
+	}
+
+	return p.hits(t)
+}
+
 // Rate returns a StepPacer's instantaneous hit rate (i.e. requests per second)
 // at the given elapsed duration.
 func (p *StepPacer) Rate(elapsed time.Duration) float64 {
@@ -303,6 +359,13 @@ func (p *LinearPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, b
 	return p.sp.Pace(elapsed, hits)
 }
 
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+func (p *LinearPacer) Scheduled(hits uint64) time.Duration {
+	p.initialize()
+
+	return p.sp.Scheduled(hits)
+}
+
 // Rate returns a LinearPacer's instantaneous hit rate (i.e. requests per second)
 // at the given elapsed duration.
 func (p *LinearPacer) Rate(elapsed time.Duration) float64 {
@@ -317,3 +380,538 @@ func (p *LinearPacer) Rate(elapsed time.Duration) float64 {
 func (p *LinearPacer) String() string {
 	return fmt.Sprintf("Linear{%d hits / 1s}", p.Slope)
 }
+
+// PoissonPacer paces the hits as a Poisson process with the given average rate,
+// so that the intervals between the hits are exponentially distributed
+// as they are for independent arrivals.
+type PoissonPacer struct {
+	Freq uint64 // Average frequency of hits per second
+	Max  uint64 // Optional maximum allowed hits
+	Seed int64  // Optional seed of the random intervals, the current time is used if 0
+
+	once sync.Once
+	lock sync.Mutex
+	rnd  *rand.Rand
+	n    uint64        // number of the next hit
+	next time.Duration // elapsed time of the next hit
+}
+
+func (p *PoissonPacer) initialize() {
+	seed := p.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	p.rnd = rand.New(rand.NewSource(seed))
+	p.next = p.interval()
+}
+
+// interval returns a random interval between two hits
+func (p *PoissonPacer) interval() time.Duration {
+	return time.Duration(p.rnd.ExpFloat64() / float64(p.Freq) * nano)
+}
+
+// Pace determines the length of time to sleep until the next hit is sent.
+func (p *PoissonPacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	if p.Max > 0 && hits >= p.Max {
+		return 0, true
+	}
+
+	if p.Freq == 0 {
+		return 0, false // Zero value = infinite rate
+	}
+
+	p.once.Do(p.initialize)
+
+	return p.arrival(hits) - elapsed, false
+}
+
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+func (p *PoissonPacer) Scheduled(hits uint64) time.Duration {
+	if p.Freq == 0 {
+		return 0
+	}
+
+	p.once.Do(p.initialize)
+
+	return p.arrival(hits)
+}
+
+// arrival returns the elapsed duration of the arrival of the next hit.
+// The arrivals are generated in order so hits must not decrease.
+func (p *PoissonPacer) arrival(hits uint64) time.Duration {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for p.n < hits {
+		p.next += p.interval()
+		p.n++
+	}
+
+	return p.next
+}
+
+// Rate returns a PoissonPacer's average hit rate (i.e. requests per second),
+// which is independent of the given elapsed duration.
+func (p *PoissonPacer) Rate(elapsed time.Duration) float64 {
+	return float64(p.Freq)
+}
+
+// String returns a pretty-printed description of the PoissonPacer's behaviour:
+//   PoissonPacer{Freq: 1} => Poisson{1 hits / 1s}
+func (p *PoissonPacer) String() string {
+	return fmt.Sprintf("Poisson{%d hits / 1s}", p.Freq)
+}
+
+// SinePacer paces the hits with a rate that follows a sine wave around a base rate,
+// such as to model diurnal traffic. The rate starts at the base rate and reaches
+// its peak a quarter of the period in.
+type SinePacer struct {
+	Base      uint64        // Base frequency of hits per second
+	Amplitude uint64        // Frequency added to and subtracted from the base at the peak and trough
+	Period    time.Duration // Period of the wave
+	Max       uint64        // Optional maximum allowed hits
+}
+
+func (p *SinePacer) validate() {
+	if p.Base == 0 {
+		panic("SinePacer.Base cannot be 0")
+	}
+
+	if p.Amplitude > p.Base {
+		panic("SinePacer.Amplitude cannot be greater than SinePacer.Base")
+	}
+
+	if p.Period <= 0 {
+		panic("SinePacer.Period must be greater than 0")
+	}
+}
+
+// Pace determines the length of time to sleep until the next hit is sent.
+func (p *SinePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	if p.Max > 0 && hits >= p.Max {
+		return 0, true
+	}
+
+	p.validate()
+
+	return paceHits(elapsed, hits, p.hits, 0)
+}
+
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+func (p *SinePacer) Scheduled(hits uint64) time.Duration {
+	p.validate()
+
+	wait, _ := paceHits(0, hits, p.hits, 0)
+
+	return wait
+}
+
+// Rate returns a SinePacer's instantaneous hit rate (i.e. requests per second)
+// at the given elapsed duration.
+func (p *SinePacer) Rate(elapsed time.Duration) float64 {
+	return float64(p.Base) + float64(p.Amplitude)*math.Sin(2*math.Pi*elapsed.Seconds()/p.Period.Seconds())
+}
+
+// hits returns the number of hits that have been sent at elapsed duration t.
+func (p *SinePacer) hits(t time.Duration) float64 {
+	if t < 0 {
+		return 0
+	}
+
+	w := 2 * math.Pi / p.Period.Seconds()
+
+	return float64(p.Base)*t.Seconds() + float64(p.Amplitude)/w*(1-math.Cos(w*t.Seconds()))
+}
+
+// String returns a pretty-printed description of the SinePacer's behaviour:
+//   SinePacer{Base: 10, Amplitude: 5, Period: 1m} => Sine{10±5 hits / 1s over 1m0s}
+func (p *SinePacer) String() string {
+	return fmt.Sprintf("Sine{%d±%d hits / 1s over %s}", p.Base, p.Amplitude, p.Period.String())
+}
+
+// SpikePacer paces the hits at a base rate with periodic spikes of a higher rate.
+// Each period is at the base rate and ends with a spike of the given duration.
+type SpikePacer struct {
+	Base          uint64        // Base frequency of hits per second
+	Spike         uint64        // Frequency of hits per second during a spike
+	Period        time.Duration // Interval between the starts of the spikes
+	SpikeDuration time.Duration // Duration of each spike
+	Max           uint64        // Optional maximum allowed hits
+}
+
+func (p *SpikePacer) validate() {
+	if p.Spike == 0 {
+		panic("SpikePacer.Spike cannot be 0")
+	}
+
+	if p.Period <= 0 {
+		panic("SpikePacer.Period must be greater than 0")
+	}
+
+	if p.SpikeDuration <= 0 || p.SpikeDuration > p.Period {
+		panic("SpikePacer.SpikeDuration must be greater than 0 and not greater than SpikePacer.Period")
+	}
+}
+
+// Pace determines the length of time to sleep until the next hit is sent.
+func (p *SpikePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	if p.Max > 0 && hits >= p.Max {
+		return 0, true
+	}
+
+	p.validate()
+
+	return paceHits(elapsed, hits, p.hits, 0)
+}
+
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+func (p *SpikePacer) Scheduled(hits uint64) time.Duration {
+	p.validate()
+
+	wait, _ := paceHits(0, hits, p.hits, 0)
+
+	return wait
+}
+
+// Rate returns a SpikePacer's instantaneous hit rate (i.e. requests per second)
+// at the given elapsed duration.
+func (p *SpikePacer) Rate(elapsed time.Duration) float64 {
+	if elapsed%p.Period >= p.Period-p.SpikeDuration {
+		return float64(p.Spike)
+	}
+
+	return float64(p.Base)
+}
+
+// hits returns the number of hits that have been sent at elapsed duration t.
+func (p *SpikePacer) hits(t time.Duration) float64 {
+	if t < 0 {
+		return 0
+	}
+
+	baseDuration := (p.Period - p.SpikeDuration).Seconds()
+	perPeriod := float64(p.Base)*baseDuration + float64(p.Spike)*p.SpikeDuration.Seconds()
+
+	periods := t / p.Period
+	r := (t % p.Period).Seconds()
+
+	s := float64(periods) * perPeriod
+	if r <= baseDuration {
+		return s + float64(p.Base)*r
+	}
+
+	return s + float64(p.Base)*baseDuration + float64(p.Spike)*(r-baseDuration)
+}
+
+// String returns a pretty-printed description of the SpikePacer's behaviour:
+//   SpikePacer{Base: 10, Spike: 100, Period: 1m, SpikeDuration: 5s} => Spike{10 hits / 1s, 100 hits / 1s for 5s every 1m0s}
+func (p *SpikePacer) String() string {
+	return fmt.Sprintf("Spike{%d hits / 1s, %d hits / 1s for %s every %s}",
+		p.Base, p.Spike, p.SpikeDuration.String(), p.Period.String())
+}
+
+// RatePoint is the hit rate at an elapsed duration of a PiecewisePacer schedule
+type RatePoint struct {
+	Elapsed time.Duration // Elapsed duration of the point
+	Rate    float64       // Frequency of hits per second at the point
+}
+
+// PiecewisePacer paces the hits with a rate that is linearly interpolated between
+// the points of a schedule. The rate is 0 before the first point and the hits
+// stop at the last point.
+type PiecewisePacer struct {
+	Points []RatePoint // Points of the schedule in increasing order of elapsed duration
+	Max    uint64      // Optional maximum allowed hits
+
+	once       sync.Once
+	cumulative []float64 // hits that have been sent at each point
+}
+
+func (p *PiecewisePacer) initialize() {
+	if err := ValidateRatePoints(p.Points); err != nil {
+		panic("PiecewisePacer.Points " + err.Error())
+	}
+
+	p.cumulative = make([]float64, len(p.Points))
+	for i := 1; i < len(p.Points); i++ {
+		a, b := p.Points[i-1], p.Points[i]
+		p.cumulative[i] = p.cumulative[i-1] + (a.Rate+b.Rate)/2*(b.Elapsed-a.Elapsed).Seconds()
+	}
+}
+
+// Pace determines the length of time to sleep until the next hit is sent.
+func (p *PiecewisePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	if p.Max > 0 && hits >= p.Max {
+		return 0, true
+	}
+
+	p.once.Do(p.initialize)
+
+	return paceHits(elapsed, hits, p.hits, p.Points[len(p.Points)-1].Elapsed)
+}
+
+// Scheduled returns the elapsed duration at which the next hit is scheduled to be sent.
+// The hits beyond the schedule are scheduled at the last point.
+func (p *PiecewisePacer) Scheduled(hits uint64) time.Duration {
+	p.once.Do(p.initialize)
+
+	end := p.Points[len(p.Points)-1].Elapsed
+	wait, stop := paceHits(0, hits, p.hits, end)
+	if stop {
+		return end
+	}
+
+	return wait
+}
+
+// Rate returns a PiecewisePacer's instantaneous hit rate (i.e. requests per second)
+// at the given elapsed duration.
+func (p *PiecewisePacer) Rate(elapsed time.Duration) float64 {
+	i := p.segment(elapsed)
+	if i < 0 || i == len(p.Points)-1 {
+		return 0
+	}
+
+	a, b := p.Points[i], p.Points[i+1]
+
+	return a.Rate + (b.Rate-a.Rate)*float64(elapsed-a.Elapsed)/float64(b.Elapsed-a.Elapsed)
+}
+
+// segment returns the index of the last point at or before elapsed duration t, or -1
+func (p *PiecewisePacer) segment(t time.Duration) int {
+	return sort.Search(len(p.Points), func(i int) bool {
+		return p.Points[i].Elapsed > t
+	}) - 1
+}
+
+// hits returns the number of hits that have been sent at elapsed duration t.
+func (p *PiecewisePacer) hits(t time.Duration) float64 {
+	i := p.segment(t)
+	if i < 0 {
+		return 0
+	}
+
+	if i == len(p.Points)-1 {
+		return p.cumulative[i]
+	}
+
+	a := p.Points[i]
+
+	return p.cumulative[i] + (a.Rate+p.Rate(t))/2*(t-a.Elapsed).Seconds()
+}
+
+// String returns a pretty-printed description of the PiecewisePacer's behaviour:
+//   PiecewisePacer{Points: [{0 10} {1m 100}]} => Piecewise{2 points over 1m0s}
+func (p *PiecewisePacer) String() string {
+	var d time.Duration
+	if len(p.Points) > 0 {
+		d = p.Points[len(p.Points)-1].Elapsed
+	}
+
+	return fmt.Sprintf("Piecewise{%d points over %s}", len(p.Points), d.String())
+}
+
+// ValidateRatePoints checks that the points form a valid PiecewisePacer schedule
+func ValidateRatePoints(points []RatePoint) error {
+	if len(points) < 2 {
+		return errors.New("must have at least 2 points")
+	}
+
+	for i, pt := range points {
+		if pt.Elapsed < 0 || pt.Rate < 0 || math.IsNaN(pt.Rate) || math.IsInf(pt.Rate, 0) {
+			return fmt.Errorf("point %d: elapsed duration and rate cannot be negative", i)
+		}
+
+		if i > 0 && pt.Elapsed <= points[i-1].Elapsed {
+			return fmt.Errorf("point %d: elapsed duration must be greater than that of the previous point", i)
+		}
+	}
+
+	return nil
+}
+
+// ReadRatePoints reads the points of a PiecewisePacer schedule.
+// Each line has the elapsed duration and the rate of a point separated by
+// a comma or whitespace. The elapsed duration is either a duration such as
+// 1m30s or a number of seconds. Empty lines and lines starting with # are ignored.
+//
+//	# ramp up to 100 RPS over a minute, hold for 5 minutes and ramp down
+//	0s,0
+//	1m,100
+//	6m,100
+//	7m,0
+func ReadRatePoints(r io.Reader) ([]RatePoint, error) {
+	var points []RatePoint
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected elapsed duration and rate", line)
+		}
+
+		elapsed, err := time.ParseDuration(fields[0])
+		if err != nil {
+			secs, serr := strconv.ParseFloat(fields[0], 64)
+			if serr != nil {
+				return nil, fmt.Errorf("line %d: invalid elapsed duration %q", line, fields[0])
+			}
+
+			elapsed = time.Duration(secs * nano)
+		}
+
+		rate, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, fields[1])
+		}
+
+		points = append(points, RatePoint{Elapsed: elapsed, Rate: rate})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRatePoints(points); err != nil {
+		return nil, fmt.Errorf("rate points %s", err.Error())
+	}
+
+	return points, nil
+}
+
+// AdaptivePacer paces the hits at a constant rate that can be changed while the hits
+// are sent, such as by a controller searching for the highest sustainable rate.
+// A rate change takes effect from the next hit without catching up on the hits of
+// the previous rate. It must be created using NewAdaptivePacer.
+type AdaptivePacer struct {
+	lock    sync.Mutex
+	freq    uint64
+	changed bool          // rate changed since the last pace
+	since   time.Duration // elapsed duration of the last rate change
+	base    uint64        // hits at the last rate change
+	stopped bool
+}
+
+// NewAdaptivePacer creates a new AdaptivePacer with the initial frequency of hits per second
+func NewAdaptivePacer(freq uint64) *AdaptivePacer {
+	if freq == 0 {
+		panic("AdaptivePacer frequency cannot be 0")
+	}
+
+	return &AdaptivePacer{freq: freq}
+}
+
+// SetRate changes the frequency of hits per second
+func (p *AdaptivePacer) SetRate(freq uint64) {
+	if freq == 0 {
+		panic("AdaptivePacer frequency cannot be 0")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.freq = freq
+	p.changed = true
+}
+
+// Stop stops the hits
+func (p *AdaptivePacer) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.stopped = true
+}
+
+// Pace determines the length of time to sleep until the next hit is sent.
+func (p *AdaptivePacer) Pace(elapsed time.Duration, hits uint64) (time.Duration, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.stopped {
+		return 0, true
+	}
+
+	if p.changed {
+		p.changed = false
+		p.since = elapsed
+		p.base = hits
+	}
+
+	interval := time.Duration(nano / int64(p.freq))
+	due := p.since + time.Duration(hits-p.base+1)*interval
+
+	return due - elapsed, false
+}
+
+// Rate returns an AdaptivePacer's current hit rate (i.e. requests per second).
+func (p *AdaptivePacer) Rate(elapsed time.Duration) float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return float64(p.freq)
+}
+
+// String returns a pretty-printed description of the AdaptivePacer's behaviour:
+//   AdaptivePacer{freq: 5} => Adaptive{5 hits / 1s}
+func (p *AdaptivePacer) String() string {
+	return fmt.Sprintf("Adaptive{%d hits / 1s}", uint64(p.Rate(0)))
+}
+
+// paceHits returns the wait from the elapsed duration until the hits function reaches the
+// next hit. If end is greater than 0 and the next hit is not reached by then, stop is returned.
+func paceHits(elapsed time.Duration, hits uint64, hitsAt func(time.Duration) float64, end time.Duration) (time.Duration, bool) {
+	target := float64(hits + 1)
+
+	if hitsAt(elapsed) >= target {
+		// Running behind, send next hit immediately.
+		return 0, false
+	}
+
+	if end > 0 && hitsAt(end) < target {
+		return 0, true
+	}
+
+	// find an upper bound of the next hit and then narrow it down
+	lo, hi := elapsed, elapsed
+	for step := time.Millisecond; ; step *= 2 {
+		if step > math.MaxInt64/4 {
+			// We would overflow the wait if we continued, so stop the attack.
+			return 0, true
+		}
+
+		hi = elapsed + step
+		if end > 0 && hi >= end {
+			hi = end
+			break
+		}
+
+		if hitsAt(hi) >= target {
+			break
+		}
+
+		lo = hi
+	}
+
+	for hi-lo > time.Microsecond {
+		mid := lo + (hi-lo)/2
+		if hitsAt(mid) >= target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return hi - elapsed, false
+}
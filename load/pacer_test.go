@@ -1,8 +1,10 @@
 package load
 
 import (
+	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -155,6 +157,18 @@ func TestConstantPacer_Rate(t *testing.T) {
 	}
 }
 
+func TestConstantPacer_Scheduled(t *testing.T) {
+	cp := ConstantPacer{Freq: 10}
+	assert.Equal(t, 100*time.Millisecond, cp.Scheduled(0))
+	assert.Equal(t, 2*time.Second, cp.Scheduled(19))
+
+	// the schedule is independent of when the hits are sent
+	wait, _ := cp.Pace(time.Second, 19)
+	assert.Equal(t, cp.Scheduled(19)-time.Second, wait)
+
+	assert.Zero(t, (&ConstantPacer{}).Scheduled(10))
+}
+
 func TestConstantPacer_String(t *testing.T) {
 	cp := ConstantPacer{Freq: 5}
 	actual := cp.String()
@@ -1041,6 +1055,30 @@ func TestStepPacer(t *testing.T) {
 	}
 }
 
+func TestStepPacer_Scheduled(t *testing.T) {
+	p := StepPacer{
+		Start:        ConstantPacer{Freq: 10},
+		Step:         10,
+		StepDuration: time.Second,
+		LoadDuration: 2 * time.Second,
+	}
+
+	// 10 hits in the first step, 20 in the second and then 30 per second
+	assert.InDelta(t, 100*time.Millisecond, p.Scheduled(0), float64(10*time.Microsecond))
+	assert.InDelta(t, time.Second, p.Scheduled(9), float64(10*time.Microsecond))
+	assert.InDelta(t, 1500*time.Millisecond, p.Scheduled(19), float64(10*time.Microsecond))
+	assert.InDelta(t, 2*time.Second, p.Scheduled(29), float64(10*time.Microsecond))
+	assert.InDelta(t, 3*time.Second, p.Scheduled(59), float64(10*time.Microsecond))
+
+	// the schedule is independent of when the hits are sent
+	wait, _ := p.Pace(1200*time.Millisecond, 19)
+	assert.InDelta(t, p.Scheduled(19)-1200*time.Millisecond, wait, float64(10*time.Microsecond))
+
+	lp := LinearPacer{Start: ConstantPacer{Freq: 10}, Slope: 10, LoadDuration: 2 * time.Second}
+	assert.InDelta(t, time.Second, lp.Scheduled(9), float64(10*time.Microsecond))
+	assert.InDelta(t, 3*time.Second, lp.Scheduled(59), float64(10*time.Microsecond))
+}
+
 func TestStepPacer_String(t *testing.T) {
 	p := StepPacer{
 		Start: ConstantPacer{Freq: 5, Max: 100},
@@ -1052,6 +1090,248 @@ func TestStepPacer_String(t *testing.T) {
 	assert.Equal(t, "Step{Step: 2 hits / 5s}", actual)
 }
 
+func TestPoissonPacer(t *testing.T) {
+	t.Run("average rate", func(t *testing.T) {
+		p := PoissonPacer{Freq: 100, Seed: 1}
+
+		// the arrival of each hit is independent of the elapsed duration
+		var last time.Duration
+		for hits := uint64(0); hits < 10000; hits++ {
+			wait, stop := p.Pace(0, hits)
+			assert.False(t, stop)
+			assert.True(t, wait >= last)
+
+			last = wait
+		}
+
+		// 10000 hits at 100 hits / 1s take about 100s
+		assert.InDelta(t, 100*time.Second, last, float64(5*time.Second))
+	})
+
+	t.Run("repeated pace", func(t *testing.T) {
+		p := PoissonPacer{Freq: 10, Seed: 2}
+
+		wait1, _ := p.Pace(0, 3)
+		wait2, _ := p.Pace(100*time.Millisecond, 3)
+		assert.Equal(t, wait1-100*time.Millisecond, wait2)
+	})
+
+	t.Run("max", func(t *testing.T) {
+		p := PoissonPacer{Freq: 10, Max: 5}
+
+		_, stop := p.Pace(0, 4)
+		assert.False(t, stop)
+
+		_, stop = p.Pace(0, 5)
+		assert.True(t, stop)
+	})
+
+	t.Run("infinite rate", func(t *testing.T) {
+		p := PoissonPacer{}
+
+		wait, stop := p.Pace(time.Second, 100)
+		assert.False(t, stop)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		p := PoissonPacer{Freq: 10, Seed: 3}
+
+		scheduled := p.Scheduled(5)
+
+		// the schedule is independent of the elapsed duration
+		wait, _ := p.Pace(time.Second, 5)
+		assert.Equal(t, scheduled-time.Second, wait)
+	})
+
+	assert.Equal(t, 10.0, (&PoissonPacer{Freq: 10}).Rate(time.Minute))
+	assert.Equal(t, "Poisson{10 hits / 1s}", (&PoissonPacer{Freq: 10}).String())
+}
+
+func TestSinePacer(t *testing.T) {
+	p := SinePacer{Base: 10, Amplitude: 5, Period: 4 * time.Second, Max: 1000}
+
+	assert.True(t, floatEqual(10, p.Rate(0)))
+	assert.True(t, floatEqual(15, p.Rate(time.Second)))
+	assert.True(t, floatEqual(10, p.Rate(2*time.Second)))
+	assert.True(t, floatEqual(5, p.Rate(3*time.Second)))
+
+	// a full period averages the base rate
+	assert.True(t, floatEqual(40, p.hits(4*time.Second)))
+	assert.True(t, floatEqual(80, p.hits(8*time.Second)))
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		hits    uint64
+		wait    time.Duration
+		stop    bool
+	}{
+		// the rate rises above the base from the start
+		{0, 0, 96361 * time.Microsecond, false},
+		{time.Second, 0, 0, false},
+		{4 * time.Second, 40, 96361 * time.Microsecond, false},
+		{4 * time.Second, 1000, 0, true},
+	} {
+		t.Run(fmt.Sprintf("%s %d", tc.elapsed, tc.hits), func(t *testing.T) {
+			wait, stop := p.Pace(tc.elapsed, tc.hits)
+			assert.InDelta(t, tc.wait, wait, float64(10*time.Microsecond))
+			assert.Equal(t, tc.stop, stop)
+		})
+	}
+
+	assert.InDelta(t, 4*time.Second, p.Scheduled(39), float64(10*time.Microsecond))
+	assert.Equal(t, "Sine{10±5 hits / 1s over 4s}", p.String())
+	assert.Panics(t, func() {
+		(&SinePacer{Base: 5, Amplitude: 10, Period: time.Second}).Pace(0, 0)
+	})
+}
+
+func TestSpikePacer(t *testing.T) {
+	p := SpikePacer{Base: 10, Spike: 100, Period: 10 * time.Second, SpikeDuration: 2 * time.Second}
+
+	assert.Equal(t, 10.0, p.Rate(0))
+	assert.Equal(t, 10.0, p.Rate(7*time.Second))
+	assert.Equal(t, 100.0, p.Rate(8*time.Second))
+	assert.Equal(t, 100.0, p.Rate(9999*time.Millisecond))
+	assert.Equal(t, 10.0, p.Rate(10*time.Second))
+
+	assert.True(t, floatEqual(80, p.hits(8*time.Second)))
+	assert.True(t, floatEqual(280, p.hits(10*time.Second)))
+	assert.True(t, floatEqual(290, p.hits(11*time.Second)))
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		hits    uint64
+		wait    time.Duration
+	}{
+		{0, 0, 100 * time.Millisecond},
+		{8 * time.Second, 80, 10 * time.Millisecond},
+		{10 * time.Second, 280, 100 * time.Millisecond},
+		{5 * time.Second, 10, 0},
+	} {
+		t.Run(fmt.Sprintf("%s %d", tc.elapsed, tc.hits), func(t *testing.T) {
+			wait, stop := p.Pace(tc.elapsed, tc.hits)
+			assert.InDelta(t, tc.wait, wait, float64(100*time.Microsecond))
+			assert.False(t, stop)
+		})
+	}
+
+	assert.InDelta(t, 8*time.Second+10*time.Millisecond, p.Scheduled(80), float64(10*time.Microsecond))
+	assert.Equal(t, "Spike{10 hits / 1s, 100 hits / 1s for 2s every 10s}", p.String())
+}
+
+func TestPiecewisePacer(t *testing.T) {
+	p := PiecewisePacer{Points: []RatePoint{
+		{Elapsed: 0, Rate: 0},
+		{Elapsed: 10 * time.Second, Rate: 100},
+		{Elapsed: 20 * time.Second, Rate: 100},
+	}}
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		hits    uint64
+		wait    time.Duration
+		stop    bool
+	}{
+		// 1 hit is reached when 5t^2 = 1
+		{0, 0, time.Duration(math.Sqrt(0.2) * 1e9), false},
+		{10 * time.Second, 500, 10 * time.Millisecond, false},
+		{10 * time.Second, 100, 0, false},
+		{20 * time.Second, 1499, 0, false},
+		{19 * time.Second, 1499, time.Second, false},
+		{19 * time.Second, 1500, 0, true},
+		{25 * time.Second, 1500, 0, true},
+	} {
+		t.Run(fmt.Sprintf("%s %d", tc.elapsed, tc.hits), func(t *testing.T) {
+			wait, stop := p.Pace(tc.elapsed, tc.hits)
+			assert.InDelta(t, tc.wait, wait, float64(100*time.Microsecond))
+			assert.Equal(t, tc.stop, stop)
+		})
+	}
+
+	assert.InDelta(t, 10*time.Second, p.Scheduled(499), float64(10*time.Microsecond))
+	assert.Equal(t, 20*time.Second, p.Scheduled(5000))
+
+	assert.Equal(t, 0.0, p.Rate(-time.Second))
+	assert.Equal(t, 50.0, p.Rate(5*time.Second))
+	assert.Equal(t, 100.0, p.Rate(15*time.Second))
+	assert.Equal(t, 0.0, p.Rate(20*time.Second))
+	assert.Equal(t, "Piecewise{3 points over 20s}", p.String())
+
+	assert.Panics(t, func() {
+		(&PiecewisePacer{Points: []RatePoint{{Elapsed: time.Second, Rate: 1}}}).Pace(0, 0)
+	})
+}
+
+func TestReadRatePoints(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		points, err := ReadRatePoints(strings.NewReader(`
+# ramp up
+0s,0
+1m, 100
+90 100.5
+2m30s	0
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, []RatePoint{
+			{Elapsed: 0, Rate: 0},
+			{Elapsed: time.Minute, Rate: 100},
+			{Elapsed: 90 * time.Second, Rate: 100.5},
+			{Elapsed: 150 * time.Second, Rate: 0},
+		}, points)
+	})
+
+	for _, tc := range []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{"fields", "0s,1,2\n1s,1", "line 1: expected elapsed duration and rate"},
+		{"duration", "0s,1\nsoon,1", `line 2: invalid elapsed duration "soon"`},
+		{"rate", "0s,1\n1s,lots", `line 2: invalid rate "lots"`},
+		{"order", "1s,1\n1s,2", "rate points point 1: elapsed duration must be greater than that of the previous point"},
+		{"negative", "0s,1\n1s,-2", "rate points point 1: elapsed duration and rate cannot be negative"},
+		{"single", "0s,1", "rate points must have at least 2 points"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadRatePoints(strings.NewReader(tc.in))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestAdaptivePacer(t *testing.T) {
+	p := NewAdaptivePacer(10)
+
+	wait, stop := p.Pace(0, 0)
+	assert.False(t, stop)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	wait, _ = p.Pace(time.Second, 10)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	// the new rate applies from the next hit without catching up
+	p.SetRate(100)
+	assert.Equal(t, 100.0, p.Rate(0))
+
+	wait, _ = p.Pace(2*time.Second, 10)
+	assert.Equal(t, 10*time.Millisecond, wait)
+
+	wait, _ = p.Pace(2*time.Second, 15)
+	assert.Equal(t, 60*time.Millisecond, wait)
+
+	wait, _ = p.Pace(3*time.Second, 15)
+	assert.True(t, wait < 0)
+
+	assert.Equal(t, "Adaptive{100 hits / 1s}", p.String())
+
+	p.Stop()
+	_, stop = p.Pace(3*time.Second, 16)
+	assert.True(t, stop)
+
+	assert.Panics(t, func() { NewAdaptivePacer(0) })
+}
+
 // Stolen from https://github.com/google/go-cmp/cmp/cmpopts/equate.go
 // to avoid an unwieldy dependency. Both fraction and margin set at 1e-6.
 func floatEqual(x, y float64) bool {
@@ -0,0 +1,239 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/template"
+	"github.com/bojand/ghz/runner"
+)
+
+// ComparisonPrinter is used for printing the comparison of a report against a baseline report
+type ComparisonPrinter struct {
+	Out        io.Writer
+	Comparison *runner.Comparison
+}
+
+// Print the comparison using the given format
+// If format is "summary" prints the summary of the comparison
+// If format is "markdown" prints the comparison as a markdown table
+// If format is "json" prints the comparison as JSON
+// If format is "pretty" prints the comparison as pretty JSON
+// If format is "html" prints the comparison as HTML
+func (cp *ComparisonPrinter) Print(format string) error {
+	switch format {
+	case "", "summary":
+		return cp.print(compareTmpl)
+	case "markdown":
+		return cp.print(compareMarkdownTmpl)
+	case "json", "pretty":
+		return cp.printJSON(format == "pretty")
+	case "html":
+		return cp.print(compareHTMLTmpl)
+	default:
+		return fmt.Errorf("unknown comparison format %q", format)
+	}
+}
+
+func (cp *ComparisonPrinter) print(tmpl string) error {
+	templ := template.Must(template.New("tmpl").Funcs(compareFuncMap).Parse(tmpl))
+	return templ.Execute(cp.Out, cp.Comparison)
+}
+
+func (cp *ComparisonPrinter) printJSON(pretty bool) error {
+	_, err := fmt.Fprintln(cp.Out, jsonify(cp.Comparison, pretty))
+	return err
+}
+
+var compareFuncMap = template.FuncMap{
+	"formatDate":         formatDate,
+	"formatNanoUnit":     formatNanoUnit,
+	"formatCompareValue": formatCompareValue,
+	"formatChange":       formatChange,
+	"formatTolerance":    formatTolerance,
+	"formatMetrics":      formatMetrics,
+}
+
+// formatCompareValue formats the value of the compared metric
+func formatCompareValue(metric string, v float64) string {
+	switch metric {
+	case "rps":
+		return formatSeconds(v)
+	case "error-rate":
+		return fmt.Sprintf("%.2f %%", v)
+	}
+
+	return formatNanoUnit(time.Duration(v))
+}
+
+// formatChange formats the change of the compared metric, the error rate
+// change is in percentage points and the other changes are relative
+func formatChange(m runner.MetricComparison) string {
+	if m.Metric == "error-rate" {
+		return fmt.Sprintf("%+.2f pts", m.Delta)
+	}
+
+	return fmt.Sprintf("%+.2f %%", m.DeltaPercent)
+}
+
+func formatTolerance(m runner.MetricComparison) string {
+	if m.Metric == "error-rate" {
+		return fmt.Sprintf("%.2f pts", m.Tolerance)
+	}
+
+	return fmt.Sprintf("%.2f %%", m.Tolerance)
+}
+
+func formatMetrics(metrics []runner.MetricComparison) string {
+	padding := 3
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	// bytes.Buffer can be assumed to not fail on write
+	_, _ = fmt.Fprint(w, "  Metric\tBaseline\tCurrent\tChange\tTolerance\tStatus\t\n")
+	for _, m := range metrics {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t\n", m.Metric,
+			formatCompareValue(m.Metric, m.Baseline), formatCompareValue(m.Metric, m.Current),
+			formatChange(m), formatTolerance(m), strings.ToUpper(m.Status))
+	}
+	// bytes.Buffer can be assumed to not fail on write
+	_ = w.Flush()
+	return buf.String()
+}
+
+var (
+	compareTmpl = `
+Comparison:
+  Baseline:	{{ if .Baseline.Name }}{{ .Baseline.Name }} {{ end }}{{ formatDate .Baseline.Date }} ({{ .Baseline.Count }} requests in {{ formatNanoUnit .Baseline.Total }})
+  Current:	{{ if .Current.Name }}{{ .Current.Name }} {{ end }}{{ formatDate .Current.Date }} ({{ .Current.Count }} requests in {{ formatNanoUnit .Current.Total }})
+
+{{ formatMetrics .Metrics }}
+{{ if .Significance }}Significance:
+  Mann-Whitney U test of {{ .Significance.BaselineSamples }} baseline and {{ .Significance.CurrentSamples }} current latencies
+  p-value:	{{ printf "%.4f" .Significance.PValue }}{{ if .Significance.Significant }} (significant){{ else }} (not significant){{ end }}
+  P(current slower):	{{ printf "%.2f" .Significance.ProbabilitySlower }}
+{{ else }}Significance:
+  Not enough result details for the significance test
+{{ end }}
+Result: {{ if .Regressed }}REGRESSED{{ else }}PASSED{{ end }}
+`
+
+	compareMarkdownTmpl = `## Comparison {{ if .Regressed }}:x: Regressed{{ else }}:white_check_mark: Passed{{ end }}
+
+| | Name | Date | Count | Total |
+|---|---|---|---|---|
+| Baseline | {{ .Baseline.Name }} | {{ formatDate .Baseline.Date }} | {{ .Baseline.Count }} | {{ formatNanoUnit .Baseline.Total }} |
+| Current | {{ .Current.Name }} | {{ formatDate .Current.Date }} | {{ .Current.Count }} | {{ formatNanoUnit .Current.Total }} |
+
+| Metric | Baseline | Current | Change | Tolerance | Status |
+|---|---:|---:|---:|---:|---|
+{{ range .Metrics }}| {{ .Metric }} | {{ formatCompareValue .Metric .Baseline }} | {{ formatCompareValue .Metric .Current }} | {{ formatChange . }} | {{ formatTolerance . }} | {{ .Status }} |
+{{ end }}
+{{ if .Significance }}Mann-Whitney U test of {{ .Significance.BaselineSamples }} baseline and {{ .Significance.CurrentSamples }} current latencies: p-value {{ printf "%.4f" .Significance.PValue }} ({{ if .Significance.Significant }}significant{{ else }}not significant{{ end }}), P(current slower) {{ printf "%.2f" .Significance.ProbabilitySlower }}.{{ else }}Not enough result details for the significance test.{{ end }}
+`
+
+	compareHTMLTmpl = `
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>ghz comparison</title>
+    <link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/bulma/0.7.1/css/bulma.min.css">
+  </head>
+
+  <body>
+    <section class="section">
+      <div class="container">
+        <h1 class="title">Comparison <span class="tag is-medium {{ if .Regressed }}is-danger">Regressed{{ else }}is-success">Passed{{ end }}</span></h1>
+        <table class="table">
+          <thead>
+            <tr>
+              <th></th>
+              <th>Name</th>
+              <th>Date</th>
+              <th>Count</th>
+              <th>Total</th>
+            </tr>
+          </thead>
+          <tbody>
+            <tr>
+              <th>Baseline</th>
+              <td>{{ .Baseline.Name }}</td>
+              <td>{{ formatDate .Baseline.Date }}</td>
+              <td>{{ .Baseline.Count }}</td>
+              <td>{{ formatNanoUnit .Baseline.Total }}</td>
+            </tr>
+            <tr>
+              <th>Current</th>
+              <td>{{ .Current.Name }}</td>
+              <td>{{ formatDate .Current.Date }}</td>
+              <td>{{ .Current.Count }}</td>
+              <td>{{ formatNanoUnit .Current.Total }}</td>
+            </tr>
+          </tbody>
+        </table>
+      </div>
+
+      <br />
+      <div class="container">
+        <h3 class="title is-4">Metrics</h3>
+        <table class="table is-hoverable">
+          <thead>
+            <tr>
+              <th>Metric</th>
+              <th>Baseline</th>
+              <th>Current</th>
+              <th>Change</th>
+              <th>Tolerance</th>
+              <th>Status</th>
+            </tr>
+          </thead>
+          <tbody>
+            {{ range .Metrics }}
+              <tr>
+                <td>{{ .Metric }}</td>
+                <td>{{ formatCompareValue .Metric .Baseline }}</td>
+                <td>{{ formatCompareValue .Metric .Current }}</td>
+                <td>{{ formatChange . }}</td>
+                <td>{{ formatTolerance . }}</td>
+                <td>
+                  {{ if eq .Status "regressed" }}<span class="tag is-danger">regressed</span>
+                  {{ else if eq .Status "improved" }}<span class="tag is-success">improved</span>
+                  {{ else }}<span class="tag">unchanged</span>{{ end }}
+                </td>
+              </tr>
+            {{ end }}
+          </tbody>
+        </table>
+      </div>
+
+      <br />
+      <div class="container">
+        <h3 class="title is-4">Significance</h3>
+        {{ if .Significance }}
+        <p>Mann-Whitney U test of {{ .Significance.BaselineSamples }} baseline and {{ .Significance.CurrentSamples }} current latencies.</p>
+        <table class="table">
+          <tbody>
+            <tr>
+              <th>p-value</th>
+              <td>{{ printf "%.4f" .Significance.PValue }} ({{ if .Significance.Significant }}significant{{ else }}not significant{{ end }})</td>
+            </tr>
+            <tr>
+              <th>P(current slower)</th>
+              <td>{{ printf "%.2f" .Significance.ProbabilitySlower }}</td>
+            </tr>
+          </tbody>
+        </table>
+        {{ else }}
+        <p>Not enough result details for the significance test.</p>
+        {{ end }}
+      </div>
+    </section>
+  </body>
+</html>
+`
+)
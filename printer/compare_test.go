@@ -0,0 +1,105 @@
+package printer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/runner"
+)
+
+func newTestComparison() *runner.Comparison {
+	return &runner.Comparison{
+		Baseline: runner.ComparedRun{Name: "v1", Date: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), Count: 100, Total: time.Second},
+		Current:  runner.ComparedRun{Name: "v2", Date: time.Date(2021, 1, 3, 3, 4, 5, 0, time.UTC), Count: 100, Total: time.Second},
+		Metrics: []runner.MetricComparison{
+			{Metric: "p99", Baseline: float64(10 * time.Millisecond), Current: float64(15 * time.Millisecond),
+				Delta: float64(5 * time.Millisecond), DeltaPercent: 50, Tolerance: 10, Status: runner.CompareRegressed},
+			{Metric: "rps", Baseline: 100, Current: 101, Delta: 1, DeltaPercent: 1, Tolerance: 10, Status: runner.CompareUnchanged},
+			{Metric: "error-rate", Baseline: 2, Current: 0, Delta: -2, DeltaPercent: -100, Tolerance: 1, Status: runner.CompareImproved},
+		},
+		Significance: &runner.Significance{Test: "mann-whitney-u", BaselineSamples: 100, CurrentSamples: 100,
+			PValue: 0.0012, ProbabilitySlower: 0.71, Significant: true},
+		Regressed: true,
+	}
+}
+
+func TestComparisonPrinter_Print(t *testing.T) {
+	t.Run("summary", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ComparisonPrinter{Out: buf, Comparison: newTestComparison()}
+
+		assert.NoError(t, p.Print("summary"))
+
+		out := buf.String()
+		assert.Contains(t, out, "Baseline:\tv1 Sat Jan  2 2021 @ 03:04:05 (100 requests in 1.00 s)")
+		assert.Contains(t, out, "p99")
+		assert.Contains(t, out, "10.00 ms")
+		assert.Contains(t, out, "15.00 ms")
+		assert.Contains(t, out, "+50.00 %")
+		assert.Contains(t, out, "REGRESSED")
+		assert.Contains(t, out, "-2.00 pts")
+		assert.Contains(t, out, "IMPROVED")
+		assert.Contains(t, out, "p-value:\t0.0012 (significant)")
+		assert.Contains(t, out, "Result: REGRESSED")
+	})
+
+	t.Run("summary without significance", func(t *testing.T) {
+		c := newTestComparison()
+		c.Significance = nil
+		c.Regressed = false
+
+		buf := &strings.Builder{}
+		p := ComparisonPrinter{Out: buf, Comparison: c}
+
+		assert.NoError(t, p.Print(""))
+		assert.Contains(t, buf.String(), "Not enough result details")
+		assert.Contains(t, buf.String(), "Result: PASSED")
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ComparisonPrinter{Out: buf, Comparison: newTestComparison()}
+
+		assert.NoError(t, p.Print("markdown"))
+
+		out := buf.String()
+		assert.Contains(t, out, "## Comparison :x: Regressed")
+		assert.Contains(t, out, "| p99 | 10.00 ms | 15.00 ms | +50.00 % | 10.00 % | regressed |")
+		assert.Contains(t, out, "| rps | 100.00 | 101.00 | +1.00 % | 10.00 % | unchanged |")
+		assert.Contains(t, out, "p-value 0.0012 (significant)")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ComparisonPrinter{Out: buf, Comparison: newTestComparison()}
+
+		assert.NoError(t, p.Print("json"))
+
+		c := &runner.Comparison{}
+		assert.NoError(t, json.Unmarshal([]byte(buf.String()), c))
+		assert.True(t, c.Regressed)
+		assert.Len(t, c.Metrics, 3)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ComparisonPrinter{Out: buf, Comparison: newTestComparison()}
+
+		assert.NoError(t, p.Print("html"))
+
+		out := buf.String()
+		assert.Contains(t, out, `<span class="tag is-danger">regressed</span>`)
+		assert.Contains(t, out, `<span class="tag is-success">improved</span>`)
+		assert.Contains(t, out, "0.0012 (significant)")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		p := ComparisonPrinter{Out: &strings.Builder{}, Comparison: newTestComparison()}
+
+		assert.EqualError(t, p.Print("csv"), `unknown comparison format "csv"`)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/bojand/ghz/runner"
 )
 
 func (rp *ReportPrinter) printInfluxLine() error {
@@ -22,6 +24,25 @@ func (rp *ReportPrinter) printInfluxLine() error {
 	return nil
 }
 
+// PrintInflux prints the summary of the report as InfluxDB line protocol, followed by the
+// details if details is set and the assertion results. It is used to write the report to
+// InfluxDB using a runner.InfluxWriter, leaving out the details if they were streamed during the run.
+func (rp *ReportPrinter) PrintInflux(details bool) error {
+	if err := rp.printInfluxLine(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(rp.Out); err != nil {
+		return err
+	}
+
+	if details {
+		return rp.printInfluxDetails()
+	}
+
+	return rp.printInfluxAssertions()
+}
+
 func (rp *ReportPrinter) printInfluxDetails() error {
 	measurement := "ghz_detail"
 	commonTags := rp.getInfluxTags(false)
@@ -48,6 +69,31 @@ func (rp *ReportPrinter) printInfluxDetails() error {
 			return err
 		}
 	}
+
+	return rp.printInfluxAssertions()
+}
+
+func (rp *ReportPrinter) printInfluxAssertions() error {
+	if rp.Report.Assertions == nil {
+		return nil
+	}
+
+	measurement := "ghz_assertion"
+	commonTags := rp.getInfluxTags(false)
+	timestamp := rp.Report.Date.UnixNano()
+	if timestamp < 0 {
+		timestamp = 0
+	}
+
+	for _, v := range rp.Report.Assertions.Results {
+		tags := commonTags + fmt.Sprintf(",assertion=%v", cleanInfluxString(v.Assertion))
+		fields := fmt.Sprintf(`passed=%v,actual="%v"`, v.Passed, v.Actual)
+
+		if _, err := fmt.Fprintf(rp.Out, "%v,%v %v %v\n", measurement, tags, fields, timestamp); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -192,6 +238,43 @@ func (rp *ReportPrinter) getInfluxFields() string {
 
 	s = append(s, fmt.Sprintf("errors=%v", errCount))
 
+	if rp.Report.ValidationFailures > 0 {
+		s = append(s, fmt.Sprintf("validation_failures=%v", rp.Report.ValidationFailures))
+	}
+
+	if sizes := rp.Report.Sizes; sizes != nil {
+		for _, v := range []struct {
+			name string
+			ss   runner.SizeStats
+		}{{"request", sizes.Request}, {"response", sizes.Response}} {
+			s = append(s, fmt.Sprintf("%s_bytes=%v", v.name, v.ss.Total))
+			s = append(s, fmt.Sprintf("%s_throughput=%4.2f", v.name, v.ss.Throughput))
+			s = append(s, fmt.Sprintf("%s_size_average=%4.2f", v.name, v.ss.Average))
+
+			for _, d := range v.ss.SizeDistribution {
+				if d.Percentage == 50 {
+					s = append(s, fmt.Sprintf("%s_size_median=%v", v.name, d.Size))
+				}
+
+				if d.Percentage == 95 {
+					s = append(s, fmt.Sprintf("%s_size_p95=%v", v.name, d.Size))
+				}
+			}
+		}
+	}
+
+	if rp.Report.Assertions != nil {
+		failed := 0
+		for _, v := range rp.Report.Assertions.Results {
+			if !v.Passed {
+				failed++
+			}
+		}
+
+		s = append(s, fmt.Sprintf("assertions_passed=%v", rp.Report.Assertions.Passed))
+		s = append(s, fmt.Sprintf("assertions_failed=%v", failed))
+	}
+
 	return strings.Join(s, ",")
 }
 
@@ -3,6 +3,7 @@ package printer
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -248,3 +249,76 @@ func TestPrinter_printInfluxDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestPrinter_printInfluxAssertions(t *testing.T) {
+	date := time.Now()
+
+	report := &runner.Report{
+		Name:    "run name",
+		Date:    date,
+		Count:   200,
+		Options: runner.Options{Call: "helloworld.Greeter.SayHello", LoadSchedule: "const", CSchedule: "const"},
+		Assertions: &runner.AssertionReport{
+			Passed: false,
+			Results: []runner.AssertionResult{
+				{Assertion: "p99 < 250ms", Actual: "300ms", Passed: false},
+				{Assertion: "rps >= 10", Actual: "100.00", Passed: true},
+			},
+		},
+	}
+
+	buf := bytes.Buffer{}
+	p := ReportPrinter{Report: report, Out: &buf}
+
+	err := p.printInfluxLine()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "assertions_passed=false,assertions_failed=1")
+
+	buf.Reset()
+	err = p.printInfluxDetails()
+	assert.NoError(t, err)
+
+	expected := fmt.Sprintf(`ghz_assertion,name="run\ name",call="helloworld.Greeter.SayHello",host="",n=0,c=0,rps=0,z=0,timeout=0,dial_timeout=0,keepalive=0,data="null",metadata="",tags="",assertion=p99\ <\ 250ms passed=false,actual="300ms" %v
+ghz_assertion,name="run\ name",call="helloworld.Greeter.SayHello",host="",n=0,c=0,rps=0,z=0,timeout=0,dial_timeout=0,keepalive=0,data="null",metadata="",tags="",assertion=rps\ >\=\ 10 passed=true,actual="100.00" %v
+`, date.UnixNano(), date.UnixNano())
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestPrinter_PrintInflux(t *testing.T) {
+	date := time.Now()
+
+	report := &runner.Report{
+		Name:    "run name",
+		Date:    date,
+		Count:   1,
+		Options: runner.Options{Call: "helloworld.Greeter.SayHello", LoadSchedule: "const", CSchedule: "const"},
+		Details: []runner.ResultDetail{{Timestamp: date, Latency: time.Millisecond, Status: "OK"}},
+		Assertions: &runner.AssertionReport{
+			Results: []runner.AssertionResult{{Assertion: "p99 < 250ms", Actual: "1ms", Passed: true}},
+		},
+	}
+
+	buf := bytes.Buffer{}
+	p := ReportPrinter{Report: report, Out: &buf}
+
+	t.Run("with details", func(t *testing.T) {
+		buf.Reset()
+		assert.NoError(t, p.PrintInflux(true))
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "ghz_run,"))
+		assert.True(t, strings.HasPrefix(lines[1], "ghz_detail,"))
+		assert.True(t, strings.HasPrefix(lines[2], "ghz_assertion,"))
+	})
+
+	t.Run("without details", func(t *testing.T) {
+		buf.Reset()
+		assert.NoError(t, p.PrintInflux(false))
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], "ghz_run,"))
+		assert.True(t, strings.HasPrefix(lines[1], "ghz_assertion,"))
+	})
+}
@@ -0,0 +1,187 @@
+package printer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// printJUnit prints the report as JUnit XML test results. The summary statistics and status codes
+// are passing test cases, each error is a failing test case and each assertion is a test case
+// failing if the assertion failed.
+func (rp *ReportPrinter) printJUnit() error {
+	r := rp.Report
+
+	name := r.Name
+	if name == "" {
+		name = r.Options.Call
+	}
+	if name == "" {
+		name = "ghz"
+	}
+
+	suite := junitTestSuite{
+		Name: name,
+		Time: junitSeconds(r.Total),
+	}
+
+	if !r.Date.IsZero() {
+		suite.Timestamp = r.Date.Format("2006-01-02T15:04:05")
+	}
+
+	for _, p := range []junitProperty{
+		{"call", r.Options.Call},
+		{"host", r.Options.Host},
+		{"proto", r.Options.Proto},
+		{"protoset", r.Options.Protoset},
+		{"end-reason", string(r.EndReason)},
+	} {
+		if p.Value != "" {
+			suite.Properties = append(suite.Properties, p)
+		}
+	}
+
+	stat := func(name, value string) {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      name,
+			ClassName: "ghz.summary",
+			Time:      "0",
+			SystemOut: value,
+		})
+	}
+
+	stat("count", strconv.FormatUint(r.Count, 10))
+	stat("total", formatNanoUnit(r.Total))
+	stat("slowest", formatNanoUnit(r.Slowest))
+	stat("fastest", formatNanoUnit(r.Fastest))
+	stat("average", formatNanoUnit(r.Average))
+	stat("requests/sec", formatSeconds(r.Rps))
+
+	for _, ld := range r.LatencyDistribution {
+		stat("p"+strconv.Itoa(ld.Percentage), formatNanoUnit(ld.Latency))
+	}
+
+	// the failed calls are counted by their errors, so the status codes are informational
+	for _, code := range sortedKeys(r.StatusCodeDist) {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      code,
+			ClassName: "ghz.status",
+			Time:      "0",
+			SystemOut: fmt.Sprintf("%d responses", r.StatusCodeDist[code]),
+		})
+	}
+
+	for _, e := range sortedKeys(r.ErrorDist) {
+		n := r.ErrorDist[e]
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      e,
+			ClassName: "ghz.errors",
+			Time:      "0",
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%d responses with error", n),
+				Type:    "error",
+				Text:    e,
+			},
+		})
+	}
+
+	if r.Assertions != nil {
+		for _, a := range r.Assertions.Results {
+			tc := junitTestCase{
+				Name:      a.Assertion,
+				ClassName: "ghz.assertions",
+				Time:      "0",
+				SystemOut: "actual: " + a.Actual,
+			}
+
+			if !a.Passed {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("assertion %s failed, actual: %s", a.Assertion, a.Actual),
+					Type:    "assertion",
+				}
+			}
+
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+	}
+
+	suite.Tests = len(suite.TestCases)
+	for _, tc := range suite.TestCases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	suites := junitTestSuites{
+		Name:     "ghz",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	out, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return rp.print(xml.Header + string(out) + "\n")
+}
+
+// junitSeconds formats the duration in seconds
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,80 @@
+package printer
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/runner"
+)
+
+func TestPrinter_printJUnit(t *testing.T) {
+	report := &runner.Report{
+		Name:      "greeter",
+		EndReason: runner.ReasonNormalEnd,
+		Date:      time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		Options:   runner.Options{Call: "helloworld.Greeter.SayHello", Host: "localhost:50051"},
+		Count:     10,
+		Total:     1500 * time.Millisecond,
+		Average:   10 * time.Millisecond,
+		Fastest:   5 * time.Millisecond,
+		Slowest:   20 * time.Millisecond,
+		Rps:       6.67,
+		StatusCodeDist: map[string]int{
+			"OK":          8,
+			"Unavailable": 2,
+		},
+		ErrorDist: map[string]int{
+			"rpc error: code = Unavailable desc = <down>": 2,
+		},
+		LatencyDistribution: []runner.LatencyDistribution{
+			{Percentage: 99, Latency: 19 * time.Millisecond},
+		},
+		Assertions: &runner.AssertionReport{
+			Results: []runner.AssertionResult{
+				{Assertion: "p99 < 10ms", Actual: "19ms"},
+				{Assertion: "count == 10", Actual: "10", Passed: true},
+			},
+		},
+	}
+
+	buf := &strings.Builder{}
+	p := ReportPrinter{Out: buf, Report: report}
+
+	assert.NoError(t, p.Print("junit"))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, xml.Header))
+	assert.Contains(t, out, `<testsuites name="ghz" tests="12" failures="2" errors="0" time="1.500">`)
+	assert.Contains(t, out, `<testsuite name="greeter" tests="12" failures="2" errors="0" time="1.500" timestamp="2021-01-02T03:04:05">`)
+	assert.Contains(t, out, `<property name="call" value="helloworld.Greeter.SayHello"></property>`)
+	assert.Contains(t, out, `<testcase name="p99" classname="ghz.summary" time="0">`)
+	assert.Contains(t, out, `<testcase name="Unavailable" classname="ghz.status" time="0">`)
+	assert.Contains(t, out, `<failure message="2 responses with error" type="error">rpc error: code = Unavailable desc = &lt;down&gt;</failure>`)
+	assert.Contains(t, out, `<failure message="assertion p99 &lt; 10ms failed, actual: 19ms" type="assertion"></failure>`)
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal([]byte(out), &suites))
+	assert.Len(t, suites.Suites, 1)
+
+	var failed []string
+	for _, tc := range suites.Suites[0].TestCases {
+		if tc.Failure != nil {
+			failed = append(failed, tc.ClassName+":"+tc.Name)
+		}
+	}
+	assert.Equal(t, []string{
+		"ghz.errors:rpc error: code = Unavailable desc = <down>",
+		"ghz.assertions:p99 < 10ms",
+	}, failed)
+
+	// a failed call is only counted once, by its error
+	for _, tc := range suites.Suites[0].TestCases {
+		if tc.ClassName == "ghz.status" && tc.Name == "Unavailable" {
+			assert.Equal(t, "2 responses", tc.SystemOut)
+		}
+	}
+}
@@ -36,16 +36,21 @@ type ReportPrinter struct {
 // 		html
 // 		influx-summary
 // 		influx-details
+// 		prometheus
+// 		markdown
+// 		junit
 func (rp *ReportPrinter) Print(format string) error {
 	if format == "" {
 		format = "summary"
 	}
 
 	switch format {
-	case "summary", "csv":
+	case "summary", "csv", "markdown":
 		outputTmpl := defaultTmpl
 		if format == "csv" {
 			outputTmpl = csvTmpl
+		} else if format == "markdown" {
+			outputTmpl = markdownTmpl
 		}
 		buf := &bytes.Buffer{}
 		templ := template.Must(template.New("tmpl").Funcs(tmplFuncMap).Parse(outputTmpl))
@@ -82,6 +87,8 @@ func (rp *ReportPrinter) Print(format string) error {
 		return rp.printInfluxDetails()
 	case "prometheus":
 		return rp.printPrometheus()
+	case "junit":
+		return rp.printJUnit()
 	default:
 		return fmt.Errorf("unknown format: %s", format)
 	}
@@ -103,6 +110,91 @@ var tmplFuncMap = template.FuncMap{
 	"formatErrorDist":  formatErrorDist,
 	"formatDate":       formatDate,
 	"formatNanoUnit":   formatNanoUnit,
+	"formatAssertions": formatAssertions,
+	"formatCalls":      formatCalls,
+	"callPercentile":   callPercentile,
+
+	"latencyPercentile": latencyPercentile,
+	"formatSearchSteps": formatSearchSteps,
+	"formatStreaming":   formatStreaming,
+	"formatBytes":       formatBytes,
+	"formatSizes":       formatSizes,
+	"timelineData":      timelineData,
+	"escapeMarkdown":    escapeMarkdown,
+	"escapeCSV":         escapeCSV,
+	"multiply":          multiply,
+}
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\n", " ", "\r", "")
+
+// escapeMarkdown escapes the text for a markdown table cell
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// escapeCSV quotes the text for a csv field if it contains a separator, a quote or a line break
+func escapeCSV(s string) string {
+	if !strings.ContainsAny(s, ",\"\r\n") {
+		return s
+	}
+
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func multiply(a, b float64) float64 {
+	return a * b
+}
+
+// timelineTopic is a line of a timeline chart
+type timelineTopic struct {
+	Topic     int             `json:"topic"`
+	TopicName string          `json:"topicName"`
+	Dates     []timelinePoint `json:"dates"`
+}
+
+type timelinePoint struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// timelineData returns the JSON chart data of the throughput or the latency of the timeline
+func timelineData(timeline []runner.TimelineBucket, chart string) string {
+	var names []string
+	var values []func(b runner.TimelineBucket) float64
+
+	if chart == "latency" {
+		for _, p := range []int{50, 95, 99} {
+			p := p
+			names = append(names, fmt.Sprintf("%d %%", p))
+			values = append(values, func(b runner.TimelineBucket) float64 {
+				return float64(latencyPercentile(b.LatencyDistribution, p)) / float64(time.Millisecond)
+			})
+		}
+	} else {
+		names = append(names, "Requests / sec")
+		values = append(values, func(b runner.TimelineBucket) float64 { return b.Rps })
+
+		for _, b := range timeline {
+			if b.TargetRps > 0 {
+				names = append(names, "Target RPS")
+				values = append(values, func(b runner.TimelineBucket) float64 { return b.TargetRps })
+				break
+			}
+		}
+
+		names = append(names, "Workers")
+		values = append(values, func(b runner.TimelineBucket) float64 { return float64(b.Workers) })
+	}
+
+	topics := make([]timelineTopic, len(names))
+	for i, name := range names {
+		topics[i] = timelineTopic{Topic: i + 1, TopicName: name, Dates: make([]timelinePoint, len(timeline))}
+		for j, b := range timeline {
+			topics[i].Dates[j] = timelinePoint{Date: b.Timestamp.Format(time.RFC3339Nano), Value: values[i](b)}
+		}
+	}
+
+	return jsonify(map[string]interface{}{"dataByTopic": topics}, false)
 }
 
 func jsonify(v interface{}, pretty bool) string {
@@ -229,3 +321,171 @@ func formatErrorDist(errDist map[string]int) string {
 	_ = w.Flush()
 	return buf.String()
 }
+
+func formatAssertions(results []runner.AssertionResult) string {
+	padding := 3
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	for _, r := range results {
+		res := "FAIL"
+		if r.Passed {
+			res = "PASS"
+		}
+		// bytes.Buffer can be assumed to not fail on write
+		_, _ = fmt.Fprintf(w, "  [%s]\t%s\tactual: %s\t\n", res, r.Assertion, r.Actual)
+	}
+	// bytes.Buffer can be assumed to not fail on write
+	_ = w.Flush()
+	return buf.String()
+}
+
+func formatCalls(calls []runner.CallReport) string {
+	padding := 3
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	// bytes.Buffer can be assumed to not fail on write
+	_, _ = fmt.Fprint(w, "  Name\tCount\tRequests/sec\tAverage\tp50\tp95\tp99\tErrors\t\n")
+	for _, c := range calls {
+		errCount := 0
+		for _, n := range c.ErrorDist {
+			errCount += n
+		}
+
+		_, _ = fmt.Fprintf(w, "  %s\t%d\t%s\t%s\t%s\t%s\t%s\t%d\t\n",
+			c.Name, c.Count, formatSeconds(c.Rps), formatNanoUnit(c.Average),
+			formatNanoUnit(callPercentile(c, 50)), formatNanoUnit(callPercentile(c, 95)),
+			formatNanoUnit(callPercentile(c, 99)), errCount)
+	}
+	// bytes.Buffer can be assumed to not fail on write
+	_ = w.Flush()
+	return buf.String()
+}
+
+// callPercentile returns the latency of the percentile in the call latency distribution
+func callPercentile(c runner.CallReport, p int) time.Duration {
+	return latencyPercentile(c.LatencyDistribution, p)
+}
+
+// latencyPercentile returns the latency of the percentile in the latency distribution
+func latencyPercentile(dist []runner.LatencyDistribution, p int) time.Duration {
+	for _, ld := range dist {
+		if ld.Percentage == p {
+			return ld.Latency
+		}
+	}
+
+	return 0
+}
+
+// formatBytes formats the size in bytes using binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit && exp < 4; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.2f %ciB", float64(n)/float64(div), "KMGTP"[exp])
+}
+
+func formatSizes(s *runner.SizesReport) string {
+	padding := 3
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	// bytes.Buffer can be assumed to not fail on write
+	_, _ = fmt.Fprint(w, "  Size\tTotal\tBytes/sec\tAverage\tSmallest\tp50\tp95\tp99\tLargest\t\n")
+	for _, l := range []struct {
+		name string
+		ss   runner.SizeStats
+	}{{"Request", s.Request}, {"Response", s.Response}} {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			l.name, formatBytes(l.ss.Total), formatBytes(int64(l.ss.Throughput)), formatBytes(int64(l.ss.Average)),
+			formatBytes(l.ss.Smallest), formatBytes(sizePercentile(l.ss.SizeDistribution, 50)),
+			formatBytes(sizePercentile(l.ss.SizeDistribution, 95)), formatBytes(sizePercentile(l.ss.SizeDistribution, 99)),
+			formatBytes(l.ss.Largest))
+	}
+	// bytes.Buffer can be assumed to not fail on write
+	_ = w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sizePercentile returns the size of the percentile in the size distribution
+func sizePercentile(dist []runner.SizeDistribution, p int) int64 {
+	for _, sd := range dist {
+		if sd.Percentage == p {
+			return sd.Size
+		}
+	}
+
+	return 0
+}
+
+func formatStreaming(s *runner.StreamingReport) string {
+	padding := 3
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	// bytes.Buffer can be assumed to not fail on write
+	_, _ = fmt.Fprintf(w, "  Calls:\t%d\n", s.Count)
+	_, _ = fmt.Fprintf(w, "  Messages sent:\t%d\t(%s/sec, %s)\n", s.MessagesSent, formatSeconds(s.SentRate), formatBytes(s.BytesSent))
+	_, _ = fmt.Fprintf(w, "  Messages received:\t%d\t(%s/sec, %s)\n", s.MessagesReceived, formatSeconds(s.ReceivedRate), formatBytes(s.BytesReceived))
+	_ = w.Flush()
+
+	if s.TimeToFirstResponse == nil && s.InterMessageLatency == nil {
+		return buf.String()
+	}
+
+	_, _ = fmt.Fprint(buf, "\n")
+	w = tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	_, _ = fmt.Fprint(w, "  Latency\tCount\tAverage\tFastest\tp50\tp95\tp99\tSlowest\t\n")
+	for _, l := range []struct {
+		name string
+		ml   *runner.MessageLatencies
+	}{{"Time to first response", s.TimeToFirstResponse}, {"Inter-message", s.InterMessageLatency}} {
+		if l.ml == nil {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(w, "  %s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			l.name, l.ml.Count, formatNanoUnit(l.ml.Average), formatNanoUnit(l.ml.Fastest),
+			formatNanoUnit(latencyPercentile(l.ml.LatencyDistribution, 50)),
+			formatNanoUnit(latencyPercentile(l.ml.LatencyDistribution, 95)),
+			formatNanoUnit(latencyPercentile(l.ml.LatencyDistribution, 99)),
+			formatNanoUnit(l.ml.Slowest))
+	}
+	// bytes.Buffer can be assumed to not fail on write
+	_ = w.Flush()
+	return buf.String()
+}
+
+func formatSearchSteps(steps []runner.SearchStep) string {
+	padding := 3
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, padding, ' ', 0)
+	// bytes.Buffer can be assumed to not fail on write
+	_, _ = fmt.Fprint(w, "  Target RPS\tResult\tRequests/sec\tAverage\tp50\tp95\tp99\tErrors\t\n")
+	for _, s := range steps {
+		res := "FAIL"
+		if s.Passed {
+			res = "PASS"
+		}
+
+		errCount := 0
+		for _, n := range s.ErrorDist {
+			errCount += n
+		}
+
+		_, _ = fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t\n",
+			s.RPS, res, formatSeconds(s.Rps), formatNanoUnit(s.Average),
+			formatNanoUnit(latencyPercentile(s.LatencyDistribution, 50)),
+			formatNanoUnit(latencyPercentile(s.LatencyDistribution, 95)),
+			formatNanoUnit(latencyPercentile(s.LatencyDistribution, 99)), errCount)
+	}
+	// bytes.Buffer can be assumed to not fail on write
+	_ = w.Flush()
+	return buf.String()
+}
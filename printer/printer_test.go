@@ -0,0 +1,463 @@
+package printer
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bojand/ghz/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrinter_formatCalls(t *testing.T) {
+	calls := []runner.CallReport{
+		{
+			Name:    "get",
+			Call:    "catalog.Catalog.GetItem",
+			Count:   70,
+			Rps:     35,
+			Average: 2 * time.Millisecond,
+			LatencyDistribution: []runner.LatencyDistribution{
+				{Percentage: 50, Latency: 2 * time.Millisecond},
+				{Percentage: 95, Latency: 4 * time.Millisecond},
+				{Percentage: 99, Latency: 5 * time.Millisecond},
+			},
+		},
+		{
+			Name:      "update",
+			Call:      "catalog.Catalog.UpdateItem",
+			Count:     10,
+			Rps:       5,
+			Average:   12 * time.Millisecond,
+			ErrorDist: map[string]int{"rpc error: code = Unavailable": 2},
+		},
+	}
+
+	lines := strings.Split(strings.TrimRight(formatCalls(calls), "\n"), "\n")
+
+	assert.Len(t, lines, 3)
+	assert.Equal(t, []string{"Name", "Count", "Requests/sec", "Average", "p50", "p95", "p99", "Errors"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"get", "70", "35.00", "2.00", "ms", "2.00", "ms", "4.00", "ms", "5.00", "ms", "0"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"update", "10", "5.00", "12.00", "ms", "0", "ns", "0", "ns", "0", "ns", "2"}, strings.Fields(lines[2]))
+}
+
+func TestPrinter_Print_journey(t *testing.T) {
+	report := &runner.Report{
+		Count:          6,
+		ErrorDist:      map[string]int{},
+		StatusCodeDist: map[string]int{"OK": 6},
+		Calls: []runner.CallReport{
+			{Name: "login", Call: "auth.Auth.Login", Count: 3},
+			{Name: "profile", Call: "users.Users.GetProfile", Count: 3},
+		},
+		Journey: &runner.JourneyReport{
+			Count:          3,
+			Average:        12 * time.Millisecond,
+			ErrorDist:      map[string]int{"profile: rpc error: code = NotFound": 1},
+			StatusCodeDist: map[string]int{"OK": 2, "NotFound": 1},
+			LatencyDistribution: []runner.LatencyDistribution{
+				{Percentage: 50, Latency: 11 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, format := range []string{"summary", "html"} {
+		t.Run(format, func(t *testing.T) {
+			buf := &strings.Builder{}
+			p := ReportPrinter{Out: buf, Report: report}
+
+			err := p.Print(format)
+			assert.NoError(t, err)
+
+			out := buf.String()
+			assert.Contains(t, out, "Steps")
+			assert.Contains(t, out, "Journey")
+			assert.Contains(t, out, "profile: rpc error: code = NotFound")
+			assert.Contains(t, out, "12.00 ms")
+		})
+	}
+}
+
+func TestPrinter_Print_validationFailures(t *testing.T) {
+	report := &runner.Report{
+		Count:              4,
+		ErrorDist:          map[string]int{"response validation failed: status == ACTIVE": 2},
+		StatusCodeDist:     map[string]int{"OK": 4},
+		ValidationFailures: 2,
+	}
+
+	for _, format := range []string{"summary", "html"} {
+		t.Run(format, func(t *testing.T) {
+			buf := &strings.Builder{}
+			p := ReportPrinter{Out: buf, Report: report}
+
+			err := p.Print(format)
+			assert.NoError(t, err)
+
+			out := buf.String()
+			assert.Contains(t, out, "response validation failed: status == ACTIVE")
+			assert.Regexp(t, `Validation failures:\s+2`, out)
+		})
+	}
+
+	t.Run("influx-summary", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: report}
+
+		err := p.Print("influx-summary")
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "errors=2,validation_failures=2")
+	})
+}
+
+func TestPrinter_Print_corrected(t *testing.T) {
+	report := &runner.Report{
+		Count:          4,
+		StatusCodeDist: map[string]int{"OK": 4},
+		LatencyDistribution: []runner.LatencyDistribution{
+			{Percentage: 99, Latency: 5 * time.Millisecond},
+		},
+		Corrected: &runner.CorrectedLatencies{
+			Average: 40 * time.Millisecond,
+			Slowest: 120 * time.Millisecond,
+			LatencyDistribution: []runner.LatencyDistribution{
+				{Percentage: 99, Latency: 110 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, format := range []string{"summary", "html"} {
+		t.Run(format, func(t *testing.T) {
+			buf := &strings.Builder{}
+			p := ReportPrinter{Out: buf, Report: report}
+
+			err := p.Print(format)
+			assert.NoError(t, err)
+
+			out := buf.String()
+			assert.Contains(t, out, "Corrected latency distribution")
+			assert.Contains(t, out, "110.00 ms")
+		})
+	}
+
+	t.Run("summary without correction", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: &runner.Report{Count: 4}}
+
+		err := p.Print("summary")
+		assert.NoError(t, err)
+		assert.NotContains(t, buf.String(), "Corrected")
+	})
+}
+
+func TestPrinter_Print_streaming(t *testing.T) {
+	report := &runner.Report{
+		Count:          4,
+		StatusCodeDist: map[string]int{"OK": 4},
+		Streaming: &runner.StreamingReport{
+			Count:            4,
+			MessagesSent:     4,
+			MessagesReceived: 16,
+			BytesSent:        64,
+			BytesReceived:    3 * 1024,
+			SentRate:         2,
+			ReceivedRate:     8,
+			TimeToFirstResponse: &runner.MessageLatencies{
+				Count:   4,
+				Average: 3 * time.Millisecond,
+				Slowest: 5 * time.Millisecond,
+			},
+			InterMessageLatency: &runner.MessageLatencies{
+				Count:   12,
+				Average: 4 * time.Millisecond,
+				LatencyDistribution: []runner.LatencyDistribution{
+					{Percentage: 99, Latency: 42 * time.Millisecond},
+				},
+			},
+		},
+	}
+
+	for format, expected := range map[string][]string{
+		"summary":  {"Streaming:", "Messages received:   16   (8.00/sec, 3.00 KiB)", "Time to first response", "42.00 ms"},
+		"markdown": {"### Streaming", "| 4 | 4 | 2.00 | 64 B | 16 | 8.00 | 3.00 KiB |", "| Inter-message | 12 | 4.00 ms |"},
+		"html":     {"<h3>Streaming</h3>", "received 16 messages (8.00/sec, 3.00 KiB)", "42.00 ms"},
+	} {
+		t.Run(format, func(t *testing.T) {
+			buf := &strings.Builder{}
+			p := ReportPrinter{Out: buf, Report: report}
+
+			assert.NoError(t, p.Print(format))
+
+			for _, e := range expected {
+				assert.Contains(t, buf.String(), e)
+			}
+		})
+	}
+}
+
+func TestPrinter_Print_sizes(t *testing.T) {
+	dist := func(p50, p95, p99 int64) []runner.SizeDistribution {
+		return []runner.SizeDistribution{{Percentage: 50, Size: p50}, {Percentage: 95, Size: p95}, {Percentage: 99, Size: p99}}
+	}
+
+	report := &runner.Report{
+		Count:          4,
+		Total:          2 * time.Second,
+		StatusCodeDist: map[string]int{"OK": 4},
+		Sizes: &runner.SizesReport{
+			Request: runner.SizeStats{
+				Total: 64, Average: 16, Smallest: 16, Largest: 16, Throughput: 32,
+				SizeDistribution: dist(16, 16, 16),
+			},
+			Response: runner.SizeStats{
+				Total: 6 * 1024, Average: 1536, Smallest: 1024, Largest: 2048, Throughput: 3 * 1024,
+				SizeDistribution: dist(1024, 2048, 2048),
+			},
+		},
+	}
+
+	for format, expected := range map[string][]string{
+		"summary":        {"Sizes:", "Request    64 B       32 B        16 B       16 B       16 B       16 B       16 B       16 B", "Response   6.00 KiB   3.00 KiB    1.50 KiB   1.00 KiB   1.00 KiB   2.00 KiB   2.00 KiB   2.00 KiB"},
+		"json":           {`"sizes":{"request":{"total":64,"average":16,`},
+		"influx-summary": {"request_bytes=64,request_throughput=32.00,request_size_average=16.00,request_size_median=16,request_size_p95=16", "response_bytes=6144,response_throughput=3072.00"},
+	} {
+		t.Run(format, func(t *testing.T) {
+			buf := &strings.Builder{}
+			p := ReportPrinter{Out: buf, Report: report}
+
+			assert.NoError(t, p.Print(format))
+
+			for _, e := range expected {
+				assert.Contains(t, buf.String(), e)
+			}
+		})
+	}
+
+	t.Run("prometheus", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: report}
+
+		assert.NoError(t, p.Print("prometheus"))
+
+		assert.Regexp(t, `ghz_run_request_bytes\{.*\} 64\n`, buf.String())
+		assert.Regexp(t, `ghz_run_response_bytes_per_second\{.*\} 3072\n`, buf.String())
+		assert.Regexp(t, `ghz_run_response_size\{.*quantile="0.95".*\} 2048\n`, buf.String())
+		assert.Regexp(t, `ghz_run_response_size_sum\{.*\} 6144\n`, buf.String())
+		assert.Regexp(t, `ghz_run_request_size_count\{.*\} 4\n`, buf.String())
+	})
+}
+
+func TestPrinter_formatBytes(t *testing.T) {
+	assert.Equal(t, "0 B", formatBytes(0))
+	assert.Equal(t, "1023 B", formatBytes(1023))
+	assert.Equal(t, "1.50 KiB", formatBytes(1536))
+	assert.Equal(t, "2.00 MiB", formatBytes(2*1024*1024))
+	assert.Equal(t, "1.00 GiB", formatBytes(1024*1024*1024))
+}
+
+func TestPrinter_Print_search(t *testing.T) {
+	report := &runner.Report{
+		Count:          300,
+		StatusCodeDist: map[string]int{"OK": 300},
+		Search: &runner.SearchReport{
+			MaxRPS: 100,
+			Steps: []runner.SearchStep{
+				{
+					RPS:     100,
+					Passed:  true,
+					Count:   100,
+					Rps:     99.5,
+					Average: 10 * time.Millisecond,
+					LatencyDistribution: []runner.LatencyDistribution{
+						{Percentage: 99, Latency: 25 * time.Millisecond},
+					},
+				},
+				{
+					RPS:       200,
+					Count:     200,
+					Rps:       150,
+					Average:   80 * time.Millisecond,
+					ErrorDist: map[string]int{"rpc error: code = Unavailable desc = down": 3},
+				},
+			},
+		},
+	}
+
+	t.Run("summary", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: report}
+
+		err := p.Print("summary")
+		assert.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, "Max sustainable RPS:\t100 (search incomplete)")
+		assert.Contains(t, out, "PASS")
+		assert.Contains(t, out, "FAIL")
+		assert.Contains(t, out, "25.00 ms")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: report}
+
+		err := p.Print("html")
+		assert.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, `href="#search"`)
+		assert.Contains(t, out, "Max sustainable RPS: <strong>100</strong>")
+	})
+
+	t.Run("summary without search", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: &runner.Report{Count: 4}}
+
+		err := p.Print("summary")
+		assert.NoError(t, err)
+		assert.NotContains(t, buf.String(), "Search")
+	})
+}
+
+func TestPrinter_Print_timeline(t *testing.T) {
+	date := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := &runner.Report{
+		Count:   3,
+		Options: runner.Options{TimelineInterval: time.Second},
+		Timeline: []runner.TimelineBucket{
+			{
+				Timestamp: date,
+				Count:     2,
+				Rps:       2,
+				TargetRps: 5,
+				Workers:   4,
+				LatencyDistribution: []runner.LatencyDistribution{
+					{Percentage: 50, Latency: 2 * time.Millisecond},
+					{Percentage: 95, Latency: 3 * time.Millisecond},
+					{Percentage: 99, Latency: 4 * time.Millisecond},
+				},
+			},
+			{Timestamp: date.Add(time.Second), Count: 1, Rps: 1, TargetRps: 5, Workers: 4},
+		},
+	}
+
+	t.Run("html", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: report}
+
+		err := p.Print("html")
+		assert.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, `href="#timeline"`)
+		assert.Contains(t, out, "js-timeline-throughput-container")
+		assert.Contains(t, out, `{"date":"2021-01-02T03:04:05Z","value":2}`)
+	})
+
+	t.Run("data", func(t *testing.T) {
+		assert.Equal(t,
+			`{"dataByTopic":[{"topic":1,"topicName":"50 %","dates":[{"date":"2021-01-02T03:04:05Z","value":2},{"date":"2021-01-02T03:04:06Z","value":0}]},`+
+				`{"topic":2,"topicName":"95 %","dates":[{"date":"2021-01-02T03:04:05Z","value":3},{"date":"2021-01-02T03:04:06Z","value":0}]},`+
+				`{"topic":3,"topicName":"99 %","dates":[{"date":"2021-01-02T03:04:05Z","value":4},{"date":"2021-01-02T03:04:06Z","value":0}]}]}`,
+			timelineData(report.Timeline, "latency"))
+
+		throughput := timelineData(report.Timeline, "throughput")
+		assert.Contains(t, throughput, `"topicName":"Target RPS"`)
+		assert.Contains(t, throughput, `"topicName":"Workers"`)
+
+		throughput = timelineData([]runner.TimelineBucket{{Timestamp: date, Rps: 2}}, "throughput")
+		assert.NotContains(t, throughput, "Target RPS")
+	})
+
+	t.Run("html without timeline", func(t *testing.T) {
+		buf := &strings.Builder{}
+		p := ReportPrinter{Out: buf, Report: &runner.Report{Count: 4}}
+
+		err := p.Print("html")
+		assert.NoError(t, err)
+		assert.NotContains(t, buf.String(), "createTimelineChart('")
+	})
+}
+
+func TestPrinter_Print_markdown(t *testing.T) {
+	report := &runner.Report{
+		Name:    "greeter",
+		Count:   10,
+		Total:   time.Second,
+		Average: 10 * time.Millisecond,
+		Fastest: 5 * time.Millisecond,
+		Slowest: 20 * time.Millisecond,
+		Rps:     10,
+		StatusCodeDist: map[string]int{
+			"OK":          8,
+			"Unavailable": 2,
+		},
+		ErrorDist: map[string]int{
+			"rpc error: a | b": 2,
+		},
+		LatencyDistribution: []runner.LatencyDistribution{
+			{Percentage: 50, Latency: 10 * time.Millisecond},
+			{Percentage: 99, Latency: 19 * time.Millisecond},
+		},
+		Histogram: []runner.Bucket{
+			{Mark: 0.005, Count: 6, Frequency: 0.6},
+			{Mark: 0.02, Count: 4, Frequency: 0.4},
+		},
+		Assertions: &runner.AssertionReport{
+			Results: []runner.AssertionResult{{Assertion: "p99 < 10ms", Actual: "19ms"}},
+		},
+	}
+
+	buf := &strings.Builder{}
+	p := ReportPrinter{Out: buf, Report: report}
+
+	assert.NoError(t, p.Print("markdown"))
+
+	out := buf.String()
+	assert.Contains(t, out, "## greeter")
+	assert.Contains(t, out, "| 10 | 1.00 s | 20.00 ms | 5.00 ms | 10.00 ms | 10.00 |")
+	assert.Contains(t, out, "| 50 % | 99 % |\n|---:|---:|\n| 10.00 ms | 19.00 ms |")
+	assert.Contains(t, out, "| OK | 8 | 80.00 |\n| Unavailable | 2 | 20.00 |")
+	assert.Contains(t, out, "| rpc error: a \\| b | 2 | 20.00 |")
+	assert.Contains(t, out, "| 5.000 | 6 | 60.00 |")
+	assert.Contains(t, out, "### Assertions: failed")
+	assert.Contains(t, out, "| p99 < 10ms | 19ms | FAIL |")
+}
+
+func TestPrinter_Print_csvAssertions(t *testing.T) {
+	report := &runner.Report{
+		Count: 2,
+		Details: []runner.ResultDetail{
+			{Latency: 10 * time.Millisecond, Status: "OK"},
+			{Latency: 20 * time.Millisecond, Status: "OK"},
+		},
+		Assertions: &runner.AssertionReport{
+			Passed: false,
+			Results: []runner.AssertionResult{
+				{Assertion: "p99 < 1ms", Actual: "20ms", Passed: false},
+				{Assertion: `error-rate < 1%, "strict"`, Actual: "0%", Passed: true},
+			},
+		},
+	}
+
+	buf := &strings.Builder{}
+	p := ReportPrinter{Out: buf, Report: report}
+
+	assert.NoError(t, p.Print("csv"))
+
+	// the assertions follow the details in a separate section with the same number of columns
+	assert.Equal(t, "\nduration (ms),status,error\n10.00,OK,\n20.00,OK,\n"+
+		"\nassertion,actual,passed\np99 < 1ms,20ms,false\n\"error-rate < 1%, \"\"strict\"\"\",0%,true\n", buf.String())
+
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"duration (ms)", "status", "error"},
+		{"10.00", "OK", ""},
+		{"20.00", "OK", ""},
+		{"assertion", "actual", "passed"},
+		{"p99 < 1ms", "20ms", "false"},
+		{`error-rate < 1%, "strict"`, "0%", "true"},
+	}, records)
+}
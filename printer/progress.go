@@ -0,0 +1,104 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bojand/ghz/runner"
+)
+
+// ProgressPrinter is used for printing live progress snapshots of a run
+type ProgressPrinter struct {
+	Out io.Writer
+
+	// Live rewrites the progress line in place when set.
+	// Otherwise every snapshot is printed on its own line.
+	Live bool
+
+	lock    sync.Mutex
+	lastLen int
+}
+
+// NewProgressPrinter creates a new progress printer writing to out.
+// Live mode is enabled when out is a terminal.
+func NewProgressPrinter(out io.Writer) *ProgressPrinter {
+	live := false
+	if f, ok := out.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil {
+			live = fi.Mode()&os.ModeCharDevice != 0
+		}
+	}
+
+	return &ProgressPrinter{Out: out, Live: live}
+}
+
+// Print prints the progress snapshot
+func (pp *ProgressPrinter) Print(p *runner.Progress) {
+	if p == nil {
+		return
+	}
+
+	line := formatProgress(p)
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	if !pp.Live {
+		_, _ = fmt.Fprintln(pp.Out, line)
+		return
+	}
+
+	pad := ""
+	if n := pp.lastLen - len(line); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	pp.lastLen = len(line)
+
+	_, _ = fmt.Fprint(pp.Out, "\r"+line+pad)
+}
+
+// Done finishes the live progress line
+func (pp *ProgressPrinter) Done() {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	if pp.Live && pp.lastLen > 0 {
+		_, _ = fmt.Fprintln(pp.Out)
+		pp.lastLen = 0
+	}
+}
+
+func formatProgress(p *runner.Progress) string {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "[%s] count: %d  rps: %.2f", p.Elapsed.Round(100*time.Millisecond), p.Count, p.Rps)
+
+	for _, l := range p.LatencyDistribution {
+		fmt.Fprintf(buf, "  p%d: %s", l.Percentage, formatNanoUnit(l.Latency))
+	}
+
+	fmt.Fprintf(buf, "  errors: %d", p.ErrorCount)
+
+	if len(p.StatusCodeDist) > 0 {
+		codes := make([]string, 0, len(p.StatusCodeDist))
+		for code := range p.StatusCodeDist {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		parts := make([]string, len(codes))
+		for i, code := range codes {
+			parts[i] = fmt.Sprintf("%s: %d", code, p.StatusCodeDist[code])
+		}
+
+		fmt.Fprintf(buf, "  [%s]", strings.Join(parts, ", "))
+	}
+
+	return buf.String()
+}
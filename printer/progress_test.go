@@ -0,0 +1,50 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bojand/ghz/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressPrinter_Print(t *testing.T) {
+	p := &runner.Progress{
+		Elapsed:       1500 * time.Millisecond,
+		Count:         300,
+		ErrorCount:    2,
+		IntervalCount: 150,
+		Rps:           150,
+		LatencyDistribution: []runner.LatencyDistribution{
+			{Percentage: 50, Latency: 2 * time.Millisecond},
+			{Percentage: 95, Latency: 5 * time.Millisecond},
+			{Percentage: 99, Latency: 8 * time.Millisecond},
+		},
+		StatusCodeDist: map[string]int{"OK": 148, "Unavailable": 2},
+	}
+
+	expected := "[1.5s] count: 300  rps: 150.00  p50: 2.00 ms  p95: 5.00 ms  p99: 8.00 ms  errors: 2  [OK: 148, Unavailable: 2]"
+
+	t.Run("lines", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		pp := NewProgressPrinter(buf)
+		assert.False(t, pp.Live)
+
+		pp.Print(p)
+		pp.Print(p)
+		pp.Done()
+
+		assert.Equal(t, expected+"\n"+expected+"\n", buf.String())
+	})
+
+	t.Run("live", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		pp := &ProgressPrinter{Out: buf, Live: true}
+
+		pp.Print(p)
+		pp.Done()
+
+		assert.Equal(t, "\r"+expected+"\n", buf.String())
+	})
+}
@@ -135,6 +135,101 @@ func (rp *ReportPrinter) printPrometheus() error {
 		return err
 	}
 
+	if rp.Report.Sizes != nil {
+		if err := rp.printPrometheusSizes(encoder, labels); err != nil {
+			return err
+		}
+	}
+
+	if rp.Report.Assertions != nil {
+		if err := rp.printPrometheusAssertions(encoder, labels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rp *ReportPrinter) printPrometheusAssertions(encoder expfmt.Encoder, labels []*promtypes.LabelPair) error {
+	if err := rp.printPrometheusMetricGauge(
+		encoder, labels,
+		"ghz_run_assertions_passed",
+		&promtypes.Gauge{Value: ptrFloat64(boolToFloat64(rp.Report.Assertions.Passed))}); err != nil {
+		return err
+	}
+
+	name := "ghz_run_assertion"
+	metricType := promtypes.MetricType_GAUGE
+	mf := promtypes.MetricFamily{
+		Name: &name,
+		Type: &metricType,
+	}
+
+	for _, v := range rp.Report.Assertions.Results {
+		assertionLabels := make([]*promtypes.LabelPair, len(labels), len(labels)+1)
+		copy(assertionLabels, labels)
+		assertionLabels = append(assertionLabels, &promtypes.LabelPair{
+			Name:  ptrString("assertion"),
+			Value: ptrString(v.Assertion),
+		})
+
+		mf.Metric = append(mf.Metric, &promtypes.Metric{
+			Label: assertionLabels,
+			Gauge: &promtypes.Gauge{Value: ptrFloat64(boolToFloat64(v.Passed))},
+		})
+	}
+
+	return encoder.Encode(&mf)
+}
+
+func (rp *ReportPrinter) printPrometheusSizes(encoder expfmt.Encoder, labels []*promtypes.LabelPair) error {
+	for _, v := range []struct {
+		name string
+		ss   runner.SizeStats
+	}{{"request", rp.Report.Sizes.Request}, {"response", rp.Report.Sizes.Response}} {
+		if err := rp.printPrometheusMetricGauge(
+			encoder, labels,
+			"ghz_run_"+v.name+"_bytes", &promtypes.Gauge{Value: ptrFloat64(float64(v.ss.Total))}); err != nil {
+			return err
+		}
+
+		if err := rp.printPrometheusMetricGauge(
+			encoder, labels,
+			"ghz_run_"+v.name+"_bytes_per_second", &promtypes.Gauge{Value: ptrFloat64(v.ss.Throughput)}); err != nil {
+			return err
+		}
+
+		name := "ghz_run_" + v.name + "_size"
+		metricType := promtypes.MetricType_SUMMARY
+		mf := promtypes.MetricFamily{
+			Name: &name,
+			Type: &metricType,
+		}
+
+		summary := &promtypes.Summary{
+			SampleCount: &rp.Report.Count,
+			SampleSum:   ptrFloat64(float64(v.ss.Total)),
+			Quantile:    make([]*promtypes.Quantile, 0, len(v.ss.SizeDistribution)),
+		}
+
+		for _, d := range v.ss.SizeDistribution {
+			summary.Quantile = append(summary.Quantile,
+				&promtypes.Quantile{
+					Quantile: ptrFloat64(float64(d.Percentage) / 100.0),
+					Value:    ptrFloat64(float64(d.Size)),
+				})
+		}
+
+		mf.Metric = append(mf.Metric, &promtypes.Metric{
+			Label:   labels,
+			Summary: summary,
+		})
+
+		if err := encoder.Encode(&mf); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -190,12 +285,19 @@ func (rp *ReportPrinter) getCommonPrometheusLabels() ([]*promtypes.LabelPair, er
 		Metadata         string `json:"metadata,omitempty"`
 		LoadStepDuration string `json:"load-step-duration"`
 		LoadMaxDuration  string `json:"load-max-duration"`
+		LoadAmplitude    string `json:"load-amplitude,omitempty"`
+		LoadPeriod       string `json:"load-period,omitempty"`
+		LoadSpike        string `json:"load-spike-duration,omitempty"`
 		CStepDuration    string `json:"concurrency-step-duration"`
 		CMaxDuration     string `json:"concurrency-max-duration"`
 		Duration         string `json:"duration,omitempty"`
 		Timeout          string `json:"timeout,omitempty"`
 		DialTimeout      string `json:"dial-timeout,omitempty"`
 		KeepaliveTime    string `json:"keepalive,omitempty"`
+		HistPrecision    string `json:"histogram-precision,omitempty"`
+		DetailsSample    string `json:"details-sample-size,omitempty"`
+		SearchSLOs       string `json:"search-slos,omitempty"`
+		TimelineInterval string `json:"timeline-interval,omitempty"`
 		*Alias
 	}{
 		ImportPaths:      strings.Join(rp.Report.Options.ImportPaths, ","),
@@ -220,12 +322,19 @@ func (rp *ReportPrinter) getCommonPrometheusLabels() ([]*promtypes.LabelPair, er
 		Metadata:         "",
 		LoadStepDuration: *ptrString(strconv.Itoa(int(rp.Report.Options.LoadStepDuration.Nanoseconds()))),
 		LoadMaxDuration:  *ptrString(strconv.Itoa(int(rp.Report.Options.LoadMaxDuration.Nanoseconds()))),
+		LoadAmplitude:    *ptrNonZeroIntToStr(rp.Report.Options.LoadAmplitude),
+		LoadPeriod:       *ptrNonZeroIntToStr(int(rp.Report.Options.LoadPeriod.Nanoseconds())),
+		LoadSpike:        *ptrNonZeroIntToStr(int(rp.Report.Options.LoadSpikeDuration.Nanoseconds())),
 		CStepDuration:    *ptrString(strconv.Itoa(int(rp.Report.Options.CStepDuration.Nanoseconds()))),
 		CMaxDuration:     *ptrString(strconv.Itoa(int(rp.Report.Options.CMaxDuration.Nanoseconds()))),
 		Duration:         *ptrString(strconv.Itoa(int(rp.Report.Options.Duration.Nanoseconds()))),
 		Timeout:          *ptrString(strconv.Itoa(int(rp.Report.Options.Timeout.Nanoseconds()))),
 		DialTimeout:      *ptrString(strconv.Itoa(int(rp.Report.Options.DialTimeout.Nanoseconds()))),
 		KeepaliveTime:    *ptrString(strconv.Itoa(int(rp.Report.Options.KeepaliveTime.Nanoseconds()))),
+		HistPrecision:    *ptrNonZeroIntToStr(rp.Report.Options.HistogramPrecision),
+		DetailsSample:    *ptrNonZeroIntToStr(rp.Report.Options.DetailsSampleSize),
+		SearchSLOs:       strings.Join(rp.Report.Options.SearchSLOs, ","),
+		TimelineInterval: *ptrNonZeroIntToStr(int(rp.Report.Options.TimelineInterval.Nanoseconds())),
 		Alias:            (*Alias)(&rp.Report.Options),
 	})
 	if err != nil {
@@ -271,6 +380,14 @@ func (rp *ReportPrinter) getCommonPrometheusLabels() ([]*promtypes.LabelPair, er
 	return labels, nil
 }
 
+func boolToFloat64(v bool) float64 {
+	if v {
+		return 1
+	}
+
+	return 0
+}
+
 func ptrUint64(v uint64) *uint64 {
 	return &v
 }
@@ -283,6 +400,14 @@ func ptrString(v string) *string {
 	return &v
 }
 
+func ptrNonZeroIntToStr(v int) *string {
+	if v == 0 {
+		return ptrString("")
+	}
+
+	return ptrString(strconv.Itoa(v))
+}
+
 func ptrBoolToStr(v bool) *string {
 	switch v {
 	case true:
@@ -228,3 +228,101 @@ ghz_run_latency_count{name="run name",end_reason="normal",insecure="false",rps="
 # TYPE ghz_run_errors gauge
 ghz_run_errors{name="run name",end_reason="normal",insecure="false",rps="0",connections="0",keepalive="0",skipFirst="0",dial_timeout="0",proto="/apis/greeter.proto",concurrency="50",call="helloworld.Greeter.SayHello",import_paths="",async="false",binary="false",total="200",host="0.0.0.0:50051",skipTLS="false",CPUs="0",timeout="0",count_errors="false",duration="0"} 5
 `
+
+func TestPrinter_printPrometheus_histogramOptions(t *testing.T) {
+	buf := bytes.Buffer{}
+	p := ReportPrinter{
+		Out: &buf,
+		Report: &runner.Report{
+			Name:      "run name",
+			EndReason: runner.ReasonNormalEnd,
+			Count:     10,
+			Options: runner.Options{
+				Call:               "helloworld.Greeter.SayHello",
+				LoadSchedule:       "const",
+				CSchedule:          "const",
+				HistogramPrecision: 3,
+				DetailsSampleSize:  1000,
+			},
+		},
+	}
+
+	err := p.printPrometheus()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `histogram_precision="3"`)
+	assert.Contains(t, buf.String(), `details_sample_size="1000"`)
+}
+
+func TestPrinter_printPrometheus_loadShapeOptions(t *testing.T) {
+	buf := bytes.Buffer{}
+	p := ReportPrinter{
+		Out: &buf,
+		Report: &runner.Report{
+			Name:      "run name",
+			EndReason: runner.ReasonNormalEnd,
+			Count:     10,
+			Options: runner.Options{
+				Call:              "helloworld.Greeter.SayHello",
+				LoadSchedule:      "spike",
+				LoadStart:         10,
+				LoadEnd:           100,
+				LoadAmplitude:     5,
+				LoadPeriod:        time.Minute,
+				LoadSpikeDuration: 5 * time.Second,
+				CSchedule:         "const",
+			},
+		},
+	}
+
+	err := p.printPrometheus()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `load_schedule="spike"`)
+	assert.Contains(t, buf.String(), `load_amplitude="5"`)
+	assert.Contains(t, buf.String(), `load_period="60000000000"`)
+	assert.Contains(t, buf.String(), `load_spike_duration="5000000000"`)
+}
+
+func TestPrinter_printPrometheus_searchOptions(t *testing.T) {
+	buf := bytes.Buffer{}
+	p := ReportPrinter{
+		Out: &buf,
+		Report: &runner.Report{
+			Name:      "run name",
+			EndReason: runner.ReasonNormalEnd,
+			Count:     10,
+			Options: runner.Options{
+				Call:         "helloworld.Greeter.SayHello",
+				LoadSchedule: "search",
+				LoadStart:    10,
+				CSchedule:    "const",
+				SearchSLOs:   []string{"p99 < 100ms", "error-rate < 0.01"},
+			},
+		},
+	}
+
+	err := p.printPrometheus()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `search_slos="p99 < 100ms,error-rate < 0.01"`)
+}
+
+func TestPrinter_printPrometheus_timelineOptions(t *testing.T) {
+	buf := bytes.Buffer{}
+	p := ReportPrinter{
+		Out: &buf,
+		Report: &runner.Report{
+			Name:      "run name",
+			EndReason: runner.ReasonNormalEnd,
+			Count:     10,
+			Options: runner.Options{
+				Call:             "helloworld.Greeter.SayHello",
+				LoadSchedule:     "const",
+				CSchedule:        "const",
+				TimelineInterval: time.Second,
+			},
+		},
+	}
+
+	err := p.printPrometheus()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `timeline_interval="1000000000"`)
+}
@@ -0,0 +1,157 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	promtypes "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// pushTimeout is the timeout of the push to the Pushgateway
+const pushTimeout = 30 * time.Second
+
+// PushPrometheus pushes the metrics of the report in the Prometheus format to the Pushgateway
+// at gatewayURL, replacing the metrics previously pushed to the same group. The job is the name
+// of the report, or "ghz" if the report has no name, and the tags of the report are the grouping
+// labels. Metric labels named like the job or a grouping label are dropped.
+func (rp *ReportPrinter) PushPrometheus(gatewayURL string) error {
+	job := rp.Report.Name
+	if job == "" {
+		job = "ghz"
+	}
+
+	grouping := make(map[string]string, len(rp.Report.Tags))
+	for k, v := range rp.Report.Tags {
+		grouping[sanitizeLabelName(k)] = v
+	}
+
+	buf := &bytes.Buffer{}
+	p := ReportPrinter{Out: buf, Report: rp.Report}
+	if err := p.printPrometheus(); err != nil {
+		return err
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(buf)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	body := &bytes.Buffer{}
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	encoder := expfmt.NewEncoder(body, format)
+	for _, name := range names {
+		mf := families[name]
+		for _, m := range mf.Metric {
+			m.Label = withoutGroupingLabels(m.Label, grouping)
+		}
+
+		if err := encoder.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, pushURL(gatewayURL, job, grouping), body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", string(format))
+
+	client := &http.Client{Timeout: pushTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing metrics to %s: %w", gatewayURL, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("pushing metrics to %s failed with status %s: %s",
+			gatewayURL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// pushURL returns the Pushgateway URL of the metrics group of the job and grouping labels
+func pushURL(gatewayURL, job string, grouping map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSuffix(gatewayURL, "/"))
+	sb.WriteString("/metrics/job")
+	sb.WriteString(pushLabelValue(job))
+
+	keys := make([]string, 0, len(grouping))
+	for k := range grouping {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		sb.WriteString("/")
+		sb.WriteString(k)
+		sb.WriteString(pushLabelValue(grouping[k]))
+	}
+
+	return sb.String()
+}
+
+// pushLabelValue returns the URL path segment of the label value, base64 encoded
+// if the value is empty or contains a slash as required by the Pushgateway
+func pushLabelValue(v string) string {
+	if v == "" {
+		// a single padding character stands for the empty value
+		return "@base64/="
+	}
+
+	if strings.Contains(v, "/") {
+		return "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(v))
+	}
+
+	return "/" + url.PathEscape(v)
+}
+
+func withoutGroupingLabels(labels []*promtypes.LabelPair, grouping map[string]string) []*promtypes.LabelPair {
+	res := labels[:0]
+	for _, l := range labels {
+		name := l.GetName()
+		if _, ok := grouping[name]; ok || name == "job" {
+			continue
+		}
+
+		res = append(res, l)
+	}
+
+	return res
+}
+
+// sanitizeLabelName replaces the characters not allowed in a Prometheus label name with underscores
+func sanitizeLabelName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package printer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bojand/ghz/runner"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrinter_PushPrometheus(t *testing.T) {
+	var method, path, contentType string
+	var families map[string]int
+
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.EscapedPath()
+		contentType = r.Header.Get("Content-Type")
+
+		var parser expfmt.TextParser
+		mfs, err := parser.TextToMetricFamilies(r.Body)
+		assert.NoError(t, err)
+
+		families = make(map[string]int, len(mfs))
+		for name, mf := range mfs {
+			for _, m := range mf.Metric {
+				for _, l := range m.Label {
+					// grouping labels are not repeated in the metrics
+					assert.NotEqual(t, "env", l.GetName())
+					assert.NotEqual(t, "name", l.GetName())
+				}
+			}
+
+			families[name] = len(mf.Metric)
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("push rejected"))
+	}))
+	defer srv.Close()
+
+	report := &runner.Report{
+		Name:      "greeter run",
+		EndReason: runner.ReasonNormalEnd,
+		Date:      time.Now(),
+		Count:     10,
+		Total:     time.Second,
+		Average:   10 * time.Millisecond,
+		Rps:       10,
+		Options:   runner.Options{Call: "helloworld.Greeter.SayHello", Host: "localhost:50051"},
+		Tags: map[string]string{
+			"env":      "staging/eu",
+			"name":     "nightly",
+			"build-id": "42",
+		},
+		Histogram: []runner.Bucket{{Mark: 0.01, Count: 10, Frequency: 1}},
+	}
+
+	p := ReportPrinter{Report: report}
+
+	t.Run("pushes the report metrics", func(t *testing.T) {
+		assert.NoError(t, p.PushPrometheus(srv.URL+"/"))
+
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, "/metrics/job/greeter%20run/build_id/42/env@base64/c3RhZ2luZy9ldQ/name/nightly", path)
+		assert.Contains(t, contentType, "text/plain")
+		assert.Equal(t, 1, families["ghz_run_count"])
+		assert.Equal(t, 1, families["ghz_run_histogram"])
+		assert.Equal(t, 1, families["ghz_run_errors"])
+	})
+
+	t.Run("push rejected", func(t *testing.T) {
+		status = http.StatusBadRequest
+		defer func() { status = http.StatusOK }()
+
+		err := p.PushPrometheus(srv.URL)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "400 Bad Request: push rejected")
+	})
+}
+
+func Test_pushURL(t *testing.T) {
+	assert.Equal(t, "http://gw:9091/metrics/job/ghz", pushURL("http://gw:9091", "ghz", nil))
+	assert.Equal(t, "http://gw:9091/metrics/job/ghz/env@base64/=",
+		pushURL("http://gw:9091", "ghz", map[string]string{"env": ""}))
+	assert.Equal(t, "http://gw:9091/metrics/job@base64/YS9i",
+		pushURL("http://gw:9091/", "a/b", nil))
+}
@@ -14,18 +14,97 @@ Summary:
 Response time histogram:
 {{ histogram .Histogram }}
 Latency distribution:{{ range .LatencyDistribution }}
+  {{ .Percentage }} % in {{ formatNanoUnit .Latency }} {{ end }}{{ if .Corrected }}
+
+Corrected latency distribution:{{ range .Corrected.LatencyDistribution }}
   {{ .Percentage }} % in {{ formatNanoUnit .Latency }} {{ end }}
+  Corrected average:	{{ formatNanoUnit .Corrected.Average }}
+  Corrected slowest:	{{ formatNanoUnit .Corrected.Slowest }}{{ end }}{{ if .Sizes }}
+
+Sizes:
+{{ formatSizes .Sizes }}{{ end }}
 
 {{ if gt (len .StatusCodeDist) 0 }}Status code distribution:
 {{ formatStatusCode .StatusCodeDist }}{{ end }}
 {{ if gt (len .ErrorDist) 0 }}Error distribution:
 {{ formatErrorDist .ErrorDist }}{{ end }}
-`
+{{ if gt .ValidationFailures 0 }}Validation failures:	{{ .ValidationFailures }}
+
+{{ end }}{{ if .Calls }}{{ if .Journey }}Steps:{{ else }}Calls:{{ end }}
+{{ formatCalls .Calls }}
+{{ end }}{{ if .Journey }}Journey:
+  Count:	{{ .Journey.Count }}
+  Slowest:	{{ formatNanoUnit .Journey.Slowest }}
+  Fastest:	{{ formatNanoUnit .Journey.Fastest }}
+  Average:	{{ formatNanoUnit .Journey.Average }}
+  Journeys/sec:	{{ formatSeconds .Journey.Rps }}
+  Latency distribution:{{ range .Journey.LatencyDistribution }}
+    {{ .Percentage }} % in {{ formatNanoUnit .Latency }} {{ end }}
+{{ if gt (len .Journey.ErrorDist) 0 }}  Error distribution:
+{{ formatErrorDist .Journey.ErrorDist }}{{ end }}
+{{ end }}{{ if .Streaming }}Streaming:
+{{ formatStreaming .Streaming }}
+{{ end }}{{ if .Search }}Search:
+  Max sustainable RPS:	{{ .Search.MaxRPS }}{{ if not .Search.Completed }} (search incomplete){{ end }}
+{{ formatSearchSteps .Search.Steps }}
+{{ end }}{{ if .Assertions }}Assertions: {{ if .Assertions.Passed }}passed{{ else }}failed{{ end }}
+{{ formatAssertions .Assertions.Results }}{{ end }}`
 
 	csvTmpl = `
 duration (ms),status,error{{ range $i, $v := .Details }}
 {{ formatMilli .Latency.Seconds }},{{ .Status }},{{ .Error }}{{ end }}
-`
+{{ if .Assertions }}
+assertion,actual,passed{{ range .Assertions.Results }}
+{{ escapeCSV .Assertion }},{{ escapeCSV .Actual }},{{ .Passed }}{{ end }}
+{{ end }}`
+
+	markdownTmpl = `## {{ if .Name }}{{ escapeMarkdown .Name }}{{ else }}ghz report{{ end }}
+
+| Count | Total | Slowest | Fastest | Average | Requests/sec |
+|---:|---:|---:|---:|---:|---:|
+| {{ .Count }} | {{ formatNanoUnit .Total }} | {{ formatNanoUnit .Slowest }} | {{ formatNanoUnit .Fastest }} | {{ formatNanoUnit .Average }} | {{ formatSeconds .Rps }} |
+{{ if .LatencyDistribution }}
+### Latency distribution
+
+|{{ range .LatencyDistribution }} {{ .Percentage }} % |{{ end }}
+|{{ range .LatencyDistribution }}---:|{{ end }}
+|{{ range .LatencyDistribution }} {{ formatNanoUnit .Latency }} |{{ end }}
+{{ end }}{{ if gt (len .StatusCodeDist) 0 }}
+### Status code distribution
+
+| Status | Responses | % |
+|---|---:|---:|
+{{ range $code, $num := .StatusCodeDist }}| {{ escapeMarkdown $code }} | {{ $num }} | {{ formatPercent $num $.Count }} |
+{{ end }}{{ end }}{{ if gt (len .ErrorDist) 0 }}
+### Error distribution
+
+| Error | Count | % |
+|---|---:|---:|
+{{ range $err, $num := .ErrorDist }}| {{ escapeMarkdown $err }} | {{ $num }} | {{ formatPercent $num $.Count }} |
+{{ end }}{{ end }}{{ if .Histogram }}
+### Response time histogram
+
+| Latency (ms) | Count | % |
+|---:|---:|---:|
+{{ range .Histogram }}| {{ printf "%.3f" (multiply .Mark 1000) }} | {{ .Count }} | {{ printf "%.2f" (multiply .Frequency 100) }} |
+{{ end }}{{ end }}{{ if .Streaming }}
+### Streaming
+
+| Calls | Messages sent | Sent/sec | Bytes sent | Messages received | Received/sec | Bytes received |
+|---:|---:|---:|---:|---:|---:|---:|
+| {{ .Streaming.Count }} | {{ .Streaming.MessagesSent }} | {{ formatSeconds .Streaming.SentRate }} | {{ formatBytes .Streaming.BytesSent }} | {{ .Streaming.MessagesReceived }} | {{ formatSeconds .Streaming.ReceivedRate }} | {{ formatBytes .Streaming.BytesReceived }} |
+{{ if or .Streaming.TimeToFirstResponse .Streaming.InterMessageLatency }}
+| Latency | Count | Average | Fastest | p50 | p95 | p99 | Slowest |
+|---|---:|---:|---:|---:|---:|---:|---:|
+{{ with .Streaming.TimeToFirstResponse }}| Time to first response | {{ .Count }} | {{ formatNanoUnit .Average }} | {{ formatNanoUnit .Fastest }} | {{ formatNanoUnit (latencyPercentile .LatencyDistribution 50) }} | {{ formatNanoUnit (latencyPercentile .LatencyDistribution 95) }} | {{ formatNanoUnit (latencyPercentile .LatencyDistribution 99) }} | {{ formatNanoUnit .Slowest }} |
+{{ end }}{{ with .Streaming.InterMessageLatency }}| Inter-message | {{ .Count }} | {{ formatNanoUnit .Average }} | {{ formatNanoUnit .Fastest }} | {{ formatNanoUnit (latencyPercentile .LatencyDistribution 50) }} | {{ formatNanoUnit (latencyPercentile .LatencyDistribution 95) }} | {{ formatNanoUnit (latencyPercentile .LatencyDistribution 99) }} | {{ formatNanoUnit .Slowest }} |
+{{ end }}{{ end }}{{ end }}{{ if .Assertions }}
+### Assertions: {{ if .Assertions.Passed }}passed{{ else }}failed{{ end }}
+
+| Assertion | Actual | Result |
+|---|---:|---|
+{{ range .Assertions.Results }}| {{ escapeMarkdown .Assertion }} | {{ escapeMarkdown .Actual }} | {{ if .Passed }}PASS{{ else }}FAIL{{ end }} |
+{{ end }}{{ end }}`
 
 	htmlTmpl = `
 <html>
@@ -84,6 +163,26 @@ duration (ms),status,error{{ range $i, $v := .Details }}
               <span>Latency Distribution</span>
             </a>
           </li>
+					{{ if .Corrected }}
+          <li>
+            <a href="#corrected">
+              <span class="icon is-small">
+                <i class="far fa-clock" aria-hidden="true"></i>
+              </span>
+              <span>Corrected Latency</span>
+            </a>
+					</li>
+					{{ end }}
+					{{ if .Timeline }}
+          <li>
+            <a href="#timeline">
+              <span class="icon is-small">
+                <i class="fas fa-chart-line" aria-hidden="true"></i>
+              </span>
+              <span>Timeline</span>
+            </a>
+					</li>
+					{{ end }}
           <li>
             <a href="#status">
               <span class="icon is-small">
@@ -99,6 +198,36 @@ duration (ms),status,error{{ range $i, $v := .Details }}
                 <i class="fas fa-exclamation-circle" aria-hidden="true"></i>
               </span>
               <span>Errors</span>
+            </a>
+					</li>
+					{{ end }}
+					{{ if .Calls }}
+          <li>
+            <a href="#calls">
+              <span class="icon is-small">
+                <i class="fas fa-random" aria-hidden="true"></i>
+              </span>
+              <span>{{ if .Journey }}Steps{{ else }}Calls{{ end }}</span>
+            </a>
+					</li>
+					{{ end }}
+					{{ if .Search }}
+          <li>
+            <a href="#search">
+              <span class="icon is-small">
+                <i class="fas fa-search" aria-hidden="true"></i>
+              </span>
+              <span>Search</span>
+            </a>
+					</li>
+					{{ end }}
+					{{ if .Assertions }}
+          <li>
+            <a href="#assertions">
+              <span class="icon is-small">
+                <i class="fas fa-tasks" aria-hidden="true"></i>
+              </span>
+              <span>Assertions</span>
             </a>
 					</li>
 					{{ end }}
@@ -225,6 +354,104 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 			</div>
 		</div>
 
+		{{ if .Corrected }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="corrected">
+					<h3>Corrected latency distribution</h3>
+				</a>
+				<p>Latencies measured from the intended start of each request on the load schedule. Average: {{ formatNanoUnit .Corrected.Average }}, slowest: {{ formatNanoUnit .Corrected.Slowest }}.</p>
+				<table class="table is-fullwidth">
+					<thead>
+						<tr>
+							{{ range .Corrected.LatencyDistribution }}
+								<th>{{ .Percentage }} %</th>
+							{{ end }}
+						</tr>
+					</thead>
+					<tbody>
+						<tr>
+							{{ range .Corrected.LatencyDistribution }}
+								<td>{{ formatNanoUnit .Latency }}</td>
+							{{ end }}
+						</tr>
+					</tbody>
+				</table>
+			</div>
+		</div>
+		{{ end }}
+
+		{{ if .Streaming }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="streaming">
+					<h3>Streaming</h3>
+				</a>
+				<p>{{ .Streaming.Count }} streaming calls sent {{ .Streaming.MessagesSent }} messages ({{ formatSeconds .Streaming.SentRate }}/sec, {{ formatBytes .Streaming.BytesSent }}) and received {{ .Streaming.MessagesReceived }} messages ({{ formatSeconds .Streaming.ReceivedRate }}/sec, {{ formatBytes .Streaming.BytesReceived }}).</p>
+				{{ if or .Streaming.TimeToFirstResponse .Streaming.InterMessageLatency }}
+				<table class="table is-fullwidth">
+					<thead>
+						<tr>
+							<th>Latency</th>
+							<th>Count</th>
+							<th>Average</th>
+							<th>Fastest</th>
+							<th>p50</th>
+							<th>p95</th>
+							<th>p99</th>
+							<th>Slowest</th>
+						</tr>
+					</thead>
+					<tbody>
+						{{ with .Streaming.TimeToFirstResponse }}
+								<tr>
+									<td>Time to first response</td>
+									<td>{{ .Count }}</td>
+									<td>{{ formatNanoUnit .Average }}</td>
+									<td>{{ formatNanoUnit .Fastest }}</td>
+									<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 50) }}</td>
+									<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 95) }}</td>
+									<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 99) }}</td>
+									<td>{{ formatNanoUnit .Slowest }}</td>
+								</tr>
+						{{ end }}
+						{{ with .Streaming.InterMessageLatency }}
+								<tr>
+									<td>Inter-message</td>
+									<td>{{ .Count }}</td>
+									<td>{{ formatNanoUnit .Average }}</td>
+									<td>{{ formatNanoUnit .Fastest }}</td>
+									<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 50) }}</td>
+									<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 95) }}</td>
+									<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 99) }}</td>
+									<td>{{ formatNanoUnit .Slowest }}</td>
+								</tr>
+						{{ end }}
+					</tbody>
+				</table>
+				{{ end }}
+			</div>
+		</div>
+		{{ end }}
+
+		{{ if .Timeline }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="timeline">
+					<h3>Timeline</h3>
+				</a>
+				<p>Results received in each {{ .Options.TimelineInterval }} window of the run.</p>
+				<h5>Throughput</h5>
+				<div class="js-timeline-throughput-container"></div>
+				<h5>Latency (ms)</h5>
+				<div class="js-timeline-latency-container"></div>
+			</div>
+		</div>
+		{{ end }}
+
 		<br />
 		<div class="container">
 			<div class="columns">
@@ -284,6 +511,194 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 											{{ end }}
 										</tbody>
 									</table>
+									{{ if gt .ValidationFailures 0 }}
+									<p>Validation failures: {{ .ValidationFailures }}</p>
+									{{ end }}
+								</div>
+							</div>
+						</div>
+					</div>
+
+			{{ end }}
+
+			{{ if .Calls }}
+
+				<br />
+				<div class="container">
+					<div class="columns">
+						<div class="column is-narrow">
+							<div class="content">
+								<a name="calls">
+									<h3>{{ if .Journey }}Steps{{ else }}Calls{{ end }}</h3>
+								</a>
+								<table class="table is-hoverable">
+									<thead>
+										<tr>
+											<th>Name</th>
+											<th>Call</th>
+											<th>Weight</th>
+											<th>Count</th>
+											<th>Requests / sec</th>
+											<th>Average</th>
+											<th>Fastest</th>
+											<th>Slowest</th>
+											<th>50 %</th>
+											<th>95 %</th>
+											<th>99 %</th>
+											<th>Status codes</th>
+										</tr>
+									</thead>
+									<tbody>
+										{{ range .Calls }}
+											<tr>
+												<td>{{ .Name }}</td>
+												<td>{{ .Call }}</td>
+												<td>{{ .Weight }}</td>
+												<td>{{ .Count }}</td>
+												<td>{{ formatSeconds .Rps }}</td>
+												<td>{{ formatNanoUnit .Average }}</td>
+												<td>{{ formatNanoUnit .Fastest }}</td>
+												<td>{{ formatNanoUnit .Slowest }}</td>
+												<td>{{ formatNanoUnit (callPercentile . 50) }}</td>
+												<td>{{ formatNanoUnit (callPercentile . 95) }}</td>
+												<td>{{ formatNanoUnit (callPercentile . 99) }}</td>
+												<td>{{ range $code, $num := .StatusCodeDist }}{{ $code }}: {{ $num }} {{ end }}</td>
+											</tr>
+											{{ end }}
+										</tbody>
+									</table>
+									{{ if .Journey }}
+									<h4>Journey</h4>
+									<table class="table is-hoverable">
+										<thead>
+											<tr>
+												<th>Count</th>
+												<th>Journeys / sec</th>
+												<th>Average</th>
+												<th>Fastest</th>
+												<th>Slowest</th>
+												{{ range .Journey.LatencyDistribution }}
+												<th>{{ .Percentage }} %</th>
+												{{ end }}
+												<th>Status codes</th>
+											</tr>
+										</thead>
+										<tbody>
+											<tr>
+												<td>{{ .Journey.Count }}</td>
+												<td>{{ formatSeconds .Journey.Rps }}</td>
+												<td>{{ formatNanoUnit .Journey.Average }}</td>
+												<td>{{ formatNanoUnit .Journey.Fastest }}</td>
+												<td>{{ formatNanoUnit .Journey.Slowest }}</td>
+												{{ range .Journey.LatencyDistribution }}
+												<td>{{ formatNanoUnit .Latency }}</td>
+												{{ end }}
+												<td>{{ range $code, $num := .Journey.StatusCodeDist }}{{ $code }}: {{ $num }} {{ end }}</td>
+											</tr>
+										</tbody>
+									</table>
+									{{ if gt (len .Journey.ErrorDist) 0 }}
+									<table class="table is-hoverable">
+										<thead>
+											<tr>
+												<th>Journey error</th>
+												<th>Count</th>
+											</tr>
+										</thead>
+										<tbody>
+											{{ range $err, $num := .Journey.ErrorDist }}
+											<tr>
+												<td>{{ $err }}</td>
+												<td>{{ $num }}</td>
+											</tr>
+											{{ end }}
+										</tbody>
+									</table>
+									{{ end }}
+									{{ end }}
+								</div>
+							</div>
+						</div>
+					</div>
+
+			{{ end }}
+
+			{{ if .Search }}
+
+				<br />
+				<div class="container">
+					<div class="columns">
+						<div class="column is-narrow">
+							<div class="content">
+								<a name="search">
+									<h3>Search</h3>
+								</a>
+								<p>Max sustainable RPS: <strong>{{ .Search.MaxRPS }}</strong>{{ if not .Search.Completed }} (search incomplete){{ end }}</p>
+								<table class="table is-hoverable">
+									<thead>
+										<tr>
+											<th>Target RPS</th>
+											<th>Result</th>
+											<th>Count</th>
+											<th>Requests / sec</th>
+											<th>Average</th>
+											<th>50 %</th>
+											<th>95 %</th>
+											<th>99 %</th>
+											<th>SLOs</th>
+										</tr>
+									</thead>
+									<tbody>
+										{{ range .Search.Steps }}
+											<tr>
+												<td>{{ .RPS }}</td>
+												<td>{{ if .Passed }}<span class="tag is-success">pass</span>{{ else }}<span class="tag is-danger">fail</span>{{ end }}</td>
+												<td>{{ .Count }}</td>
+												<td>{{ formatSeconds .Rps }}</td>
+												<td>{{ formatNanoUnit .Average }}</td>
+												<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 50) }}</td>
+												<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 95) }}</td>
+												<td>{{ formatNanoUnit (latencyPercentile .LatencyDistribution 99) }}</td>
+												<td>{{ range .SLOs }}{{ .Assertion }}: {{ .Actual }} {{ end }}</td>
+											</tr>
+										{{ end }}
+									</tbody>
+								</table>
+							</div>
+						</div>
+					</div>
+				</div>
+
+			{{ end }}
+
+			{{ if .Assertions }}
+
+				<br />
+				<div class="container">
+					<div class="columns">
+						<div class="column is-narrow">
+							<div class="content">
+								<a name="assertions">
+									<h3>Assertions {{ if .Assertions.Passed }}<span class="tag is-success">passed</span>{{ else }}<span class="tag is-danger">failed</span>{{ end }}</h3>
+								</a>
+								<table class="table is-hoverable">
+									<thead>
+										<tr>
+											<th>Assertion</th>
+											<th>Actual</th>
+											<th>Result</th>
+										</tr>
+									</thead>
+									<tbody>
+										{{ range .Assertions.Results }}
+											<tr>
+												<td>{{ .Assertion }}</td>
+												<td>{{ .Actual }}</td>
+												<td>{{ if .Passed }}<span class="tag is-success">pass</span>{{ else }}<span class="tag is-danger">fail</span>{{ end }}</td>
+											</tr>
+											{{ end }}
+										</tbody>
+									</table>
 								</div>
 							</div>
 						</div>
@@ -396,6 +811,40 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 		}
 	}
 
+	function createTimelineChart(selector, dataset) {
+		let lineChart = britecharts.line(),
+			chartTooltip = britecharts.tooltip(),
+			container = d3.select(selector),
+			containerWidth = container.node() ? container.node().getBoundingClientRect().width : false,
+			tooltipContainer;
+
+		if (containerWidth) {
+			lineChart
+				.isAnimated(true)
+				.grid('horizontal')
+				.margin({
+					left: 60,
+					right: 20,
+					top: 20,
+					bottom: 40
+				})
+				.width(containerWidth)
+				.height(300)
+				.xAxisFormat('custom')
+				.xAxisCustomFormat('%H:%M:%S')
+				.on('customMouseOver', chartTooltip.show)
+				.on('customMouseMove', chartTooltip.update)
+				.on('customMouseOut', chartTooltip.hide);
+
+			container.datum(dataset).call(lineChart);
+
+			chartTooltip.dateFormat(chartTooltip.axisTimeCombinations.CUSTOM).dateCustomFormat('%H:%M:%S');
+
+			tooltipContainer = d3.select(selector + ' .metadata-group .hover-marker');
+			tooltipContainer.datum([]).call(chartTooltip);
+		}
+	}
+
 	function setJSONDownloadLink () {
 		var filename = "data.json";
 		var btn = document.getElementById('dlJSON');
@@ -418,6 +867,12 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 
 	createHorizontalBarChart();
 
+	{{ if .Timeline }}
+	createTimelineChart('.js-timeline-throughput-container', {{ timelineData .Timeline "throughput" }});
+
+	createTimelineChart('.js-timeline-latency-container', {{ timelineData .Timeline "latency" }});
+	{{ end }}
+
 	setJSONDownloadLink();
 
 	setCSVDownloadLink();
@@ -41,6 +41,47 @@ func GetMethodDescFromProto(call, proto string, imports []string) (*desc.MethodD
 	return getMethodDesc(call, files)
 }
 
+// GetProtoSetFromProto parses the proto file and returns the protoset binary of the file and its dependencies
+// imports is used for import paths in parsing the proto file
+func GetProtoSetFromProto(protoFile string, imports []string) ([]byte, error) {
+	p := &protoparse.Parser{ImportPaths: imports}
+
+	filename := protoFile
+	if filepath.IsAbs(filename) {
+		filename = filepath.Base(protoFile)
+	}
+
+	fds, err := p.ParseFiles(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptor.FileDescriptorSet{}
+	added := map[string]bool{}
+
+	// the dependencies are added before the files depending on them
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if added[fd.GetName()] {
+			return
+		}
+
+		added[fd.GetName()] = true
+
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+
+	for _, fd := range fds {
+		add(fd)
+	}
+
+	return proto.Marshal(set)
+}
+
 // GetMethodDescFromProtoSet gets method descriptor for the given call symbol from protoset file given my path protoset
 func GetMethodDescFromProtoSet(call, protoset string) (*desc.MethodDescriptor, error) {
 	b, err := os.ReadFile(protoset)
@@ -100,6 +100,23 @@ func TestProtodesc_GetMethodDescFromProtoSet(t *testing.T) {
 	})
 }
 
+func TestProtodesc_GetProtoSetFromProto(t *testing.T) {
+	t.Run("invalid path", func(t *testing.T) {
+		b, err := GetProtoSetFromProto("invalid.proto", []string{})
+		assert.Error(t, err)
+		assert.Nil(t, b)
+	})
+
+	t.Run("includes the dependencies", func(t *testing.T) {
+		b, err := GetProtoSetFromProto("../testdata/gtime.proto", []string{"../testdata", "."})
+		assert.NoError(t, err)
+
+		md, err := GetMethodDescFromProtoSetBinary("gtime.TimeService.TestCall", b)
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+	})
+}
+
 func TestParseServiceMethod(t *testing.T) {
 	testParseServiceMethodSuccess(t, "package.Service.Method", "package.Service", "Method")
 	testParseServiceMethodSuccess(t, ".package.Service.Method", "package.Service", "Method")
@@ -0,0 +1,245 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AssertionReport holds the results of the assertions evaluated against the final report
+type AssertionReport struct {
+	// Passed is true only if all the assertions passed
+	Passed bool `json:"passed"`
+
+	Results []AssertionResult `json:"results"`
+}
+
+// AssertionResult is the result of a single assertion
+type AssertionResult struct {
+	// Assertion is the assertion expression, for example "p99 < 250ms"
+	Assertion string `json:"assertion"`
+
+	// Actual is the actual value of the asserted metric
+	Actual string `json:"actual"`
+
+	Passed bool `json:"passed"`
+}
+
+// assertion is a parsed assertion expression in the form "<metric> <op> <value>".
+//
+// Supported metrics are:
+//
+//	average, fastest, slowest   latency durations, for example "average < 100ms"
+//	p10, p25, ... p99           latency distribution percentiles, for example "p99 <= 250ms"
+//	corrected-<latency metric>  latencies corrected for coordinated omission, for example "corrected-p99 <= 500ms"
+//	rps                         requests per second, for example "rps >= 500"
+//	count                       the total number of responses
+//	errors                      the total number of erroneous responses
+//	error-rate                  percentage of erroneous responses, for example "error-rate < 1%"
+//	validation-failures         the number of responses failing validation, for example "validation-failures == 0"
+//	status:<code>               the number of responses with the status code, for example "status:Unavailable == 0"
+type assertion struct {
+	expr   string
+	metric string
+	op     string
+	value  float64
+}
+
+var assertionRegexp = regexp.MustCompile(`^\s*([a-zA-Z0-9_:\-]+)\s*(<=|>=|==|!=|<|>)\s*(\S+)\s*$`)
+
+// correctedPrefix is the prefix of the latency metrics of the corrected latencies
+const correctedPrefix = "corrected-"
+
+var latencyMetrics = map[string]bool{
+	"average": true,
+	"fastest": true,
+	"slowest": true,
+}
+
+func parseAssertion(expr string) (*assertion, error) {
+	m := assertionRegexp.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid assertion %q: must be in the form \"<metric> <op> <value>\"", expr)
+	}
+
+	a := &assertion{metric: strings.ToLower(m[1]), op: m[2]}
+	valStr := m[3]
+
+	if strings.HasPrefix(a.metric, "status:") {
+		// status codes are case sensitive
+		a.metric = "status:" + m[1][len("status:"):]
+	}
+
+	base := strings.TrimPrefix(a.metric, correctedPrefix)
+	isLatency := latencyMetrics[base] || isPercentileMetric(base)
+
+	switch {
+	case base != a.metric && !isLatency:
+		return nil, fmt.Errorf("invalid assertion %q: unknown metric %q", expr, m[1])
+	case isLatency:
+		d, err := time.ParseDuration(valStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %v", expr, err)
+		}
+		a.value = float64(d)
+	case a.metric == "error-rate":
+		v, err := strconv.ParseFloat(strings.TrimSuffix(valStr, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %v", expr, err)
+		}
+		a.value = v
+		valStr = strings.TrimSuffix(valStr, "%") + "%"
+	case a.metric == "rps" || a.metric == "count" || a.metric == "errors" ||
+		a.metric == "validation-failures" || strings.HasPrefix(a.metric, "status:"):
+		v, err := strconv.ParseFloat(valStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %v", expr, err)
+		}
+		a.value = v
+	default:
+		return nil, fmt.Errorf("invalid assertion %q: unknown metric %q", expr, m[1])
+	}
+
+	a.expr = a.metric + " " + a.op + " " + valStr
+
+	return a, nil
+}
+
+func isPercentileMetric(metric string) bool {
+	if !strings.HasPrefix(metric, "p") {
+		return false
+	}
+
+	p, err := strconv.Atoi(metric[1:])
+	if err != nil {
+		return false
+	}
+
+	for _, rp := range reportPercentiles {
+		if rp == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// actual returns the value of the asserted metric in the report and its display string.
+// ok is false if the report does not have the metric.
+func (a *assertion) actual(r *Report) (value float64, display string, ok bool) {
+	if strings.HasPrefix(a.metric, correctedPrefix) {
+		if r.Corrected == nil {
+			return 0, "", false
+		}
+
+		cr := &Report{
+			Count:               r.Count,
+			Average:             r.Corrected.Average,
+			Fastest:             r.Corrected.Fastest,
+			Slowest:             r.Corrected.Slowest,
+			LatencyDistribution: r.Corrected.LatencyDistribution,
+		}
+
+		ca := &assertion{metric: strings.TrimPrefix(a.metric, correctedPrefix)}
+
+		return ca.actual(cr)
+	}
+
+	switch {
+	case a.metric == "average":
+		return float64(r.Average), r.Average.String(), r.Count > 0
+	case a.metric == "fastest":
+		return float64(r.Fastest), r.Fastest.String(), len(r.LatencyDistribution) > 0
+	case a.metric == "slowest":
+		return float64(r.Slowest), r.Slowest.String(), len(r.LatencyDistribution) > 0
+	case isPercentileMetric(a.metric):
+		p, _ := strconv.Atoi(a.metric[1:])
+		for _, ld := range r.LatencyDistribution {
+			if ld.Percentage == p {
+				return float64(ld.Latency), ld.Latency.String(), true
+			}
+		}
+		return 0, "", false
+	case a.metric == "rps":
+		return r.Rps, strconv.FormatFloat(r.Rps, 'f', 2, 64), true
+	case a.metric == "count":
+		return float64(r.Count), strconv.FormatUint(r.Count, 10), true
+	case a.metric == "errors":
+		n := errorCount(r)
+		return float64(n), strconv.Itoa(n), true
+	case a.metric == "validation-failures":
+		return float64(r.ValidationFailures), strconv.FormatUint(r.ValidationFailures, 10), true
+	case a.metric == "error-rate":
+		rate := 0.0
+		if r.Count > 0 {
+			rate = float64(errorCount(r)) / float64(r.Count) * 100
+		}
+		return rate, strconv.FormatFloat(rate, 'f', 2, 64) + "%", true
+	case strings.HasPrefix(a.metric, "status:"):
+		n := r.StatusCodeDist[strings.TrimPrefix(a.metric, "status:")]
+		return float64(n), strconv.Itoa(n), true
+	}
+
+	return 0, "", false
+}
+
+func (a *assertion) evaluate(r *Report) AssertionResult {
+	res := AssertionResult{Assertion: a.expr, Actual: "n/a"}
+
+	v, display, ok := a.actual(r)
+	if !ok {
+		return res
+	}
+
+	res.Actual = display
+
+	switch a.op {
+	case "<":
+		res.Passed = v < a.value
+	case "<=":
+		res.Passed = v <= a.value
+	case ">":
+		res.Passed = v > a.value
+	case ">=":
+		res.Passed = v >= a.value
+	case "==":
+		res.Passed = v == a.value
+	case "!=":
+		res.Passed = v != a.value
+	}
+
+	return res
+}
+
+func evaluateAssertions(assertions []*assertion, r *Report) *AssertionReport {
+	if len(assertions) == 0 {
+		return nil
+	}
+
+	ar := &AssertionReport{
+		Passed:  true,
+		Results: make([]AssertionResult, 0, len(assertions)),
+	}
+
+	for _, a := range assertions {
+		res := a.evaluate(r)
+		if !res.Passed {
+			ar.Passed = false
+		}
+
+		ar.Results = append(ar.Results, res)
+	}
+
+	return ar
+}
+
+func errorCount(r *Report) int {
+	n := 0
+	for _, c := range r.ErrorDist {
+		n += c
+	}
+
+	return n
+}
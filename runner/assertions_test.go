@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertions_parseAssertion(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected *assertion
+		ok       bool
+	}{
+		{"p99 < 250ms", &assertion{expr: "p99 < 250ms", metric: "p99", op: "<", value: float64(250 * time.Millisecond)}, true},
+		{"  P95<=1s ", &assertion{expr: "p95 <= 1s", metric: "p95", op: "<=", value: float64(time.Second)}, true},
+		{"average < 10ms", &assertion{expr: "average < 10ms", metric: "average", op: "<", value: float64(10 * time.Millisecond)}, true},
+		{"error-rate < 1%", &assertion{expr: "error-rate < 1%", metric: "error-rate", op: "<", value: 1}, true},
+		{"error-rate <= 0.5", &assertion{expr: "error-rate <= 0.5%", metric: "error-rate", op: "<=", value: 0.5}, true},
+		{"rps >= 500", &assertion{expr: "rps >= 500", metric: "rps", op: ">=", value: 500}, true},
+		{"status:Unavailable == 0", &assertion{expr: "status:Unavailable == 0", metric: "status:Unavailable", op: "==", value: 0}, true},
+		{"validation-failures == 0", &assertion{expr: "validation-failures == 0", metric: "validation-failures", op: "==", value: 0}, true},
+		{"corrected-p99 < 1s", &assertion{expr: "corrected-p99 < 1s", metric: "corrected-p99", op: "<", value: float64(time.Second)}, true},
+		{"corrected-rps > 1", nil, false},
+		{"p98 < 10ms", nil, false},
+		{"p99 < 10", nil, false},
+		{"rps >= fast", nil, false},
+		{"foo > 1", nil, false},
+		{"p99 250ms", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			a, err := parseAssertion(tt.input)
+			if tt.ok {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, a)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestAssertions_evaluateAssertions(t *testing.T) {
+	report := &Report{
+		Count:   100,
+		Average: 50 * time.Millisecond,
+		Fastest: 5 * time.Millisecond,
+		Slowest: 300 * time.Millisecond,
+		Rps:     600,
+		LatencyDistribution: []LatencyDistribution{
+			{Percentage: 50, Latency: 40 * time.Millisecond},
+			{Percentage: 99, Latency: 200 * time.Millisecond},
+		},
+		ErrorDist:      map[string]int{"rpc error: code = Unavailable desc = down": 2},
+		StatusCodeDist: map[string]int{"OK": 98, "Unavailable": 2},
+	}
+
+	t.Run("no assertions", func(t *testing.T) {
+		assert.Nil(t, evaluateAssertions(nil, report))
+	})
+
+	t.Run("passing and failing", func(t *testing.T) {
+		parse := func(expr string) *assertion {
+			a, err := parseAssertion(expr)
+			assert.NoError(t, err)
+			return a
+		}
+
+		res := evaluateAssertions([]*assertion{
+			parse("p99 < 250ms"),
+			parse("rps >= 500"),
+			parse("error-rate < 1%"),
+			parse("status:Unavailable == 0"),
+			parse("p95 < 1s"),
+		}, report)
+
+		assert.False(t, res.Passed)
+		assert.Equal(t, []AssertionResult{
+			{Assertion: "p99 < 250ms", Actual: "200ms", Passed: true},
+			{Assertion: "rps >= 500", Actual: "600.00", Passed: true},
+			{Assertion: "error-rate < 1%", Actual: "2.00%", Passed: false},
+			{Assertion: "status:Unavailable == 0", Actual: "2", Passed: false},
+			{Assertion: "p95 < 1s", Actual: "n/a", Passed: false},
+		}, res.Results)
+	})
+
+	t.Run("corrected", func(t *testing.T) {
+		parse := func(expr string) *assertion {
+			a, err := parseAssertion(expr)
+			assert.NoError(t, err)
+			return a
+		}
+
+		assertions := []*assertion{parse("corrected-p99 < 250ms"), parse("corrected-average < 100ms")}
+
+		res := evaluateAssertions(assertions, report)
+		assert.Equal(t, []AssertionResult{
+			{Assertion: "corrected-p99 < 250ms", Actual: "n/a", Passed: false},
+			{Assertion: "corrected-average < 100ms", Actual: "n/a", Passed: false},
+		}, res.Results)
+
+		corrected := *report
+		corrected.Corrected = &CorrectedLatencies{
+			Average: 120 * time.Millisecond,
+			LatencyDistribution: []LatencyDistribution{
+				{Percentage: 99, Latency: 900 * time.Millisecond},
+			},
+		}
+
+		res = evaluateAssertions(assertions, &corrected)
+		assert.Equal(t, []AssertionResult{
+			{Assertion: "corrected-p99 < 250ms", Actual: "900ms", Passed: false},
+			{Assertion: "corrected-average < 100ms", Actual: "120ms", Passed: false},
+		}, res.Results)
+	})
+
+	t.Run("all passing", func(t *testing.T) {
+		c, err := NewConfig("call", "localhost:50050", WithAssertions("average < 100ms", "slowest <= 300ms", "errors < 5"))
+		assert.NoError(t, err)
+
+		res := evaluateAssertions(c.assertions, report)
+		assert.True(t, res.Passed)
+		assert.Len(t, res.Results, 3)
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		_, err := NewConfig("call", "localhost:50050", WithAssertions("p99 < fast"))
+		assert.Error(t, err)
+	})
+}
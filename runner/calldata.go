@@ -15,6 +15,7 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	"github.com/google/uuid"
 	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
 )
 
 const charset = "abcdefghijklmnopqrstuvwxyz" +
@@ -45,6 +46,9 @@ type CallData struct {
 	TimestampUnixNano  int64  // timestamp of the call as unix time in nanoseconds
 	UUID               string // generated UUIDv4 for each call
 
+	Vars      map[string]interface{}      // values extracted from the responses of the previous steps of a journey
+	Responses map[string]*dynamic.Message // responses of the previous steps of a journey by step name
+
 	t *template.Template
 }
 
@@ -123,6 +127,8 @@ func (td *CallData) Regenerate() *CallData {
 		TimestampUnixMilli: now.UnixNano() / 1000000,
 		TimestampUnixNano:  now.UnixNano(),
 		UUID:               newUUID.String(),
+		Vars:               td.Vars,
+		Responses:          td.Responses,
 		t:                  td.t,
 	}
 }
@@ -0,0 +1,399 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Comparison statuses of a metric
+const (
+	// CompareUnchanged is the status of a metric changed within its tolerance
+	CompareUnchanged = "unchanged"
+
+	// CompareRegressed is the status of a metric that got worse beyond its tolerance
+	CompareRegressed = "regressed"
+
+	// CompareImproved is the status of a metric that got better beyond its tolerance
+	CompareImproved = "improved"
+)
+
+const (
+	// defaultLatencyTolerance is the default tolerated latency increase in percent
+	defaultLatencyTolerance = 10.0
+
+	// defaultRPSTolerance is the default tolerated rps decrease in percent
+	defaultRPSTolerance = 10.0
+
+	// defaultErrorRateTolerance is the default tolerated error rate increase in percentage points
+	defaultErrorRateTolerance = 1.0
+
+	// significanceLevel is the highest p-value of a significant latency difference
+	significanceLevel = 0.05
+
+	// minSignificanceSamples is the fewest latencies of each report needed for the significance test
+	minSignificanceSamples = 20
+)
+
+// Comparison holds the differences between the report of a run and the report of a baseline run
+type Comparison struct {
+	Baseline ComparedRun `json:"baseline"`
+	Current  ComparedRun `json:"current"`
+
+	// Metrics are the compared metrics in order
+	Metrics []MetricComparison `json:"metrics"`
+
+	// Significance is the result of the significance test of the latency difference,
+	// nil if the reports do not have enough successful result details
+	Significance *Significance `json:"significance,omitempty"`
+
+	// Regressed is true if any of the metrics regressed
+	Regressed bool `json:"regressed"`
+}
+
+// ComparedRun identifies a compared report
+type ComparedRun struct {
+	Name  string        `json:"name,omitempty"`
+	Date  time.Time     `json:"date"`
+	Count uint64        `json:"count"`
+	Total time.Duration `json:"total"`
+}
+
+// MetricComparison is the comparison of a single metric. Latency values are in nanoseconds
+// and the error rate is in percent.
+type MetricComparison struct {
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+
+	// Delta is the current value less the baseline value
+	Delta float64 `json:"delta"`
+
+	// DeltaPercent is the delta relative to the baseline value in percent, 0 if the baseline value is 0
+	DeltaPercent float64 `json:"deltaPercent"`
+
+	// Tolerance is the tolerated change, in percent of the baseline value for latencies and rps
+	// and in percentage points for the error rate
+	Tolerance float64 `json:"tolerance"`
+
+	// Status is unchanged, regressed or improved
+	Status string `json:"status"`
+}
+
+// Significance is the result of the Mann-Whitney U test of the successful latencies in the
+// result details of the reports, telling whether the latencies of the runs differ by more than chance.
+type Significance struct {
+	Test string `json:"test"`
+
+	BaselineSamples int `json:"baselineSamples"`
+	CurrentSamples  int `json:"currentSamples"`
+
+	U      float64 `json:"u"`
+	Z      float64 `json:"z"`
+	PValue float64 `json:"pValue"`
+
+	// ProbabilitySlower is the probability that a latency of the current run is higher than
+	// a latency of the baseline run, 0.5 if neither run is slower
+	ProbabilitySlower float64 `json:"probabilitySlower"`
+
+	// Significant is true if the p-value is below 0.05
+	Significant bool `json:"significant"`
+}
+
+// ReadReport reads a report serialized as JSON, such as the output of the json format
+func ReadReport(r io.Reader) (*Report, error) {
+	rep := &Report{}
+	if err := json.NewDecoder(r).Decode(rep); err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+
+	return rep, nil
+}
+
+// ValidateTolerances checks that the comparison tolerances are valid, see CompareReports
+func ValidateTolerances(tolerances ...string) error {
+	_, err := parseTolerances(tolerances)
+	return err
+}
+
+// CompareReports compares the report of a run against the report of a baseline run. The average,
+// fastest and slowest latencies, the latency percentiles present in both reports, the rps and the
+// error rate are compared.
+//
+// Each tolerance is in the form "<metric>=<value>", where the metric is one of the compared
+// metrics or "latency" for all the latency metrics. Latency and rps tolerances are in percent of
+// the baseline value and the error rate tolerance is in percentage points. By default latencies
+// can increase and the rps can decrease by 10% and the error rate can increase by 1 point.
+//
+// When both reports have at least 20 successful result details the latencies are compared using the
+// Mann-Whitney U test, and average and p50 changes beyond the tolerance that are not significant are
+// considered unchanged. The other latency metrics are judged by their tolerance alone.
+//
+//	comparison, err := runner.CompareReports(baseline, report, "p99=5%", "error-rate=0.5")
+func CompareReports(baseline, current *Report, tolerances ...string) (*Comparison, error) {
+	if baseline == nil || current == nil {
+		return nil, errors.New("baseline and current reports required")
+	}
+
+	tol, err := parseTolerances(tolerances)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Comparison{
+		Baseline:     comparedRun(baseline),
+		Current:      comparedRun(current),
+		Significance: mannWhitney(detailLatencies(baseline), detailLatencies(current)),
+	}
+
+	significant := c.Significance == nil || c.Significance.Significant
+
+	addLatency := func(metric string, b, cur time.Duration) {
+		// the significance test compares the typical latencies of the runs, so it can not tell
+		// whether the tail latencies changed, and those are judged by their tolerance alone
+		changed := significant || !centralLatency(metric)
+
+		m := newMetricComparison(metric, float64(b), float64(cur), tol.get(metric))
+		if changed && m.Baseline > 0 {
+			if m.DeltaPercent > m.Tolerance {
+				m.Status = CompareRegressed
+			} else if -m.DeltaPercent > m.Tolerance {
+				m.Status = CompareImproved
+			}
+		}
+
+		c.add(m)
+	}
+
+	if baseline.Count > 0 && current.Count > 0 {
+		addLatency("average", baseline.Average, current.Average)
+	}
+
+	if len(baseline.LatencyDistribution) > 0 && len(current.LatencyDistribution) > 0 {
+		addLatency("fastest", baseline.Fastest, current.Fastest)
+		addLatency("slowest", baseline.Slowest, current.Slowest)
+	}
+
+	for _, bld := range baseline.LatencyDistribution {
+		for _, cld := range current.LatencyDistribution {
+			if bld.Percentage == cld.Percentage && bld.Percentage > 0 {
+				addLatency("p"+strconv.Itoa(bld.Percentage), bld.Latency, cld.Latency)
+			}
+		}
+	}
+
+	rps := newMetricComparison("rps", baseline.Rps, current.Rps, tol.get("rps"))
+	if rps.Baseline > 0 {
+		if -rps.DeltaPercent > rps.Tolerance {
+			rps.Status = CompareRegressed
+		} else if rps.DeltaPercent > rps.Tolerance {
+			rps.Status = CompareImproved
+		}
+	}
+	c.add(rps)
+
+	er := newMetricComparison("error-rate", errorRate(baseline), errorRate(current), tol.get("error-rate"))
+	if er.Delta > er.Tolerance {
+		er.Status = CompareRegressed
+	} else if -er.Delta > er.Tolerance {
+		er.Status = CompareImproved
+	}
+	c.add(er)
+
+	return c, nil
+}
+
+// centralLatency returns whether the latency metric is a central tendency of the latencies,
+// which changes with the significance test
+func centralLatency(metric string) bool {
+	return metric == "average" || metric == "p50"
+}
+
+func (c *Comparison) add(m MetricComparison) {
+	if m.Status == CompareRegressed {
+		c.Regressed = true
+	}
+
+	c.Metrics = append(c.Metrics, m)
+}
+
+func newMetricComparison(metric string, baseline, current, tolerance float64) MetricComparison {
+	m := MetricComparison{
+		Metric:    metric,
+		Baseline:  baseline,
+		Current:   current,
+		Delta:     current - baseline,
+		Tolerance: tolerance,
+		Status:    CompareUnchanged,
+	}
+
+	if baseline != 0 {
+		m.DeltaPercent = m.Delta / baseline * 100
+	}
+
+	return m
+}
+
+func comparedRun(r *Report) ComparedRun {
+	return ComparedRun{Name: r.Name, Date: r.Date, Count: r.Count, Total: r.Total}
+}
+
+// errorRate returns the percentage of erroneous responses of the report
+func errorRate(r *Report) float64 {
+	if r.Count == 0 {
+		return 0
+	}
+
+	return float64(errorCount(r)) / float64(r.Count) * 100
+}
+
+// detailLatencies returns the latencies of the successful result details of the report
+func detailLatencies(r *Report) []float64 {
+	res := make([]float64, 0, len(r.Details))
+	for _, d := range r.Details {
+		if d.Error == "" {
+			res = append(res, float64(d.Latency))
+		}
+	}
+
+	return res
+}
+
+// compareTolerances holds the parsed comparison tolerances
+type compareTolerances map[string]float64
+
+func parseTolerances(tolerances []string) (compareTolerances, error) {
+	res := compareTolerances{}
+	for _, t := range tolerances {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(t, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid tolerance %q: must be in the form \"<metric>=<value>\"", t)
+		}
+
+		metric := strings.ToLower(strings.TrimSpace(parts[0]))
+		if metric != "latency" && metric != "rps" && metric != "error-rate" &&
+			!latencyMetrics[metric] && !isPercentileMetric(metric) {
+			return nil, fmt.Errorf("invalid tolerance %q: unknown metric %q", t, parts[0])
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(parts[1]), "%"), 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid tolerance %q: value must be a non negative number", t)
+		}
+
+		res[metric] = v
+	}
+
+	return res, nil
+}
+
+// get returns the tolerance of the metric
+func (t compareTolerances) get(metric string) float64 {
+	if v, ok := t[metric]; ok {
+		return v
+	}
+
+	switch metric {
+	case "rps":
+		return defaultRPSTolerance
+	case "error-rate":
+		return defaultErrorRateTolerance
+	}
+
+	if v, ok := t["latency"]; ok {
+		return v
+	}
+
+	return defaultLatencyTolerance
+}
+
+// mannWhitney runs the two-sided Mann-Whitney U test using the normal approximation
+// with tie correction. It returns nil if either sample is too small.
+func mannWhitney(baseline, current []float64) *Significance {
+	n1, n2 := len(current), len(baseline)
+	if n1 < minSignificanceSamples || n2 < minSignificanceSamples {
+		return nil
+	}
+
+	type sample struct {
+		v       float64
+		current bool
+	}
+
+	all := make([]sample, 0, n1+n2)
+	for _, v := range current {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range baseline {
+		all = append(all, sample{v, false})
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// rank the samples averaging the ranks of ties
+	var rankSum, tieSum float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].current {
+				rankSum += rank
+			}
+		}
+
+		if t := float64(j - i); t > 1 {
+			tieSum += t*t*t - t
+		}
+
+		i = j
+	}
+
+	fn1, fn2 := float64(n1), float64(n2)
+	n := fn1 + fn2
+	u := rankSum - fn1*(fn1+1)/2
+	mu := fn1 * fn2 / 2
+	sigma := math.Sqrt(fn1 * fn2 / 12 * ((n + 1) - tieSum/(n*(n-1))))
+
+	s := &Significance{
+		Test:              "mann-whitney-u",
+		BaselineSamples:   n2,
+		CurrentSamples:    n1,
+		U:                 u,
+		PValue:            1,
+		ProbabilitySlower: u / (fn1 * fn2),
+	}
+
+	if sigma > 0 {
+		// continuity correction towards the mean
+		d := u - mu
+		switch {
+		case d > 0.5:
+			d -= 0.5
+		case d < -0.5:
+			d += 0.5
+		default:
+			d = 0
+		}
+
+		s.Z = d / sigma
+		s.PValue = math.Erfc(math.Abs(s.Z) / math.Sqrt2)
+	}
+
+	s.Significant = s.PValue < significanceLevel
+
+	return s
+}
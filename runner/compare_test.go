@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCompareReport(latency time.Duration, rps float64, errs int) *Report {
+	r := &Report{
+		Count:          100,
+		Average:        latency,
+		Fastest:        latency / 2,
+		Slowest:        latency * 2,
+		Rps:            rps,
+		ErrorDist:      map[string]int{},
+		StatusCodeDist: map[string]int{"OK": 100 - errs},
+		LatencyDistribution: []LatencyDistribution{
+			{Percentage: 50, Latency: latency},
+			{Percentage: 99, Latency: latency * 3 / 2},
+		},
+	}
+
+	if errs > 0 {
+		r.ErrorDist["rpc error: code = Unavailable desc = down"] = errs
+		r.StatusCodeDist["Unavailable"] = errs
+	}
+
+	return r
+}
+
+func findMetric(c *Comparison, metric string) *MetricComparison {
+	for i := range c.Metrics {
+		if c.Metrics[i].Metric == metric {
+			return &c.Metrics[i]
+		}
+	}
+
+	return nil
+}
+
+func TestCompareReports(t *testing.T) {
+	t.Run("unchanged", func(t *testing.T) {
+		c, err := CompareReports(newTestCompareReport(10*time.Millisecond, 100, 0), newTestCompareReport(10500*time.Microsecond, 98, 0))
+		assert.NoError(t, err)
+		assert.False(t, c.Regressed)
+		assert.Nil(t, c.Significance)
+
+		var metrics []string
+		for _, m := range c.Metrics {
+			metrics = append(metrics, m.Metric)
+			assert.Equal(t, CompareUnchanged, m.Status, m.Metric)
+		}
+		assert.Equal(t, []string{"average", "fastest", "slowest", "p50", "p99", "rps", "error-rate"}, metrics)
+
+		avg := findMetric(c, "average")
+		assert.Equal(t, float64(10*time.Millisecond), avg.Baseline)
+		assert.Equal(t, float64(500*time.Microsecond), avg.Delta)
+		assert.InDelta(t, 5.0, avg.DeltaPercent, 0.0001)
+		assert.Equal(t, 10.0, avg.Tolerance)
+	})
+
+	t.Run("regressed", func(t *testing.T) {
+		c, err := CompareReports(newTestCompareReport(10*time.Millisecond, 100, 0), newTestCompareReport(20*time.Millisecond, 50, 3))
+		assert.NoError(t, err)
+		assert.True(t, c.Regressed)
+
+		for _, m := range c.Metrics {
+			assert.Equal(t, CompareRegressed, m.Status, m.Metric)
+		}
+
+		er := findMetric(c, "error-rate")
+		assert.Equal(t, 3.0, er.Current)
+		assert.Equal(t, 3.0, er.Delta)
+		assert.Equal(t, 1.0, er.Tolerance)
+	})
+
+	t.Run("improved", func(t *testing.T) {
+		c, err := CompareReports(newTestCompareReport(20*time.Millisecond, 50, 3), newTestCompareReport(10*time.Millisecond, 100, 0))
+		assert.NoError(t, err)
+		assert.False(t, c.Regressed)
+
+		for _, m := range c.Metrics {
+			assert.Equal(t, CompareImproved, m.Status, m.Metric)
+		}
+	})
+
+	t.Run("tolerances", func(t *testing.T) {
+		c, err := CompareReports(newTestCompareReport(10*time.Millisecond, 100, 0), newTestCompareReport(12*time.Millisecond, 100, 2),
+			"latency=25%", "p99=5", "error-rate=2.5")
+		assert.NoError(t, err)
+		assert.True(t, c.Regressed)
+
+		assert.Equal(t, CompareUnchanged, findMetric(c, "average").Status)
+		assert.Equal(t, 25.0, findMetric(c, "p50").Tolerance)
+		assert.Equal(t, CompareRegressed, findMetric(c, "p99").Status)
+		assert.Equal(t, 10.0, findMetric(c, "rps").Tolerance)
+		assert.Equal(t, CompareUnchanged, findMetric(c, "error-rate").Status)
+	})
+
+	t.Run("not significant", func(t *testing.T) {
+		baseline := newTestCompareReport(10*time.Millisecond, 100, 0)
+		current := newTestCompareReport(12*time.Millisecond, 100, 0)
+
+		// the same spread of latencies in both runs
+		for i := 0; i < 50; i++ {
+			d := time.Duration(5+i%10) * time.Millisecond
+			baseline.Details = append(baseline.Details, ResultDetail{Latency: d})
+			current.Details = append(current.Details, ResultDetail{Latency: d})
+		}
+
+		c, err := CompareReports(baseline, current)
+		assert.NoError(t, err)
+		assert.NotNil(t, c.Significance)
+		assert.False(t, c.Significance.Significant)
+		assert.Equal(t, 1.0, c.Significance.PValue)
+		assert.Equal(t, 0.5, c.Significance.ProbabilitySlower)
+		assert.Equal(t, CompareUnchanged, findMetric(c, "average").Status)
+		assert.Equal(t, CompareUnchanged, findMetric(c, "p50").Status)
+
+		// the tail latencies are judged by their tolerance alone
+		assert.Equal(t, CompareRegressed, findMetric(c, "slowest").Status)
+		assert.Equal(t, CompareRegressed, findMetric(c, "p99").Status)
+		assert.True(t, c.Regressed)
+	})
+
+	t.Run("tail regressed", func(t *testing.T) {
+		baseline := newTestCompareReport(10*time.Millisecond, 100, 0)
+		current := newTestCompareReport(10*time.Millisecond, 100, 0)
+		current.Slowest = baseline.Slowest * 2
+		current.LatencyDistribution[1].Latency = baseline.LatencyDistribution[1].Latency * 2
+
+		// the same median latencies with a slower tail
+		for i := 0; i < 100; i++ {
+			d := time.Duration(5+i%10) * time.Millisecond
+			baseline.Details = append(baseline.Details, ResultDetail{Latency: d})
+			if i == 99 {
+				d *= 2
+			}
+			current.Details = append(current.Details, ResultDetail{Latency: d})
+		}
+
+		c, err := CompareReports(baseline, current)
+		assert.NoError(t, err)
+		assert.NotNil(t, c.Significance)
+		assert.False(t, c.Significance.Significant)
+		assert.Equal(t, CompareUnchanged, findMetric(c, "average").Status)
+		assert.Equal(t, CompareUnchanged, findMetric(c, "p50").Status)
+		assert.Equal(t, CompareRegressed, findMetric(c, "slowest").Status)
+		assert.Equal(t, CompareRegressed, findMetric(c, "p99").Status)
+		assert.True(t, c.Regressed)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := CompareReports(nil, &Report{})
+		assert.EqualError(t, err, "baseline and current reports required")
+
+		_, err = CompareReports(&Report{}, &Report{}, "p99")
+		assert.EqualError(t, err, `invalid tolerance "p99": must be in the form "<metric>=<value>"`)
+
+		_, err = CompareReports(&Report{}, &Report{}, "count=5")
+		assert.EqualError(t, err, `invalid tolerance "count=5": unknown metric "count"`)
+
+		_, err = CompareReports(&Report{}, &Report{}, "rps=-5")
+		assert.EqualError(t, err, `invalid tolerance "rps=-5": value must be a non negative number`)
+	})
+}
+
+func TestMannWhitney(t *testing.T) {
+	t.Run("too few samples", func(t *testing.T) {
+		assert.Nil(t, mannWhitney([]float64{1, 2, 3}, []float64{4, 5, 6}))
+	})
+
+	t.Run("slower", func(t *testing.T) {
+		var baseline, current []float64
+		for i := 0; i < 30; i++ {
+			baseline = append(baseline, float64(i))
+			current = append(current, float64(i+20))
+		}
+
+		s := mannWhitney(baseline, current)
+		assert.NotNil(t, s)
+		assert.Equal(t, "mann-whitney-u", s.Test)
+		assert.Equal(t, 30, s.BaselineSamples)
+		assert.Equal(t, 30, s.CurrentSamples)
+		// 10 ties at 20..29 count half each
+		assert.Equal(t, 900.0-50, s.U)
+		assert.InDelta(t, 850.0/900, s.ProbabilitySlower, 0.0001)
+		assert.True(t, s.Z > 0)
+		assert.True(t, s.PValue < 0.0001)
+		assert.True(t, s.Significant)
+
+		s = mannWhitney(current, baseline)
+		assert.True(t, s.Z < 0)
+		assert.InDelta(t, 50.0/900, s.ProbabilitySlower, 0.0001)
+		assert.True(t, s.Significant)
+	})
+}
+
+func TestReadReport(t *testing.T) {
+	rep := newTestCompareReport(10*time.Millisecond, 100, 1)
+	rep.Date = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	data, err := rep.MarshalJSON()
+	assert.NoError(t, err)
+
+	read, err := ReadReport(strings.NewReader(string(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, rep.Count, read.Count)
+	assert.Equal(t, rep.Average, read.Average)
+	assert.Equal(t, rep.LatencyDistribution, read.LatencyDistribution)
+	assert.True(t, rep.Date.Equal(read.Date))
+
+	_, err = ReadReport(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestValidateTolerances(t *testing.T) {
+	assert.NoError(t, ValidateTolerances("p99=5%", "latency=10", "rps=1", "error-rate=0.5", ""))
+	assert.EqualError(t, ValidateTolerances("p42=5"), `invalid tolerance "p42=5": unknown metric "p42"`)
+}
@@ -113,11 +113,46 @@ type Config struct {
 	LoadStep              int               `json:"load-step" toml:"load-step" yaml:"load-step"`
 	LoadStepDuration      Duration          `json:"load-step-duration" toml:"load-step-duration" yaml:"load-step-duration"`
 	LoadMaxDuration       Duration          `json:"load-max-duration" toml:"load-max-duration" yaml:"load-max-duration"`
+	LoadAmplitude         uint              `json:"load-amplitude,omitempty" toml:"load-amplitude,omitempty" yaml:"load-amplitude,omitempty"`
+	LoadPeriod            Duration          `json:"load-period,omitempty" toml:"load-period,omitempty" yaml:"load-period,omitempty"`
+	LoadSpikeDuration     Duration          `json:"load-spike-duration,omitempty" toml:"load-spike-duration,omitempty" yaml:"load-spike-duration,omitempty"`
+	LoadScheduleFile      string            `json:"load-schedule-file,omitempty" toml:"load-schedule-file,omitempty" yaml:"load-schedule-file,omitempty"`
+	SearchSLOs            []string          `json:"search-slos,omitempty" toml:"search-slos,omitempty" yaml:"search-slos,omitempty"`
 	LBStrategy            string            `json:"lb-strategy" toml:"lb-strategy" yaml:"lb-strategy"`
 	MaxCallRecvMsgSize    string            `json:"max-recv-message-size" toml:"max-recv-message-size" yaml:"max-recv-message-size"`
 	MaxCallSendMsgSize    string            `json:"max-send-message-size" toml:"max-send-message-size" yaml:"max-send-message-size"`
 	DisableTemplateFuncs  bool              `json:"disable-template-functions" toml:"disable-template-functions" yaml:"disable-template-functions"`
 	DisableTemplateData   bool              `json:"disable-template-data" toml:"disable-template-data" yaml:"disable-template-data"`
+	HistogramPrecision    uint              `json:"histogram-precision" toml:"histogram-precision" yaml:"histogram-precision" default:"3"`
+	DetailsSampleSize     uint              `json:"details-sample-size" toml:"details-sample-size" yaml:"details-sample-size" default:"1000000"`
+	SkipDetails           bool              `json:"skip-details" toml:"skip-details" yaml:"skip-details"`
+	Progress              bool              `json:"progress,omitempty" toml:"progress,omitempty" yaml:"progress,omitempty"`
+	ProgressInterval      Duration          `json:"progress-interval" toml:"progress-interval" yaml:"progress-interval" default:"1s"`
+	TimelineInterval      Duration          `json:"timeline-interval,omitempty" toml:"timeline-interval,omitempty" yaml:"timeline-interval,omitempty"`
+	OTLPEndpoint          string            `json:"otlp-endpoint,omitempty" toml:"otlp-endpoint,omitempty" yaml:"otlp-endpoint,omitempty"`
+	OTLPHeaders           map[string]string `json:"otlp-headers,omitempty" toml:"otlp-headers,omitempty" yaml:"otlp-headers,omitempty"`
+	OTLPInterval          Duration          `json:"otlp-interval,omitempty" toml:"otlp-interval,omitempty" yaml:"otlp-interval,omitempty"`
+	OTLPTraces            bool              `json:"otlp-traces,omitempty" toml:"otlp-traces,omitempty" yaml:"otlp-traces,omitempty"`
+	MetricsAddress        string            `json:"metrics-address,omitempty" toml:"metrics-address,omitempty" yaml:"metrics-address,omitempty"`
+	Pushgateway           string            `json:"pushgateway,omitempty" toml:"pushgateway,omitempty" yaml:"pushgateway,omitempty"`
+	ResultsFile           string            `json:"results-file,omitempty" toml:"results-file,omitempty" yaml:"results-file,omitempty"`
+	InfluxURL             string            `json:"influx-url,omitempty" toml:"influx-url,omitempty" yaml:"influx-url,omitempty"`
+	InfluxDatabase        string            `json:"influx-db,omitempty" toml:"influx-db,omitempty" yaml:"influx-db,omitempty"`
+	InfluxRetention       string            `json:"influx-rp,omitempty" toml:"influx-rp,omitempty" yaml:"influx-rp,omitempty"`
+	InfluxUsername        string            `json:"influx-username,omitempty" toml:"influx-username,omitempty" yaml:"influx-username,omitempty"`
+	InfluxPassword        string            `json:"influx-password,omitempty" toml:"influx-password,omitempty" yaml:"influx-password,omitempty"`
+	InfluxOrg             string            `json:"influx-org,omitempty" toml:"influx-org,omitempty" yaml:"influx-org,omitempty"`
+	InfluxBucket          string            `json:"influx-bucket,omitempty" toml:"influx-bucket,omitempty" yaml:"influx-bucket,omitempty"`
+	InfluxToken           string            `json:"influx-token,omitempty" toml:"influx-token,omitempty" yaml:"influx-token,omitempty"`
+	InfluxStreamDetails   bool              `json:"influx-stream-details,omitempty" toml:"influx-stream-details,omitempty" yaml:"influx-stream-details,omitempty"`
+	Assertions            []string          `json:"assertions,omitempty" toml:"assertions,omitempty" yaml:"assertions,omitempty"`
+	Scenario              string            `json:"scenario,omitempty" toml:"scenario,omitempty" yaml:"scenario,omitempty"`
+	Validations           []string          `json:"validations,omitempty" toml:"validations,omitempty" yaml:"validations,omitempty"`
+	Baseline              string            `json:"baseline,omitempty" toml:"baseline,omitempty" yaml:"baseline,omitempty"`
+	Tolerances            []string          `json:"tolerances,omitempty" toml:"tolerances,omitempty" yaml:"tolerances,omitempty"`
+	Agents                []string          `json:"agents,omitempty" toml:"agents,omitempty" yaml:"agents,omitempty"`
+	AgentsSecret          string            `json:"agents-secret,omitempty" toml:"agents-secret,omitempty" yaml:"agents-secret,omitempty"`
+	AgentsRootCert        string            `json:"agents-cacert,omitempty" toml:"agents-cacert,omitempty" yaml:"agents-cacert,omitempty"`
 }
 
 func checkData(data interface{}) error {
@@ -150,23 +185,9 @@ func LoadConfig(p string, c *Config) error {
 	}
 
 	if c.Data != nil {
-		ext := path.Ext(p)
-		if strings.EqualFold(ext, ".yaml") || strings.EqualFold(ext, ".yml") {
-			objData, isObjData2 := c.Data.(map[interface{}]interface{})
-			if isObjData2 {
-				nd := make(map[string]interface{})
-				for k, v := range objData {
-					sk, isString := k.(string)
-					if !isString {
-						return errors.New("data key must string")
-					}
-					if len(sk) > 0 {
-						nd[sk] = v
-					}
-				}
-
-				c.Data = nd
-			}
+		c.Data, err = normalizeData(p, c.Data)
+		if err != nil {
+			return err
 		}
 
 		err := checkData(c.Data)
@@ -196,3 +217,29 @@ func LoadConfig(p string, c *Config) error {
 
 	return nil
 }
+
+// normalizeData converts YAML decoded data objects to JSON compatible maps
+func normalizeData(p string, data interface{}) (interface{}, error) {
+	ext := path.Ext(p)
+	if !strings.EqualFold(ext, ".yaml") && !strings.EqualFold(ext, ".yml") {
+		return data, nil
+	}
+
+	objData, isObjData := data.(map[interface{}]interface{})
+	if !isObjData {
+		return data, nil
+	}
+
+	nd := make(map[string]interface{})
+	for k, v := range objData {
+		sk, isString := k.(string)
+		if !isString {
+			return nil, errors.New("data key must string")
+		}
+		if len(sk) > 0 {
+			nd[sk] = v
+		}
+	}
+
+	return nd, nil
+}
@@ -66,6 +66,9 @@ func TestConfig_Load(t *testing.T) {
 				CStart:             1,
 				MaxCallRecvMsgSize: "1024mb",
 				MaxCallSendMsgSize: "2000mib",
+				HistogramPrecision: 3,
+				DetailsSampleSize:  1000000,
+				ProgressInterval:   Duration(time.Second),
 			},
 			true,
 		},
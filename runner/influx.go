@@ -0,0 +1,407 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInfluxBatchSize     = 5000
+	defaultInfluxFlushInterval = time.Second
+	defaultInfluxMaxRetries    = 3
+
+	// influxMaxPendingBatches is the most batches buffered for writing, further points are dropped
+	influxMaxPendingBatches = 100
+
+	// influxRetryBackoff is the wait before the first retry of a failed write, doubled on each retry
+	influxRetryBackoff = 250 * time.Millisecond
+
+	influxWriteTimeout = 30 * time.Second
+)
+
+// InfluxConfig is the configuration of the InfluxDB write API of an InfluxWriter.
+// The InfluxDB 1.x API is used when the database is set and the 2.x API is used when the bucket is set.
+type InfluxConfig struct {
+	// URL is the base URL of the InfluxDB server, for example http://localhost:8086
+	URL string
+
+	// Database, RetentionPolicy, Username and Password configure the InfluxDB 1.x /write API
+	Database        string
+	RetentionPolicy string
+	Username        string
+	Password        string
+
+	// Org, Bucket and Token configure the InfluxDB 2.x /api/v2/write API
+	Org    string
+	Bucket string
+	Token  string
+
+	// BatchSize is the most points written in a single request. Default is 5000.
+	BatchSize int
+
+	// FlushInterval is the interval of writing the buffered points. Default is 1 second.
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times a write failing with a network error, a 5xx status or
+	// a 429 status is retried with exponential backoff. Default is 3.
+	MaxRetries int
+}
+
+// InfluxWriter writes InfluxDB line protocol points to the InfluxDB HTTP write API in batches.
+// Writes only buffer the points and never block on the network. The buffered points are
+// written periodically and once a batch is full. It is safe for concurrent use.
+type InfluxWriter struct {
+	url        string
+	username   string
+	password   string
+	token      string
+	batchSize  int
+	maxRetries int
+	client     *http.Client
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	partial []byte
+	lines   []string
+	dropped uint64
+	err     error
+	closed  bool
+
+	// serializes the writes to InfluxDB
+	writeMu sync.Mutex
+}
+
+// NewInfluxWriter creates a new InfluxWriter and starts writing the buffered points periodically.
+// It has to be closed to write the remaining points.
+//
+//	w, err := runner.NewInfluxWriter(runner.InfluxConfig{
+//		URL:    "http://localhost:8086",
+//		Org:    "ghz",
+//		Bucket: "ghz",
+//		Token:  "secret",
+//	})
+func NewInfluxWriter(cfg InfluxConfig) (*InfluxWriter, error) {
+	base, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid InfluxDB URL %q: must be an http or https URL", cfg.URL)
+	}
+
+	q := url.Values{}
+	q.Set("precision", "ns")
+
+	switch {
+	case cfg.Bucket != "" && cfg.Database != "":
+		return nil, errors.New("InfluxDB database and bucket cannot both be set")
+	case cfg.Bucket != "":
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/api/v2/write"
+		q.Set("bucket", cfg.Bucket)
+		if cfg.Org != "" {
+			q.Set("org", cfg.Org)
+		}
+	case cfg.Database != "":
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/write"
+		q.Set("db", cfg.Database)
+		if cfg.RetentionPolicy != "" {
+			q.Set("rp", cfg.RetentionPolicy)
+		}
+	default:
+		return nil, errors.New("InfluxDB database or bucket required")
+	}
+
+	base.RawQuery = q.Encode()
+
+	if cfg.BatchSize < 0 || cfg.MaxRetries < 0 || cfg.FlushInterval < 0 {
+		return nil, errors.New("InfluxDB batch size, flush interval and max retries cannot be negative")
+	}
+
+	w := &InfluxWriter{
+		url:        base.String(),
+		username:   cfg.Username,
+		password:   cfg.Password,
+		token:      cfg.Token,
+		batchSize:  cfg.BatchSize,
+		maxRetries: cfg.MaxRetries,
+		client:     &http.Client{Timeout: influxWriteTimeout},
+		flushCh:    make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if w.batchSize == 0 {
+		w.batchSize = defaultInfluxBatchSize
+	}
+
+	if cfg.MaxRetries == 0 {
+		w.maxRetries = defaultInfluxMaxRetries
+	}
+
+	interval := cfg.FlushInterval
+	if interval == 0 {
+		interval = defaultInfluxFlushInterval
+	}
+
+	go w.run(interval)
+
+	return w, nil
+}
+
+// Write buffers the newline separated line protocol points. A trailing point without
+// a newline is buffered until it is completed by the next write or the writer is flushed.
+func (w *InfluxWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("InfluxDB writer is closed")
+	}
+
+	data := append(w.partial, p...)
+	w.partial = nil
+
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			w.partial = append([]byte{}, data...)
+			break
+		}
+
+		w.add(string(data[:i]))
+		data = data[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// WritePoint buffers a single line protocol point
+func (w *InfluxWriter) WritePoint(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		w.add(line)
+	}
+}
+
+// add buffers the point, must be called with the lock held
+func (w *InfluxWriter) add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	if len(w.lines) >= influxMaxPendingBatches*w.batchSize {
+		w.dropped++
+		return
+	}
+
+	w.lines = append(w.lines, line)
+
+	if len(w.lines) >= w.batchSize {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush writes all the buffered points and returns the first error of the writes since the last flush
+func (w *InfluxWriter) Flush() error {
+	w.mu.Lock()
+	if len(w.partial) > 0 {
+		w.add(string(w.partial))
+		w.partial = nil
+	}
+	w.mu.Unlock()
+
+	w.write()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.err
+	w.err = nil
+
+	if w.dropped > 0 {
+		dropped := fmt.Errorf("dropped %d InfluxDB points buffered beyond the write capacity", w.dropped)
+		if err == nil {
+			err = dropped
+		}
+		w.dropped = 0
+	}
+
+	return err
+}
+
+// Close writes the remaining points and stops the periodic writes
+func (w *InfluxWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stopCh)
+	<-w.done
+
+	return w.Flush()
+}
+
+func (w *InfluxWriter) run(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.write()
+		case <-w.flushCh:
+			w.write()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// write writes the buffered points in batches, keeping the first error
+func (w *InfluxWriter) write() {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	for {
+		w.mu.Lock()
+		n := len(w.lines)
+		if n > w.batchSize {
+			n = w.batchSize
+		}
+		batch := w.lines[:n:n]
+		if n == len(w.lines) {
+			// release the written points
+			w.lines = nil
+		} else {
+			w.lines = w.lines[n:]
+		}
+		w.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := w.writeBatch(batch); err != nil {
+			w.mu.Lock()
+			if w.err == nil {
+				w.err = err
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (w *InfluxWriter) writeBatch(batch []string) error {
+	body := []byte(strings.Join(batch, "\n") + "\n")
+	backoff := influxRetryBackoff
+
+	var err error
+	for attempt := 0; attempt <= w.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var retry bool
+		if retry, err = w.post(body); err == nil || !retry {
+			return err
+		}
+	}
+
+	return err
+}
+
+// post makes a single write request, returning whether a failed write can be retried
+func (w *InfluxWriter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	} else if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("writing to InfluxDB: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		// drain the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("writing to InfluxDB failed with status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// influxDetailLine returns the line protocol point of the result detail of a call. The tags are
+// the name of the run, the call, the host and whether the call failed. The tag values are quoted
+// the same as in the details written by the influx-details output format.
+func influxDetailLine(c *RunConfig, res *callResult, errStr string) string {
+	call := res.call
+	if call == "" {
+		call = c.call
+	}
+
+	var sb strings.Builder
+	sb.WriteString("ghz_detail")
+
+	for _, tag := range [][2]string{{"name", c.name}, {"call", call}, {"host", c.host}} {
+		if tag[0] == "name" && tag[1] == "" {
+			continue
+		}
+
+		sb.WriteString("," + tag[0] + `="` + escapeInfluxTag(tag[1]) + `"`)
+	}
+
+	sb.WriteString(",hasError=" + strconv.FormatBool(errStr != ""))
+
+	sb.WriteString(" latency=" + strconv.FormatInt(res.duration.Nanoseconds(), 10))
+	sb.WriteString(`,error="` + escapeInfluxField(errStr) + `"`)
+	sb.WriteString(`,status="` + escapeInfluxField(res.status) + `"`)
+	sb.WriteString(" " + strconv.FormatInt(res.timestamp.UnixNano(), 10))
+
+	return sb.String()
+}
+
+var influxTagReplacer = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+
+func escapeInfluxTag(v string) string {
+	return influxTagReplacer.Replace(v)
+}
+
+var influxFieldReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeInfluxField(v string) string {
+	return influxFieldReplacer.Replace(v)
+}
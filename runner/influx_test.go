@@ -0,0 +1,268 @@
+package runner
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+)
+
+type influxWrite struct {
+	path  string
+	query map[string]string
+	auth  string
+	user  string
+	pass  string
+	lines []string
+}
+
+// influxStub is an InfluxDB write API stub recording the writes
+type influxStub struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	writes []influxWrite
+	status []int
+}
+
+func newInfluxStub(status ...int) *influxStub {
+	s := &influxStub{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		write := influxWrite{
+			path:  r.URL.Path,
+			query: map[string]string{},
+			auth:  r.Header.Get("Authorization"),
+			lines: strings.Split(strings.TrimSuffix(string(body), "\n"), "\n"),
+		}
+		write.user, write.pass, _ = r.BasicAuth()
+		for k := range r.URL.Query() {
+			write.query[k] = r.URL.Query().Get(k)
+		}
+
+		s.mu.Lock()
+		s.writes = append(s.writes, write)
+		code := http.StatusNoContent
+		if len(s.status) > 0 {
+			code = s.status[0]
+			s.status = s.status[1:]
+		}
+		s.mu.Unlock()
+
+		w.WriteHeader(code)
+		if code >= 300 {
+			_, _ = w.Write([]byte(`{"code":"invalid","message":"write failed"}`))
+		}
+	}))
+
+	return s
+}
+
+func (s *influxStub) getWrites() []influxWrite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]influxWrite{}, s.writes...)
+}
+
+func TestNewInfluxWriter(t *testing.T) {
+	var tests = []struct {
+		name string
+		cfg  InfluxConfig
+		err  string
+	}{
+		{"invalid url", InfluxConfig{URL: "localhost:8086", Database: "ghz"}, "invalid InfluxDB URL"},
+		{"no database or bucket", InfluxConfig{URL: "http://localhost:8086"}, "InfluxDB database or bucket required"},
+		{"database and bucket", InfluxConfig{URL: "http://localhost:8086", Database: "ghz", Bucket: "ghz"}, "cannot both be set"},
+		{"negative batch size", InfluxConfig{URL: "http://localhost:8086", Database: "ghz", BatchSize: -1}, "cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewInfluxWriter(tt.cfg)
+			assert.Nil(t, w)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestInfluxWriter(t *testing.T) {
+	t.Run("v1", func(t *testing.T) {
+		srv := newInfluxStub()
+		defer srv.Close()
+
+		w, err := NewInfluxWriter(InfluxConfig{
+			URL:             srv.URL + "/",
+			Database:        "ghz",
+			RetentionPolicy: "week",
+			Username:        "user",
+			Password:        "pass",
+		})
+		assert.NoError(t, err)
+
+		w.WritePoint("m v=1 1")
+		_, err = w.Write([]byte("m v=2 2\nm v="))
+		assert.NoError(t, err)
+		_, err = w.Write([]byte("3 3"))
+		assert.NoError(t, err)
+
+		assert.NoError(t, w.Close())
+
+		writes := srv.getWrites()
+		assert.Len(t, writes, 1)
+		assert.Equal(t, "/write", writes[0].path)
+		assert.Equal(t, map[string]string{"db": "ghz", "rp": "week", "precision": "ns"}, writes[0].query)
+		assert.Equal(t, "user", writes[0].user)
+		assert.Equal(t, "pass", writes[0].pass)
+		assert.Equal(t, []string{"m v=1 1", "m v=2 2", "m v=3 3"}, writes[0].lines)
+
+		w.WritePoint("m v=4 4")
+		_, err = w.Write([]byte("m v=5 5\n"))
+		assert.Error(t, err)
+		assert.NoError(t, w.Close())
+		assert.Len(t, srv.getWrites(), 1)
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		srv := newInfluxStub()
+		defer srv.Close()
+
+		w, err := NewInfluxWriter(InfluxConfig{
+			URL:    srv.URL,
+			Org:    "acme",
+			Bucket: "ghz",
+			Token:  "secret",
+		})
+		assert.NoError(t, err)
+
+		w.WritePoint("m v=1 1")
+		assert.NoError(t, w.Close())
+
+		writes := srv.getWrites()
+		assert.Len(t, writes, 1)
+		assert.Equal(t, "/api/v2/write", writes[0].path)
+		assert.Equal(t, map[string]string{"bucket": "ghz", "org": "acme", "precision": "ns"}, writes[0].query)
+		assert.Equal(t, "Token secret", writes[0].auth)
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		srv := newInfluxStub()
+		defer srv.Close()
+
+		w, err := NewInfluxWriter(InfluxConfig{URL: srv.URL, Database: "ghz", BatchSize: 2, FlushInterval: time.Hour})
+		assert.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			w.WritePoint("m v=1")
+		}
+
+		// full batches are written without waiting for the flush interval
+		assert.Eventually(t, func() bool { return len(srv.getWrites()) >= 2 }, 5*time.Second, 10*time.Millisecond)
+
+		assert.NoError(t, w.Close())
+
+		writes := srv.getWrites()
+		assert.Len(t, writes, 3)
+
+		var n int
+		for _, write := range writes {
+			assert.LessOrEqual(t, len(write.lines), 2)
+			n += len(write.lines)
+		}
+		assert.Equal(t, 5, n)
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		srv := newInfluxStub(http.StatusInternalServerError, http.StatusTooManyRequests)
+		defer srv.Close()
+
+		w, err := NewInfluxWriter(InfluxConfig{URL: srv.URL, Database: "ghz"})
+		assert.NoError(t, err)
+
+		w.WritePoint("m v=1")
+		assert.NoError(t, w.Close())
+		assert.Len(t, srv.getWrites(), 3)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		srv := newInfluxStub(http.StatusBadRequest)
+		defer srv.Close()
+
+		w, err := NewInfluxWriter(InfluxConfig{URL: srv.URL, Database: "ghz"})
+		assert.NoError(t, err)
+
+		w.WritePoint("m v=1")
+		err = w.Close()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "400 Bad Request")
+		assert.Contains(t, err.Error(), "write failed")
+		assert.Len(t, srv.getWrites(), 1)
+	})
+}
+
+func TestInfluxDetailLine(t *testing.T) {
+	c, err := NewConfig("helloworld.Greeter.SayHello", "localhost:50051",
+		WithName("run name"),
+		WithProtoFile("../testdata/greeter.proto", []string{}))
+	assert.NoError(t, err)
+
+	ts := time.Unix(0, 1000)
+
+	line := influxDetailLine(c, &callResult{status: "OK", duration: 2 * time.Millisecond, timestamp: ts}, "")
+	assert.Equal(t, `ghz_detail,name="run\ name",call="helloworld.Greeter.SayHello",host="localhost:50051",hasError=false latency=2000000,error="",status="OK" 1000`, line)
+
+	res := &callResult{status: "Unavailable", err: errors.New(`a "b"`), duration: time.Millisecond, timestamp: ts, call: "second"}
+	line = influxDetailLine(c, res, `a "b"`)
+	assert.Equal(t, `ghz_detail,name="run\ name",call="second",host="localhost:50051",hasError=true latency=1000000,error="a \"b\"",status="Unavailable" 1000`, line)
+}
+
+func TestRunInfluxDetails(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	srv := newInfluxStub()
+	defer srv.Close()
+
+	w, err := NewInfluxWriter(InfluxConfig{URL: srv.URL, Database: "ghz"})
+	assert.NoError(t, err)
+
+	report, err := Run(
+		"helloworld.Greeter.SayHello",
+		internal.TestLocalhost,
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithTotalRequests(10),
+		WithConcurrency(2),
+		WithInfluxDetails(w),
+		WithData(map[string]interface{}{"name": "bob"}),
+		WithInsecure(true),
+	)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, report)
+	assert.NoError(t, w.Close())
+
+	var lines []string
+	for _, write := range srv.getWrites() {
+		lines = append(lines, write.lines...)
+	}
+
+	assert.Len(t, lines, 10)
+	for _, line := range lines {
+		assert.True(t, strings.HasPrefix(line, `ghz_detail,call="helloworld.Greeter.SayHello",`), line)
+		assert.Contains(t, line, `status="OK"`)
+	}
+}
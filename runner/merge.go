@@ -0,0 +1,539 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// MergeReports combines the reports of several runs made at the same time, for example by
+// the agents of a distributed test, into a single report. The reports must include their
+// latency histograms, see WithLatencyHistogram. Counts and distributions are summed and the
+// latency distribution is computed from the merged histograms, which are not included in the
+// merged report. The total duration is the longest of the runs. The assertions are evaluated
+// against the merged report.
+//
+//	report, err := runner.MergeReports([]*runner.Report{r1, r2}, "p99 < 250ms")
+func MergeReports(reports []*Report, assertions ...string) (*Report, error) {
+	if len(reports) == 0 {
+		return nil, errors.New("no reports to merge")
+	}
+
+	var parsed []*assertion
+	for _, expr := range assertions {
+		a, err := parseAssertion(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, a)
+	}
+
+	first := reports[0]
+
+	rep := &Report{
+		Name:      first.Name,
+		EndReason: first.EndReason,
+		Date:      first.Date,
+		Options:   first.Options,
+		Tags:      first.Tags,
+	}
+
+	rep.Options.Total, rep.Options.RPS, rep.Options.Concurrency, rep.Options.Connections = 0, 0, 0, 0
+
+	parts := make([]reportPart, len(reports))
+	for i, r := range reports {
+		if r.Total > rep.Total {
+			rep.Total = r.Total
+		}
+
+		if r.EndReason != ReasonNormalEnd {
+			rep.EndReason = r.EndReason
+		}
+
+		rep.Options.Total += r.Options.Total
+		rep.Options.RPS += r.Options.RPS
+		rep.Options.Concurrency += r.Options.Concurrency
+		rep.Options.Connections += r.Options.Connections
+
+		rep.Details = append(rep.Details, r.Details...)
+
+		parts[i] = reportPart{
+			count:              r.Count,
+			average:            r.Average,
+			fastest:            r.Fastest,
+			slowest:            r.Slowest,
+			errorDist:          r.ErrorDist,
+			statusCodeDist:     r.StatusCodeDist,
+			validationFailures: r.ValidationFailures,
+			hist:               r.LatencyHistogram,
+		}
+	}
+
+	sortDetails(rep.Details)
+
+	m, err := mergeParts(parts, rep.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	rep.Count = m.count
+	rep.Average, rep.Fastest, rep.Slowest, rep.Rps = m.average, m.fastest, m.slowest, m.rps
+	rep.ErrorDist, rep.StatusCodeDist = m.errorDist, m.statusCodeDist
+	rep.ValidationFailures = m.validationFailures
+	rep.LatencyDistribution, rep.Histogram = m.latencyDistribution, m.histogram
+
+	if first.Corrected != nil {
+		parts := make([]reportPart, len(reports))
+		for i, r := range reports {
+			if r.Corrected == nil {
+				return nil, fmt.Errorf("report %d: corrected latencies missing", i)
+			}
+
+			// the corrected average is over the counted latencies only
+			var count uint64
+			if r.Corrected.LatencyHistogram != nil {
+				count = uint64(hdrhistogram.Import(r.Corrected.LatencyHistogram).TotalCount())
+			}
+
+			parts[i] = reportPart{
+				count:   count,
+				average: r.Corrected.Average,
+				fastest: r.Corrected.Fastest,
+				slowest: r.Corrected.Slowest,
+				hist:    r.Corrected.LatencyHistogram,
+			}
+		}
+
+		m, err := mergeParts(parts, rep.Total)
+		if err != nil {
+			return nil, fmt.Errorf("corrected: %w", err)
+		}
+
+		rep.Corrected = &CorrectedLatencies{
+			Average:             m.average,
+			Fastest:             m.fastest,
+			Slowest:             m.slowest,
+			LatencyDistribution: m.latencyDistribution,
+			Histogram:           m.histogram,
+		}
+	}
+
+	if len(first.Calls) > 0 {
+		rep.Calls = make([]CallReport, len(first.Calls))
+		for ci, c := range first.Calls {
+			parts := make([]reportPart, len(reports))
+			for i, r := range reports {
+				if len(r.Calls) != len(first.Calls) || r.Calls[ci].Name != c.Name {
+					return nil, fmt.Errorf("report %d: calls do not match", i)
+				}
+
+				rc := r.Calls[ci]
+				parts[i] = reportPart{
+					count:              rc.Count,
+					average:            rc.Average,
+					fastest:            rc.Fastest,
+					slowest:            rc.Slowest,
+					errorDist:          rc.ErrorDist,
+					statusCodeDist:     rc.StatusCodeDist,
+					validationFailures: rc.ValidationFailures,
+					hist:               rc.LatencyHistogram,
+				}
+			}
+
+			m, err := mergeParts(parts, rep.Total)
+			if err != nil {
+				return nil, fmt.Errorf("call %q: %w", c.Name, err)
+			}
+
+			rep.Calls[ci] = CallReport{
+				Name:                c.Name,
+				Call:                c.Call,
+				Weight:              c.Weight,
+				Count:               m.count,
+				Average:             m.average,
+				Fastest:             m.fastest,
+				Slowest:             m.slowest,
+				Rps:                 m.rps,
+				ErrorDist:           m.errorDist,
+				StatusCodeDist:      m.statusCodeDist,
+				ValidationFailures:  m.validationFailures,
+				LatencyDistribution: m.latencyDistribution,
+				Histogram:           m.histogram,
+			}
+		}
+	}
+
+	if first.Journey != nil {
+		parts := make([]reportPart, len(reports))
+		for i, r := range reports {
+			if r.Journey == nil {
+				return nil, fmt.Errorf("report %d: journey missing", i)
+			}
+
+			parts[i] = reportPart{
+				count:              r.Journey.Count,
+				average:            r.Journey.Average,
+				fastest:            r.Journey.Fastest,
+				slowest:            r.Journey.Slowest,
+				errorDist:          r.Journey.ErrorDist,
+				statusCodeDist:     r.Journey.StatusCodeDist,
+				validationFailures: r.Journey.ValidationFailures,
+				hist:               r.Journey.LatencyHistogram,
+			}
+		}
+
+		m, err := mergeParts(parts, rep.Total)
+		if err != nil {
+			return nil, fmt.Errorf("journey: %w", err)
+		}
+
+		rep.Journey = &JourneyReport{
+			Count:               m.count,
+			Average:             m.average,
+			Fastest:             m.fastest,
+			Slowest:             m.slowest,
+			Rps:                 m.rps,
+			ErrorDist:           m.errorDist,
+			StatusCodeDist:      m.statusCodeDist,
+			ValidationFailures:  m.validationFailures,
+			LatencyDistribution: m.latencyDistribution,
+			Histogram:           m.histogram,
+		}
+	}
+
+	sizes, err := mergeSizes(reports, rep.Total)
+	if err != nil {
+		return nil, fmt.Errorf("sizes: %w", err)
+	}
+
+	rep.Sizes = sizes
+
+	streaming, err := mergeStreaming(reports, rep.Total)
+	if err != nil {
+		return nil, fmt.Errorf("streaming: %w", err)
+	}
+
+	rep.Streaming = streaming
+
+	rep.Timeline = mergeTimelines(reports)
+
+	rep.Assertions = evaluateAssertions(parsed, rep)
+
+	return rep, nil
+}
+
+// mergeTimelines combines the timelines of the reports window by window. Counts, rates and
+// workers are summed and the average is weighted by the counts. The windows do not keep their
+// histograms so the percentiles are the highest of the report percentiles.
+func mergeTimelines(reports []*Report) []TimelineBucket {
+	var res []TimelineBucket
+	for _, r := range reports {
+		for i, b := range r.Timeline {
+			if i == len(res) {
+				res = append(res, TimelineBucket{
+					Timestamp: b.Timestamp,
+					Elapsed:   b.Elapsed,
+					Duration:  b.Duration,
+					Fastest:   b.Fastest,
+				})
+			}
+
+			m := &res[i]
+			if b.Timestamp.Before(m.Timestamp) {
+				m.Timestamp = b.Timestamp
+			}
+
+			if b.Duration > m.Duration {
+				m.Duration = b.Duration
+			}
+
+			if b.Count > 0 {
+				total := m.Average*time.Duration(m.Count) + b.Average*time.Duration(b.Count)
+				m.Average = total / time.Duration(m.Count+b.Count)
+			}
+
+			m.Count += b.Count
+			m.ErrorCount += b.ErrorCount
+			m.Rps += b.Rps
+			m.TargetRps += b.TargetRps
+			m.Workers += b.Workers
+
+			if b.Fastest > 0 && (m.Fastest == 0 || b.Fastest < m.Fastest) {
+				m.Fastest = b.Fastest
+			}
+
+			if b.Slowest > m.Slowest {
+				m.Slowest = b.Slowest
+			}
+
+			for _, ld := range b.LatencyDistribution {
+				found := false
+				for j := range m.LatencyDistribution {
+					if m.LatencyDistribution[j].Percentage == ld.Percentage {
+						found = true
+						if ld.Latency > m.LatencyDistribution[j].Latency {
+							m.LatencyDistribution[j].Latency = ld.Latency
+						}
+					}
+				}
+
+				if !found {
+					m.LatencyDistribution = append(m.LatencyDistribution, ld)
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// mergeSizes combines the request and response sizes of the reports. Totals are summed and
+// the size distributions are computed from the merged histograms.
+func mergeSizes(reports []*Report, total time.Duration) (*SizesReport, error) {
+	var requests, responses []SizeStats
+	var counts []uint64
+	for _, r := range reports {
+		if r.Sizes == nil {
+			continue
+		}
+
+		requests = append(requests, r.Sizes.Request)
+		responses = append(responses, r.Sizes.Response)
+		counts = append(counts, r.Count)
+	}
+
+	if len(counts) == 0 {
+		return nil, nil
+	}
+
+	req, err := mergeSizeStats(requests, counts, total)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+
+	resp, err := mergeSizeStats(responses, counts, total)
+	if err != nil {
+		return nil, fmt.Errorf("response: %w", err)
+	}
+
+	return &SizesReport{Request: req, Response: resp}, nil
+}
+
+func mergeSizeStats(stats []SizeStats, counts []uint64, total time.Duration) (SizeStats, error) {
+	var m SizeStats
+	var h *hdrhistogram.Histogram
+	var count uint64
+	for i, s := range stats {
+		if counts[i] == 0 {
+			continue
+		}
+
+		if s.SizeHistogram == nil {
+			return m, fmt.Errorf("report %d: size histogram required", i)
+		}
+
+		if count == 0 || s.Smallest < m.Smallest {
+			m.Smallest = s.Smallest
+		}
+
+		if s.Largest > m.Largest {
+			m.Largest = s.Largest
+		}
+
+		m.Total += s.Total
+		count += counts[i]
+
+		ph := hdrhistogram.Import(s.SizeHistogram)
+		if h == nil {
+			h = ph
+		} else {
+			h.Merge(ph)
+		}
+	}
+
+	if count > 0 {
+		m.Average = float64(m.Total) / float64(count)
+	}
+
+	if total > 0 {
+		m.Throughput = float64(m.Total) / total.Seconds()
+	}
+
+	if h != nil {
+		m.SizeDistribution = sizeDistribution(h, m.Smallest, m.Largest)
+	}
+
+	return m, nil
+}
+
+// mergeStreaming combines the streaming stats of the reports. Counts and bytes are summed and the
+// message latency distributions are computed from the merged histograms.
+func mergeStreaming(reports []*Report, total time.Duration) (*StreamingReport, error) {
+	var res *StreamingReport
+	var firstResponse, gaps []reportPart
+	for _, r := range reports {
+		s := r.Streaming
+		if s == nil {
+			continue
+		}
+
+		if res == nil {
+			res = &StreamingReport{}
+		}
+
+		res.Count += s.Count
+		res.MessagesSent += s.MessagesSent
+		res.MessagesReceived += s.MessagesReceived
+		res.BytesSent += s.BytesSent
+		res.BytesReceived += s.BytesReceived
+
+		if ml := s.TimeToFirstResponse; ml != nil {
+			firstResponse = append(firstResponse, messageLatenciesPart(ml))
+		}
+
+		if ml := s.InterMessageLatency; ml != nil {
+			gaps = append(gaps, messageLatenciesPart(ml))
+		}
+	}
+
+	if res == nil {
+		return nil, nil
+	}
+
+	if total > 0 {
+		res.SentRate = float64(res.MessagesSent) / total.Seconds()
+		res.ReceivedRate = float64(res.MessagesReceived) / total.Seconds()
+	}
+
+	var err error
+	if res.TimeToFirstResponse, err = mergeMessageLatencies(firstResponse); err != nil {
+		return nil, fmt.Errorf("time to first response: %w", err)
+	}
+
+	if res.InterMessageLatency, err = mergeMessageLatencies(gaps); err != nil {
+		return nil, fmt.Errorf("inter-message latency: %w", err)
+	}
+
+	return res, nil
+}
+
+func messageLatenciesPart(ml *MessageLatencies) reportPart {
+	return reportPart{
+		count:   ml.Count,
+		average: ml.Average,
+		fastest: ml.Fastest,
+		slowest: ml.Slowest,
+		hist:    ml.LatencyHistogram,
+	}
+}
+
+func mergeMessageLatencies(parts []reportPart) (*MessageLatencies, error) {
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	m, err := mergeParts(parts, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageLatencies{
+		Count:               m.count,
+		Average:             m.average,
+		Fastest:             m.fastest,
+		Slowest:             m.slowest,
+		LatencyDistribution: m.latencyDistribution,
+	}, nil
+}
+
+// reportPart is the part of a report or call report to be merged
+type reportPart struct {
+	count              uint64
+	average            time.Duration
+	fastest            time.Duration
+	slowest            time.Duration
+	errorDist          map[string]int
+	statusCodeDist     map[string]int
+	validationFailures uint64
+	hist               *hdrhistogram.Snapshot
+}
+
+type mergedPart struct {
+	reportPart
+
+	rps                 float64
+	latencyDistribution []LatencyDistribution
+	histogram           []Bucket
+}
+
+func mergeParts(parts []reportPart, total time.Duration) (*mergedPart, error) {
+	m := &mergedPart{
+		reportPart: reportPart{
+			errorDist:      make(map[string]int),
+			statusCodeDist: make(map[string]int),
+		},
+	}
+
+	var h *hdrhistogram.Histogram
+	var totalLatencies time.Duration
+	for i, p := range parts {
+		m.count += p.count
+		m.validationFailures += p.validationFailures
+		totalLatencies += p.average * time.Duration(p.count)
+
+		for k, v := range p.errorDist {
+			m.errorDist[k] += v
+		}
+
+		for k, v := range p.statusCodeDist {
+			m.statusCodeDist[k] += v
+		}
+
+		if p.hist == nil {
+			if p.count > 0 && p.slowest > 0 {
+				return nil, fmt.Errorf("report %d: latency histogram required", i)
+			}
+
+			continue
+		}
+
+		ph := hdrhistogram.Import(p.hist)
+		if ph.TotalCount() == 0 {
+			continue
+		}
+
+		if h == nil {
+			h = ph
+			m.fastest, m.slowest = p.fastest, p.slowest
+			continue
+		}
+
+		h.Merge(ph)
+
+		if p.fastest < m.fastest {
+			m.fastest = p.fastest
+		}
+
+		if p.slowest > m.slowest {
+			m.slowest = p.slowest
+		}
+	}
+
+	if m.count > 0 {
+		m.average = totalLatencies / time.Duration(m.count)
+
+		if total > 0 {
+			m.rps = float64(m.count) / total.Seconds()
+		}
+	}
+
+	if h != nil {
+		m.histogram = histogram(h, m.slowest.Seconds(), m.fastest.Seconds())
+		m.latencyDistribution = latencies(h, m.fastest, m.slowest)
+	}
+
+	return m, nil
+}
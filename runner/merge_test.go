@@ -0,0 +1,185 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMergeReport(latencies []time.Duration, errs int, total time.Duration) *Report {
+	h := hdrhistogram.New(1, int64(maxTrackableLatency), 3)
+
+	r := &Report{
+		Name:           "test",
+		EndReason:      ReasonNormalEnd,
+		Count:          uint64(len(latencies) + errs),
+		Total:          total,
+		ErrorDist:      map[string]int{},
+		StatusCodeDist: map[string]int{"OK": len(latencies)},
+		Options:        Options{Total: len(latencies) + errs, Concurrency: 2, Connections: 1},
+	}
+
+	var sum time.Duration
+	for i, d := range latencies {
+		_ = h.RecordValue(int64(d))
+		sum += d
+
+		if i == 0 || d < r.Fastest {
+			r.Fastest = d
+		}
+
+		if d > r.Slowest {
+			r.Slowest = d
+		}
+	}
+
+	if errs > 0 {
+		r.ErrorDist["rpc error: code = Unavailable desc = down"] = errs
+		r.StatusCodeDist["Unavailable"] = errs
+	}
+
+	if r.Count > 0 {
+		r.Average = sum / time.Duration(r.Count)
+	}
+
+	r.LatencyHistogram = h.Export()
+
+	return r
+}
+
+func TestMergeReports(t *testing.T) {
+	t.Run("merges", func(t *testing.T) {
+		r1 := newTestMergeReport([]time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, 0, time.Second)
+		r2 := newTestMergeReport([]time.Duration{30 * time.Millisecond, 40 * time.Millisecond}, 1, 2*time.Second)
+		r2.ValidationFailures = 1
+		r2.EndReason = ReasonTimeout
+
+		rep, err := MergeReports([]*Report{r1, r2}, "count == 5", "errors < 1")
+		assert.NoError(t, err)
+
+		assert.Equal(t, "test", rep.Name)
+		assert.Equal(t, ReasonTimeout, rep.EndReason)
+		assert.Equal(t, uint64(5), rep.Count)
+		assert.Equal(t, 2*time.Second, rep.Total)
+		assert.Equal(t, 2.5, rep.Rps)
+		assert.Equal(t, 10*time.Millisecond, rep.Fastest)
+		assert.Equal(t, 40*time.Millisecond, rep.Slowest)
+		assert.InDelta(t, float64(20*time.Millisecond), float64(rep.Average), float64(time.Microsecond))
+		assert.Equal(t, uint64(1), rep.ValidationFailures)
+
+		assert.Equal(t, map[string]int{"OK": 4, "Unavailable": 1}, rep.StatusCodeDist)
+		assert.Equal(t, map[string]int{"rpc error: code = Unavailable desc = down": 1}, rep.ErrorDist)
+
+		assert.Equal(t, 5, rep.Options.Total)
+		assert.Equal(t, 4, rep.Options.Concurrency)
+
+		assert.Len(t, rep.LatencyDistribution, len(reportPercentiles))
+		for _, ld := range rep.LatencyDistribution {
+			if ld.Percentage == 50 {
+				assert.InDelta(t, float64(20*time.Millisecond), float64(ld.Latency), float64(50*time.Microsecond))
+			}
+		}
+
+		var buckets int
+		for _, b := range rep.Histogram {
+			buckets += b.Count
+		}
+		assert.Equal(t, 4, buckets)
+
+		// the merged histograms are not included in the report
+		assert.Nil(t, rep.LatencyHistogram)
+		assert.Equal(t, []AssertionResult{
+			{Assertion: "count == 5", Actual: "5", Passed: true},
+			{Assertion: "errors < 1", Actual: "1", Passed: false},
+		}, rep.Assertions.Results)
+	})
+
+	t.Run("calls", func(t *testing.T) {
+		r1 := newTestMergeReport([]time.Duration{10 * time.Millisecond}, 0, time.Second)
+		r2 := newTestMergeReport([]time.Duration{30 * time.Millisecond}, 0, time.Second)
+
+		for _, r := range []*Report{r1, r2} {
+			r.Calls = []CallReport{{
+				Name:             "hello",
+				Call:             "helloworld.Greeter.SayHello",
+				Weight:           3,
+				Count:            r.Count,
+				Average:          r.Average,
+				Fastest:          r.Fastest,
+				Slowest:          r.Slowest,
+				ErrorDist:        map[string]int{},
+				StatusCodeDist:   map[string]int{"OK": 1},
+				LatencyHistogram: r.LatencyHistogram,
+			}}
+		}
+
+		rep, err := MergeReports([]*Report{r1, r2})
+		assert.NoError(t, err)
+		assert.Nil(t, rep.Assertions)
+
+		assert.Len(t, rep.Calls, 1)
+		assert.Equal(t, "hello", rep.Calls[0].Name)
+		assert.Equal(t, uint(3), rep.Calls[0].Weight)
+		assert.Equal(t, uint64(2), rep.Calls[0].Count)
+		assert.Equal(t, 20*time.Millisecond, rep.Calls[0].Average)
+		assert.Equal(t, map[string]int{"OK": 2}, rep.Calls[0].StatusCodeDist)
+		assert.NotEmpty(t, rep.Calls[0].LatencyDistribution)
+
+		r2.Calls[0].Name = "other"
+		_, err = MergeReports([]*Report{r1, r2})
+		assert.EqualError(t, err, "report 1: calls do not match")
+	})
+
+	t.Run("timeline", func(t *testing.T) {
+		now := time.Now()
+
+		r1 := newTestMergeReport([]time.Duration{10 * time.Millisecond}, 0, time.Second)
+		r1.Timeline = []TimelineBucket{
+			{Timestamp: now, Duration: time.Second, Count: 1, Rps: 1, TargetRps: 5, Workers: 2, Average: 10 * time.Millisecond,
+				Fastest: 10 * time.Millisecond, Slowest: 10 * time.Millisecond,
+				LatencyDistribution: []LatencyDistribution{{Percentage: 99, Latency: 10 * time.Millisecond}}},
+		}
+
+		r2 := newTestMergeReport([]time.Duration{30 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}, 0, 2*time.Second)
+		r2.Timeline = []TimelineBucket{
+			{Timestamp: now.Add(-time.Millisecond), Duration: time.Second, Count: 2, ErrorCount: 1, Rps: 2, TargetRps: 5, Workers: 2,
+				Average: 30 * time.Millisecond, Fastest: 30 * time.Millisecond, Slowest: 30 * time.Millisecond,
+				LatencyDistribution: []LatencyDistribution{{Percentage: 99, Latency: 30 * time.Millisecond}}},
+			{Timestamp: now.Add(time.Second), Elapsed: time.Second, Duration: time.Second, Count: 1, Rps: 1},
+		}
+
+		rep, err := MergeReports([]*Report{r1, r2})
+		assert.NoError(t, err)
+		assert.Len(t, rep.Timeline, 2)
+
+		b := rep.Timeline[0]
+		assert.Equal(t, now.Add(-time.Millisecond), b.Timestamp)
+		assert.Equal(t, uint64(3), b.Count)
+		assert.Equal(t, uint64(1), b.ErrorCount)
+		assert.Equal(t, 3.0, b.Rps)
+		assert.Equal(t, 10.0, b.TargetRps)
+		assert.Equal(t, 4, b.Workers)
+		assert.InDelta(t, float64(70*time.Millisecond/3), float64(b.Average), float64(time.Microsecond))
+		assert.Equal(t, 10*time.Millisecond, b.Fastest)
+		assert.Equal(t, 30*time.Millisecond, b.Slowest)
+		assert.Equal(t, []LatencyDistribution{{Percentage: 99, Latency: 30 * time.Millisecond}}, b.LatencyDistribution)
+
+		assert.Equal(t, time.Second, rep.Timeline[1].Elapsed)
+		assert.Equal(t, uint64(1), rep.Timeline[1].Count)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := MergeReports(nil)
+		assert.EqualError(t, err, "no reports to merge")
+
+		r := newTestMergeReport([]time.Duration{10 * time.Millisecond}, 0, time.Second)
+		_, err = MergeReports([]*Report{r}, "p99 < fast")
+		assert.Error(t, err)
+
+		r.LatencyHistogram = nil
+		_, err = MergeReports([]*Report{r})
+		assert.EqualError(t, err, "report 0: latency histogram required")
+	})
+}
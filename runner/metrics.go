@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsShutdownTimeout is how long the metrics server waits for in-flight scrapes at the end of the run
+const metricsShutdownTimeout = 5 * time.Second
+
+// liveMetrics holds the Prometheus metrics of the run, updated as the results are received
+// and served on the /metrics endpoint while the run is in progress
+type liveMetrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+
+	call   string
+	server *http.Server
+	ln     net.Listener
+}
+
+func newLiveMetrics(c *RunConfig, workers func() int) *liveMetrics {
+	var constLabels prometheus.Labels
+	if c.name != "" {
+		constLabels = prometheus.Labels{"name": c.name}
+	}
+
+	m := &liveMetrics{
+		registry: prometheus.NewRegistry(),
+		call:     c.call,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ghz_requests_total",
+			Help:        "The number of completed requests by call and status code.",
+			ConstLabels: constLabels,
+		}, []string{"call", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ghz_errors_total",
+			Help:        "The number of failed requests by call and status code.",
+			ConstLabels: constLabels,
+		}, []string{"call", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "ghz_request_duration_seconds",
+			Help:        "The latency of the completed requests by call.",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"call"}),
+	}
+
+	m.registry.MustRegister(m.requests, m.errors, m.latency,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "ghz_workers_active",
+			Help:        "The number of active workers.",
+			ConstLabels: constLabels,
+		}, func() float64 {
+			return float64(workers())
+		}))
+
+	return m
+}
+
+// record accumulates the result of a call into the metrics
+func (m *liveMetrics) record(res *callResult) {
+	call := res.call
+	if call == "" {
+		call = m.call
+	}
+
+	m.requests.WithLabelValues(call, res.status).Inc()
+	if res.err != nil {
+		m.errors.WithLabelValues(call, res.status).Inc()
+	}
+
+	m.latency.WithLabelValues(call).Observe(res.duration.Seconds())
+}
+
+// serve starts serving the metrics on the /metrics path of the address
+func (m *liveMetrics) serve(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	m.ln = ln
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		_ = m.server.Serve(ln)
+	}()
+
+	return nil
+}
+
+// addr returns the address the metrics are served on
+func (m *liveMetrics) addr() string {
+	if m.ln == nil {
+		return ""
+	}
+
+	return m.ln.Addr().String()
+}
+
+// close stops serving the metrics
+func (m *liveMetrics) close() error {
+	if m.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+
+	if err := m.server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+)
+
+func scrapeMetrics(t *testing.T, addr string) string {
+	t.Helper()
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		return ""
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return string(body)
+}
+
+func TestLiveMetrics(t *testing.T) {
+	c, err := NewConfig("helloworld.Greeter.SayHello", "localhost:50051",
+		WithName("greeter"),
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithData(map[string]interface{}{"name": "bob"}))
+	assert.NoError(t, err)
+
+	m := newLiveMetrics(c, func() int { return 4 })
+
+	m.record(&callResult{status: "OK", duration: 3 * time.Millisecond})
+	m.record(&callResult{status: "OK", duration: 30 * time.Millisecond})
+	m.record(&callResult{status: "Unavailable", err: errors.New("down"), duration: time.Second, call: "second"})
+
+	assert.NoError(t, m.serve("127.0.0.1:0"))
+
+	out := scrapeMetrics(t, m.addr())
+
+	assert.Contains(t, out, `ghz_requests_total{call="helloworld.Greeter.SayHello",name="greeter",status="OK"} 2`)
+	assert.Contains(t, out, `ghz_requests_total{call="second",name="greeter",status="Unavailable"} 1`)
+	assert.Contains(t, out, `ghz_errors_total{call="second",name="greeter",status="Unavailable"} 1`)
+	assert.NotContains(t, out, `ghz_errors_total{call="helloworld.Greeter.SayHello"`)
+	assert.Contains(t, out, `ghz_request_duration_seconds_bucket{call="helloworld.Greeter.SayHello",name="greeter",le="0.004"} 1`)
+	assert.Contains(t, out, `ghz_request_duration_seconds_count{call="helloworld.Greeter.SayHello",name="greeter"} 2`)
+	assert.Contains(t, out, `ghz_workers_active{name="greeter"} 4`)
+
+	assert.NoError(t, m.close())
+
+	_, err = http.Get("http://" + m.addr() + "/metrics")
+	assert.Error(t, err)
+}
+
+func TestRunMetrics(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("scraped during the run", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		addr := ln.Addr().String()
+		assert.NoError(t, ln.Close())
+
+		var mu sync.Mutex
+		var scraped []string
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithConcurrency(2),
+			WithRunDuration(time.Second),
+			WithRPS(50),
+			WithMetricsAddress(addr),
+			WithProgressCallback(200*time.Millisecond, func(p *Progress) {
+				out := scrapeMetrics(t, addr)
+
+				mu.Lock()
+				scraped = append(scraped, out)
+				mu.Unlock()
+			}),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		assert.NotEmpty(t, scraped)
+		assert.Contains(t, scraped[len(scraped)-1], `ghz_requests_total{call="helloworld.Greeter.SayHello",status="OK"}`)
+		assert.Contains(t, scraped[len(scraped)-1], `ghz_workers_active 2`)
+
+		// the metrics are no longer served after the run
+		_, err = http.Get("http://" + addr + "/metrics")
+		assert.Error(t, err)
+	})
+
+	t.Run("address in use", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer ln.Close()
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(5),
+			WithMetricsAddress(ln.Addr().String()),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "serving metrics:"))
+		assert.Nil(t, report)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -37,6 +38,21 @@ const ScheduleStep = "step"
 // ScheduleLine is the line load schedule
 const ScheduleLine = "line"
 
+// SchedulePoisson is the load schedule of random arrivals at an average rate
+const SchedulePoisson = "poisson"
+
+// ScheduleSine is the load schedule with a rate following a sine wave
+const ScheduleSine = "sine"
+
+// ScheduleSpike is the load schedule with periodic spikes over a base rate
+const ScheduleSpike = "spike"
+
+// ScheduleFile is the load schedule with a rate interpolated between the points of a file
+const ScheduleFile = "file"
+
+// ScheduleSearch is the load schedule searching for the maximum rate meeting the search SLOs
+const ScheduleSearch = "search"
+
 // RunConfig represents the request Configs
 type RunConfig struct {
 	// call settings
@@ -68,6 +84,12 @@ type RunConfig struct {
 	loadDuration     time.Duration
 	loadStepDuration time.Duration
 
+	loadAmplitude     uint
+	loadPeriod        time.Duration
+	loadSpikeDuration time.Duration
+	loadScheduleFile  string
+	loadPoints        []load.RatePoint
+
 	pacer load.Pacer
 
 	// concurrency
@@ -134,10 +156,44 @@ type RunConfig struct {
 	tags                          []byte
 	skipFirst                     int
 	countErrors                   bool
+	histogramPrecision            int
+	detailsSampleSize             int
+	latencyHistogram              bool
+	progressFunc                  ProgressFunc
+	assertions                    []*assertion
+	searchSLOs                    []*assertion
+	scenario                      *Scenario
+	responseValidations           []*responseValidation
+	progressInterval              time.Duration
+	timelineInterval              time.Duration
+	otlpEndpoint                  string
+	otlpHeaders                   map[string]string
+	otlpInterval                  time.Duration
+	otlpTraces                    bool
+	metricsAddress                string
+	influxDetails                 *InfluxWriter
+	resultSinks                   []ResultSink
 	recvMsgFunc                   StreamRecvMsgInterceptFunc
 	streamInterceptorProviderFunc StreamInterceptorProviderFunc
 }
 
+// rateLimited returns whether the requests are paced by a load schedule
+func (c *RunConfig) rateLimited() bool {
+	return c.pacer != nil || c.loadSchedule != ScheduleConst || c.rps > 0
+}
+
+// scheduled returns whether the requests are paced on a schedule of intended start times,
+// so that their latencies can be corrected for coordinated omission
+func (c *RunConfig) scheduled() bool {
+	if !c.rateLimited() {
+		return false
+	}
+
+	_, ok := createPacer(c).(load.ScheduledPacer)
+
+	return ok
+}
+
 // Option controls some aspect of run
 type Option func(*RunConfig) error
 
@@ -154,6 +210,9 @@ func NewConfig(call, host string, options ...Option) (*RunConfig, error) {
 		cpus:         runtime.GOMAXPROCS(-1),
 		zstop:        "close",
 		loadSchedule: ScheduleConst,
+
+		histogramPrecision: 3,
+		detailsSampleSize:  maxResult,
 	}
 
 	// apply options
@@ -185,7 +244,7 @@ func NewConfig(call, host string, options ...Option) (*RunConfig, error) {
 		return nil, errors.New("number of connections cannot be greater than concurrency")
 	}
 
-	if c.call == "" {
+	if c.call == "" && c.scenario == nil {
 		return nil, errors.New("call required")
 	}
 
@@ -199,9 +258,72 @@ func NewConfig(call, host string, options ...Option) (*RunConfig, error) {
 
 	if c.loadSchedule != ScheduleConst &&
 		c.loadSchedule != ScheduleStep &&
-		c.loadSchedule != ScheduleLine {
-		return nil, fmt.Errorf(`schedule much be "%s", "%s", or "%s"`,
-			ScheduleConst, ScheduleStep, ScheduleLine)
+		c.loadSchedule != ScheduleLine &&
+		c.loadSchedule != SchedulePoisson &&
+		c.loadSchedule != ScheduleSine &&
+		c.loadSchedule != ScheduleSpike &&
+		c.loadSchedule != ScheduleFile &&
+		c.loadSchedule != ScheduleSearch {
+		return nil, fmt.Errorf(`schedule much be "%s", "%s", "%s", "%s", "%s", "%s", "%s", or "%s"`,
+			ScheduleConst, ScheduleStep, ScheduleLine, SchedulePoisson, ScheduleSine, ScheduleSpike, ScheduleFile, ScheduleSearch)
+	}
+
+	if c.loadSchedule == ScheduleSearch {
+		if c.loadStart == 0 {
+			return nil, errors.New("search load schedule requires load start")
+		}
+
+		if c.loadEnd > 0 && c.loadEnd < c.loadStart {
+			return nil, errors.New("load end cannot be less than load start")
+		}
+
+		// the resolution of the search
+		if c.loadStep <= 0 {
+			return nil, errors.New("invalid load step")
+		}
+
+		if c.loadStepDuration <= 0 {
+			return nil, errors.New("invalid load step duration")
+		}
+
+		if len(c.searchSLOs) == 0 {
+			return nil, errors.New("search load schedule requires at least one search SLO")
+		}
+
+		// the search decides when the run ends
+		c.n = math.MaxInt32
+	}
+
+	if (c.loadSchedule == SchedulePoisson || c.loadSchedule == ScheduleSine) && c.rps <= 0 {
+		return nil, fmt.Errorf("%s load schedule requires rps", c.loadSchedule)
+	}
+
+	if c.loadSchedule == ScheduleSine {
+		if c.loadAmplitude > uint(c.rps) {
+			return nil, errors.New("load amplitude cannot be greater than rps")
+		}
+
+		if c.loadPeriod <= 0 {
+			return nil, errors.New("invalid load period")
+		}
+	}
+
+	if c.loadSchedule == ScheduleSpike {
+		if c.loadAmplitude == 0 {
+			return nil, errors.New("invalid load amplitude")
+		}
+
+		if c.loadPeriod <= 0 {
+			return nil, errors.New("invalid load period")
+		}
+
+		if c.loadSpikeDuration <= 0 || c.loadSpikeDuration > c.loadPeriod {
+			return nil, errors.New("load spike duration must be greater than 0 and not greater than load period")
+		}
+	}
+
+	if c.loadSchedule == ScheduleFile && len(c.loadPoints) == 0 {
+		return nil, errors.New("load schedule file required")
 	}
 
 	if c.loadSchedule == ScheduleStep || c.loadSchedule == ScheduleLine {
@@ -248,6 +370,10 @@ func NewConfig(call, host string, options ...Option) (*RunConfig, error) {
 		return nil, errors.New("you cannot skip more requests than those run")
 	}
 
+	if c.histogramPrecision < 1 || c.histogramPrecision > 5 {
+		return nil, errors.New("histogram precision must be between 1 and 5")
+	}
+
 	creds, err := createClientTransportCredentials(
 		c.skipVerify,
 		c.cacert,
@@ -713,6 +839,307 @@ func WithCountErrors(v bool) Option {
 	}
 }
 
+// WithHistogramPrecision specifies the number of significant value digits
+// maintained by the latency histogram. Must be between 1 and 5. Default is 3.
+// Higher precision uses more memory.
+//
+//	WithHistogramPrecision(4)
+func WithHistogramPrecision(digits uint) Option {
+	return func(o *RunConfig) error {
+		if digits > 0 {
+			o.histogramPrecision = int(digits)
+		}
+
+		return nil
+	}
+}
+
+// WithDetailsSampleSize specifies the maximum number of result details kept in the report.
+// Once the limit is reached the details are a uniform random sample of all the results.
+// Use 0 to not keep any details. Default is 1000000.
+//
+//	WithDetailsSampleSize(10000)
+func WithDetailsSampleSize(n uint) Option {
+	return func(o *RunConfig) error {
+		o.detailsSampleSize = int(n)
+
+		return nil
+	}
+}
+
+// WithLatencyHistogram specifies whether the mergeable latency histograms are included in the report.
+// The histograms allow the reports of several runs to be combined using MergeReports.
+//
+//	WithLatencyHistogram(true)
+func WithLatencyHistogram(v bool) Option {
+	return func(o *RunConfig) error {
+		o.latencyHistogram = v
+
+		return nil
+	}
+}
+
+// WithTimelineInterval specifies the length of the windows of the timeline breakdown of the results
+// included in the report. The timeline is not included unless the interval is set.
+//
+//	WithTimelineInterval(time.Second)
+func WithTimelineInterval(interval time.Duration) Option {
+	return func(o *RunConfig) error {
+		if interval < 0 {
+			return errors.New("timeline interval cannot be negative")
+		}
+
+		o.timelineInterval = interval
+
+		return nil
+	}
+}
+
+// WithOTLPEndpoint specifies the base URL of an OpenTelemetry collector receiving OTLP over HTTP.
+// The live metrics of the run are pushed to the /v1/metrics path of the endpoint periodically
+// during the run and once more at the end. Export errors do not fail the run.
+//
+//	WithOTLPEndpoint("http://localhost:4318")
+func WithOTLPEndpoint(endpoint string) Option {
+	return func(o *RunConfig) error {
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint != "" {
+			u, err := url.Parse(endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid OTLP endpoint %q: must be an http or https URL", endpoint)
+			}
+		}
+
+		o.otlpEndpoint = endpoint
+
+		return nil
+	}
+}
+
+// WithOTLPHeaders specifies the HTTP headers sent with the OTLP exports, such as authentication headers
+//
+//	WithOTLPHeaders(map[string]string{"Authorization": "Bearer secret"})
+func WithOTLPHeaders(headers map[string]string) Option {
+	return func(o *RunConfig) error {
+		o.otlpHeaders = headers
+
+		return nil
+	}
+}
+
+// WithOTLPInterval specifies the interval of the OTLP metrics exports. Default is 10 seconds.
+//
+//	WithOTLPInterval(5 * time.Second)
+func WithOTLPInterval(interval time.Duration) Option {
+	return func(o *RunConfig) error {
+		if interval < 0 {
+			return errors.New("OTLP interval cannot be negative")
+		}
+
+		o.otlpInterval = interval
+
+		return nil
+	}
+}
+
+// WithOTLPTraces specifies whether a client span is created for each call and exported to the
+// OTLP endpoint. The W3C trace context of the span is propagated in the traceparent metadata
+// of the call so server side traces can be correlated with the calls of the run.
+//
+//	WithOTLPTraces(true)
+func WithOTLPTraces(v bool) Option {
+	return func(o *RunConfig) error {
+		o.otlpTraces = v
+
+		return nil
+	}
+}
+
+// WithMetricsAddress specifies the address to serve the live Prometheus metrics of the run on.
+// The metrics are updated as the results are received and served on the /metrics path until the run ends.
+//
+//	WithMetricsAddress(":9100")
+func WithMetricsAddress(addr string) Option {
+	return func(o *RunConfig) error {
+		o.metricsAddress = strings.TrimSpace(addr)
+
+		return nil
+	}
+}
+
+// WithInfluxDetails specifies an InfluxWriter to stream a ghz_detail point to for each result
+// during the run. The writer is not closed at the end of the run.
+//
+//	w, err := runner.NewInfluxWriter(runner.InfluxConfig{URL: "http://localhost:8086", Database: "ghz"})
+//	...
+//	defer w.Close()
+//
+//	WithInfluxDetails(w)
+func WithInfluxDetails(w *InfluxWriter) Option {
+	return func(o *RunConfig) error {
+		o.influxDetails = w
+
+		return nil
+	}
+}
+
+// WithResultSink registers a result sink to receive the result of each call as it is received
+// and the final report of the run. It can be used multiple times to register several sinks.
+//
+//	WithResultSink(mySink)
+func WithResultSink(sink ResultSink) Option {
+	return func(o *RunConfig) error {
+		if sink == nil {
+			return errors.New("result sink cannot be nil")
+		}
+
+		o.resultSinks = append(o.resultSinks, sink)
+
+		return nil
+	}
+}
+
+// WithProgressCallback specifies a function to be called periodically with a snapshot of the run progress.
+// The snapshots are computed from the call results and never block the request workers.
+//
+//	WithProgressCallback(time.Second, func(p *Progress) {
+//		fmt.Printf("%d requests at %.2f rps\n", p.Count, p.Rps)
+//	})
+func WithProgressCallback(interval time.Duration, fn ProgressFunc) Option {
+	return func(o *RunConfig) error {
+		if interval <= 0 {
+			return errors.New("progress interval must be greater than 0")
+		}
+
+		o.progressInterval = interval
+		o.progressFunc = fn
+
+		return nil
+	}
+}
+
+// WithAssertions specifies the pass / fail assertions to be evaluated against the final report.
+// Each assertion is in the form "<metric> <op> <value>". The results are available in Report.Assertions.
+//
+//	WithAssertions("p99 < 250ms", "error-rate < 1%", "rps >= 500", "status:Unavailable == 0")
+func WithAssertions(assertions ...string) Option {
+	return func(o *RunConfig) error {
+		for _, expr := range assertions {
+			if strings.TrimSpace(expr) == "" {
+				continue
+			}
+
+			a, err := parseAssertion(expr)
+			if err != nil {
+				return err
+			}
+
+			o.assertions = append(o.assertions, a)
+		}
+
+		return nil
+	}
+}
+
+// WithSearchSLOs specifies the SLOs of the search load schedule. Each SLO is an assertion in the
+// form "<metric> <op> <value>" evaluated against the results of each probe of the search.
+// The results of the search are available in Report.Search.
+//
+//	WithSearchSLOs("p99 < 250ms", "error-rate < 1%")
+func WithSearchSLOs(slos ...string) Option {
+	return func(o *RunConfig) error {
+		for _, expr := range slos {
+			if strings.TrimSpace(expr) == "" {
+				continue
+			}
+
+			a, err := parseAssertion(expr)
+			if err != nil {
+				return err
+			}
+
+			o.searchSLOs = append(o.searchSLOs, a)
+		}
+
+		return nil
+	}
+}
+
+// WithResponseValidations specifies the validations of the received response messages.
+// Each validation is in the form "<path> <op> <value>" and is evaluated against the unary
+// response or each message received on a stream. Calls with a response failing validation
+// are counted as errors and in Report.ValidationFailures.
+//
+//	WithResponseValidations(`status == ACTIVE`, `token =~ ^[a-f0-9]{32}$`, `len(items) >= 1`)
+func WithResponseValidations(rules ...string) Option {
+	return func(o *RunConfig) error {
+		vs, err := parseResponseValidations(rules)
+		if err != nil {
+			return err
+		}
+
+		o.responseValidations = append(o.responseValidations, vs...)
+
+		return nil
+	}
+}
+
+// WithScenario specifies a scenario of several calls to be made within the run.
+// Each request is made to one of the scenario calls according to the call weights,
+// using the shared connections. The report contains a per call breakdown in Report.Calls.
+// When a scenario is used the call does not have to be set.
+// Calls without their own data or metadata use the data and metadata of the run.
+//
+//	WithScenario(&Scenario{
+//		Calls: []ScenarioCall{
+//			{Call: "catalog.Catalog.GetItem", Weight: 7, Data: map[string]interface{}{"id": "{{.RequestNumber}}"}},
+//			{Call: "catalog.Catalog.ListItems", Weight: 2},
+//			{Call: "catalog.Catalog.UpdateItem", Weight: 1, DataPath: "./update.json"},
+//		},
+//	})
+//
+// With Steps instead of Calls each request is a journey making all the steps in order.
+// Values extracted from the step responses are available to the later steps in CallData.Vars.
+// The whole journey results are in Report.Journey.
+//
+//	WithScenario(&Scenario{
+//		Steps: []ScenarioCall{
+//			{Call: "shop.Shop.CreateSession", Extract: map[string]string{"token": "token"}},
+//			{Call: "shop.Shop.GetProfile", Metadata: map[string]string{"authorization": "{{.Vars.token}}"}},
+//		},
+//	})
+func WithScenario(s *Scenario) Option {
+	return func(o *RunConfig) error {
+		if s == nil {
+			return nil
+		}
+
+		if err := s.validate(); err != nil {
+			return err
+		}
+
+		o.scenario = s
+
+		return nil
+	}
+}
+
+// WithScenarioFromFile loads the scenario from a JSON, TOML or YAML file.
+//
+//	WithScenarioFromFile("./scenario.yaml")
+func WithScenarioFromFile(path string) Option {
+	return func(o *RunConfig) error {
+		s := &Scenario{}
+		if err := LoadScenario(path, s); err != nil {
+			return err
+		}
+
+		o.scenario = s
+
+		return nil
+	}
+}
+
 // WithProtoFile specified proto file path and optionally import paths
 // We will automatically add the proto file path's directory and the current directory
 //
@@ -921,6 +1348,87 @@ func WithLoadDuration(duration time.Duration) Option {
 	}
 }
 
+// WithLoadAmplitude specifies the rate added to the rps at the peak of the sine
+// load schedule or during the spikes of the spike load schedule
+//
+//	WithLoadAmplitude(50)
+func WithLoadAmplitude(amplitude uint) Option {
+	return func(o *RunConfig) error {
+		o.loadAmplitude = amplitude
+
+		return nil
+	}
+}
+
+// WithLoadPeriod specifies the period of the sine wave of the sine load schedule
+// or the interval between the spikes of the spike load schedule
+//
+//	WithLoadPeriod(time.Duration(time.Minute))
+func WithLoadPeriod(period time.Duration) Option {
+	return func(o *RunConfig) error {
+		o.loadPeriod = period
+
+		return nil
+	}
+}
+
+// WithLoadSpikeDuration specifies the duration of each spike of the spike load schedule
+//
+//	WithLoadSpikeDuration(time.Duration(5*time.Second))
+func WithLoadSpikeDuration(duration time.Duration) Option {
+	return func(o *RunConfig) error {
+		o.loadSpikeDuration = duration
+
+		return nil
+	}
+}
+
+// WithLoadScheduleFile specifies the path of the file with the points of the file load schedule.
+// Each line of the file has the elapsed duration and the RPS of a point separated
+// by a comma or whitespace. The RPS is interpolated between the points and the test
+// ends at the last point.
+//
+//	WithLoadScheduleFile("./schedule.csv")
+func WithLoadScheduleFile(path string) Option {
+	return func(o *RunConfig) error {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		points, err := load.ReadRatePoints(file)
+		if err != nil {
+			return fmt.Errorf("load schedule file %s: %w", path, err)
+		}
+
+		o.loadScheduleFile = path
+		o.loadPoints = points
+
+		return nil
+	}
+}
+
+// WithLoadSchedulePoints specifies the points of the file load schedule directly
+//
+//	WithLoadSchedulePoints([]load.RatePoint{{Elapsed: 0, Rate: 10}, {Elapsed: time.Minute, Rate: 100}})
+func WithLoadSchedulePoints(points []load.RatePoint) Option {
+	return func(o *RunConfig) error {
+		if err := load.ValidateRatePoints(points); err != nil {
+			return fmt.Errorf("load schedule points %s", err.Error())
+		}
+
+		o.loadPoints = points
+
+		return nil
+	}
+}
+
 // WithAsync specifies the async option
 func WithAsync(async bool) Option {
 	return func(o *RunConfig) error {
@@ -1210,6 +1718,10 @@ func fromConfig(cfg *Config) []Option {
 		WithLoadStepDuration(time.Duration(cfg.LoadStepDuration)),
 		WithLoadEnd(cfg.LoadEnd),
 		WithLoadDuration(time.Duration(cfg.LoadMaxDuration)),
+		WithLoadAmplitude(cfg.LoadAmplitude),
+		WithLoadPeriod(time.Duration(cfg.LoadPeriod)),
+		WithLoadSpikeDuration(time.Duration(cfg.LoadSpikeDuration)),
+		WithLoadScheduleFile(cfg.LoadScheduleFile),
 		WithClientLoadBalancing(cfg.LBStrategy),
 		WithAsync(cfg.Async),
 		WithConcurrencySchedule(cfg.CSchedule),
@@ -1221,6 +1733,16 @@ func fromConfig(cfg *Config) []Option {
 		WithCountErrors(cfg.CountErrors),
 		WithDisableTemplateFuncs(cfg.DisableTemplateFuncs),
 		WithDisableTemplateData(cfg.DisableTemplateData),
+		WithHistogramPrecision(cfg.HistogramPrecision),
+		WithAssertions(cfg.Assertions...),
+		WithSearchSLOs(cfg.SearchSLOs...),
+		WithTimelineInterval(time.Duration(cfg.TimelineInterval)),
+		WithOTLPEndpoint(cfg.OTLPEndpoint),
+		WithOTLPHeaders(cfg.OTLPHeaders),
+		WithOTLPInterval(time.Duration(cfg.OTLPInterval)),
+		WithOTLPTraces(cfg.OTLPTraces),
+		WithMetricsAddress(cfg.MetricsAddress),
+		WithResponseValidations(cfg.Validations...),
 		func(o *RunConfig) error {
 			o.call = cfg.Call
 			return nil
@@ -1267,6 +1789,17 @@ func fromConfig(cfg *Config) []Option {
 		options = append(options, WithData(cfg.Data))
 	}
 
+	if strings.TrimSpace(cfg.Scenario) != "" {
+		options = append(options, WithScenarioFromFile(strings.TrimSpace(cfg.Scenario)))
+	}
+
+	// details sample
+	if cfg.SkipDetails {
+		options = append(options, WithDetailsSampleSize(0))
+	} else if cfg.DetailsSampleSize > 0 {
+		options = append(options, WithDetailsSampleSize(cfg.DetailsSampleSize))
+	}
+
 	// or binary data
 	if len(cfg.BinData) > 0 {
 		options = append(options, WithBinaryData(cfg.BinData))
@@ -11,6 +11,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+
+	"github.com/bojand/ghz/load"
 )
 
 func TestRunConfig_newRunConfig(t *testing.T) {
@@ -65,6 +67,8 @@ func TestRunConfig_newRunConfig(t *testing.T) {
 		assert.Equal(t, "", string(c.protoset))
 		assert.Equal(t, []string{"testdata", "."}, c.importPaths)
 		assert.Equal(t, c.enableCompression, false)
+		assert.Equal(t, 3, c.histogramPrecision)
+		assert.Equal(t, 1000000, c.detailsSampleSize)
 	})
 
 	t.Run("skipFirst > n", func(t *testing.T) {
@@ -488,6 +492,54 @@ func TestRunConfig_newRunConfig(t *testing.T) {
 		})
 	})
 
+	t.Run("with histogram precision and details sample size", func(t *testing.T) {
+		c, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithHistogramPrecision(4),
+			WithDetailsSampleSize(0),
+		)
+
+		assert.NoError(t, err)
+
+		assert.Equal(t, 4, c.histogramPrecision)
+		assert.Equal(t, 0, c.detailsSampleSize)
+	})
+
+	t.Run("with otlp", func(t *testing.T) {
+		c, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithOTLPEndpoint(" http://localhost:4318 "),
+			WithOTLPHeaders(map[string]string{"Authorization": "Bearer secret"}),
+			WithOTLPInterval(5*time.Second),
+			WithOTLPTraces(true),
+		)
+
+		assert.NoError(t, err)
+
+		assert.Equal(t, "http://localhost:4318", c.otlpEndpoint)
+		assert.Equal(t, map[string]string{"Authorization": "Bearer secret"}, c.otlpHeaders)
+		assert.Equal(t, 5*time.Second, c.otlpInterval)
+		assert.True(t, c.otlpTraces)
+	})
+
+	t.Run("invalid otlp endpoint", func(t *testing.T) {
+		_, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithOTLPEndpoint("localhost:4318"),
+		)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid histogram precision", func(t *testing.T) {
+		_, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithHistogramPrecision(6),
+		)
+
+		assert.Error(t, err)
+	})
+
 	t.Run("invalid schedule", func(t *testing.T) {
 		_, err := NewConfig("  call  ", "  localhost:50050  ",
 			WithProtoFile("testdata/data.proto", []string{}),
@@ -592,6 +644,111 @@ func TestRunConfig_newRunConfig(t *testing.T) {
 		})
 	})
 
+	t.Run("with load poisson", func(t *testing.T) {
+		_, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(SchedulePoisson),
+		)
+
+		assert.EqualError(t, err, "poisson load schedule requires rps")
+
+		c, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(SchedulePoisson),
+			WithRPS(20),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &load.PoissonPacer{Freq: 20, Max: 200}, createPacer(c))
+	})
+
+	t.Run("with load sine", func(t *testing.T) {
+		_, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleSine),
+			WithRPS(20),
+			WithLoadAmplitude(30),
+			WithLoadPeriod(time.Minute),
+		)
+
+		assert.EqualError(t, err, "load amplitude cannot be greater than rps")
+
+		_, err = NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleSine),
+			WithRPS(20),
+			WithLoadAmplitude(10),
+		)
+
+		assert.EqualError(t, err, "invalid load period")
+
+		c, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleSine),
+			WithRPS(20),
+			WithLoadAmplitude(10),
+			WithLoadPeriod(time.Minute),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &load.SinePacer{Base: 20, Amplitude: 10, Period: time.Minute, Max: 200}, createPacer(c))
+	})
+
+	t.Run("with load spike", func(t *testing.T) {
+		_, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleSpike),
+			WithLoadAmplitude(100),
+			WithLoadPeriod(time.Minute),
+			WithLoadSpikeDuration(2*time.Minute),
+		)
+
+		assert.EqualError(t, err, "load spike duration must be greater than 0 and not greater than load period")
+
+		c, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleSpike),
+			WithRPS(10),
+			WithLoadAmplitude(100),
+			WithLoadPeriod(time.Minute),
+			WithLoadSpikeDuration(5*time.Second),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &load.SpikePacer{Base: 10, Spike: 110, Period: time.Minute, SpikeDuration: 5 * time.Second, Max: 200}, createPacer(c))
+	})
+
+	t.Run("with load file", func(t *testing.T) {
+		_, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleFile),
+		)
+
+		assert.EqualError(t, err, "load schedule file required")
+
+		_, err = NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleFile),
+			WithLoadScheduleFile("../testdata/data.json"),
+		)
+
+		assert.Error(t, err)
+
+		c, err := NewConfig("  call  ", "  localhost:50050  ",
+			WithProtoFile("testdata/data.proto", []string{}),
+			WithLoadSchedule(ScheduleFile),
+			WithLoadScheduleFile("../testdata/load_schedule.csv"),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "../testdata/load_schedule.csv", c.loadScheduleFile)
+		assert.Equal(t, []load.RatePoint{
+			{Elapsed: 0, Rate: 10},
+			{Elapsed: 2 * time.Second, Rate: 50},
+			{Elapsed: 4 * time.Second, Rate: 0},
+		}, c.loadPoints)
+	})
+
 	t.Run("with concurrency step", func(t *testing.T) {
 		t.Run("no step", func(t *testing.T) {
 			_, err := NewConfig("  call  ", "  localhost:50050  ",
@@ -0,0 +1,539 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultOTLPInterval is the default interval of the OTLP exports
+	defaultOTLPInterval = 10 * time.Second
+
+	// otlpSpanBatchSize is the number of buffered spans triggering an early export
+	otlpSpanBatchSize = 512
+
+	// otlpMaxQueuedSpans is the most spans buffered between exports, further spans are dropped
+	otlpMaxQueuedSpans = 16 * otlpSpanBatchSize
+
+	// otlpExportTimeout is the timeout of a single OTLP export request
+	otlpExportTimeout = 10 * time.Second
+
+	otlpScopeName = "github.com/bojand/ghz"
+
+	// aggregation temporality and span kind and status codes of the OTLP protocol
+	otlpTemporalityCumulative = 2
+	otlpSpanKindClient        = 3
+	otlpStatusCodeError       = 2
+)
+
+// otlpLatencyBounds are the explicit bucket bounds of the latency histogram in milliseconds
+var otlpLatencyBounds = []float64{0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000}
+
+// otlpExporter pushes the live metrics of the run and the client spans of the calls
+// to an OpenTelemetry collector using OTLP over HTTP with JSON encoding.
+type otlpExporter struct {
+	metricsURL string
+	tracesURL  string
+	headers    map[string]string
+	interval   time.Duration
+	traces     bool
+	resource   otlpResource
+	client     *http.Client
+
+	hasLog bool
+	log    Logger
+
+	// workers returns the number of active workers
+	workers func() int
+
+	start  time.Time
+	flush  chan struct{}
+	stopCh chan struct{}
+	done   chan struct{}
+
+	mu           sync.Mutex
+	requests     map[string]uint64
+	errors       map[string]uint64
+	count        uint64
+	sum          float64
+	min          float64
+	max          float64
+	bucketCounts []uint64
+	spans        []otlpSpan
+	droppedSpans uint64
+}
+
+func newOTLPExporter(c *RunConfig) *otlpExporter {
+	base := strings.TrimSuffix(c.otlpEndpoint, "/")
+
+	interval := c.otlpInterval
+	if interval <= 0 {
+		interval = defaultOTLPInterval
+	}
+
+	attrs := []otlpKeyValue{
+		otlpString("service.name", "ghz"),
+		otlpString("ghz.call", c.call),
+		otlpString("server.address", c.host),
+	}
+
+	if c.name != "" {
+		attrs = append(attrs, otlpString("ghz.name", c.name))
+	}
+
+	return &otlpExporter{
+		metricsURL:   base + "/v1/metrics",
+		tracesURL:    base + "/v1/traces",
+		headers:      c.otlpHeaders,
+		interval:     interval,
+		traces:       c.otlpTraces,
+		resource:     otlpResource{Attributes: attrs},
+		client:       &http.Client{Timeout: otlpExportTimeout},
+		hasLog:       c.hasLog,
+		log:          c.log,
+		flush:        make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		done:         make(chan struct{}),
+		requests:     make(map[string]uint64),
+		errors:       make(map[string]uint64),
+		bucketCounts: make([]uint64, len(otlpLatencyBounds)+1),
+	}
+}
+
+// run starts exporting periodically until stopped
+func (e *otlpExporter) run(start time.Time, workers func() int) {
+	e.start = start
+	e.workers = workers
+
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				e.export(true)
+			case <-e.flush:
+				e.export(false)
+			case <-e.stopCh:
+				e.export(true)
+				return
+			}
+		}
+	}()
+}
+
+// stop makes the final export and waits for it to complete
+func (e *otlpExporter) stop() {
+	close(e.stopCh)
+	<-e.done
+}
+
+// record accumulates the result of a call into the metrics
+func (e *otlpExporter) record(res *callResult) {
+	ms := float64(res.duration) / float64(time.Millisecond)
+
+	i := 0
+	for i < len(otlpLatencyBounds) && ms > otlpLatencyBounds[i] {
+		i++
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requests[res.status]++
+	if res.err != nil {
+		e.errors[res.status]++
+	}
+
+	if e.count == 0 || ms < e.min {
+		e.min = ms
+	}
+	if ms > e.max {
+		e.max = ms
+	}
+
+	e.count++
+	e.sum += ms
+	e.bucketCounts[i]++
+}
+
+// startSpan starts the client span of a call to the full method
+func (e *otlpExporter) startSpan(fullMethod string) *otlpSpan {
+	var traceID [16]byte
+	var spanID [8]byte
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+
+	service, method := splitFullMethod(fullMethod)
+
+	return &otlpSpan{
+		TraceID: hex.EncodeToString(traceID[:]),
+		SpanID:  hex.EncodeToString(spanID[:]),
+		Name:    strings.TrimPrefix(fullMethod, "/"),
+		Kind:    otlpSpanKindClient,
+		start:   time.Now(),
+		Attributes: []otlpKeyValue{
+			otlpString("rpc.system", "grpc"),
+			otlpString("rpc.service", service),
+			otlpString("rpc.method", method),
+		},
+	}
+}
+
+// endSpan ends the client span with the result of the call and queues it for export
+func (e *otlpExporter) endSpan(s *otlpSpan, end time.Time, err error) {
+	st, _ := status.FromError(err)
+
+	s.StartTime = otlpTime(s.start)
+	s.EndTime = otlpTime(end)
+	s.Attributes = append(s.Attributes, otlpInt("rpc.grpc.status_code", int64(st.Code())))
+	if err != nil {
+		s.Status = otlpStatus{Code: otlpStatusCodeError, Message: err.Error()}
+	}
+
+	e.mu.Lock()
+	if len(e.spans) >= otlpMaxQueuedSpans {
+		e.droppedSpans++
+		e.mu.Unlock()
+		return
+	}
+
+	e.spans = append(e.spans, *s)
+	full := len(e.spans) >= otlpSpanBatchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// export sends the buffered spans and, if metrics is set, the current metrics
+func (e *otlpExporter) export(metrics bool) {
+	now := time.Now()
+
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	dropped := e.droppedSpans
+	e.droppedSpans = 0
+
+	var req *otlpMetricsRequest
+	if metrics {
+		req = e.metrics(now)
+	}
+	e.mu.Unlock()
+
+	if dropped > 0 && e.hasLog {
+		e.log.Errorw("Dropped OTLP spans", "count", dropped)
+	}
+
+	if req != nil {
+		if err := e.post(e.metricsURL, req); err != nil && e.hasLog {
+			e.log.Errorw("Error exporting OTLP metrics", "error", err)
+		}
+	}
+
+	if len(spans) > 0 {
+		traces := &otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+			Resource:   e.resource,
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}, Spans: spans}},
+		}}}
+
+		if err := e.post(e.tracesURL, traces); err != nil && e.hasLog {
+			e.log.Errorw("Error exporting OTLP traces", "error", err)
+		}
+	}
+}
+
+// metrics returns the metrics export request, must be called with the lock held
+func (e *otlpExporter) metrics(now time.Time) *otlpMetricsRequest {
+	start, ts := otlpTime(e.start), otlpTime(now)
+
+	sum := func(name, description string, counts map[string]uint64) otlpMetric {
+		points := make([]otlpNumberDataPoint, 0, len(counts))
+		for _, code := range sortedCodes(counts) {
+			points = append(points, otlpNumberDataPoint{
+				Attributes: []otlpKeyValue{otlpString("ghz.status", code)},
+				StartTime:  start,
+				Time:       ts,
+				AsInt:      strconv.FormatUint(counts[code], 10),
+			})
+		}
+
+		return otlpMetric{
+			Name:        name,
+			Description: description,
+			Unit:        "{request}",
+			Sum: &otlpSum{
+				AggregationTemporality: otlpTemporalityCumulative,
+				IsMonotonic:            true,
+				DataPoints:             points,
+			},
+		}
+	}
+
+	buckets := make([]string, len(e.bucketCounts))
+	for i, n := range e.bucketCounts {
+		buckets[i] = strconv.FormatUint(n, 10)
+	}
+
+	hp := otlpHistogramDataPoint{
+		StartTime:      start,
+		Time:           ts,
+		Count:          strconv.FormatUint(e.count, 10),
+		Sum:            e.sum,
+		BucketCounts:   buckets,
+		ExplicitBounds: otlpLatencyBounds,
+	}
+
+	if e.count > 0 {
+		hp.Min = &e.min
+		hp.Max = &e.max
+	}
+
+	workers := 0
+	if e.workers != nil {
+		workers = e.workers()
+	}
+
+	metrics := []otlpMetric{
+		sum("ghz.requests", "The number of completed requests by status code", e.requests),
+		sum("ghz.errors", "The number of failed requests by status code", e.errors),
+		{
+			Name:        "ghz.request.duration",
+			Description: "The latency of the completed requests",
+			Unit:        "ms",
+			Histogram: &otlpHistogram{
+				AggregationTemporality: otlpTemporalityCumulative,
+				DataPoints:             []otlpHistogramDataPoint{hp},
+			},
+		},
+		{
+			Name:        "ghz.workers.active",
+			Description: "The number of active workers",
+			Unit:        "{worker}",
+			Gauge: &otlpGauge{DataPoints: []otlpNumberDataPoint{{
+				Time:  ts,
+				AsInt: strconv.Itoa(workers),
+			}}},
+		},
+	}
+
+	return &otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     e.resource,
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: otlpScopeName}, Metrics: metrics}},
+	}}}
+}
+
+func (e *otlpExporter) post(url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("OTLP export to %s failed with status %s", url, resp.Status)
+	}
+
+	return nil
+}
+
+type otlpSpanKey struct{}
+
+// withOTLPSpan returns the context tagged with the client span of the RPC and
+// the W3C trace context of the span propagated in the outgoing metadata
+func withOTLPSpan(ctx context.Context, s *otlpSpan) context.Context {
+	ctx = context.WithValue(ctx, otlpSpanKey{}, s)
+	return metadata.AppendToOutgoingContext(ctx, "traceparent", "00-"+s.TraceID+"-"+s.SpanID+"-01")
+}
+
+// otlpSpanFromContext returns the client span the RPC context was tagged with
+func otlpSpanFromContext(ctx context.Context) *otlpSpan {
+	s, _ := ctx.Value(otlpSpanKey{}).(*otlpSpan)
+	return s
+}
+
+// splitFullMethod splits the full gRPC method name "/package.Service/Method" into the service and method
+func splitFullMethod(fullMethod string) (string, string) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+
+	return name, ""
+}
+
+func sortedCodes(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int64) otlpKeyValue {
+	v := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &v}}
+}
+
+// The OTLP JSON encoding of the export requests. 64 bit integers are encoded as strings
+// and the trace and span IDs are hex encoded as specified by the OTLP protocol.
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	StartTime  string         `json:"startTimeUnixNano,omitempty"`
+	Time       string         `json:"timeUnixNano"`
+	AsInt      string         `json:"asInt"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	StartTime      string         `json:"startTimeUnixNano"`
+	Time           string         `json:"timeUnixNano"`
+	Count          string         `json:"count"`
+	Sum            float64        `json:"sum"`
+	BucketCounts   []string       `json:"bucketCounts"`
+	ExplicitBounds []float64      `json:"explicitBounds"`
+	Min            *float64       `json:"min,omitempty"`
+	Max            *float64       `json:"max,omitempty"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID    string         `json:"traceId"`
+	SpanID     string         `json:"spanId"`
+	Name       string         `json:"name"`
+	Kind       int            `json:"kind"`
+	StartTime  string         `json:"startTimeUnixNano"`
+	EndTime    string         `json:"endTimeUnixNano"`
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	Status     otlpStatus     `json:"status"`
+
+	start time.Time
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
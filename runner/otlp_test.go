@@ -0,0 +1,299 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+
+	"github.com/bojand/ghz/internal"
+	"github.com/bojand/ghz/internal/helloworld"
+)
+
+// otlpReceiver is a local OTLP over HTTP receiver stub recording the export requests
+type otlpReceiver struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	metrics []otlpMetricsRequest
+	traces  []otlpTracesRequest
+	headers []http.Header
+}
+
+func newOTLPReceiver() *otlpReceiver {
+	r := &otlpReceiver{}
+
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.headers = append(r.headers, req.Header)
+
+		var err error
+		switch req.URL.Path {
+		case "/v1/metrics":
+			var m otlpMetricsRequest
+			if err = json.NewDecoder(req.Body).Decode(&m); err == nil {
+				r.metrics = append(r.metrics, m)
+			}
+		case "/v1/traces":
+			var t otlpTracesRequest
+			if err = json.NewDecoder(req.Body).Decode(&t); err == nil {
+				r.traces = append(r.traces, t)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+
+	return r
+}
+
+// lastMetrics returns the metrics of the last metrics export by name
+func (r *otlpReceiver) lastMetrics() map[string]otlpMetric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make(map[string]otlpMetric)
+	if len(r.metrics) == 0 {
+		return res
+	}
+
+	for _, m := range r.metrics[len(r.metrics)-1].ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		res[m.Name] = m
+	}
+
+	return res
+}
+
+// spans returns all the exported spans
+func (r *otlpReceiver) spans() []otlpSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []otlpSpan
+	for _, t := range r.traces {
+		for _, rs := range t.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				res = append(res, ss.Spans...)
+			}
+		}
+	}
+
+	return res
+}
+
+func sumCount(m otlpMetric) uint64 {
+	var total uint64
+	for _, dp := range m.Sum.DataPoints {
+		n, _ := strconv.ParseUint(dp.AsInt, 10, 64)
+		total += n
+	}
+
+	return total
+}
+
+func TestOTLPExporter(t *testing.T) {
+	recv := newOTLPReceiver()
+	defer recv.Close()
+
+	c, err := NewConfig("helloworld.Greeter.SayHello", "localhost:50051",
+		WithName("greeter"),
+		WithOTLPEndpoint(recv.URL+"/"),
+		WithOTLPHeaders(map[string]string{"Authorization": "Bearer secret"}),
+		WithOTLPInterval(time.Hour),
+		WithOTLPTraces(true),
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithData(map[string]interface{}{"name": "bob"}))
+	assert.NoError(t, err)
+
+	e := newOTLPExporter(c)
+	e.run(time.Now(), func() int { return 3 })
+
+	e.record(&callResult{status: "OK", duration: 3 * time.Millisecond})
+	e.record(&callResult{status: "OK", duration: 30 * time.Millisecond})
+	e.record(&callResult{status: "Unavailable", err: errors.New("down"), duration: 20 * time.Second})
+
+	ctx := withOTLPSpan(context.Background(), e.startSpan("/helloworld.Greeter/SayHello"))
+	span := otlpSpanFromContext(ctx)
+	assert.NotNil(t, span)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"00-" + span.TraceID + "-" + span.SpanID + "-01"}, md.Get("traceparent"))
+
+	e.endSpan(span, time.Now(), status.Error(codes.Unavailable, "down"))
+
+	e.stop()
+
+	assert.Len(t, recv.metrics, 1)
+	assert.Len(t, recv.traces, 1)
+	assert.Equal(t, "Bearer secret", recv.headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", recv.headers[0].Get("Content-Type"))
+
+	resource := recv.metrics[0].ResourceMetrics[0].Resource.Attributes
+	assert.Contains(t, resource, otlpString("service.name", "ghz"))
+	assert.Contains(t, resource, otlpString("ghz.name", "greeter"))
+
+	metrics := recv.lastMetrics()
+
+	requests := metrics["ghz.requests"]
+	assert.NotNil(t, requests.Sum)
+	assert.True(t, requests.Sum.IsMonotonic)
+	assert.Equal(t, otlpTemporalityCumulative, requests.Sum.AggregationTemporality)
+	assert.Len(t, requests.Sum.DataPoints, 2)
+	assert.Equal(t, []otlpKeyValue{otlpString("ghz.status", "OK")}, requests.Sum.DataPoints[0].Attributes)
+	assert.Equal(t, "2", requests.Sum.DataPoints[0].AsInt)
+	assert.Equal(t, uint64(3), sumCount(requests))
+
+	errs := metrics["ghz.errors"]
+	assert.Len(t, errs.Sum.DataPoints, 1)
+	assert.Equal(t, []otlpKeyValue{otlpString("ghz.status", "Unavailable")}, errs.Sum.DataPoints[0].Attributes)
+	assert.Equal(t, "1", errs.Sum.DataPoints[0].AsInt)
+
+	hist := metrics["ghz.request.duration"].Histogram
+	assert.NotNil(t, hist)
+	dp := hist.DataPoints[0]
+	assert.Equal(t, "3", dp.Count)
+	assert.Equal(t, 20033.0, dp.Sum)
+	assert.Equal(t, 3.0, *dp.Min)
+	assert.Equal(t, 20000.0, *dp.Max)
+	assert.Equal(t, otlpLatencyBounds, dp.ExplicitBounds)
+	assert.Len(t, dp.BucketCounts, len(otlpLatencyBounds)+1)
+	assert.Equal(t, "1", dp.BucketCounts[1])
+	assert.Equal(t, "1", dp.BucketCounts[4])
+	assert.Equal(t, "1", dp.BucketCounts[len(otlpLatencyBounds)])
+
+	workers := metrics["ghz.workers.active"].Gauge
+	assert.NotNil(t, workers)
+	assert.Equal(t, "3", workers.DataPoints[0].AsInt)
+
+	spans := recv.spans()
+	assert.Len(t, spans, 1)
+	s := spans[0]
+	assert.Len(t, s.TraceID, 32)
+	assert.Len(t, s.SpanID, 16)
+	assert.Equal(t, "helloworld.Greeter/SayHello", s.Name)
+	assert.Equal(t, otlpSpanKindClient, s.Kind)
+	assert.Equal(t, otlpStatusCodeError, s.Status.Code)
+	assert.Contains(t, s.Attributes, otlpString("rpc.service", "helloworld.Greeter"))
+	assert.Contains(t, s.Attributes, otlpString("rpc.method", "SayHello"))
+	assert.Contains(t, s.Attributes, otlpInt("rpc.grpc.status_code", int64(codes.Unavailable)))
+}
+
+func TestOTLPStatsHandler(t *testing.T) {
+	recv := newOTLPReceiver()
+	defer recv.Close()
+
+	c, err := NewConfig("helloworld.Greeter.SayHello", "localhost:50051",
+		WithOTLPEndpoint(recv.URL),
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithData(map[string]interface{}{"name": "bob"}))
+	assert.NoError(t, err)
+
+	e := newOTLPExporter(c)
+	e.run(time.Now(), nil)
+
+	results := make(chan *callResult, 1)
+	sh := &statsHandler{results: results, otlp: e}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "token", "secret")
+	ctx = sh.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/helloworld.Greeter/SayHello"})
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	assert.Equal(t, []string{"secret"}, md.Get("token"))
+	assert.True(t, strings.HasPrefix(md.Get("traceparent")[0], "00-"))
+
+	now := time.Now()
+	sh.HandleRPC(ctx, &stats.End{BeginTime: now.Add(-time.Millisecond), EndTime: now})
+
+	res := <-results
+	assert.Equal(t, "OK", res.status)
+
+	e.stop()
+
+	spans := recv.spans()
+	assert.Len(t, spans, 1)
+	assert.Zero(t, spans[0].Status.Code)
+	assert.Equal(t, strconv.FormatInt(now.UnixNano(), 10), spans[0].EndTime)
+}
+
+func TestRunOTLP(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	recv := newOTLPReceiver()
+	defer recv.Close()
+
+	gs.ResetCounters()
+
+	report, err := Run(
+		"helloworld.Greeter.SayHello",
+		internal.TestLocalhost,
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithTotalRequests(20),
+		WithConcurrency(2),
+		WithOTLPEndpoint(recv.URL),
+		WithOTLPTraces(true),
+		WithData(map[string]interface{}{"name": "__record_metadata__"}),
+		WithInsecure(true),
+	)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, report)
+	assert.Equal(t, 20, int(report.Count))
+
+	metrics := recv.lastMetrics()
+	assert.Equal(t, report.Count, sumCount(metrics["ghz.requests"]))
+	assert.Empty(t, metrics["ghz.errors"].Sum.DataPoints)
+	assert.Equal(t, "20", metrics["ghz.request.duration"].Histogram.DataPoints[0].Count)
+
+	spans := recv.spans()
+	assert.Len(t, spans, 20)
+
+	traceIDs := make(map[string]bool, len(spans))
+	for _, s := range spans {
+		traceIDs[s.TraceID] = true
+		assert.Equal(t, "helloworld.Greeter/SayHello", s.Name)
+	}
+
+	calls := gs.GetCalls(callType)
+	assert.Len(t, calls, 20)
+
+	for _, msgs := range calls {
+		assert.Len(t, msgs, 1)
+
+		// the server records the propagated trace context
+		tp := strings.TrimPrefix(msgs[0].GetName(), "__record_metadata__||traceparent:")
+		parts := strings.Split(tp, "-")
+		assert.Len(t, parts, 4)
+		assert.True(t, traceIDs[parts[1]], "unknown trace id %s", parts[1])
+	}
+}
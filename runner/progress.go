@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// Progress is a snapshot of the run progress.
+// Interval values are computed over the results received since the previous snapshot.
+type Progress struct {
+	// Elapsed is the time since the start of the run
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Count is the total number of results so far
+	Count uint64 `json:"count"`
+
+	// ErrorCount is the total number of erroneous results so far
+	ErrorCount uint64 `json:"errorCount"`
+
+	// IntervalCount is the number of results in the last interval
+	IntervalCount uint64 `json:"intervalCount"`
+
+	// Rps is the rate of results per second over the last interval
+	Rps float64 `json:"rps"`
+
+	// LatencyDistribution is the 50th, 95th and 99th percentile latencies over the last interval
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+
+	// ErrorDist is the error distribution over the last interval
+	ErrorDist map[string]int `json:"errorDistribution"`
+
+	// StatusCodeDist is the status code distribution over the last interval
+	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
+}
+
+// ProgressFunc is called periodically with a snapshot of the run progress.
+// It is called from its own goroutine and does not block the run. If the function
+// does not return before the next snapshot is ready, that snapshot is dropped.
+type ProgressFunc func(*Progress)
+
+var progressPercentiles = []int{50, 95, 99}
+
+// progressTracker accumulates the results of a single progress interval
+type progressTracker struct {
+	start time.Time
+	last  time.Time
+
+	errorCount uint64
+
+	count          uint64
+	latencyHist    *hdrhistogram.Histogram
+	fastest        time.Duration
+	slowest        time.Duration
+	errorDist      map[string]int
+	statusCodeDist map[string]int
+}
+
+func newProgressTracker(precision int) *progressTracker {
+	now := time.Now()
+
+	return &progressTracker{
+		start:          now,
+		last:           now,
+		latencyHist:    hdrhistogram.New(1, int64(maxTrackableLatency), precision),
+		errorDist:      make(map[string]int),
+		statusCodeDist: make(map[string]int),
+	}
+}
+
+func (p *progressTracker) record(res *callResult, errStr string, countLatency bool) {
+	p.count++
+	p.statusCodeDist[res.status]++
+
+	if errStr != "" {
+		p.errorCount++
+		p.errorDist[errStr]++
+	}
+
+	if countLatency {
+		if p.latencyHist.TotalCount() == 0 || res.duration < p.fastest {
+			p.fastest = res.duration
+		}
+
+		if res.duration > p.slowest {
+			p.slowest = res.duration
+		}
+
+		recordHistValue(p.latencyHist, res.duration)
+	}
+}
+
+// snapshot creates the progress snapshot and resets the interval state
+func (p *progressTracker) snapshot(now time.Time, totalCount uint64) *Progress {
+	prog := &Progress{
+		Elapsed:        now.Sub(p.start),
+		Count:          totalCount,
+		ErrorCount:     p.errorCount,
+		IntervalCount:  p.count,
+		ErrorDist:      p.errorDist,
+		StatusCodeDist: p.statusCodeDist,
+	}
+
+	if interval := now.Sub(p.last); interval > 0 {
+		prog.Rps = float64(p.count) / interval.Seconds()
+	}
+
+	if p.latencyHist.TotalCount() > 0 {
+		prog.LatencyDistribution = percentiles(p.latencyHist, progressPercentiles, p.fastest, p.slowest)
+	}
+
+	p.last = now
+	p.count = 0
+	p.fastest = 0
+	p.slowest = 0
+	p.latencyHist.Reset()
+	p.errorDist = make(map[string]int)
+	p.statusCodeDist = make(map[string]int)
+
+	return prog
+}
@@ -2,10 +2,17 @@ package runner
 
 import (
 	"encoding/json"
+	"math/rand"
 	"sort"
 	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
 )
 
+// maxTrackableLatency is the highest latency value the latency histogram can
+// discern. Longer latencies are recorded as this value.
+const maxTrackableLatency = time.Hour
+
 // Reporter gathers all the results
 type Reporter struct {
 	config *RunConfig
@@ -13,13 +20,76 @@ type Reporter struct {
 	results chan *callResult
 	done    chan bool
 
-	totalLatenciesSec float64
+	totalLatencies time.Duration
+
+	// latency histogram of results included in the stats calculations
+	latencyHist *hdrhistogram.Histogram
+	fastest     time.Duration
+	slowest     time.Duration
+
+	// latencies measured from the intended start of the results, only for rate limited runs
+	corrected *correctedStats
 
+	// bounded random sample of result details
 	details []ResultDetail
+	rnd     *rand.Rand
 
 	errorDist      map[string]int
 	statusCodeDist map[string]int
 	totalCount     uint64
+
+	// number of responses failing validation
+	validationFailures uint64
+
+	progress *progressTracker
+
+	// request and response sizes of the calls
+	sizes *callSizes
+
+	// per message stats of the streaming calls, created on the first streaming result
+	streaming *streamStats
+
+	// registered result sinks receiving each result
+	sinks []*sinkState
+
+	// per call stats of the scenario
+	calls map[string]*callStats
+
+	// whole journey stats of the scenario steps
+	journey *callStats
+
+	// search for the maximum sustainable rate of the search load schedule
+	search *rateSearch
+
+	// results split into fixed windows, only when a timeline interval is set
+	timeline *timelineTracker
+
+	// live metrics export, only when an OTLP endpoint is set
+	otlp *otlpExporter
+
+	// live Prometheus metrics, only when a metrics address is set
+	metrics *liveMetrics
+}
+
+// correctedStats accumulates the latencies measured from the intended start of the calls
+type correctedStats struct {
+	totalLatencies time.Duration
+	latencyHist    *hdrhistogram.Histogram
+	fastest        time.Duration
+	slowest        time.Duration
+}
+
+// callStats accumulates the results of a single scenario call
+type callStats struct {
+	count          uint64
+	totalLatencies time.Duration
+	latencyHist    *hdrhistogram.Histogram
+	fastest        time.Duration
+	slowest        time.Duration
+	errorDist      map[string]int
+	statusCodeDist map[string]int
+
+	validationFailures uint64
 }
 
 // Options represents the request options
@@ -48,6 +118,11 @@ type Options struct {
 	LoadStepDuration time.Duration `json:"load-step-duration"`
 	LoadMaxDuration  time.Duration `json:"load-max-duration"`
 
+	LoadAmplitude     int           `json:"load-amplitude,omitempty"`
+	LoadPeriod        time.Duration `json:"load-period,omitempty"`
+	LoadSpikeDuration time.Duration `json:"load-spike-duration,omitempty"`
+	LoadScheduleFile  string        `json:"load-schedule-file,omitempty"`
+
 	Concurrency   int           `json:"concurrency,omitempty"`
 	CSchedule     string        `json:"concurrency-schedule"`
 	CStart        int           `json:"concurrency-start"`
@@ -74,6 +149,13 @@ type Options struct {
 
 	SkipFirst   int  `json:"skipFirst,omitempty"`
 	CountErrors bool `json:"count-errors,omitempty"`
+
+	HistogramPrecision int `json:"histogram-precision,omitempty"`
+	DetailsSampleSize  int `json:"details-sample-size,omitempty"`
+
+	SearchSLOs []string `json:"search-slos,omitempty"`
+
+	TimelineInterval time.Duration `json:"timeline-interval,omitempty"`
 }
 
 // Report holds the data for the full test
@@ -93,11 +175,102 @@ type Report struct {
 	ErrorDist      map[string]int `json:"errorDistribution"`
 	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
 
+	// ValidationFailures is the number of calls with a response failing validation.
+	// The failures are also included in the error distribution.
+	ValidationFailures uint64 `json:"validationFailures,omitempty"`
+
 	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
 	Histogram           []Bucket              `json:"histogram"`
 	Details             []ResultDetail        `json:"details"`
 
+	// LatencyHistogram is the mergeable latency histogram, only included when requested using WithLatencyHistogram
+	LatencyHistogram *hdrhistogram.Snapshot `json:"latencyHistogram,omitempty"`
+
 	Tags map[string]string `json:"tags,omitempty"`
+
+	Assertions *AssertionReport `json:"assertions,omitempty"`
+
+	// Corrected holds the latencies of a rate limited run corrected for coordinated omission
+	Corrected *CorrectedLatencies `json:"corrected,omitempty"`
+
+	// Calls is the per call breakdown of a scenario run, in the scenario order.
+	// For a journey scenario this is the per step breakdown.
+	Calls []CallReport `json:"calls,omitempty"`
+
+	// Journey holds the whole journey results of a journey scenario
+	Journey *JourneyReport `json:"journey,omitempty"`
+
+	// Sizes holds the wire sizes of the request and response messages of the calls
+	Sizes *SizesReport `json:"sizes,omitempty"`
+
+	// Streaming holds the per message stats of the streaming calls
+	Streaming *StreamingReport `json:"streaming,omitempty"`
+
+	// Search holds the results of the search load schedule
+	Search *SearchReport `json:"search,omitempty"`
+
+	// Timeline is the breakdown of the results into windows of the timeline interval, in order.
+	// Only included when requested using WithTimelineInterval.
+	Timeline []TimelineBucket `json:"timeline,omitempty"`
+}
+
+// CorrectedLatencies holds the latency stats of a rate limited run measured from the intended
+// start of each call on the load schedule rather than its actual start. When the server stalls
+// the calls are held up behind the busy workers and their measured latencies omit the wait,
+// which the corrected latencies include.
+type CorrectedLatencies struct {
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+	Histogram           []Bucket              `json:"histogram"`
+
+	LatencyHistogram *hdrhistogram.Snapshot `json:"latencyHistogram,omitempty"`
+}
+
+// JourneyReport holds the results of the whole journeys of a scenario.
+// The journey latency is the time taken to make all the steps of a journey.
+// A journey fails on the first failed step and the error is prefixed with the step name.
+type JourneyReport struct {
+	Count   uint64        `json:"count"`
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+	Rps     float64       `json:"rps"`
+
+	ErrorDist      map[string]int `json:"errorDistribution"`
+	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
+
+	ValidationFailures uint64 `json:"validationFailures,omitempty"`
+
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+	Histogram           []Bucket              `json:"histogram"`
+
+	LatencyHistogram *hdrhistogram.Snapshot `json:"latencyHistogram,omitempty"`
+}
+
+// CallReport holds the results of a single call of a scenario
+type CallReport struct {
+	Name   string `json:"name"`
+	Call   string `json:"call"`
+	Weight uint   `json:"weight,omitempty"`
+
+	Count   uint64        `json:"count"`
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+	Rps     float64       `json:"rps"`
+
+	ErrorDist      map[string]int `json:"errorDistribution"`
+	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
+
+	ValidationFailures uint64 `json:"validationFailures,omitempty"`
+
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+	Histogram           []Bucket              `json:"histogram"`
+
+	LatencyHistogram *hdrhistogram.Snapshot `json:"latencyHistogram,omitempty"`
 }
 
 // MarshalJSON is custom marshal for report to properly format the date
@@ -136,53 +309,378 @@ type ResultDetail struct {
 	Latency   time.Duration `json:"latency"`
 	Error     string        `json:"error"`
 	Status    string        `json:"status"`
+	Call      string        `json:"call,omitempty"`
 }
 
 func newReporter(results chan *callResult, c *RunConfig) *Reporter {
 
-	cap := min(c.n, maxResult)
+	cap := min(c.n, c.detailsSampleSize)
+
+	var calls map[string]*callStats
+	var journey *callStats
+	if c.scenario != nil {
+		entries := c.scenario.entries()
+		calls = make(map[string]*callStats, len(entries))
+		for _, sc := range entries {
+			calls[sc.Name] = newCallStats(c.histogramPrecision)
+		}
+
+		if c.scenario.isJourney() {
+			journey = newCallStats(c.histogramPrecision)
+		}
+	}
+
+	var corrected *correctedStats
+	if c.scheduled() {
+		corrected = &correctedStats{
+			latencyHist: hdrhistogram.New(1, int64(maxTrackableLatency), c.histogramPrecision),
+		}
+	}
 
 	return &Reporter{
-		config:  c,
-		results: results,
-		done:    make(chan bool, 1),
-		details: make([]ResultDetail, 0, cap),
+		config:    c,
+		results:   results,
+		corrected: corrected,
+		done:      make(chan bool, 1),
+		details:   make([]ResultDetail, 0, cap),
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		latencyHist: hdrhistogram.New(1, int64(maxTrackableLatency), c.histogramPrecision),
 
 		statusCodeDist: make(map[string]int),
 		errorDist:      make(map[string]int),
+
+		progress: newProgressTracker(c.histogramPrecision),
+
+		calls:   calls,
+		journey: journey,
+		sinks:   newSinkStates(c.resultSinks),
+		sizes:   newCallSizes(c.histogramPrecision),
+	}
+}
+
+func newCallStats(precision int) *callStats {
+	return &callStats{
+		latencyHist:    hdrhistogram.New(1, int64(maxTrackableLatency), precision),
+		errorDist:      make(map[string]int),
+		statusCodeDist: make(map[string]int),
 	}
 }
 
 // Run runs the reporter
 func (r *Reporter) Run() {
+	var tick <-chan time.Time
+	var progressCh chan *Progress
+	progressDone := make(chan struct{})
+	if r.config.progressFunc != nil && r.config.progressInterval > 0 {
+		ticker := time.NewTicker(r.config.progressInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+
+		progressCh = make(chan *Progress, 1)
+
+		go func() {
+			defer close(progressDone)
+			for p := range progressCh {
+				r.config.progressFunc(p)
+			}
+		}()
+	} else {
+		close(progressDone)
+	}
+
+	var searchTick <-chan time.Time
+	if r.search != nil {
+		ticker := time.NewTicker(r.config.loadStepDuration)
+		defer ticker.Stop()
+		searchTick = ticker.C
+	}
+
+	var timelineTick <-chan time.Time
+	if r.timeline != nil {
+		ticker := time.NewTicker(r.config.timelineInterval)
+		defer ticker.Stop()
+		timelineTick = ticker.C
+	}
+
 	var skipCount int
 
-	for res := range r.results {
-		if skipCount < r.config.skipFirst {
-			skipCount++
-			continue
+	for {
+		select {
+		case res, ok := <-r.results:
+			if !ok {
+				if progressCh != nil {
+					close(progressCh)
+				}
+				<-progressDone
+
+				r.done <- true
+				return
+			}
+
+			// the first journeys are skipped along with the calls of their steps
+			if res.journey || res.step {
+				if res.journeyNumber < uint64(r.config.skipFirst) {
+					continue
+				}
+
+				if res.journey {
+					r.recordJourney(res)
+					continue
+				}
+			} else if skipCount < r.config.skipFirst {
+				skipCount++
+				continue
+			}
+
+			r.record(res)
+		case now := <-searchTick:
+			r.search.probe(now)
+		case now := <-timelineTick:
+			r.timeline.close(now)
+		case now := <-tick:
+			// never block the results processing on a slow consumer
+			select {
+			case progressCh <- r.progress.snapshot(now, r.totalCount):
+			default:
+			}
 		}
+	}
+}
 
-		errStr := ""
-		r.totalCount++
-		r.totalLatenciesSec += res.duration.Seconds()
-		r.statusCodeDist[res.status]++
+func (r *Reporter) record(res *callResult) {
+	errStr := ""
+	r.totalCount++
+	r.totalLatencies += res.duration
+	r.statusCodeDist[res.status]++
 
-		if res.err != nil {
-			errStr = res.err.Error()
-			r.errorDist[errStr]++
+	if res.err != nil {
+		errStr = res.err.Error()
+		r.errorDist[errStr]++
+
+		if isValidationError(res.err) {
+			r.validationFailures++
 		}
+	}
 
-		if len(r.details) < maxResult {
-			r.details = append(r.details, ResultDetail{
-				Latency:   res.duration,
-				Timestamp: res.timestamp,
-				Status:    res.status,
-				Error:     errStr,
-			})
+	countLatency := res.err == nil || r.config.countErrors
+	if countLatency {
+		r.recordLatency(res.duration)
+
+		if r.corrected != nil {
+			r.corrected.record(res)
 		}
 	}
-	r.done <- true
+
+	r.progress.record(res, errStr, countLatency)
+
+	if cs, ok := r.calls[res.call]; ok {
+		cs.record(res, errStr, countLatency)
+	}
+
+	if r.search != nil {
+		r.search.record(res, errStr, countLatency)
+	}
+
+	if r.timeline != nil {
+		r.timeline.record(res, errStr, countLatency)
+	}
+
+	r.sizes.record(res)
+
+	if res.stream != nil {
+		if r.streaming == nil {
+			r.streaming = newStreamStats(r.config.histogramPrecision)
+		}
+
+		r.streaming.record(res)
+	}
+
+	if r.otlp != nil {
+		r.otlp.record(res)
+	}
+
+	if r.metrics != nil {
+		r.metrics.record(res)
+	}
+
+	if r.config.influxDetails != nil {
+		r.config.influxDetails.WritePoint(influxDetailLine(r.config, res, errStr))
+	}
+
+	r.recordSinks(res, errStr)
+
+	r.sampleDetail(ResultDetail{
+		Latency:   res.duration,
+		Timestamp: res.timestamp,
+		Status:    res.status,
+		Error:     errStr,
+		Call:      res.call,
+	})
+}
+
+func (r *Reporter) recordJourney(res *callResult) {
+	if r.journey == nil {
+		return
+	}
+
+	errStr := ""
+	if res.err != nil {
+		errStr = res.err.Error()
+	}
+
+	r.journey.record(res, errStr, res.err == nil || r.config.countErrors)
+}
+
+func (cs *callStats) record(res *callResult, errStr string, countLatency bool) {
+	cs.count++
+	cs.totalLatencies += res.duration
+	cs.statusCodeDist[res.status]++
+
+	if errStr != "" {
+		cs.errorDist[errStr]++
+
+		if isValidationError(res.err) {
+			cs.validationFailures++
+		}
+	}
+
+	if countLatency {
+		if cs.latencyHist.TotalCount() == 0 || res.duration < cs.fastest {
+			cs.fastest = res.duration
+		}
+
+		if res.duration > cs.slowest {
+			cs.slowest = res.duration
+		}
+
+		recordHistValue(cs.latencyHist, res.duration)
+	}
+}
+
+func (cs *callStats) report(sc *ScenarioCall, total time.Duration, withHist bool) CallReport {
+	cr := CallReport{
+		Name:           sc.Name,
+		Call:           sc.Call,
+		Weight:         sc.Weight,
+		Count:          cs.count,
+		ErrorDist:      cs.errorDist,
+		StatusCodeDist: cs.statusCodeDist,
+
+		ValidationFailures: cs.validationFailures,
+	}
+
+	if cs.count > 0 {
+		cr.Average = cs.totalLatencies / time.Duration(cs.count)
+
+		cr.Rps = float64(cs.count) / total.Seconds()
+
+		if cs.latencyHist.TotalCount() > 0 {
+			cr.Fastest = cs.fastest
+			cr.Slowest = cs.slowest
+			cr.Histogram = histogram(cs.latencyHist, cs.slowest.Seconds(), cs.fastest.Seconds())
+			cr.LatencyDistribution = latencies(cs.latencyHist, cs.fastest, cs.slowest)
+
+			if withHist {
+				cr.LatencyHistogram = cs.latencyHist.Export()
+			}
+		}
+	}
+
+	return cr
+}
+
+// recordLatency records the latency into the histogram and tracks the exact extremes
+func (r *Reporter) recordLatency(d time.Duration) {
+	if r.latencyHist.TotalCount() == 0 || d < r.fastest {
+		r.fastest = d
+	}
+
+	if d > r.slowest {
+		r.slowest = d
+	}
+
+	recordHistValue(r.latencyHist, d)
+}
+
+func (cs *correctedStats) record(res *callResult) {
+	d := res.corrected
+	if d < res.duration {
+		d = res.duration
+	}
+
+	cs.totalLatencies += d
+
+	if cs.latencyHist.TotalCount() == 0 || d < cs.fastest {
+		cs.fastest = d
+	}
+
+	if d > cs.slowest {
+		cs.slowest = d
+	}
+
+	recordHistValue(cs.latencyHist, d)
+}
+
+func (cs *correctedStats) report(withHist bool) *CorrectedLatencies {
+	count := cs.latencyHist.TotalCount()
+	if count == 0 {
+		return nil
+	}
+
+	cl := &CorrectedLatencies{
+		Average:             cs.totalLatencies / time.Duration(count),
+		Fastest:             cs.fastest,
+		Slowest:             cs.slowest,
+		Histogram:           histogram(cs.latencyHist, cs.slowest.Seconds(), cs.fastest.Seconds()),
+		LatencyDistribution: latencies(cs.latencyHist, cs.fastest, cs.slowest),
+	}
+
+	if withHist {
+		cl.LatencyHistogram = cs.latencyHist.Export()
+	}
+
+	return cl
+}
+
+// recordHistValue records the duration clamped to the trackable range of the histogram
+func recordHistValue(h *hdrhistogram.Histogram, d time.Duration) {
+	v := int64(d)
+	if v > h.HighestTrackableValue() {
+		v = h.HighestTrackableValue()
+	} else if v < h.LowestTrackableValue() {
+		v = h.LowestTrackableValue()
+	}
+
+	// the value is always within the trackable range
+	_ = h.RecordValue(v)
+}
+
+// sampleDetail keeps a uniform random sample of result details using
+// reservoir sampling so that memory is bounded regardless of the run length.
+// It must be called after totalCount has been incremented for the result.
+func (r *Reporter) sampleDetail(d ResultDetail) {
+	size := r.config.detailsSampleSize
+	if size <= 0 {
+		return
+	}
+
+	if len(r.details) < size {
+		r.details = append(r.details, d)
+		return
+	}
+
+	if j := r.rnd.Int63n(int64(r.totalCount)); j < int64(size) {
+		r.details[j] = d
+	}
+}
+
+// sortDetails sorts the result details by their timestamp, as the
+// reservoir sampling replaces the details in random order.
+func sortDetails(details []ResultDetail) {
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].Timestamp.Before(details[j].Timestamp)
+	})
 }
 
 // Finalize all the gathered data into a final report
@@ -194,7 +692,9 @@ func (r *Reporter) Finalize(stopReason StopReason, total time.Duration) *Report
 		Count:          r.totalCount,
 		Total:          total,
 		ErrorDist:      r.errorDist,
-		StatusCodeDist: r.statusCodeDist}
+		StatusCodeDist: r.statusCodeDist,
+
+		ValidationFailures: r.validationFailures}
 
 	rep.Options = Options{
 		Call:              r.config.call,
@@ -220,6 +720,11 @@ func (r *Reporter) Finalize(stopReason StopReason, total time.Duration) *Report
 		LoadStepDuration: r.config.loadStepDuration,
 		LoadMaxDuration:  r.config.loadDuration,
 
+		LoadAmplitude:     int(r.config.loadAmplitude),
+		LoadPeriod:        r.config.loadPeriod,
+		LoadSpikeDuration: r.config.loadSpikeDuration,
+		LoadScheduleFile:  r.config.loadScheduleFile,
+
 		Concurrency:   r.config.c,
 		CSchedule:     r.config.cSchedule,
 		CStart:        int(r.config.cStart),
@@ -242,6 +747,15 @@ func (r *Reporter) Finalize(stopReason StopReason, total time.Duration) *Report
 		Name:        r.config.name,
 		SkipFirst:   r.config.skipFirst,
 		CountErrors: r.config.countErrors,
+
+		HistogramPrecision: r.config.histogramPrecision,
+		DetailsSampleSize:  r.config.detailsSampleSize,
+
+		TimelineInterval: r.config.timelineInterval,
+	}
+
+	for _, a := range r.config.searchSLOs {
+		rep.Options.SearchSLOs = append(rep.Options.SearchSLOs, a.expr)
 	}
 
 	_ = json.Unmarshal(r.config.data, &rep.Options.Data)
@@ -250,69 +764,143 @@ func (r *Reporter) Finalize(stopReason StopReason, total time.Duration) *Report
 
 	_ = json.Unmarshal(r.config.tags, &rep.Tags)
 
-	if len(r.details) > 0 {
-		average := r.totalLatenciesSec / float64(r.totalCount)
-		rep.Average = time.Duration(average * float64(time.Second))
+	if r.totalCount > 0 {
+		rep.Average = r.totalLatencies / time.Duration(r.totalCount)
 
 		rep.Rps = float64(r.totalCount) / total.Seconds()
 
-		okLats := make([]float64, 0)
-		for _, d := range r.details {
-			if d.Error == "" || rep.Options.CountErrors {
-				okLats = append(okLats, d.Latency.Seconds())
+		if r.latencyHist.TotalCount() > 0 {
+			rep.Fastest = r.fastest
+			rep.Slowest = r.slowest
+			rep.Histogram = histogram(r.latencyHist, r.slowest.Seconds(), r.fastest.Seconds())
+			rep.LatencyDistribution = latencies(r.latencyHist, r.fastest, r.slowest)
+
+			if r.config.latencyHistogram {
+				rep.LatencyHistogram = r.latencyHist.Export()
 			}
 		}
-		sort.Float64s(okLats)
-		if len(okLats) > 0 {
-			var fastestNum, slowestNum float64
-			fastestNum = okLats[0]
-			slowestNum = okLats[len(okLats)-1]
-
-			rep.Fastest = time.Duration(fastestNum * float64(time.Second))
-			rep.Slowest = time.Duration(slowestNum * float64(time.Second))
-			rep.Histogram = histogram(okLats, slowestNum, fastestNum)
-			rep.LatencyDistribution = latencies(okLats)
+
+		if len(r.details) > 0 {
+			sortDetails(r.details)
+			rep.Details = r.details
 		}
 
-		rep.Details = r.details
+		if r.corrected != nil {
+			rep.Corrected = r.corrected.report(r.config.latencyHistogram)
+		}
+	}
+
+	rep.Sizes = r.sizes.report(total, r.config.latencyHistogram)
+
+	if r.streaming != nil {
+		rep.Streaming = r.streaming.report(total, r.config.latencyHistogram)
 	}
 
+	if r.config.scenario != nil {
+		entries := r.config.scenario.entries()
+		rep.Calls = make([]CallReport, 0, len(entries))
+		for i := range entries {
+			sc := &entries[i]
+			rep.Calls = append(rep.Calls, r.calls[sc.Name].report(sc, total, r.config.latencyHistogram))
+		}
+	}
+
+	if r.journey != nil {
+		jr := r.journey.report(&ScenarioCall{}, total, r.config.latencyHistogram)
+		rep.Journey = &JourneyReport{
+			Count:               jr.Count,
+			Average:             jr.Average,
+			Fastest:             jr.Fastest,
+			Slowest:             jr.Slowest,
+			Rps:                 jr.Rps,
+			ErrorDist:           jr.ErrorDist,
+			StatusCodeDist:      jr.StatusCodeDist,
+			ValidationFailures:  jr.ValidationFailures,
+			LatencyDistribution: jr.LatencyDistribution,
+			Histogram:           jr.Histogram,
+			LatencyHistogram:    jr.LatencyHistogram,
+		}
+	}
+
+	if r.search != nil {
+		rep.Search = r.search.report()
+	}
+
+	if r.timeline != nil {
+		rep.Timeline = r.timeline.report(total)
+	}
+
+	rep.Assertions = evaluateAssertions(r.config.assertions, rep)
+
 	return rep
 }
 
-func latencies(latencies []float64) []LatencyDistribution {
-	pctls := []int{10, 25, 50, 75, 90, 95, 99}
-	data := make([]float64, len(pctls))
-	lt := float64(len(latencies))
+var reportPercentiles = []int{10, 25, 50, 75, 90, 95, 99}
+
+func latencies(h *hdrhistogram.Histogram, fastest, slowest time.Duration) []LatencyDistribution {
+	return percentiles(h, reportPercentiles, fastest, slowest)
+}
+
+// percentiles computes the latency distribution using the nearest-rank method
+// over the recorded histogram. Values are clamped to the exact fastest and
+// slowest latencies observed.
+func percentiles(h *hdrhistogram.Histogram, pctls []int, fastest, slowest time.Duration) []LatencyDistribution {
+	ranks := make([]int64, len(pctls))
+	lt := float64(h.TotalCount())
 	for i, p := range pctls {
 		ip := (float64(p) / 100.0) * lt
-		di := int(ip)
+		rank := int64(ip)
 
-		// since we're dealing with 0th based ranks we need to
-		// check if ordinal is a whole number that lands on the percentile
-		// if so adjust accordingly
-		if ip == float64(di) {
-			di = di - 1
+		// ordinal ranks are 1 based, if the ordinal is not a whole
+		// number the rank is the next whole number
+		if ip != float64(rank) {
+			rank++
 		}
 
-		if di < 0 {
-			di = 0
+		if rank < 1 {
+			rank = 1
 		}
 
-		data[i] = latencies[di]
+		ranks[i] = rank
+	}
+
+	data := make([]time.Duration, len(pctls))
+	var count int64
+	pi := 0
+	for _, bar := range h.Distribution() {
+		if bar.Count == 0 {
+			continue
+		}
+
+		count += bar.Count
+		for pi < len(pctls) && count >= ranks[pi] {
+			data[pi] = time.Duration(bar.To)
+			pi++
+		}
+
+		if pi == len(pctls) {
+			break
+		}
 	}
 
 	res := make([]LatencyDistribution, len(pctls))
 	for i := 0; i < len(pctls); i++ {
-		if data[i] > 0 {
-			lat := time.Duration(data[i] * float64(time.Second))
+		lat := data[i]
+		if lat > slowest {
+			lat = slowest
+		}
+		if lat < fastest {
+			lat = fastest
+		}
+
+		if lat > 0 {
 			res[i] = LatencyDistribution{Percentage: pctls[i], Latency: lat}
 		}
 	}
 	return res
 }
 
-func histogram(latencies []float64, slowest, fastest float64) []Bucket {
+func histogram(h *hdrhistogram.Histogram, slowest, fastest float64) []Bucket {
 	bc := 10
 	buckets := make([]float64, bc+1)
 	counts := make([]int, bc+1)
@@ -322,24 +910,26 @@ func histogram(latencies []float64, slowest, fastest float64) []Bucket {
 	}
 	buckets[bc] = slowest
 	var bi int
-	var max int
-	for i := 0; i < len(latencies); {
-		if latencies[i] <= buckets[bi] {
-			i++
-			counts[bi]++
-			if max < counts[bi] {
-				max = counts[bi]
-			}
-		} else if bi < len(buckets)-1 {
+	for _, bar := range h.Distribution() {
+		if bar.Count == 0 {
+			continue
+		}
+
+		// values within a histogram bar are equivalent, so we place the
+		// whole bar based on the lowest equivalent value of the bar
+		v := time.Duration(bar.From).Seconds()
+		for v > buckets[bi] && bi < len(buckets)-1 {
 			bi++
 		}
+		counts[bi] += int(bar.Count)
 	}
+	total := float64(h.TotalCount())
 	res := make([]Bucket, len(buckets))
 	for i := 0; i < len(buckets); i++ {
 		res[i] = Bucket{
 			Mark:      buckets[i],
 			Count:     counts[i],
-			Frequency: float64(counts[i]) / float64(len(latencies)),
+			Frequency: float64(counts[i]) / total,
 		}
 	}
 	return res
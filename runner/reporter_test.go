@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,6 +62,97 @@ func TestReport_CorrectDetails(t *testing.T) {
 	assert.Equal(t, ResultDetail{Error: cr2.err.Error(), Latency: cr2.duration, Status: cr2.status, Timestamp: cr2.timestamp}, report.Details[1])
 }
 
+func TestReport_DetailsSample(t *testing.T) {
+	callResultsChan := make(chan *callResult)
+	config, _ := NewConfig("call", "host", WithDetailsSampleSize(10))
+	reporter := newReporter(callResultsChan, config)
+
+	go reporter.Run()
+
+	start := time.Now()
+	for i := 1; i <= 1000; i++ {
+		callResultsChan <- &callResult{
+			status:    "OK",
+			duration:  time.Duration(i) * time.Millisecond,
+			timestamp: start.Add(time.Duration(i) * time.Millisecond),
+		}
+	}
+
+	close(callResultsChan)
+	<-reporter.done
+	report := reporter.Finalize("stop reason", time.Second)
+
+	assert.Equal(t, uint64(1000), report.Count)
+	assert.Len(t, report.Details, 10)
+	assert.Equal(t, time.Millisecond, report.Fastest)
+	assert.Equal(t, 1000*time.Millisecond, report.Slowest)
+	assert.Equal(t, 500500*time.Microsecond, report.Average)
+
+	// the sampled details are in the order of the results
+	for i := 1; i < len(report.Details); i++ {
+		assert.True(t, report.Details[i-1].Timestamp.Before(report.Details[i].Timestamp))
+	}
+
+	total := 0
+	for _, b := range report.Histogram {
+		total += b.Count
+	}
+	assert.Equal(t, 1000, total)
+
+	assert.Len(t, report.LatencyDistribution, 7)
+	assert.InEpsilon(t, float64(500*time.Millisecond), float64(report.LatencyDistribution[2].Latency), 0.001)
+	assert.InEpsilon(t, float64(990*time.Millisecond), float64(report.LatencyDistribution[6].Latency), 0.001)
+}
+
+func TestReport_AverageSingleCall(t *testing.T) {
+	callResultsChan := make(chan *callResult)
+	config, _ := NewConfig("call", "host")
+	reporter := newReporter(callResultsChan, config)
+
+	go reporter.Run()
+
+	callResultsChan <- &callResult{
+		status:    "OK",
+		duration:  493689 * time.Nanosecond,
+		timestamp: time.Now(),
+	}
+
+	close(callResultsChan)
+	<-reporter.done
+	report := reporter.Finalize("stop reason", time.Second)
+
+	// the average of a single latency is exactly the latency
+	assert.Equal(t, 493689*time.Nanosecond, report.Average)
+	assert.Equal(t, report.Fastest, report.Average)
+	assert.Equal(t, report.Slowest, report.Average)
+}
+
+func TestReport_SkipDetails(t *testing.T) {
+	callResultsChan := make(chan *callResult)
+	config, _ := NewConfig("call", "host", WithDetailsSampleSize(0))
+	reporter := newReporter(callResultsChan, config)
+
+	go reporter.Run()
+
+	for i := 1; i <= 100; i++ {
+		callResultsChan <- &callResult{
+			status:    "OK",
+			duration:  time.Duration(i) * time.Millisecond,
+			timestamp: time.Now(),
+		}
+	}
+
+	close(callResultsChan)
+	<-reporter.done
+	report := reporter.Finalize("stop reason", time.Second)
+
+	assert.Equal(t, uint64(100), report.Count)
+	assert.Empty(t, report.Details)
+	assert.Equal(t, 100*time.Millisecond, report.Slowest)
+	assert.NotEmpty(t, report.LatencyDistribution)
+	assert.NotEmpty(t, report.Histogram)
+}
+
 func TestReport_latencies(t *testing.T) {
 	var tests = []struct {
 		input    []float64
@@ -117,8 +210,140 @@ func TestReport_latencies(t *testing.T) {
 
 	for i, tt := range tests {
 		t.Run("latencies "+strconv.FormatInt(int64(i), 10), func(t *testing.T) {
-			lats := latencies(tt.input)
-			assert.Equal(t, tt.expected, lats)
+			h := hdrhistogram.New(1, int64(maxTrackableLatency), 3)
+			for _, v := range tt.input {
+				assert.NoError(t, h.RecordValue(int64(v*float64(time.Second))))
+			}
+
+			fastest := time.Duration(tt.input[0] * float64(time.Second))
+			slowest := time.Duration(tt.input[len(tt.input)-1] * float64(time.Second))
+
+			lats := latencies(h, fastest, slowest)
+			assert.Len(t, lats, len(tt.expected))
+			for j, e := range tt.expected {
+				assert.Equal(t, e.Percentage, lats[j].Percentage)
+				assert.InEpsilon(t, float64(e.Latency), float64(lats[j].Latency), 0.001)
+			}
 		})
 	}
 }
+
+func TestReport_Progress(t *testing.T) {
+	callResultsChan := make(chan *callResult)
+
+	var lock sync.Mutex
+	var snapshots []*Progress
+	config, _ := NewConfig("call", "host", WithProgressCallback(20*time.Millisecond, func(p *Progress) {
+		lock.Lock()
+		snapshots = append(snapshots, p)
+		lock.Unlock()
+	}))
+	reporter := newReporter(callResultsChan, config)
+
+	go reporter.Run()
+
+	for i := 1; i <= 10; i++ {
+		cr := callResult{
+			status:    "OK",
+			duration:  time.Duration(i) * time.Millisecond,
+			timestamp: time.Now(),
+		}
+		if i == 10 {
+			cr.status = "DeadlineExceeded"
+			cr.err = context.DeadlineExceeded
+		}
+		callResultsChan <- &cr
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	close(callResultsChan)
+	<-reporter.done
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.NotEmpty(t, snapshots)
+
+	first := snapshots[0]
+	assert.Equal(t, uint64(10), first.Count)
+	assert.Equal(t, uint64(10), first.IntervalCount)
+	assert.Equal(t, uint64(1), first.ErrorCount)
+	assert.Equal(t, map[string]int{"OK": 9, "DeadlineExceeded": 1}, first.StatusCodeDist)
+	assert.Equal(t, map[string]int{context.DeadlineExceeded.Error(): 1}, first.ErrorDist)
+	assert.Len(t, first.LatencyDistribution, 3)
+	assert.Equal(t, 50, first.LatencyDistribution[0].Percentage)
+	assert.Equal(t, 9*time.Millisecond, first.LatencyDistribution[2].Latency)
+	assert.True(t, first.Rps > 0)
+
+	if len(snapshots) > 1 {
+		assert.Equal(t, uint64(10), snapshots[1].Count)
+		assert.Equal(t, uint64(0), snapshots[1].IntervalCount)
+		assert.Empty(t, snapshots[1].LatencyDistribution)
+	}
+}
+
+func TestReport_Calls(t *testing.T) {
+	callResultsChan := make(chan *callResult)
+
+	config, _ := NewConfig("", "host", WithScenario(&Scenario{
+		Calls: []ScenarioCall{
+			{Name: "get", Call: "foo.Bar.Get", Weight: 2},
+			{Name: "list", Call: "foo.Bar.List"},
+		},
+	}))
+	reporter := newReporter(callResultsChan, config)
+
+	go reporter.Run()
+
+	for i := 1; i <= 6; i++ {
+		cr := callResult{
+			status:    "OK",
+			duration:  time.Duration(i) * time.Millisecond,
+			timestamp: time.Now(),
+			call:      "get",
+		}
+		if i%3 == 0 {
+			cr.call = "list"
+		}
+		if i == 6 {
+			cr.status = "DeadlineExceeded"
+			cr.err = context.DeadlineExceeded
+		}
+		callResultsChan <- &cr
+	}
+
+	close(callResultsChan)
+	<-reporter.done
+
+	report := reporter.Finalize(ReasonNormalEnd, time.Second)
+
+	assert.Equal(t, uint64(6), report.Count)
+	assert.Len(t, report.Calls, 2)
+
+	get := report.Calls[0]
+	assert.Equal(t, "get", get.Name)
+	assert.Equal(t, "foo.Bar.Get", get.Call)
+	assert.Equal(t, uint(2), get.Weight)
+	assert.Equal(t, uint64(4), get.Count)
+	assert.Equal(t, 1*time.Millisecond, get.Fastest)
+	assert.Equal(t, 5*time.Millisecond, get.Slowest)
+	assert.Equal(t, 3*time.Millisecond, get.Average)
+	assert.Equal(t, 4.0, get.Rps)
+	assert.Equal(t, map[string]int{"OK": 4}, get.StatusCodeDist)
+	assert.Empty(t, get.ErrorDist)
+	assert.Len(t, get.LatencyDistribution, len(reportPercentiles))
+	assert.Len(t, get.Histogram, 11)
+
+	list := report.Calls[1]
+	assert.Equal(t, "list", list.Name)
+	assert.Equal(t, uint64(2), list.Count)
+	assert.Equal(t, 3*time.Millisecond, list.Fastest)
+	assert.Equal(t, 3*time.Millisecond, list.Slowest)
+	assert.Equal(t, map[string]int{"OK": 1, "DeadlineExceeded": 1}, list.StatusCodeDist)
+	assert.Equal(t, map[string]int{context.DeadlineExceeded.Error(): 1}, list.ErrorDist)
+
+	for _, d := range report.Details {
+		assert.Contains(t, []string{"get", "list"}, d.Call)
+	}
+}
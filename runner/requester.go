@@ -6,6 +6,7 @@ import (
 	"math"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bojand/ghz/load"
@@ -35,6 +36,27 @@ type callResult struct {
 	status    string
 	duration  time.Duration
 	timestamp time.Time
+	call      string
+	workerID  string
+
+	// reqSize and respSize are the total wire sizes of the sent and received messages
+	reqSize  int64
+	respSize int64
+
+	// stream holds the per message stats of a streaming call
+	stream *streamResult
+
+	// corrected is the duration measured from the intended start of the call on the load schedule
+	corrected time.Duration
+
+	// journey is set for the result of a whole journey of a scenario
+	journey bool
+
+	// step is set for the result of a journey step call
+	step bool
+
+	// journeyNumber is the request number of the journey of a journey or step result
+	journeyNumber uint64
 }
 
 // Requester is used for doing the requests
@@ -43,7 +65,9 @@ type Requester struct {
 	stubs    []grpcdynamic.Stub
 	handlers []*statsHandler
 
-	mtd      *desc.MethodDescriptor
+	targets  []*callTarget
+	calls    callSchedule
+	journey  []*callTarget
 	reporter *Reporter
 
 	config *RunConfig
@@ -52,19 +76,25 @@ type Requester struct {
 	stopCh  chan bool
 	start   time.Time
 
-	dataProvider     DataProviderFunc
-	metadataProvider MetadataProviderFunc
-
 	lock       sync.Mutex
 	stopReason StopReason
+	finished   bool
 	workers    []*Worker
+
+	// number of active workers, updated atomically
+	activeWorkers int64
+
+	// exports the metrics and spans of the run, only when an OTLP endpoint is set
+	otlp *otlpExporter
+
+	// serves the live metrics of the run, only when a metrics address is set
+	metrics *liveMetrics
 }
 
 // NewRequester creates a new requestor from the passed RunConfig
 func NewRequester(c *RunConfig) (*Requester, error) {
 
 	var err error
-	var mtd *desc.MethodDescriptor
 
 	reqr := &Requester{
 		config:     c,
@@ -76,13 +106,12 @@ func NewRequester(c *RunConfig) (*Requester, error) {
 		stubs:      make([]grpcdynamic.Stub, 0, c.nConns),
 	}
 
-	if c.proto != "" {
-		mtd, err = protodesc.GetMethodDescFromProto(c.call, c.proto, c.importPaths)
-	} else if c.protoset != "" {
-		mtd, err = protodesc.GetMethodDescFromProtoSet(c.call, c.protoset)
-	} else if c.protosetBinary != nil {
-		mtd, err = protodesc.GetMethodDescFromProtoSetBinary(c.call, c.protosetBinary)
-	} else {
+	if c.otlpEndpoint != "" {
+		reqr.otlp = newOTLPExporter(c)
+	}
+
+	var refClient *grpcreflect.Client
+	if c.proto == "" && c.protoset == "" && c.protosetBinary == nil {
 		// use reflection to get method descriptor
 		var cc *grpc.ClientConn
 		// temporary connection for reflection, do not store as requester connections
@@ -108,58 +137,163 @@ func NewRequester(c *RunConfig) (*Requester, error) {
 
 		refCtx := metadata.NewOutgoingContext(ctx, md)
 
-		refClient := grpcreflect.NewClientAuto(refCtx, cc)
+		refClient = grpcreflect.NewClientAuto(refCtx, cc)
+	}
+
+	getMethodDesc := func(call string) (*desc.MethodDescriptor, error) {
+		if c.proto != "" {
+			return protodesc.GetMethodDescFromProto(call, c.proto, c.importPaths)
+		} else if c.protoset != "" {
+			return protodesc.GetMethodDescFromProtoSet(call, c.protoset)
+		} else if c.protosetBinary != nil {
+			return protodesc.GetMethodDescFromProtoSetBinary(call, c.protosetBinary)
+		}
 
-		mtd, err = protodesc.GetMethodDescFromReflect(c.call, refClient)
+		return protodesc.GetMethodDescFromReflect(call, refClient)
 	}
 
-	if err != nil {
-		return nil, err
+	if c.scenario == nil {
+		mtd, err := getMethodDesc(c.call)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := reqr.newCallTarget(c.call, mtd, 1, c.data, c.metadata, c.binary, c.dataFunc)
+		if err != nil {
+			return nil, err
+		}
+
+		t.validations = c.responseValidations
+
+		reqr.targets = append(reqr.targets, t)
+	} else {
+		entries := c.scenario.entries()
+		for i := range entries {
+			sc := &entries[i]
+
+			mtd, err := getMethodDesc(sc.Call)
+			if err != nil {
+				return nil, err
+			}
+
+			data, md, binary, err := sc.dataAndMetadata()
+			if err != nil {
+				return nil, fmt.Errorf("scenario call %q: %w", sc.Name, err)
+			}
+
+			// fall back to the data and metadata of the run
+			dataFunc := c.dataFunc
+			if data != nil {
+				dataFunc = nil
+			} else {
+				data, binary = c.data, c.binary
+			}
+
+			if md == nil {
+				md = c.metadata
+			}
+
+			t, err := reqr.newCallTarget(sc.Name, mtd, sc.Weight, data, md, binary, dataFunc)
+			if err != nil {
+				return nil, fmt.Errorf("scenario call %q: %w", sc.Name, err)
+			}
+
+			if t.extract, err = sc.extractors(); err != nil {
+				return nil, fmt.Errorf("scenario step %q: %w", sc.Name, err)
+			}
+
+			vs, err := parseResponseValidations(sc.Validations)
+			if err != nil {
+				return nil, fmt.Errorf("scenario call %q: %w", sc.Name, err)
+			}
+
+			t.validations = append(append([]*responseValidation{}, c.responseValidations...), vs...)
+
+			reqr.targets = append(reqr.targets, t)
+		}
+	}
+
+	if c.scenario != nil && c.scenario.isJourney() {
+		reqr.journey = reqr.targets
+	} else {
+		reqr.calls = newCallSchedule(reqr.targets)
 	}
 
-	md := mtd.GetInputType()
-	payloadMessage := dynamic.NewMessage(md)
+	return reqr, nil
+}
+
+func (b *Requester) newCallTarget(name string, mtd *desc.MethodDescriptor, weight uint,
+	data, md []byte, binary bool, dataFunc BinaryDataFunc) (*callTarget, error) {
+
+	c := b.config
+
+	payloadMessage := dynamic.NewMessage(mtd.GetInputType())
 	if payloadMessage == nil {
 		return nil, fmt.Errorf("no input type of method: %s", mtd.GetName())
 	}
 
-	// fill in the rest
-	reqr.mtd = mtd
+	t := &callTarget{
+		name:   name,
+		mtd:    mtd,
+		data:   data,
+		weight: weight,
+	}
 
 	if c.dataProviderFunc != nil {
-		reqr.dataProvider = c.dataProviderFunc
+		t.dataProvider = c.dataProviderFunc
 	} else {
-		defaultDataProvider, err := newDataProvider(reqr.mtd, c.binary, c.dataFunc, c.data, !c.disableTemplateFuncs, !c.disableTemplateData, c.funcs)
+		defaultDataProvider, err := newDataProvider(mtd, binary, dataFunc, data, !c.disableTemplateFuncs, !c.disableTemplateData, c.funcs)
 		if err != nil {
 			return nil, err
 		}
-		reqr.dataProvider = defaultDataProvider.getDataForCall
+		t.dataProvider = defaultDataProvider.getDataForCall
 	}
 
 	if c.mdProviderFunc != nil {
-		reqr.metadataProvider = c.mdProviderFunc
+		t.metadataProvider = c.mdProviderFunc
 	} else {
-		defaultMDProvider, err := newMetadataProvider(reqr.mtd, c.metadata, !c.disableTemplateFuncs, !c.disableTemplateData, c.funcs)
+		defaultMDProvider, err := newMetadataProvider(mtd, md, !c.disableTemplateFuncs, !c.disableTemplateData, c.funcs)
 		if err != nil {
 			return nil, err
 		}
-		reqr.metadataProvider = defaultMDProvider.getMetadataForCall
+		t.metadataProvider = defaultMDProvider.getMetadataForCall
 	}
 
-	return reqr, nil
+	return t, nil
 }
 
 // Run makes all the requests and returns a report of results
 // It blocks until all work is done.
 func (b *Requester) Run() (*Report, error) {
 
-	defer close(b.stopCh)
+	defer func() {
+		b.lock.Lock()
+		b.finished = true
+		close(b.stopCh)
+		b.lock.Unlock()
+	}()
 
 	cc, err := b.openClientConns()
 	if err != nil {
 		return nil, err
 	}
 
+	if b.config.metricsAddress != "" {
+		b.metrics = newLiveMetrics(b.config, func() int {
+			return int(atomic.LoadInt64(&b.activeWorkers))
+		})
+
+		if err := b.metrics.serve(b.config.metricsAddress); err != nil {
+			b.closeClientConns()
+			return nil, fmt.Errorf("serving metrics: %w", err)
+		}
+
+		defer func() {
+			// the run is over, the error of a slow scrape is of no consequence
+			_ = b.metrics.close()
+		}()
+	}
+
 	start := time.Now()
 
 	b.lock.Lock()
@@ -171,32 +305,68 @@ func (b *Requester) Run() (*Report, error) {
 		b.stubs = append(b.stubs, stub)
 	}
 
+	wt := createWorkerTicker(b.config)
+
+	p := createPacer(b.config)
+
 	b.reporter = newReporter(b.results, b.config)
+	if ap, ok := p.(*load.AdaptivePacer); ok && b.config.loadSchedule == ScheduleSearch {
+		b.reporter.search = newRateSearch(b.config, ap, start)
+	}
+	if b.config.timelineInterval > 0 {
+		b.reporter.timeline = newTimelineTracker(start, b.config.timelineInterval, b.config.histogramPrecision,
+			func(elapsed time.Duration) (float64, int) {
+				return p.Rate(elapsed), int(atomic.LoadInt64(&b.activeWorkers))
+			})
+	}
+	b.reporter.metrics = b.metrics
+	if b.otlp != nil {
+		b.reporter.otlp = b.otlp
+		b.otlp.run(start, func() int {
+			return int(atomic.LoadInt64(&b.activeWorkers))
+		})
+	}
 	b.lock.Unlock()
 
 	go func() {
 		b.reporter.Run()
 	}()
 
-	wt := createWorkerTicker(b.config)
-
-	p := createPacer(b.config)
-
 	err = b.runWorkers(wt, p)
 
 	report := b.Finish()
 
+	if sinkErr := b.reporter.reportSinks(report); err == nil {
+		err = sinkErr
+	}
+
 	b.closeClientConns()
 
+	if b.otlp != nil {
+		b.otlp.stop()
+	}
+
 	return report, err
 }
 
 // Stop stops the test
 func (b *Requester) Stop(reason StopReason) {
 
-	b.stopCh <- true
-
 	b.lock.Lock()
+	if b.finished {
+		// the run is already done
+		b.lock.Unlock()
+		return
+	}
+
+	select {
+	case b.stopCh <- true:
+	default:
+		// a stop is already pending
+		b.lock.Unlock()
+		return
+	}
+
 	b.stopReason = reason
 
 	if b.config.hasLog {
@@ -332,6 +502,10 @@ func (b *Requester) newClientConn(withStatsHandler bool) (*grpc.ClientConn, erro
 			log:     b.config.log,
 		}
 
+		if b.otlp != nil && b.config.otlpTraces {
+			sh.otlp = b.otlp
+		}
+
 		b.handlers = append(b.handlers, sh)
 
 		opts = append(opts, grpc.WithStatsHandler(sh))
@@ -392,12 +566,12 @@ func (b *Requester) runWorkers(wt load.WorkerTicker, p load.Pacer) error {
 						ticks:                         ticks,
 						active:                        true,
 						stub:                          b.stubs[n],
-						mtd:                           b.mtd,
+						calls:                         b.calls,
+						journey:                       b.journey,
+						results:                       b.results,
 						config:                        b.config,
 						stopCh:                        make(chan bool),
 						workerID:                      wID,
-						dataProvider:                  b.dataProvider,
-						metadataProvider:              b.metadataProvider,
 						streamRecv:                    b.config.recvMsgFunc,
 						msgProvider:                   b.config.dataStreamFunc,
 						streamInterceptorProviderFunc: b.config.streamInterceptorProviderFunc,
@@ -416,6 +590,8 @@ func (b *Requester) runWorkers(wt load.WorkerTicker, p load.Pacer) error {
 					b.workers = append(b.workers, &w)
 					wm.Unlock()
 
+					atomic.AddInt64(&b.activeWorkers, 1)
+
 					go func() {
 						errC <- w.runWorker()
 					}()
@@ -436,6 +612,8 @@ func (b *Requester) runWorkers(wt load.WorkerTicker, p load.Pacer) error {
 					}
 				}
 				wm.Unlock()
+
+				atomic.AddInt64(&b.activeWorkers, -int64(wdc))
 			}
 			if tv.Done {
 				return
@@ -461,8 +639,12 @@ func (b *Requester) runWorkers(wt load.WorkerTicker, p load.Pacer) error {
 
 		began := time.Now()
 
+		sp, scheduled := p.(load.ScheduledPacer)
+		scheduled = scheduled && b.config.rateLimited()
+
 		for {
-			wait, stop := p.Pace(time.Since(began), counter.Get())
+			hits := counter.Get()
+			wait, stop := p.Pace(time.Since(began), hits)
 
 			if stop {
 				if b.config.hasLog {
@@ -476,8 +658,15 @@ func (b *Requester) runWorkers(wt load.WorkerTicker, p load.Pacer) error {
 				time.Sleep(wait)
 			}
 
+			// without a schedule the request is intended to start once the pacer lets it go,
+			// which may be later than intended if the pacer was held up by busy workers
+			intended := time.Now()
+			if scheduled {
+				intended = began.Add(sp.Scheduled(hits))
+			}
+
 			select {
-			case ticks <- TickValue{instant: time.Now(), reqNumber: counter.Inc() - 1}:
+			case ticks <- TickValue{instant: intended, reqNumber: counter.Inc() - 1}:
 				continue
 			case <-b.stopCh:
 				if b.config.hasLog {
@@ -564,6 +753,27 @@ func createPacer(config *RunConfig) load.Pacer {
 			StepDuration: config.loadStepDuration,
 			Max:          uint64(config.n),
 		}
+	case SchedulePoisson:
+		p = &load.PoissonPacer{Freq: uint64(config.rps), Max: uint64(config.n)}
+	case ScheduleSine:
+		p = &load.SinePacer{
+			Base:      uint64(config.rps),
+			Amplitude: uint64(config.loadAmplitude),
+			Period:    config.loadPeriod,
+			Max:       uint64(config.n),
+		}
+	case ScheduleSpike:
+		p = &load.SpikePacer{
+			Base:          uint64(config.rps),
+			Spike:         uint64(config.rps) + uint64(config.loadAmplitude),
+			Period:        config.loadPeriod,
+			SpikeDuration: config.loadSpikeDuration,
+			Max:           uint64(config.n),
+		}
+	case ScheduleFile:
+		p = &load.PiecewisePacer{Points: config.loadPoints, Max: uint64(config.n)}
+	case ScheduleSearch:
+		p = load.NewAdaptivePacer(uint64(config.loadStart))
 	default:
 		p = &load.ConstantPacer{Freq: uint64(config.rps), Max: uint64(config.n)}
 	}
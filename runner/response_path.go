@@ -0,0 +1,211 @@
+package runner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// responsePath is a JSON path like selector of a value within a response message.
+// Fields are selected by their proto or JSON name separated by dots, and repeated
+// field elements are selected by index, for example "$.session.token" or "items[0].id".
+// Negative indexes select from the end of the list. Map values are selected by key, for example "labels.env".
+type responsePath struct {
+	expr     string
+	segments []pathSegment
+}
+
+type pathSegment struct {
+	name    string
+	indexes []int
+}
+
+var pathSegmentRegexp = regexp.MustCompile(`^([^\[\]]*)((?:\[-?\d+\])*)$`)
+
+var pathIndexRegexp = regexp.MustCompile(`\[(-?\d+)\]`)
+
+func parseResponsePath(expr string) (*responsePath, error) {
+	p := strings.TrimSpace(expr)
+	p = strings.TrimPrefix(p, "$")
+	p = strings.TrimPrefix(p, ".")
+
+	if p == "" {
+		return nil, fmt.Errorf("invalid path %q: empty path", expr)
+	}
+
+	rp := &responsePath{expr: strings.TrimSpace(expr)}
+
+	for _, s := range strings.Split(p, ".") {
+		m := pathSegmentRegexp.FindStringSubmatch(s)
+		if m == nil || (m[1] == "" && m[2] == "") {
+			return nil, fmt.Errorf("invalid path %q: invalid segment %q", expr, s)
+		}
+
+		seg := pathSegment{name: m[1]}
+		for _, im := range pathIndexRegexp.FindAllStringSubmatch(m[2], -1) {
+			i, err := strconv.Atoi(im[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %v", expr, err)
+			}
+
+			seg.indexes = append(seg.indexes, i)
+		}
+
+		rp.segments = append(rp.segments, seg)
+	}
+
+	return rp, nil
+}
+
+func (p *responsePath) String() string {
+	return p.expr
+}
+
+// eval returns the value selected by the path in the message along with the
+// descriptor of the field holding the value. The descriptor is nil for the message itself.
+func (p *responsePath) eval(msg *dynamic.Message) (interface{}, *desc.FieldDescriptor, error) {
+	if msg == nil {
+		return nil, nil, fmt.Errorf("path %q: no response", p.expr)
+	}
+
+	var cur interface{} = msg
+	var fd *desc.FieldDescriptor
+
+	for _, seg := range p.segments {
+		if seg.name != "" {
+			switch v := cur.(type) {
+			case *dynamic.Message:
+				f := findField(v.GetMessageDescriptor(), seg.name)
+				if f == nil {
+					return nil, nil, fmt.Errorf("path %q: unknown field %q of %s",
+						p.expr, seg.name, v.GetMessageDescriptor().GetFullyQualifiedName())
+				}
+
+				val, err := v.TryGetField(f)
+				if err != nil {
+					return nil, nil, fmt.Errorf("path %q: %v", p.expr, err)
+				}
+
+				cur, fd = val, f
+			case map[interface{}]interface{}:
+				found := false
+				for k, val := range v {
+					if fmt.Sprint(k) == seg.name {
+						cur, found = val, true
+						break
+					}
+				}
+
+				if !found {
+					return nil, nil, fmt.Errorf("path %q: key %q not found", p.expr, seg.name)
+				}
+
+				fd = fd.GetMapValueType()
+			default:
+				return nil, nil, fmt.Errorf("path %q: cannot select %q of a non message value", p.expr, seg.name)
+			}
+		}
+
+		for _, i := range seg.indexes {
+			list, ok := cur.([]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("path %q: cannot index a non repeated value", p.expr)
+			}
+
+			if i < 0 {
+				i += len(list)
+			}
+
+			if i < 0 || i >= len(list) {
+				return nil, nil, fmt.Errorf("path %q: index out of range with length %d", p.expr, len(list))
+			}
+
+			cur = list[i]
+		}
+
+		if cur == nil {
+			return nil, nil, fmt.Errorf("path %q: %q not set", p.expr, seg.name)
+		}
+	}
+
+	return cur, fd, nil
+}
+
+// value returns the value selected by the path converted to its JSON representation,
+// suitable for use in call templates.
+func (p *responsePath) value(msg *dynamic.Message) (interface{}, error) {
+	v, fd, err := p.eval(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonValue(v, fd)
+}
+
+func findField(md *desc.MessageDescriptor, name string) *desc.FieldDescriptor {
+	if f := md.FindFieldByName(name); f != nil {
+		return f
+	}
+
+	return md.FindFieldByJSONName(name)
+}
+
+var jsonValueMarshaler = &jsonpb.Marshaler{OrigName: true}
+
+// jsonValue converts the field value to the value of its JSON representation
+func jsonValue(v interface{}, fd *desc.FieldDescriptor) (interface{}, error) {
+	switch val := v.(type) {
+	case *dynamic.Message:
+		str, err := val.MarshalJSONPB(jsonValueMarshaler)
+		if err != nil {
+			return nil, err
+		}
+
+		var res interface{}
+		err = json.Unmarshal(str, &res)
+		return res, err
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, e := range val {
+			ev, err := jsonValue(e, fd)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = ev
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		var vfd *desc.FieldDescriptor
+		if fd != nil {
+			vfd = fd.GetMapValueType()
+		}
+
+		res := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			ev, err := jsonValue(e, vfd)
+			if err != nil {
+				return nil, err
+			}
+			res[fmt.Sprint(k)] = ev
+		}
+		return res, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(val), nil
+	case int32:
+		if fd != nil && fd.GetEnumType() != nil {
+			if ev := fd.GetEnumType().FindValueByNumber(val); ev != nil {
+				return ev.GetName(), nil
+			}
+		}
+		return val, nil
+	}
+
+	return v, nil
+}
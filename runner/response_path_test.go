@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/bojand/ghz/protodesc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSession(t *testing.T) *dynamic.Message {
+	t.Helper()
+
+	mtd, err := protodesc.GetMethodDescFromProto("shop.Shop.CreateSession", "../testdata/shop.proto", []string{})
+	assert.NoError(t, err)
+
+	msg := dynamic.NewMessage(mtd.GetOutputType())
+	err = msg.UnmarshalJSON([]byte(`{
+		"token": "abc123",
+		"user": {"name": "bob", "userId": "u1"},
+		"items": [{"id": "i1", "quantity": 2}, {"id": "i2", "quantity": 5}],
+		"labels": {"env": "test"},
+		"status": "ACTIVE",
+		"data": "aGVsbG8=",
+		"expires": "1700000000",
+		"tags": ["a", "b"]
+	}`))
+	assert.NoError(t, err)
+
+	return msg
+}
+
+func TestParseResponsePath(t *testing.T) {
+	var tests = []struct {
+		in       string
+		expected []pathSegment
+		err      string
+	}{
+		{"token", []pathSegment{{name: "token"}}, ""},
+		{"$.user.name", []pathSegment{{name: "user"}, {name: "name"}}, ""},
+		{".items[1].id", []pathSegment{{name: "items", indexes: []int{1}}, {name: "id"}}, ""},
+		{"items[-1]", []pathSegment{{name: "items", indexes: []int{-1}}}, ""},
+		{"$", nil, `invalid path "$": empty path`},
+		{"user..name", nil, `invalid path "user..name": invalid segment ""`},
+		{"items[a]", nil, `invalid path "items[a]": invalid segment "items[a]"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			p, err := parseResponsePath(tt.in)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, p.segments)
+			}
+		})
+	}
+}
+
+func TestResponsePath_value(t *testing.T) {
+	msg := newTestSession(t)
+
+	var tests = []struct {
+		path     string
+		expected interface{}
+		err      string
+	}{
+		{"token", "abc123", ""},
+		{"$.user.name", "bob", ""},
+		{"user.userId", "u1", ""},
+		{"user.user_id", "u1", ""},
+		{"user", map[string]interface{}{"name": "bob", "user_id": "u1"}, ""},
+		{"items[1].quantity", int32(5), ""},
+		{"items[-1].id", "i2", ""},
+		{"labels.env", "test", ""},
+		{"status", "ACTIVE", ""},
+		{"data", "aGVsbG8=", ""},
+		{"expires", int64(1700000000), ""},
+		{"tags", []interface{}{"a", "b"}, ""},
+		{"tags[0]", "a", ""},
+		{"items[2]", nil, `path "items[2]": index out of range with length 2`},
+		{"foo", nil, `path "foo": unknown field "foo" of shop.Session`},
+		{"labels.foo", nil, `path "labels.foo": key "foo" not found`},
+		{"token.foo", nil, `path "token.foo": cannot select "foo" of a non message value`},
+		{"token[0]", nil, `path "token[0]": cannot index a non repeated value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := parseResponsePath(tt.path)
+			assert.NoError(t, err)
+
+			v, err := p.value(msg)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, v)
+			}
+		})
+	}
+
+	t.Run("no response", func(t *testing.T) {
+		p, _ := parseResponsePath("token")
+		_, err := p.value(nil)
+		assert.EqualError(t, err, `path "token": no response`)
+	})
+}
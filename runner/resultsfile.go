@@ -0,0 +1,477 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The results file starts with the magic bytes and the format version, followed by the
+// records. Each record is its uvarint length followed by the record type and data.
+//
+// A result record holds the varint timestamp in Unix nanoseconds, the uvarint latency in
+// nanoseconds, the status, error, worker ID and call strings and the uvarint request and
+// response sizes. Each string is a uvarint reference to a previously seen string, or 0 followed
+// by the uvarint length and bytes of a new string. The report record holds the JSON of the
+// final report without the details.
+const (
+	resultsFileMagic   = "GHZR"
+	resultsFileVersion = 1
+
+	resultsRecordResult = 1
+	resultsRecordReport = 2
+
+	// resultsFileMaxStrings is the most strings referenced, further strings are always written in full
+	resultsFileMaxStrings = 4096
+
+	// resultsFileFlushInterval is the most time the results are buffered before being written
+	resultsFileFlushInterval = time.Second
+
+	// resultsFileMaxRecord is the largest record read, guarding against corrupt lengths
+	resultsFileMaxRecord = 64 << 20
+)
+
+// ResultsFileWriter is a ResultSink appending each call result to a compact binary results file,
+// followed by the final report. The report of the run can be regenerated from the results file
+// using ReplayResults.
+type ResultsFileWriter struct {
+	w         *bufio.Writer
+	strings   map[string]uint64
+	buf       []byte
+	rec       []byte
+	header    bool
+	lastFlush time.Time
+}
+
+// NewResultsFileWriter creates a new ResultsFileWriter writing to w
+//
+//	f, err := os.Create("results.bin")
+//	...
+//	defer f.Close()
+//
+//	WithResultSink(runner.NewResultsFileWriter(f))
+func NewResultsFileWriter(w io.Writer) *ResultsFileWriter {
+	return &ResultsFileWriter{
+		w:         bufio.NewWriterSize(w, 64*1024),
+		strings:   make(map[string]uint64),
+		lastFlush: time.Now(),
+	}
+}
+
+// Result appends the result of the call to the results file
+func (w *ResultsFileWriter) Result(res *CallResult) error {
+	rec := append(w.rec[:0], resultsRecordResult)
+	rec = binary.AppendVarint(rec, res.Timestamp.UnixNano())
+	rec = binary.AppendUvarint(rec, uint64(res.Latency))
+	rec = w.appendString(rec, res.Status)
+	rec = w.appendString(rec, res.Error)
+	rec = w.appendString(rec, res.WorkerID)
+	rec = w.appendString(rec, res.Call)
+	rec = binary.AppendUvarint(rec, uint64(res.RequestSize))
+	rec = binary.AppendUvarint(rec, uint64(res.ResponseSize))
+	w.rec = rec
+
+	if err := w.writeRecord(rec); err != nil {
+		return err
+	}
+
+	if time.Since(w.lastFlush) >= resultsFileFlushInterval {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Report appends the final report to the results file and flushes it
+func (w *ResultsFileWriter) Report(report *Report) error {
+	rep := *report
+	rep.Details = nil
+
+	data, err := json.Marshal(&rep)
+	if err != nil {
+		return err
+	}
+
+	if err := w.writeRecord(append([]byte{resultsRecordReport}, data...)); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// Flush writes the buffered records
+func (w *ResultsFileWriter) Flush() error {
+	w.lastFlush = time.Now()
+
+	return w.w.Flush()
+}
+
+func (w *ResultsFileWriter) writeRecord(rec []byte) error {
+	if !w.header {
+		if _, err := w.w.WriteString(resultsFileMagic); err != nil {
+			return err
+		}
+
+		if err := w.w.WriteByte(resultsFileVersion); err != nil {
+			return err
+		}
+
+		w.header = true
+	}
+
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(len(rec)))
+	if _, err := w.w.Write(w.buf); err != nil {
+		return err
+	}
+
+	_, err := w.w.Write(rec)
+
+	return err
+}
+
+func (w *ResultsFileWriter) appendString(b []byte, s string) []byte {
+	if ref, ok := w.strings[s]; ok {
+		return binary.AppendUvarint(b, ref)
+	}
+
+	if len(w.strings) < resultsFileMaxStrings {
+		w.strings[s] = uint64(len(w.strings) + 1)
+	}
+
+	b = binary.AppendUvarint(b, 0)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+
+	return append(b, s...)
+}
+
+// ResultsFileReader reads the call results of a results file written by a ResultsFileWriter
+type ResultsFileReader struct {
+	r       *bufio.Reader
+	strings []string
+	report  *Report
+	rec     []byte
+}
+
+// NewResultsFileReader creates a new ResultsFileReader reading from r
+func NewResultsFileReader(r io.Reader) (*ResultsFileReader, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	header := make([]byte, len(resultsFileMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(resultsFileMagic)]) != resultsFileMagic {
+		return nil, errors.New("invalid results file")
+	}
+
+	if v := header[len(resultsFileMagic)]; v != resultsFileVersion {
+		return nil, fmt.Errorf("unsupported results file version %d", v)
+	}
+
+	return &ResultsFileReader{r: br}, nil
+}
+
+// Next returns the next call result, or io.EOF at the end of the results. A record cut
+// short at the end of the file, as left by an interrupted run, is treated as the end.
+func (rr *ResultsFileReader) Next() (*CallResult, error) {
+	for {
+		rec, err := rr.next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch rec[0] {
+		case resultsRecordResult:
+			res, err := rr.decodeResult(rec[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid results file: %w", err)
+			}
+
+			return res, nil
+		case resultsRecordReport:
+			report := &Report{}
+			if err := json.Unmarshal(rec[1:], report); err != nil {
+				return nil, fmt.Errorf("invalid results file report: %w", err)
+			}
+
+			rr.report = report
+		}
+	}
+}
+
+// Report returns the final report of the run once read, or nil if the results file has no report
+func (rr *ResultsFileReader) Report() *Report {
+	return rr.report
+}
+
+func (rr *ResultsFileReader) next() ([]byte, error) {
+	n, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, io.EOF
+		}
+
+		return nil, err
+	}
+
+	if n == 0 || n > resultsFileMaxRecord {
+		return nil, fmt.Errorf("invalid results file: record length %d", n)
+	}
+
+	if uint64(cap(rr.rec)) < n {
+		rr.rec = make([]byte, n)
+	}
+
+	rec := rr.rec[:n]
+	if _, err := io.ReadFull(rr.r, rec); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, io.EOF
+		}
+
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+func (rr *ResultsFileReader) decodeResult(b []byte) (*CallResult, error) {
+	d := &resultsDecoder{b: b, strings: rr.strings}
+
+	res := &CallResult{
+		Timestamp: time.Unix(0, d.varint()),
+		Latency:   time.Duration(d.uvarint()),
+	}
+
+	res.Status = d.string()
+	res.Error = d.string()
+	res.WorkerID = d.string()
+	res.Call = d.string()
+	res.RequestSize = int64(d.uvarint())
+	res.ResponseSize = int64(d.uvarint())
+
+	rr.strings = d.strings
+
+	return res, d.err
+}
+
+// resultsDecoder decodes the fields of a result record, keeping the first error
+type resultsDecoder struct {
+	b       []byte
+	strings []string
+	err     error
+}
+
+func (d *resultsDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = errors.New("invalid uvarint")
+		return 0
+	}
+
+	d.b = d.b[n:]
+
+	return v
+}
+
+func (d *resultsDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errors.New("invalid varint")
+		return 0
+	}
+
+	d.b = d.b[n:]
+
+	return v
+}
+
+func (d *resultsDecoder) string() string {
+	ref := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+
+	if ref > 0 {
+		if ref > uint64(len(d.strings)) {
+			d.err = fmt.Errorf("invalid string reference %d", ref)
+			return ""
+		}
+
+		return d.strings[ref-1]
+	}
+
+	n := d.uvarint()
+	if d.err == nil && n > uint64(len(d.b)) {
+		d.err = errors.New("invalid string length")
+	}
+
+	if d.err != nil {
+		return ""
+	}
+
+	s := string(d.b[:n])
+	d.b = d.b[n:]
+
+	if len(d.strings) < resultsFileMaxStrings {
+		d.strings = append(d.strings, s)
+	}
+
+	return s
+}
+
+// ReplayResults regenerates the report of a run from the results file written by a ResultsFileWriter.
+// Only the results of the calls ending within the from and to times are included, a zero time
+// does not limit the range. The options, name and tags of the report are those of the final
+// report in the results file. The per call breakdown of scenarios, the corrected latencies and
+// the validation failures and the streaming stats are not regenerated for a limited time range.
+//
+//	f, err := os.Open("results.bin")
+//	...
+//	report, err := runner.ReplayResults(f, time.Time{}, time.Time{})
+func ReplayResults(rs io.ReadSeeker, from, to time.Time) (*Report, error) {
+	// the final report is at the end of the results file, read it first for the run options
+	rr, err := NewResultsFileReader(rs)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	for {
+		rec, err := rr.next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if rec[0] == resultsRecordResult {
+			count++
+		}
+
+		if rec[0] == resultsRecordReport {
+			rr.report = &Report{}
+			if err := json.Unmarshal(rec[1:], rr.report); err != nil {
+				return nil, fmt.Errorf("invalid results file report: %w", err)
+			}
+		}
+	}
+
+	stored := rr.report
+
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	rr, err = NewResultsFileReader(rs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &RunConfig{
+		histogramPrecision: 3,
+		detailsSampleSize:  maxResult,
+		n:                  count,
+	}
+
+	if stored != nil {
+		c.call = stored.Options.Call
+		c.countErrors = stored.Options.CountErrors
+
+		if stored.Options.HistogramPrecision > 0 {
+			c.histogramPrecision = stored.Options.HistogramPrecision
+		}
+
+		if stored.Options.DetailsSampleSize > 0 {
+			c.detailsSampleSize = stored.Options.DetailsSampleSize
+		}
+	}
+
+	reporter := newReporter(nil, c)
+
+	var first, last time.Time
+	for {
+		res, err := rr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if (!from.IsZero() && res.Timestamp.Before(from)) || (!to.IsZero() && res.Timestamp.After(to)) {
+			continue
+		}
+
+		if start := res.Timestamp.Add(-res.Latency); first.IsZero() || start.Before(first) {
+			first = start
+		}
+
+		if last.IsZero() || res.Timestamp.After(last) {
+			last = res.Timestamp
+		}
+
+		if c.call == "" {
+			c.call = res.Call
+		}
+
+		cr := &callResult{
+			status:    res.Status,
+			duration:  res.Latency,
+			corrected: res.Latency,
+			timestamp: res.Timestamp,
+			workerID:  res.WorkerID,
+			reqSize:   res.RequestSize,
+			respSize:  res.ResponseSize,
+		}
+
+		if res.Call != c.call {
+			cr.call = res.Call
+		}
+
+		if res.Error != "" {
+			cr.err = errors.New(res.Error)
+		}
+
+		reporter.record(cr)
+	}
+
+	ranged := !from.IsZero() || !to.IsZero()
+
+	total := last.Sub(first)
+	if stored != nil && !ranged {
+		total = stored.Total
+	}
+
+	report := reporter.Finalize(ReasonNormalEnd, total)
+	report.Date = last
+
+	if stored != nil {
+		report.Name = stored.Name
+		report.Options = stored.Options
+		report.Tags = stored.Tags
+		report.Date = stored.Date
+		report.EndReason = stored.EndReason
+
+		if !ranged {
+			report.ValidationFailures = stored.ValidationFailures
+			report.Corrected = stored.Corrected
+			report.Calls = stored.Calls
+			report.Journey = stored.Journey
+			report.Streaming = stored.Streaming
+		}
+	}
+
+	return report, nil
+}
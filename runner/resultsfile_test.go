@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+)
+
+func TestResultsFile(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+
+	results := []*CallResult{
+		{Timestamp: start.Add(time.Second), Latency: 10 * time.Millisecond, Status: "OK", WorkerID: "g0c0w0", Call: "helloworld.Greeter.SayHello", RequestSize: 10, ResponseSize: 20},
+		{Timestamp: start.Add(2 * time.Second), Latency: 20 * time.Millisecond, Status: "OK", WorkerID: "g0c0w1", Call: "helloworld.Greeter.SayHello", RequestSize: 10, ResponseSize: 20},
+		{Timestamp: start.Add(3 * time.Second), Latency: 30 * time.Millisecond, Status: "Unavailable", Error: "rpc error: code = Unavailable desc = down", WorkerID: "g0c0w0", Call: "helloworld.Greeter.SayHello"},
+		{Timestamp: start.Add(4 * time.Second), Latency: 40 * time.Millisecond, Status: "OK", WorkerID: "g0c0w1", Call: "helloworld.Greeter.SayHello", RequestSize: 12, ResponseSize: 24},
+	}
+
+	buf := &bytes.Buffer{}
+	w := NewResultsFileWriter(buf)
+	for _, res := range results {
+		assert.NoError(t, w.Result(res))
+	}
+
+	assert.NoError(t, w.Report(&Report{
+		Name:    "run",
+		Date:    start.Add(5 * time.Second),
+		Total:   5 * time.Second,
+		Options: Options{Call: "helloworld.Greeter.SayHello", Host: "localhost:50051"},
+		Tags:    map[string]string{"env": "staging"},
+		Details: []ResultDetail{{Status: "OK"}},
+	}))
+
+	data := buf.Bytes()
+
+	t.Run("read", func(t *testing.T) {
+		rr, err := NewResultsFileReader(bytes.NewReader(data))
+		assert.NoError(t, err)
+
+		var read []*CallResult
+		for {
+			res, err := rr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			assert.NoError(t, err)
+			read = append(read, res)
+		}
+
+		assert.Len(t, read, len(results))
+		for i, res := range read {
+			assert.True(t, results[i].Timestamp.Equal(res.Timestamp))
+			res.Timestamp = results[i].Timestamp
+			assert.Equal(t, results[i], res)
+		}
+
+		assert.NotNil(t, rr.Report())
+		assert.Equal(t, "run", rr.Report().Name)
+		assert.Empty(t, rr.Report().Details)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		rr, err := NewResultsFileReader(bytes.NewReader(data[:len(data)-3]))
+		assert.NoError(t, err)
+
+		var n int
+		for {
+			_, err := rr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			assert.NoError(t, err)
+			n++
+		}
+
+		assert.Equal(t, len(results), n)
+		assert.Nil(t, rr.Report())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewResultsFileReader(bytes.NewReader([]byte(`{"count":10}`)))
+		assert.EqualError(t, err, "invalid results file")
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		report, err := ReplayResults(bytes.NewReader(data), time.Time{}, time.Time{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "run", report.Name)
+		assert.Equal(t, map[string]string{"env": "staging"}, report.Tags)
+		assert.Equal(t, "localhost:50051", report.Options.Host)
+		assert.Equal(t, uint64(4), report.Count)
+		assert.Equal(t, 5*time.Second, report.Total)
+		assert.Equal(t, 0.8, report.Rps)
+		assert.Equal(t, 25*time.Millisecond, report.Average)
+		assert.Equal(t, 10*time.Millisecond, report.Fastest)
+		assert.Equal(t, 40*time.Millisecond, report.Slowest)
+		assert.Equal(t, map[string]int{"OK": 3, "Unavailable": 1}, report.StatusCodeDist)
+		assert.Equal(t, map[string]int{"rpc error: code = Unavailable desc = down": 1}, report.ErrorDist)
+		assert.Len(t, report.Details, 4)
+	})
+
+	t.Run("replay time range", func(t *testing.T) {
+		report, err := ReplayResults(bytes.NewReader(data), start.Add(2*time.Second), start.Add(3*time.Second))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "run", report.Name)
+		assert.Equal(t, uint64(2), report.Count)
+		assert.Equal(t, time.Second+20*time.Millisecond, report.Total)
+		assert.Equal(t, map[string]int{"OK": 1, "Unavailable": 1}, report.StatusCodeDist)
+		assert.Len(t, report.Details, 2)
+	})
+}
+
+func TestRunResultsFile(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	buf := &bytes.Buffer{}
+
+	report, err := Run(
+		"helloworld.Greeter.SayHello",
+		internal.TestLocalhost,
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithTotalRequests(20),
+		WithConcurrency(2),
+		WithName("results file"),
+		WithResultSink(NewResultsFileWriter(buf)),
+		WithData(map[string]interface{}{"name": "bob"}),
+		WithInsecure(true),
+	)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, report)
+
+	replayed, err := ReplayResults(bytes.NewReader(buf.Bytes()), time.Time{}, time.Time{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, report.Name, replayed.Name)
+	assert.Equal(t, report.Options.Call, replayed.Options.Call)
+	assert.Equal(t, report.Count, replayed.Count)
+	assert.Equal(t, report.Total, replayed.Total)
+	assert.Equal(t, report.Fastest, replayed.Fastest)
+	assert.Equal(t, report.Slowest, replayed.Slowest)
+	assert.Equal(t, report.StatusCodeDist, replayed.StatusCodeDist)
+	assert.Equal(t, report.LatencyDistribution, replayed.LatencyDistribution)
+	assert.Len(t, replayed.Details, 20)
+}
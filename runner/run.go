@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"runtime"
@@ -17,6 +18,21 @@ import (
 //		WithInsecure(true),
 //	)
 func Run(call, host string, options ...Option) (*Report, error) {
+	return RunContext(context.Background(), call, host, options...)
+}
+
+// RunContext executes the test like Run. The test is stopped with ReasonCancel when the context is done.
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//
+//	report, err := runner.RunContext(ctx,
+//		"helloworld.Greeter.SayHello",
+//		"localhost:50051",
+//		WithProtoFile("greeter.proto", []string{}),
+//		WithInsecure(true),
+//	)
+func RunContext(ctx context.Context, call, host string, options ...Option) (*Report, error) {
 	c, err := NewConfig(call, host, options...)
 
 	if err != nil {
@@ -49,6 +65,19 @@ func Run(call, host string, options ...Option) (*Report, error) {
 		}()
 	}
 
+	if ctx.Done() != nil {
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				reqr.Stop(ReasonCancel)
+			case <-done:
+			}
+		}()
+	}
+
 	rep, err := reqr.Run()
 
 	return rep, err
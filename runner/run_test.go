@@ -10,6 +10,7 @@ import (
 
 	"github.com/bojand/ghz/internal"
 	"github.com/bojand/ghz/internal/helloworld"
+	"github.com/bojand/ghz/load"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/uuid"
@@ -527,6 +528,133 @@ func TestRunUnary(t *testing.T) {
 		assert.NotEqual(t, report.Slowest, report.Fastest)
 	})
 
+	t.Run("test load schedule file", func(t *testing.T) {
+
+		gs.ResetCounters()
+
+		data := make(map[string]interface{})
+		data["name"] = "bob"
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(1000),
+			WithConcurrency(5),
+			WithLoadSchedule(ScheduleFile),
+			WithLoadScheduleFile("../testdata/load_schedule.csv"),
+			WithTimeout(time.Duration(20*time.Second)),
+			WithDialTimeout(time.Duration(20*time.Second)),
+			WithData(data),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+
+		assert.NotNil(t, report)
+
+		// the schedule has 110 hits over 4s and ends at the last point
+		assert.InDelta(t, 110, int(report.Count), 2)
+		assert.Equal(t, int(report.Count), gs.GetCount(callType))
+		assert.Equal(t, ReasonNormalEnd, report.EndReason)
+		assert.Equal(t, ScheduleFile, report.Options.LoadSchedule)
+		assert.Equal(t, "../testdata/load_schedule.csv", report.Options.LoadScheduleFile)
+		assert.InDelta(t, 4*time.Second, report.Total, float64(500*time.Millisecond))
+	})
+
+	t.Run("test corrected latencies", func(t *testing.T) {
+
+		gs.ResetCounters()
+
+		data := make(map[string]interface{})
+		data["name"] = "bob"
+
+		// a single worker cannot keep up with the rate so the calls fall behind the schedule
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(200),
+			WithConcurrency(1),
+			WithRPS(2000),
+			WithTimeout(time.Duration(20*time.Second)),
+			WithDialTimeout(time.Duration(20*time.Second)),
+			WithData(data),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, 200, int(report.Count))
+
+		assert.NotNil(t, report.Corrected)
+		assert.Len(t, report.Corrected.LatencyDistribution, len(report.LatencyDistribution))
+		assert.NotEmpty(t, report.Corrected.Histogram)
+		assert.True(t, report.Corrected.Fastest >= report.Fastest)
+		assert.True(t, report.Corrected.Slowest > report.Slowest)
+		assert.True(t, report.Corrected.Average > report.Average)
+
+		// the step schedule falls behind the same way
+		report, err = Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(200),
+			WithConcurrency(1),
+			WithLoadSchedule(ScheduleStep),
+			WithLoadStart(1000),
+			WithLoadStep(1000),
+			WithLoadStepDuration(50*time.Millisecond),
+			WithTimeout(time.Duration(20*time.Second)),
+			WithDialTimeout(time.Duration(20*time.Second)),
+			WithData(data),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, 200, int(report.Count))
+
+		assert.NotNil(t, report.Corrected)
+		assert.True(t, report.Corrected.Slowest > report.Slowest)
+		assert.True(t, report.Corrected.Average > report.Average)
+		for i, ld := range report.Corrected.LatencyDistribution {
+			assert.True(t, ld.Latency >= report.LatencyDistribution[i].Latency)
+		}
+
+		// a pacer without a schedule is not corrected
+		report, err = Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithRunDuration(200*time.Millisecond),
+			WithConcurrency(1),
+			WithPacer(load.NewAdaptivePacer(100)),
+			WithData(data),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Nil(t, report.Corrected)
+
+		report, err = Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(10),
+			WithConcurrency(1),
+			WithData(data),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		// no correction without a rate limit
+		assert.Nil(t, report.Corrected)
+	})
+
 	t.Run("test binary", func(t *testing.T) {
 		gs.ResetCounters()
 
@@ -3416,3 +3544,352 @@ func TestRunGtimeUnary(t *testing.T) {
 		assert.Equal(t, expectedDur, ts.LastDuration)
 	})
 }
+
+func TestRunScenario(t *testing.T) {
+	gs, s, err := internal.StartServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("weighted calls", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithScenarioFromFile("../testdata/scenario.yaml"),
+			WithTotalRequests(12),
+			WithConcurrency(2),
+			WithTimeout(time.Duration(20*time.Second)),
+			WithDialTimeout(time.Duration(20*time.Second)),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		assert.Equal(t, 12, int(report.Count))
+		assert.NotZero(t, report.Average)
+		assert.Empty(t, report.ErrorDist)
+		assert.Equal(t, map[string]int{"OK": 12}, report.StatusCodeDist)
+
+		assert.Len(t, report.Calls, 2)
+
+		hello := report.Calls[0]
+		assert.Equal(t, "hello", hello.Name)
+		assert.Equal(t, "helloworld.Greeter.SayHello", hello.Call)
+		assert.Equal(t, 9, int(hello.Count))
+		assert.Equal(t, map[string]int{"OK": 9}, hello.StatusCodeDist)
+		assert.NotEmpty(t, hello.LatencyDistribution)
+
+		hellos := report.Calls[1]
+		assert.Equal(t, "hellos", hellos.Name)
+		assert.Equal(t, "helloworld.Greeter.SayHellos", hellos.Call)
+		assert.Equal(t, 3, int(hellos.Count))
+		assert.Equal(t, map[string]int{"OK": 3}, hellos.StatusCodeDist)
+
+		assert.Equal(t, 9, gs.GetCount(helloworld.Unary))
+		assert.Equal(t, 3, gs.GetCount(helloworld.ServerStream))
+
+		for _, calls := range gs.GetCalls(helloworld.Unary) {
+			assert.True(t, strings.HasPrefix(calls[0].GetName(), "bob "))
+		}
+
+		for _, calls := range gs.GetCalls(helloworld.ServerStream) {
+			assert.Equal(t, "alice", calls[0].GetName())
+		}
+
+		for _, d := range report.Details {
+			assert.NotEmpty(t, d.Call)
+		}
+
+		assert.Equal(t, 1, gs.GetConnectionCount())
+	})
+
+	t.Run("unknown call", func(t *testing.T) {
+		report, err := Run(
+			"",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithScenario(&Scenario{Calls: []ScenarioCall{
+				{Call: "helloworld.Greeter.SayHello"},
+				{Call: "helloworld.Greeter.SayHelloAsdf"},
+			}}),
+			WithTotalRequests(2),
+			WithConcurrency(1),
+			WithInsecure(true),
+		)
+
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+}
+
+func TestRunScenarioJourney(t *testing.T) {
+	gs, s, err := internal.StartServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("chained steps", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithScenarioFromFile("../testdata/scenario_journey.yaml"),
+			WithTotalRequests(4),
+			WithConcurrency(2),
+			WithTimeout(time.Duration(20*time.Second)),
+			WithDialTimeout(time.Duration(20*time.Second)),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		// every journey makes all the steps
+		assert.Equal(t, 12, int(report.Count))
+		assert.Empty(t, report.ErrorDist)
+
+		assert.Len(t, report.Calls, 3)
+		for i, name := range []string{"greet", "token", "echo"} {
+			assert.Equal(t, name, report.Calls[i].Name)
+			assert.Equal(t, 4, int(report.Calls[i].Count))
+			assert.Equal(t, map[string]int{"OK": 4}, report.Calls[i].StatusCodeDist)
+			assert.Zero(t, report.Calls[i].Weight)
+		}
+
+		assert.NotNil(t, report.Journey)
+		assert.Equal(t, 4, int(report.Journey.Count))
+		assert.Equal(t, map[string]int{"OK": 4}, report.Journey.StatusCodeDist)
+		assert.Empty(t, report.Journey.ErrorDist)
+		assert.NotEmpty(t, report.Journey.LatencyDistribution)
+		assert.True(t, report.Journey.Fastest >= report.Calls[0].Fastest)
+
+		assert.Equal(t, 8, gs.GetCount(helloworld.Unary))
+		assert.Equal(t, 4, gs.GetCount(helloworld.ServerStream))
+
+		tokens := make(map[string]bool)
+		for _, calls := range gs.GetCalls(helloworld.Unary) {
+			name := calls[0].GetName()
+			if strings.HasPrefix(name, "__record_metadata__") {
+				tokens[name] = true
+			}
+		}
+
+		assert.Equal(t, map[string]bool{
+			"__record_metadata__||token:Hello bob 0": true,
+			"__record_metadata__||token:Hello bob 1": true,
+			"__record_metadata__||token:Hello bob 2": true,
+			"__record_metadata__||token:Hello bob 3": true,
+		}, tokens)
+
+		echoes := make(map[string]bool)
+		for _, calls := range gs.GetCalls(helloworld.ServerStream) {
+			echoes[calls[0].GetName()] = true
+		}
+
+		assert.Len(t, echoes, 4)
+		assert.True(t, echoes["Hello bob 0 again"])
+	})
+
+	t.Run("skip first journeys", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithScenarioFromFile("../testdata/scenario_journey.yaml"),
+			WithTotalRequests(4),
+			WithConcurrency(2),
+			WithSkipFirst(1),
+			WithTimeout(time.Duration(20*time.Second)),
+			WithDialTimeout(time.Duration(20*time.Second)),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		// the skipped journey is skipped along with the calls of its steps
+		assert.Equal(t, 9, int(report.Count))
+		for i := range report.Calls {
+			assert.Equal(t, 3, int(report.Calls[i].Count))
+		}
+
+		assert.Equal(t, 3, int(report.Journey.Count))
+
+		assert.Equal(t, 8, gs.GetCount(helloworld.Unary))
+		assert.Equal(t, 4, gs.GetCount(helloworld.ServerStream))
+	})
+
+	t.Run("failed extraction", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithScenario(&Scenario{Steps: []ScenarioCall{
+				{
+					Name:    "greet",
+					Call:    "helloworld.Greeter.SayHello",
+					Data:    map[string]interface{}{"name": "bob"},
+					Extract: map[string]string{"greeting": "greeting"},
+				},
+				{
+					Name: "greet again",
+					Call: "helloworld.Greeter.SayHello",
+					Data: map[string]interface{}{"name": "{{.Vars.greeting}}"},
+				},
+			}}),
+			WithTotalRequests(3),
+			WithConcurrency(1),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		assert.Equal(t, 3, int(report.Count))
+		assert.Equal(t, 3, int(report.Calls[0].Count))
+		assert.Equal(t, 0, int(report.Calls[1].Count))
+
+		assert.Equal(t, 3, int(report.Journey.Count))
+		assert.Equal(t, map[string]int{
+			`greet: extract "greeting": path "greeting": unknown field "greeting" of helloworld.HelloReply`: 3,
+		}, report.Journey.ErrorDist)
+		assert.Empty(t, report.Journey.LatencyDistribution)
+
+		assert.Equal(t, 3, gs.GetCount(helloworld.Unary))
+	})
+}
+
+func TestRunResponseValidation(t *testing.T) {
+	gs, s, err := internal.StartServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("unary passed", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithData(&helloworld.HelloRequest{Name: "bob"}),
+			WithResponseValidations(`message == "Hello bob"`, `len(message) == 9`),
+			WithAssertions("validation-failures == 0"),
+			WithTotalRequests(5),
+			WithConcurrency(1),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		assert.Equal(t, 5, int(report.Count))
+		assert.Empty(t, report.ErrorDist)
+		assert.Zero(t, report.ValidationFailures)
+		assert.True(t, report.Assertions.Passed)
+	})
+
+	t.Run("unary failed", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithData(&helloworld.HelloRequest{Name: "bob"}),
+			WithResponseValidations(`message =~ ^Hello`, `message == "Hello alice"`),
+			WithTotalRequests(5),
+			WithConcurrency(1),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		assert.Equal(t, 5, int(report.Count))
+		assert.Equal(t, map[string]int{"OK": 5}, report.StatusCodeDist)
+		assert.Equal(t, map[string]int{`response validation failed: message == "Hello alice"`: 5}, report.ErrorDist)
+		assert.Equal(t, 5, int(report.ValidationFailures))
+		assert.Empty(t, report.LatencyDistribution)
+	})
+
+	t.Run("server streaming validates each message", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"helloworld.Greeter.SayHellos",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithData(&helloworld.HelloRequest{Name: "bob"}),
+			WithResponseValidations(`message =~ ^Hello`, `message != "Hello Jim"`),
+			WithTotalRequests(3),
+			WithConcurrency(1),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		assert.Equal(t, 3, int(report.Count))
+		assert.Equal(t, map[string]int{`response validation failed: message != "Hello Jim"`: 3}, report.ErrorDist)
+		assert.Equal(t, 3, int(report.ValidationFailures))
+	})
+
+	t.Run("scenario", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithScenario(&Scenario{Steps: []ScenarioCall{
+				{
+					Name:        "greet",
+					Call:        "helloworld.Greeter.SayHello",
+					Data:        map[string]interface{}{"name": "bob"},
+					Validations: []string{`message == "Hello alice"`},
+				},
+				{
+					Name: "greet again",
+					Call: "helloworld.Greeter.SayHello",
+					Data: map[string]interface{}{"name": "bob"},
+				},
+			}}),
+			WithResponseValidations(`message != ""`),
+			WithTotalRequests(2),
+			WithConcurrency(1),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		// the journey stops at the failed validation
+		assert.Equal(t, 2, int(report.Count))
+		assert.Equal(t, 2, int(report.ValidationFailures))
+		assert.Equal(t, 2, int(report.Calls[0].ValidationFailures))
+		assert.Equal(t, 0, int(report.Calls[1].Count))
+
+		assert.Equal(t, 2, int(report.Journey.ValidationFailures))
+		assert.Equal(t, map[string]int{`greet: response validation failed: message == "Hello alice"`: 2}, report.Journey.ErrorDist)
+	})
+}
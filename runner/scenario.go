@@ -0,0 +1,310 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/jinzhu/configor"
+)
+
+// Scenario is a test plan of several calls executed against shared connections within one run.
+// A scenario either has a weighted mix of calls, or an ordered list of steps.
+type Scenario struct {
+	// Calls are the weighted mix of calls. Each request of the run is made to one of the calls.
+	Calls []ScenarioCall `json:"calls" toml:"calls" yaml:"calls"`
+
+	// Steps are executed in order as a user journey by each request of the run.
+	// The data and metadata templates of a step can use the values extracted from
+	// the responses of the previous steps via {{.Vars.<name>}}.
+	Steps []ScenarioCall `json:"steps" toml:"steps" yaml:"steps"`
+}
+
+// ScenarioCall is a single call within a scenario.
+// Each request of the run is made to one of the calls chosen according to the call weights.
+type ScenarioCall struct {
+	// Name is used to identify the call in the report. Defaults to the call.
+	Name string `json:"name" toml:"name" yaml:"name"`
+
+	// Call is a fully-qualified method name in 'package.Service/method' or 'package.Service.Method' format
+	Call string `json:"call" toml:"call" yaml:"call"`
+
+	// Weight is the relative share of requests made to this call. Defaults to 1. Not used by steps.
+	Weight uint `json:"weight" toml:"weight" yaml:"weight"`
+
+	Data         interface{}       `json:"data,omitempty" toml:"data,omitempty" yaml:"data,omitempty"`
+	DataPath     string            `json:"data-file" toml:"data-file" yaml:"data-file"`
+	BinDataPath  string            `json:"binary-file" toml:"binary-file" yaml:"binary-file"`
+	Metadata     map[string]string `json:"metadata,omitempty" toml:"metadata,omitempty" yaml:"metadata,omitempty"`
+	MetadataPath string            `json:"metadata-file" toml:"metadata-file" yaml:"metadata-file"`
+
+	// Extract maps variable names to paths of values in the response of a step,
+	// for example "token": "session.token". Only supported for steps.
+	// For streaming calls the values are extracted from the last message received.
+	Extract map[string]string `json:"extract,omitempty" toml:"extract,omitempty" yaml:"extract,omitempty"`
+
+	// Validations are the response validation rules of the call, in addition to the validations of the run
+	Validations []string `json:"validations,omitempty" toml:"validations,omitempty" yaml:"validations,omitempty"`
+}
+
+// LoadScenario loads the scenario from a JSON, TOML or YAML file
+func LoadScenario(p string, s *Scenario) error {
+	err := configor.Load(s, p)
+	if err != nil {
+		return err
+	}
+
+	entries := s.entries()
+	for i := range entries {
+		sc := &entries[i]
+		if sc.Data != nil {
+			sc.Data, err = normalizeData(p, sc.Data)
+			if err != nil {
+				return fmt.Errorf("call %q: %w", sc.Call, err)
+			}
+		}
+	}
+
+	return s.validate()
+}
+
+// isJourney returns whether the scenario is an ordered list of steps
+func (s *Scenario) isJourney() bool {
+	return len(s.Steps) > 0
+}
+
+// entries returns the steps of a journey or the calls of the scenario
+func (s *Scenario) entries() []ScenarioCall {
+	if s.isJourney() {
+		return s.Steps
+	}
+
+	return s.Calls
+}
+
+func (s *Scenario) validate() error {
+	if len(s.Calls) == 0 && len(s.Steps) == 0 {
+		return errors.New("scenario must have at least one call")
+	}
+
+	if len(s.Calls) > 0 && len(s.Steps) > 0 {
+		return errors.New("scenario cannot have both calls and steps")
+	}
+
+	kind := "call"
+	if s.isJourney() {
+		kind = "step"
+	}
+
+	entries := s.entries()
+	names := make(map[string]bool, len(entries))
+	for i := range entries {
+		sc := &entries[i]
+
+		sc.Call = strings.TrimSpace(sc.Call)
+		if sc.Call == "" {
+			return fmt.Errorf("scenario %s %d: call required", kind, i)
+		}
+
+		sc.Name = strings.TrimSpace(sc.Name)
+		if sc.Name == "" {
+			sc.Name = sc.Call
+		}
+
+		if names[sc.Name] {
+			return fmt.Errorf("scenario %s %d: duplicate name %q", kind, i, sc.Name)
+		}
+		names[sc.Name] = true
+
+		if sc.Weight == 0 && !s.isJourney() {
+			sc.Weight = 1
+		}
+
+		if sc.Data != nil {
+			if err := checkData(sc.Data); err != nil {
+				return fmt.Errorf("scenario %s %q: %w", kind, sc.Name, err)
+			}
+		}
+
+		if len(sc.Extract) > 0 && !s.isJourney() {
+			return fmt.Errorf("scenario call %q: extract is only supported for steps", sc.Name)
+		}
+
+		if _, err := sc.extractors(); err != nil {
+			return fmt.Errorf("scenario step %q: %w", sc.Name, err)
+		}
+
+		if _, err := parseResponseValidations(sc.Validations); err != nil {
+			return fmt.Errorf("scenario %s %q: %w", kind, sc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// extractors returns the parsed extract paths sorted by variable name
+func (sc *ScenarioCall) extractors() ([]*extractor, error) {
+	if len(sc.Extract) == 0 {
+		return nil, nil
+	}
+
+	vars := make([]string, 0, len(sc.Extract))
+	for name := range sc.Extract {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+
+	res := make([]*extractor, 0, len(vars))
+	for _, name := range vars {
+		p, err := parseResponsePath(sc.Extract[name])
+		if err != nil {
+			return nil, fmt.Errorf("extract %q: %w", name, err)
+		}
+
+		res = append(res, &extractor{name: name, path: p})
+	}
+
+	return res, nil
+}
+
+// dataAndMetadata returns the raw JSON data and metadata of the call
+// and whether the data is binary
+func (sc *ScenarioCall) dataAndMetadata() (data []byte, md []byte, binary bool, err error) {
+	switch {
+	case strings.TrimSpace(sc.BinDataPath) != "":
+		data, err = os.ReadFile(strings.TrimSpace(sc.BinDataPath))
+		binary = true
+	case strings.TrimSpace(sc.DataPath) != "":
+		data, err = os.ReadFile(strings.TrimSpace(sc.DataPath))
+	case sc.Data != nil:
+		data, err = json.Marshal(sc.Data)
+	}
+
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	switch {
+	case strings.TrimSpace(sc.MetadataPath) != "":
+		md, err = os.ReadFile(strings.TrimSpace(sc.MetadataPath))
+	case len(sc.Metadata) > 0:
+		md, err = json.Marshal(sc.Metadata)
+	}
+
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return data, md, binary, nil
+}
+
+// callTarget is a resolved call the workers make requests to
+type callTarget struct {
+	name   string
+	mtd    *desc.MethodDescriptor
+	data   []byte
+	weight uint
+
+	dataProvider     DataProviderFunc
+	metadataProvider MetadataProviderFunc
+
+	// values to extract from the response of a journey step
+	extract []*extractor
+
+	// validations of the received response messages
+	validations []*responseValidation
+}
+
+// extractor extracts a variable from the response of a journey step
+type extractor struct {
+	name string
+	path *responsePath
+}
+
+// journeyState is the state of a single journey execution shared by its steps
+type journeyState struct {
+	vars      map[string]interface{}
+	responses map[string]*dynamic.Message
+}
+
+func newJourneyState() *journeyState {
+	return &journeyState{
+		vars:      make(map[string]interface{}),
+		responses: make(map[string]*dynamic.Message),
+	}
+}
+
+// record records the response of the step and the values extracted from it
+func (js *journeyState) record(t *callTarget, res *dynamic.Message) error {
+	if res != nil {
+		js.responses[t.name] = res
+	}
+
+	for _, e := range t.extract {
+		v, err := e.path.value(res)
+		if err != nil {
+			return fmt.Errorf("extract %q: %w", e.name, err)
+		}
+
+		js.vars[e.name] = v
+	}
+
+	return nil
+}
+
+// callSchedule is a deterministic weighted interleaving of call targets
+// produced using smooth weighted round-robin selection
+type callSchedule []*callTarget
+
+func newCallSchedule(targets []*callTarget) callSchedule {
+	if len(targets) == 1 {
+		return callSchedule{targets[0]}
+	}
+
+	g := uint(0)
+	for _, t := range targets {
+		g = gcd(g, t.weight)
+	}
+
+	total := 0
+	weights := make([]int, len(targets))
+	for i, t := range targets {
+		weights[i] = int(t.weight / g)
+		total += weights[i]
+	}
+
+	current := make([]int, len(targets))
+	schedule := make(callSchedule, 0, total)
+	for n := 0; n < total; n++ {
+		best := 0
+		for i := range targets {
+			current[i] += weights[i]
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+
+		current[best] -= total
+		schedule = append(schedule, targets[best])
+	}
+
+	return schedule
+}
+
+// next returns the call target for the request number
+func (s callSchedule) next(reqNum uint64) *callTarget {
+	return s[reqNum%uint64(len(s))]
+}
+
+func gcd(a, b uint) uint {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
@@ -0,0 +1,205 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadScenario(t *testing.T) {
+	expected := []ScenarioCall{
+		{
+			Name:     "hello",
+			Call:     "helloworld.Greeter.SayHello",
+			Weight:   3,
+			Data:     map[string]interface{}{"name": "bob {{.RequestNumber}}"},
+			Metadata: map[string]string{"trace_id": "{{.RequestNumber}}"},
+		},
+		{
+			Name:   "hellos",
+			Call:   "helloworld.Greeter.SayHellos",
+			Weight: 1,
+			Data:   map[string]interface{}{"name": "alice"},
+		},
+	}
+
+	for _, file := range []string{"../testdata/scenario.yaml", "../testdata/scenario.json"} {
+		t.Run(file, func(t *testing.T) {
+			s := &Scenario{}
+			err := LoadScenario(file, s)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, s.Calls)
+		})
+	}
+
+	t.Run("steps", func(t *testing.T) {
+		s := &Scenario{}
+		err := LoadScenario("../testdata/scenario_journey.yaml", s)
+		assert.NoError(t, err)
+		assert.Empty(t, s.Calls)
+		assert.True(t, s.isJourney())
+		assert.Len(t, s.Steps, 3)
+		assert.Equal(t, "greet", s.Steps[0].Name)
+		assert.Equal(t, uint(0), s.Steps[0].Weight)
+		assert.Equal(t, map[string]string{"greeting": "message"}, s.Steps[0].Extract)
+		assert.Equal(t, map[string]string{"token": "{{.Vars.greeting}}"}, s.Steps[1].Metadata)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := LoadScenario("../testdata/scenario_missing.yaml", &Scenario{})
+		assert.Error(t, err)
+	})
+}
+
+func TestScenario_validate(t *testing.T) {
+	var tests = []struct {
+		name     string
+		in       *Scenario
+		expected []ScenarioCall
+		err      string
+	}{
+		{
+			"no calls",
+			&Scenario{},
+			nil,
+			"scenario must have at least one call",
+		},
+		{
+			"missing call",
+			&Scenario{Calls: []ScenarioCall{{Name: "foo"}}},
+			nil,
+			"scenario call 0: call required",
+		},
+		{
+			"defaults",
+			&Scenario{Calls: []ScenarioCall{{Call: " foo.Bar.Baz "}}},
+			[]ScenarioCall{{Name: "foo.Bar.Baz", Call: "foo.Bar.Baz", Weight: 1}},
+			"",
+		},
+		{
+			"duplicate name",
+			&Scenario{Calls: []ScenarioCall{{Call: "foo.Bar.Baz"}, {Call: "foo.Bar.Baz", Weight: 2}}},
+			nil,
+			`scenario call 1: duplicate name "foo.Bar.Baz"`,
+		},
+		{
+			"calls and steps",
+			&Scenario{Calls: []ScenarioCall{{Call: "foo.Bar.Baz"}}, Steps: []ScenarioCall{{Call: "foo.Bar.Baz"}}},
+			nil,
+			"scenario cannot have both calls and steps",
+		},
+		{
+			"missing step call",
+			&Scenario{Steps: []ScenarioCall{{Call: "foo.Bar.Baz"}, {Name: "foo"}}},
+			nil,
+			"scenario step 1: call required",
+		},
+		{
+			"extract in calls",
+			&Scenario{Calls: []ScenarioCall{{Call: "foo.Bar.Baz", Extract: map[string]string{"token": "token"}}}},
+			nil,
+			`scenario call "foo.Bar.Baz": extract is only supported for steps`,
+		},
+		{
+			"invalid extract path",
+			&Scenario{Steps: []ScenarioCall{{Call: "foo.Bar.Baz", Extract: map[string]string{"token": "$"}}}},
+			nil,
+			`scenario step "foo.Bar.Baz": extract "token": invalid path "$": empty path`,
+		},
+		{
+			"step defaults",
+			&Scenario{Steps: []ScenarioCall{{Call: "foo.Bar.Baz", Extract: map[string]string{"token": "token"}}}},
+			nil,
+			"",
+		},
+		{
+			"invalid data",
+			&Scenario{Calls: []ScenarioCall{{Call: "foo.Bar.Baz", Data: "foo"}}},
+			nil,
+			`scenario call "foo.Bar.Baz": unsupported type for Data`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.in.validate()
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, tt.in.Calls)
+				for _, sc := range tt.in.Steps {
+					assert.Equal(t, sc.Call, sc.Name)
+					assert.Zero(t, sc.Weight)
+				}
+			}
+		})
+	}
+}
+
+func TestCallSchedule(t *testing.T) {
+	t.Run("single", func(t *testing.T) {
+		a := &callTarget{name: "a", weight: 5}
+		s := newCallSchedule([]*callTarget{a})
+
+		assert.Len(t, s, 1)
+		assert.Equal(t, a, s.next(0))
+		assert.Equal(t, a, s.next(7))
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		a := &callTarget{name: "a", weight: 70}
+		b := &callTarget{name: "b", weight: 20}
+		c := &callTarget{name: "c", weight: 10}
+		s := newCallSchedule([]*callTarget{a, b, c})
+
+		// weights are reduced by their greatest common divisor
+		assert.Len(t, s, 10)
+
+		counts := make(map[string]int)
+		for i := uint64(0); i < 100; i++ {
+			counts[s.next(i).name]++
+		}
+
+		assert.Equal(t, map[string]int{"a": 70, "b": 20, "c": 10}, counts)
+	})
+
+	t.Run("interleaved", func(t *testing.T) {
+		a := &callTarget{name: "a", weight: 2}
+		b := &callTarget{name: "b", weight: 1}
+		s := newCallSchedule([]*callTarget{a, b})
+
+		names := make([]string, 0, len(s))
+		for _, t := range s {
+			names = append(names, t.name)
+		}
+
+		assert.Equal(t, []string{"a", "b", "a"}, names)
+	})
+}
+
+func TestJourneyState_record(t *testing.T) {
+	msg := newTestSession(t)
+
+	token, _ := parseResponsePath("token")
+	item, _ := parseResponsePath("items[0].id")
+	missing, _ := parseResponsePath("items[5].id")
+
+	t.Run("extracts values", func(t *testing.T) {
+		js := newJourneyState()
+		step := &callTarget{name: "session", extract: []*extractor{{"token", token}, {"item", item}}}
+
+		err := js.record(step, msg)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"token": "abc123", "item": "i1"}, js.vars)
+		assert.Equal(t, msg, js.responses["session"])
+	})
+
+	t.Run("fails on missing value", func(t *testing.T) {
+		js := newJourneyState()
+		step := &callTarget{name: "session", extract: []*extractor{{"item", missing}}}
+
+		err := js.record(step, msg)
+		assert.EqualError(t, err, `extract "item": path "items[5].id": index out of range with length 2`)
+	})
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/bojand/ghz/load"
+)
+
+// searchSustainedRatio is the lowest ratio of the achieved rate to the target rate
+// of a probe for the target rate to be considered sustained
+const searchSustainedRatio = 0.9
+
+// SearchReport holds the results of the search for the maximum sustainable rate
+type SearchReport struct {
+	// MaxRPS is the highest probed rate meeting the SLOs, 0 if no probed rate met them
+	MaxRPS uint `json:"maxRps"`
+
+	// Completed is whether the search narrowed down the maximum rate to the load step
+	// before the run ended
+	Completed bool `json:"completed"`
+
+	// Steps are the probes of the search in order
+	Steps []SearchStep `json:"steps"`
+}
+
+// SearchStep holds the results of a single probe of the search at a target rate.
+// A probe passes when all the SLOs are met and the achieved rate is at least
+// 90% of the target rate.
+type SearchStep struct {
+	RPS      uint              `json:"rps"`
+	Passed   bool              `json:"passed"`
+	Duration time.Duration     `json:"duration"`
+	SLOs     []AssertionResult `json:"slos"`
+
+	Count   uint64        `json:"count"`
+	Rps     float64       `json:"actualRps"`
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+
+	ErrorDist      map[string]int `json:"errorDistribution"`
+	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
+
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+}
+
+// rateSearch drives the pacer of the run to search for the highest rate meeting the SLOs.
+// The rate is doubled until a probe fails and then bisected between the highest passing
+// and the lowest failing rates until they are within the resolution.
+type rateSearch struct {
+	pacer      *load.AdaptivePacer
+	slos       []*assertion
+	max        uint
+	resolution uint
+	precision  int
+
+	rate   uint
+	lo, hi uint
+
+	start time.Time
+	stats *callStats
+
+	steps     []SearchStep
+	completed bool
+}
+
+func newRateSearch(c *RunConfig, pacer *load.AdaptivePacer, start time.Time) *rateSearch {
+	return &rateSearch{
+		pacer:      pacer,
+		slos:       c.searchSLOs,
+		max:        c.loadEnd,
+		resolution: uint(c.loadStep),
+		precision:  c.histogramPrecision,
+		rate:       c.loadStart,
+		start:      start,
+		stats:      newCallStats(c.histogramPrecision),
+	}
+}
+
+func (s *rateSearch) record(res *callResult, errStr string, countLatency bool) {
+	if s.completed {
+		return
+	}
+
+	s.stats.record(res, errStr, countLatency)
+}
+
+// probe evaluates the probe ending now and moves on to the next rate or completes the search
+func (s *rateSearch) probe(now time.Time) {
+	if s.completed {
+		return
+	}
+
+	duration := now.Sub(s.start)
+	cr := s.stats.report(&ScenarioCall{}, duration, false)
+
+	ar := evaluateAssertions(s.slos, &Report{
+		Count:               cr.Count,
+		Average:             cr.Average,
+		Fastest:             cr.Fastest,
+		Slowest:             cr.Slowest,
+		Rps:                 cr.Rps,
+		ErrorDist:           cr.ErrorDist,
+		StatusCodeDist:      cr.StatusCodeDist,
+		ValidationFailures:  cr.ValidationFailures,
+		LatencyDistribution: cr.LatencyDistribution,
+	})
+
+	passed := cr.Rps >= float64(s.rate)*searchSustainedRatio
+	var results []AssertionResult
+	if ar != nil {
+		passed = passed && ar.Passed
+		results = ar.Results
+	}
+
+	s.steps = append(s.steps, SearchStep{
+		RPS:                 s.rate,
+		Passed:              passed,
+		Duration:            duration,
+		SLOs:                results,
+		Count:               cr.Count,
+		Rps:                 cr.Rps,
+		Average:             cr.Average,
+		Fastest:             cr.Fastest,
+		Slowest:             cr.Slowest,
+		ErrorDist:           cr.ErrorDist,
+		StatusCodeDist:      cr.StatusCodeDist,
+		LatencyDistribution: cr.LatencyDistribution,
+	})
+
+	if passed {
+		s.lo = s.rate
+	} else {
+		s.hi = s.rate
+	}
+
+	next, done := s.next()
+	if done {
+		s.completed = true
+		s.pacer.Stop()
+		return
+	}
+
+	s.rate = next
+	s.pacer.SetRate(uint64(next))
+	s.start = now
+	s.stats = newCallStats(s.precision)
+}
+
+// next returns the next rate to probe or done if the search is complete
+func (s *rateSearch) next() (uint, bool) {
+	if s.hi == 0 {
+		// no failure yet so keep going up to the max
+		if s.max > 0 && s.lo >= s.max {
+			return 0, true
+		}
+
+		next := s.lo * 2
+		if s.max > 0 && next > s.max {
+			next = s.max
+		}
+
+		return next, false
+	}
+
+	if s.hi-s.lo <= s.resolution {
+		return 0, true
+	}
+
+	next := s.lo + (s.hi-s.lo)/2
+	if next == 0 {
+		return 0, true
+	}
+
+	return next, false
+}
+
+func (s *rateSearch) report() *SearchReport {
+	return &SearchReport{
+		MaxRPS:    s.lo,
+		Completed: s.completed,
+		Steps:     s.steps,
+	}
+}
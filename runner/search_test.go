@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+	"github.com/bojand/ghz/internal/helloworld"
+	"github.com/bojand/ghz/load"
+)
+
+func TestRateSearch_next(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		max     uint
+		results []bool
+		rates   []uint
+		maxRPS  uint
+	}{
+		{"doubles until max", 300, []bool{true, true, true, true}, []uint{50, 100, 200, 300}, 300},
+		{"bisects after failure", 0, []bool{true, true, false, true, false}, []uint{50, 100, 200, 150, 175}, 150},
+		{"fails from the start", 0, []bool{false, false}, []uint{50, 25}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &rateSearch{pacer: load.NewAdaptivePacer(50), max: tc.max, resolution: 25, rate: 50}
+
+			var rates []uint
+			for _, passed := range tc.results {
+				assert.False(t, s.completed)
+				rates = append(rates, s.rate)
+
+				if passed {
+					s.lo = s.rate
+				} else {
+					s.hi = s.rate
+				}
+
+				next, done := s.next()
+				s.completed = done
+				s.rate = next
+			}
+
+			assert.True(t, s.completed)
+			assert.Equal(t, tc.rates, rates)
+			assert.Equal(t, tc.maxRPS, s.report().MaxRPS)
+		})
+	}
+}
+
+func TestRunSearch(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("up to max", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithConcurrency(20),
+			WithLoadSchedule(ScheduleSearch),
+			WithLoadStart(50),
+			WithLoadEnd(200),
+			WithLoadStep(25),
+			WithLoadStepDuration(500*time.Millisecond),
+			WithSearchSLOs("p99 < 1s", "error-rate < 1%"),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, ReasonNormalEnd, report.EndReason)
+		assert.Equal(t, int(report.Count), gs.GetCount(callType))
+
+		assert.NotNil(t, report.Search)
+		assert.True(t, report.Search.Completed)
+		assert.Equal(t, uint(200), report.Search.MaxRPS)
+		assert.Equal(t, []string{"p99 < 1s", "error-rate < 1%"}, report.Options.SearchSLOs)
+
+		var rates []uint
+		for _, step := range report.Search.Steps {
+			rates = append(rates, step.RPS)
+			assert.True(t, step.Passed)
+			assert.Len(t, step.SLOs, 2)
+			assert.NotZero(t, step.Count)
+			assert.NotEmpty(t, step.LatencyDistribution)
+		}
+		assert.Equal(t, []uint{50, 100, 200}, rates)
+	})
+
+	t.Run("failing slo", func(t *testing.T) {
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithConcurrency(10),
+			WithLoadSchedule(ScheduleSearch),
+			WithLoadStart(50),
+			WithLoadStep(25),
+			WithLoadStepDuration(300*time.Millisecond),
+			WithSearchSLOs("rps > 100000"),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report.Search)
+		assert.True(t, report.Search.Completed)
+		assert.Zero(t, report.Search.MaxRPS)
+		assert.Len(t, report.Search.Steps, 2)
+		assert.False(t, report.Search.Steps[0].Passed)
+		assert.Equal(t, uint(25), report.Search.Steps[1].RPS)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewConfig("helloworld.Greeter.SayHello", internal.TestLocalhost,
+			WithLoadSchedule(ScheduleSearch),
+			WithLoadStart(50),
+			WithLoadStep(25),
+			WithLoadStepDuration(time.Second),
+		)
+
+		assert.EqualError(t, err, "search load schedule requires at least one search SLO")
+
+		_, err = NewConfig("helloworld.Greeter.SayHello", internal.TestLocalhost,
+			WithLoadSchedule(ScheduleSearch),
+			WithLoadStep(25),
+			WithLoadStepDuration(time.Second),
+			WithSearchSLOs("p99 < 1s"),
+		)
+
+		assert.EqualError(t, err, "search load schedule requires load start")
+	})
+}
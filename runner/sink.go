@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+)
+
+// CallResult is the result of a single call passed to the result sinks as it is received
+type CallResult struct {
+	// Timestamp is the end time of the call
+	Timestamp time.Time
+
+	// Latency is the duration of the call
+	Latency time.Duration
+
+	// Status is the gRPC status code of the call
+	Status string
+
+	// Error is the error of the call, empty if the call succeeded
+	Error string
+
+	// WorkerID is the ID of the worker that made the call
+	WorkerID string
+
+	// Call is the fully qualified method name of the call, or the name of the call of a scenario
+	Call string
+
+	// RequestSize and ResponseSize are the total wire sizes in bytes of the messages sent and
+	// received by the call
+	RequestSize  int64
+	ResponseSize int64
+}
+
+// ResultSink receives the result of each call as it is received and the final report of the run.
+// It allows streaming the results to files, message queues or databases while the run is in progress.
+//
+// The methods are called from the single goroutine processing the results, so they do not need to be
+// safe for concurrent use, but should not block for long as that holds up processing of the results.
+// The results skipped using WithSkipFirst are not passed to the sinks.
+type ResultSink interface {
+	// Result is called with the result of each call. Once it returns an error the sink
+	// does not receive further results and the error is returned by the run.
+	Result(res *CallResult) error
+
+	// Report is called with the final report once the run is done.
+	Report(report *Report) error
+}
+
+// sinkState is a registered result sink along with its first error
+type sinkState struct {
+	sink ResultSink
+	err  error
+}
+
+func newSinkStates(sinks []ResultSink) []*sinkState {
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	states := make([]*sinkState, len(sinks))
+	for i, s := range sinks {
+		states[i] = &sinkState{sink: s}
+	}
+
+	return states
+}
+
+// sinkResult returns the result of the call passed to the result sinks
+func sinkResult(c *RunConfig, res *callResult, errStr string) *CallResult {
+	call := res.call
+	if call == "" {
+		call = c.call
+	}
+
+	return &CallResult{
+		Timestamp:    res.timestamp,
+		Latency:      res.duration,
+		Status:       res.status,
+		Error:        errStr,
+		WorkerID:     res.workerID,
+		Call:         call,
+		RequestSize:  res.reqSize,
+		ResponseSize: res.respSize,
+	}
+}
+
+// recordSinks passes the result of the call to the result sinks that have not failed
+func (r *Reporter) recordSinks(res *callResult, errStr string) {
+	var cr *CallResult
+	for _, s := range r.sinks {
+		if s.err != nil {
+			continue
+		}
+
+		if cr == nil {
+			cr = sinkResult(r.config, res, errStr)
+		}
+
+		if err := s.sink.Result(cr); err != nil {
+			s.err = err
+
+			if r.config.hasLog {
+				r.config.log.Errorw("Result sink failed", "error", err)
+			}
+		}
+	}
+}
+
+// reportSinks passes the final report to the result sinks and returns the first error of the sinks
+func (r *Reporter) reportSinks(report *Report) error {
+	var first error
+	for _, s := range r.sinks {
+		err := s.sink.Report(report)
+		if s.err != nil {
+			err = s.err
+		}
+
+		if err != nil && first == nil {
+			first = fmt.Errorf("result sink: %w", err)
+		}
+	}
+
+	return first
+}
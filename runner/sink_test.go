@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+)
+
+type recordingSink struct {
+	results []*CallResult
+	reports []*Report
+
+	failAfter int
+}
+
+func (s *recordingSink) Result(res *CallResult) error {
+	s.results = append(s.results, res)
+
+	if s.failAfter > 0 && len(s.results) >= s.failAfter {
+		return errors.New("sink full")
+	}
+
+	return nil
+}
+
+func (s *recordingSink) Report(report *Report) error {
+	s.reports = append(s.reports, report)
+
+	return nil
+}
+
+func TestRunResultSink(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("receives each result and the report", func(t *testing.T) {
+		sink1 := &recordingSink{}
+		sink2 := &recordingSink{}
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(12),
+			WithConcurrency(3),
+			WithSkipFirst(2),
+			WithResultSink(sink1),
+			WithResultSink(sink2),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		for _, sink := range []*recordingSink{sink1, sink2} {
+			assert.Len(t, sink.results, 10)
+			assert.Equal(t, []*Report{report}, sink.reports)
+
+			workers := map[string]bool{}
+			for _, res := range sink.results {
+				assert.Equal(t, "helloworld.Greeter.SayHello", res.Call)
+				assert.Equal(t, "OK", res.Status)
+				assert.Empty(t, res.Error)
+				assert.NotZero(t, res.Latency)
+				assert.False(t, res.Timestamp.IsZero())
+				assert.Greater(t, res.RequestSize, int64(0))
+				assert.Greater(t, res.ResponseSize, res.RequestSize)
+
+				workers[res.WorkerID] = true
+			}
+
+			assert.NotContains(t, workers, "")
+			assert.LessOrEqual(t, len(workers), 3)
+		}
+	})
+
+	t.Run("failed sink", func(t *testing.T) {
+		sink := &recordingSink{failAfter: 2}
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(10),
+			WithResultSink(sink),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "result sink: sink full"))
+		assert.NotNil(t, report)
+		assert.Equal(t, uint64(10), report.Count)
+		assert.Len(t, sink.results, 2)
+		assert.Len(t, sink.reports, 1)
+	})
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// maxTrackableSize is the largest message size tracked by the size histograms, larger sizes are clamped
+const maxTrackableSize = 1 << 36
+
+// SizesReport holds the wire sizes of the messages sent and received by the calls. The size of
+// a call is the total size of its messages including the gRPC framing, so for streaming calls it
+// is the size of all the messages of the stream.
+type SizesReport struct {
+	Request  SizeStats `json:"request"`
+	Response SizeStats `json:"response"`
+}
+
+// SizeStats holds the stats of the request or response sizes of the calls in bytes
+type SizeStats struct {
+	Total    int64   `json:"total"`
+	Average  float64 `json:"average"`
+	Smallest int64   `json:"smallest"`
+	Largest  int64   `json:"largest"`
+
+	// Throughput is the bytes per second over the total duration of the run
+	Throughput float64 `json:"throughput"`
+
+	SizeDistribution []SizeDistribution `json:"sizeDistribution"`
+
+	// SizeHistogram is the mergeable size histogram, only included when requested using WithLatencyHistogram
+	SizeHistogram *hdrhistogram.Snapshot `json:"sizeHistogram,omitempty"`
+}
+
+// SizeDistribution holds the size distribution data
+type SizeDistribution struct {
+	Percentage int   `json:"percentage"`
+	Size       int64 `json:"size"`
+}
+
+// sizeStats accumulates the request or response sizes of the calls
+type sizeStats struct {
+	total    int64
+	hist     *hdrhistogram.Histogram
+	smallest int64
+	largest  int64
+}
+
+func newSizeStats(precision int) *sizeStats {
+	return &sizeStats{
+		hist: hdrhistogram.New(1, maxTrackableSize, precision),
+	}
+}
+
+func (ss *sizeStats) record(size int64) {
+	if ss.hist.TotalCount() == 0 || size < ss.smallest {
+		ss.smallest = size
+	}
+
+	if size > ss.largest {
+		ss.largest = size
+	}
+
+	ss.total += size
+
+	v := size
+	if v > maxTrackableSize {
+		v = maxTrackableSize
+	}
+
+	// the size is always within the trackable range, sizes below the lowest are counted in the first bucket
+	_ = ss.hist.RecordValue(v)
+}
+
+func (ss *sizeStats) report(total time.Duration, withHist bool) SizeStats {
+	s := SizeStats{
+		Total:    ss.total,
+		Smallest: ss.smallest,
+		Largest:  ss.largest,
+	}
+
+	if count := ss.hist.TotalCount(); count > 0 {
+		s.Average = float64(ss.total) / float64(count)
+		s.SizeDistribution = sizeDistribution(ss.hist, ss.smallest, ss.largest)
+	}
+
+	if total > 0 {
+		s.Throughput = float64(ss.total) / total.Seconds()
+	}
+
+	if withHist {
+		s.SizeHistogram = ss.hist.Export()
+	}
+
+	return s
+}
+
+// sizeDistribution computes the size percentiles over the recorded histogram, clamped to the exact
+// smallest and largest sizes observed
+func sizeDistribution(h *hdrhistogram.Histogram, smallest, largest int64) []SizeDistribution {
+	// the sizes are recorded as is, so the latency percentiles are the size percentiles
+	pctls := percentiles(h, reportPercentiles, time.Duration(smallest), time.Duration(largest))
+
+	res := make([]SizeDistribution, len(pctls))
+	for i, p := range pctls {
+		res[i] = SizeDistribution{Percentage: p.Percentage, Size: int64(p.Latency)}
+	}
+
+	return res
+}
+
+// callSizes accumulates the request and response sizes of the calls
+type callSizes struct {
+	request  *sizeStats
+	response *sizeStats
+}
+
+func newCallSizes(precision int) *callSizes {
+	return &callSizes{
+		request:  newSizeStats(precision),
+		response: newSizeStats(precision),
+	}
+}
+
+func (cs *callSizes) record(res *callResult) {
+	cs.request.record(res.reqSize)
+	cs.response.record(res.respSize)
+}
+
+func (cs *callSizes) report(total time.Duration, withHist bool) *SizesReport {
+	if cs.request.hist.TotalCount() == 0 {
+		return nil
+	}
+
+	return &SizesReport{
+		Request:  cs.request.report(total, withHist),
+		Response: cs.response.report(total, withHist),
+	}
+}
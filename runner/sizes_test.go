@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+)
+
+func TestCallSizes(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		cs := newCallSizes(3)
+		assert.Nil(t, cs.report(time.Second, false))
+	})
+
+	t.Run("report", func(t *testing.T) {
+		cs := newCallSizes(3)
+		cs.record(&callResult{reqSize: 10, respSize: 100})
+		cs.record(&callResult{reqSize: 20, respSize: 300})
+		cs.record(&callResult{reqSize: 30})
+
+		sr := cs.report(2*time.Second, false)
+		if !assert.NotNil(t, sr) {
+			return
+		}
+
+		assert.Equal(t, int64(60), sr.Request.Total)
+		assert.Equal(t, 20.0, sr.Request.Average)
+		assert.Equal(t, int64(10), sr.Request.Smallest)
+		assert.Equal(t, int64(30), sr.Request.Largest)
+		assert.Equal(t, 30.0, sr.Request.Throughput)
+		assert.Len(t, sr.Request.SizeDistribution, len(reportPercentiles))
+		assert.Equal(t, int64(10), sr.Request.SizeDistribution[0].Size)
+		assert.Equal(t, int64(30), sr.Request.SizeDistribution[len(reportPercentiles)-1].Size)
+		assert.Nil(t, sr.Request.SizeHistogram)
+
+		assert.Equal(t, int64(400), sr.Response.Total)
+		assert.InDelta(t, 133.33, sr.Response.Average, 0.01)
+		assert.Equal(t, int64(0), sr.Response.Smallest)
+		assert.Equal(t, int64(300), sr.Response.Largest)
+		assert.Equal(t, 200.0, sr.Response.Throughput)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		cs := newCallSizes(3)
+		cs.record(&callResult{reqSize: 10, respSize: 100})
+		cs.record(&callResult{reqSize: 20, respSize: 300})
+
+		sr := cs.report(time.Second, true)
+		assert.NotNil(t, sr.Request.SizeHistogram)
+
+		merged, err := mergeSizes([]*Report{{Count: 2, Sizes: sr}, {Count: 2, Sizes: sr}}, 2*time.Second)
+		assert.NoError(t, err)
+		if !assert.NotNil(t, merged) {
+			return
+		}
+
+		assert.Equal(t, int64(60), merged.Request.Total)
+		assert.Equal(t, 15.0, merged.Request.Average)
+		assert.Equal(t, int64(10), merged.Request.Smallest)
+		assert.Equal(t, int64(20), merged.Request.Largest)
+		assert.Equal(t, 30.0, merged.Request.Throughput)
+		assert.Equal(t, int64(800), merged.Response.Total)
+		assert.Equal(t, int64(300), merged.Response.Largest)
+	})
+}
+
+func TestRunSizes(t *testing.T) {
+	_, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	report, err := Run(
+		"helloworld.Greeter.SayHello",
+		internal.TestLocalhost,
+		WithProtoFile("../testdata/greeter.proto", []string{}),
+		WithTotalRequests(10),
+		WithConcurrency(2),
+		WithData(map[string]interface{}{"name": "bob"}),
+		WithLatencyHistogram(true),
+		WithInsecure(true),
+	)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, report)
+
+	sr := report.Sizes
+	if !assert.NotNil(t, sr) {
+		return
+	}
+
+	assert.Greater(t, sr.Request.Total, int64(0))
+	assert.Greater(t, sr.Request.Throughput, 0.0)
+	assert.Greater(t, sr.Response.Average, 0.0)
+	assert.Greater(t, sr.Response.Total, sr.Request.Total)
+	assert.NotEmpty(t, sr.Response.SizeDistribution)
+	assert.NotNil(t, sr.Response.SizeHistogram)
+
+	merged, err := MergeReports([]*Report{report, report})
+	assert.NoError(t, err)
+	assert.Equal(t, 2*sr.Request.Total, merged.Sizes.Request.Total)
+	assert.Equal(t, sr.Response.Largest, merged.Sizes.Response.Largest)
+}
@@ -3,11 +3,87 @@ package runner
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/jhump/protoreflect/dynamic"
 	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
 )
 
+type callNameKey struct{}
+
+// withCallName returns the context tagged with the name of the scenario call
+func withCallName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, callNameKey{}, name)
+}
+
+// callName returns the name of the scenario call the context was tagged with
+func callName(ctx context.Context) string {
+	name, _ := ctx.Value(callNameKey{}).(string)
+	return name
+}
+
+type workerIDKey struct{}
+
+// withWorkerID returns the context tagged with the ID of the worker making the call
+func withWorkerID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, workerIDKey{}, id)
+}
+
+// workerID returns the ID of the worker the context was tagged with
+func workerID(ctx context.Context) string {
+	id, _ := ctx.Value(workerIDKey{}).(string)
+	return id
+}
+
+type journeyKey struct{}
+
+// withJourney returns the context tagged with the request number of the journey the call is a step of
+func withJourney(ctx context.Context, reqNumber uint64) context.Context {
+	return context.WithValue(ctx, journeyKey{}, reqNumber)
+}
+
+// journeyNumber returns the request number of the journey the context was tagged with
+func journeyNumber(ctx context.Context) (uint64, bool) {
+	n, ok := ctx.Value(journeyKey{}).(uint64)
+	return n, ok
+}
+
+type rpcValidationKey struct{}
+
+// withRPCValidation returns the context tagged with the response validation state of the RPC
+func withRPCValidation(ctx context.Context, rv *rpcValidation) context.Context {
+	return context.WithValue(ctx, rpcValidationKey{}, rv)
+}
+
+// rpcValidationFromContext returns the response validation state the RPC context was tagged with
+func rpcValidationFromContext(ctx context.Context) *rpcValidation {
+	rv, _ := ctx.Value(rpcValidationKey{}).(*rpcValidation)
+	return rv
+}
+
+type intendedStartKey struct{}
+
+// withIntendedStart returns the context tagged with the intended start of the RPC on the load schedule
+func withIntendedStart(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, intendedStartKey{}, t)
+}
+
+// correctedDuration returns the duration of the RPC measured from its intended start
+// if the context was tagged with one, or the measured duration otherwise
+func correctedDuration(ctx context.Context, end time.Time, duration time.Duration) time.Duration {
+	start, ok := ctx.Value(intendedStartKey{}).(time.Time)
+	if !ok || start.IsZero() {
+		return duration
+	}
+
+	if d := end.Sub(start); d > duration {
+		return d
+	}
+
+	return duration
+}
+
 // StatsHandler is for gRPC stats
 type statsHandler struct {
 	results chan *callResult
@@ -18,6 +94,9 @@ type statsHandler struct {
 
 	lock   sync.RWMutex
 	ignore bool
+
+	// creates the client spans of the calls, only when OTLP traces are enabled
+	otlp *otlpExporter
 }
 
 // HandleConn handle the connection
@@ -34,7 +113,29 @@ func (c *statsHandler) TagConn(ctx context.Context, cti *stats.ConnTagInfo) cont
 // HandleRPC implements per-RPC tracing and stats instrumentation.
 func (c *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 	switch rs := rs.(type) {
+	case *stats.Begin:
+		if m := rpcMessagesFromContext(ctx); m != nil {
+			m.begun(rs.BeginTime, rs.IsClientStream || rs.IsServerStream)
+		}
+	case *stats.OutPayload:
+		if m := rpcMessagesFromContext(ctx); m != nil {
+			m.sentMessage(rs.WireLength)
+		}
+	case *stats.InPayload:
+		if m := rpcMessagesFromContext(ctx); m != nil {
+			m.receivedMessage(rs.WireLength, rs.RecvTime)
+		}
+
+		if rv := rpcValidationFromContext(ctx); rv != nil {
+			if msg, ok := rs.Payload.(*dynamic.Message); ok {
+				rv.validate(msg)
+			}
+		}
 	case *stats.End:
+		if span := otlpSpanFromContext(ctx); span != nil {
+			c.otlp.endSpan(span, rs.EndTime, rs.Error)
+		}
+
 		ign := false
 		c.lock.RLock()
 		ign = c.ignore
@@ -49,7 +150,36 @@ func (c *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 				st = s.Code().String()
 			}
 
-			c.results <- &callResult{rs.Error, st, duration, rs.EndTime}
+			err := rs.Error
+			if rv := rpcValidationFromContext(ctx); rv != nil && err == nil {
+				// the call succeeded but the response failed validation
+				if verr := rv.result(); verr != nil {
+					err = verr
+
+					if c.hasLog {
+						c.log.Debugw("Response validation failed",
+							"statsID", c.id, "error", verr, "actual", verr.(*validationError).actual)
+					}
+				}
+			}
+
+			res := &callResult{
+				err:       err,
+				status:    st,
+				duration:  duration,
+				corrected: correctedDuration(ctx, rs.EndTime, duration),
+				timestamp: rs.EndTime,
+				call:      callName(ctx),
+				workerID:  workerID(ctx),
+			}
+
+			res.journeyNumber, res.step = journeyNumber(ctx)
+
+			if m := rpcMessagesFromContext(ctx); m != nil {
+				m.result(res)
+			}
+
+			c.results <- res
 
 			if c.hasLog {
 				c.log.Debugw("Received RPC Stats",
@@ -69,5 +199,11 @@ func (c *statsHandler) Ignore(val bool) {
 
 // TagRPC implements per-RPC context management.
 func (c *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	ctx = withRPCMessages(ctx)
+
+	if c.otlp != nil {
+		ctx = withOTLPSpan(ctx, c.otlp.startSpan(info.FullMethodName))
+	}
+
 	return ctx
 }
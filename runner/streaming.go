@@ -0,0 +1,248 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// maxStreamGaps is the most inter-message latencies kept for a single streaming call,
+// the latencies of further messages of the call are left out of the stats
+const maxStreamGaps = 1 << 16
+
+// rpcMessages accumulates the messages sent and received by an RPC. The messages
+// of streaming calls can be sent and received concurrently.
+type rpcMessages struct {
+	mu sync.Mutex
+
+	streaming bool
+	begin     time.Time
+
+	sent          uint64
+	received      uint64
+	sentBytes     int64
+	receivedBytes int64
+
+	firstResponse time.Duration
+	lastReceived  time.Time
+	gaps          []time.Duration
+}
+
+type rpcMessagesKey struct{}
+
+// withRPCMessages returns the context tagged with the messages state of the RPC
+func withRPCMessages(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rpcMessagesKey{}, &rpcMessages{})
+}
+
+// rpcMessagesFromContext returns the messages state of the RPC the context was tagged with
+func rpcMessagesFromContext(ctx context.Context) *rpcMessages {
+	m, _ := ctx.Value(rpcMessagesKey{}).(*rpcMessages)
+	return m
+}
+
+func (m *rpcMessages) begun(begin time.Time, streaming bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.begin = begin
+	m.streaming = streaming
+}
+
+func (m *rpcMessages) sentMessage(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent++
+	m.sentBytes += int64(size)
+}
+
+func (m *rpcMessages) receivedMessage(size int, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.received++
+	m.receivedBytes += int64(size)
+
+	if m.streaming {
+		if m.received == 1 {
+			m.firstResponse = at.Sub(m.begin)
+		} else if len(m.gaps) < maxStreamGaps {
+			m.gaps = append(m.gaps, at.Sub(m.lastReceived))
+		}
+	}
+
+	m.lastReceived = at
+}
+
+// result sets the message sizes of the call result, along with the per message stats of a streaming call
+func (m *rpcMessages) result(res *callResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res.reqSize = m.sentBytes
+	res.respSize = m.receivedBytes
+
+	if m.streaming {
+		res.stream = &streamResult{
+			sent:          m.sent,
+			received:      m.received,
+			firstResponse: m.firstResponse,
+			gaps:          m.gaps,
+		}
+	}
+}
+
+// streamResult holds the per message stats of a streaming call
+type streamResult struct {
+	sent     uint64
+	received uint64
+
+	// firstResponse is the time from the start of the call to its first received message,
+	// zero if no message was received
+	firstResponse time.Duration
+
+	// gaps are the times between the consecutive received messages
+	gaps []time.Duration
+}
+
+// StreamingReport holds the per message stats of the streaming calls of the run
+type StreamingReport struct {
+	// Count is the number of streaming calls
+	Count uint64 `json:"count"`
+
+	MessagesSent     uint64 `json:"messagesSent"`
+	MessagesReceived uint64 `json:"messagesReceived"`
+
+	// BytesSent and BytesReceived are the total wire sizes of the messages
+	BytesSent     int64 `json:"bytesSent"`
+	BytesReceived int64 `json:"bytesReceived"`
+
+	// SentRate and ReceivedRate are the messages per second over the total duration of the run
+	SentRate     float64 `json:"sentRate"`
+	ReceivedRate float64 `json:"receivedRate"`
+
+	// TimeToFirstResponse is the time from the start of the calls to their first received message
+	TimeToFirstResponse *MessageLatencies `json:"timeToFirstResponse,omitempty"`
+
+	// InterMessageLatency is the time between the consecutive received messages of the calls
+	InterMessageLatency *MessageLatencies `json:"interMessageLatency,omitempty"`
+}
+
+// MessageLatencies holds the latency stats of the streamed messages
+type MessageLatencies struct {
+	Count   uint64        `json:"count"`
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+
+	LatencyHistogram *hdrhistogram.Snapshot `json:"latencyHistogram,omitempty"`
+}
+
+// streamStats accumulates the per message stats of the streaming calls
+type streamStats struct {
+	count            uint64
+	messagesSent     uint64
+	messagesReceived uint64
+	bytesSent        int64
+	bytesReceived    int64
+
+	firstResponse *messageLatencyStats
+	gaps          *messageLatencyStats
+}
+
+func newStreamStats(precision int) *streamStats {
+	return &streamStats{
+		firstResponse: newMessageLatencyStats(precision),
+		gaps:          newMessageLatencyStats(precision),
+	}
+}
+
+func (ss *streamStats) record(res *callResult) {
+	s := res.stream
+
+	ss.count++
+	ss.messagesSent += s.sent
+	ss.messagesReceived += s.received
+	ss.bytesSent += res.reqSize
+	ss.bytesReceived += res.respSize
+
+	if s.received > 0 {
+		ss.firstResponse.record(s.firstResponse)
+	}
+
+	for _, d := range s.gaps {
+		ss.gaps.record(d)
+	}
+}
+
+func (ss *streamStats) report(total time.Duration, withHist bool) *StreamingReport {
+	sr := &StreamingReport{
+		Count:               ss.count,
+		MessagesSent:        ss.messagesSent,
+		MessagesReceived:    ss.messagesReceived,
+		BytesSent:           ss.bytesSent,
+		BytesReceived:       ss.bytesReceived,
+		TimeToFirstResponse: ss.firstResponse.report(withHist),
+		InterMessageLatency: ss.gaps.report(withHist),
+	}
+
+	if total > 0 {
+		sr.SentRate = float64(ss.messagesSent) / total.Seconds()
+		sr.ReceivedRate = float64(ss.messagesReceived) / total.Seconds()
+	}
+
+	return sr
+}
+
+// messageLatencyStats accumulates the latencies of the streamed messages
+type messageLatencyStats struct {
+	total       time.Duration
+	latencyHist *hdrhistogram.Histogram
+	fastest     time.Duration
+	slowest     time.Duration
+}
+
+func newMessageLatencyStats(precision int) *messageLatencyStats {
+	return &messageLatencyStats{
+		latencyHist: hdrhistogram.New(1, int64(maxTrackableLatency), precision),
+	}
+}
+
+func (ms *messageLatencyStats) record(d time.Duration) {
+	if ms.latencyHist.TotalCount() == 0 || d < ms.fastest {
+		ms.fastest = d
+	}
+
+	if d > ms.slowest {
+		ms.slowest = d
+	}
+
+	ms.total += d
+	recordHistValue(ms.latencyHist, d)
+}
+
+func (ms *messageLatencyStats) report(withHist bool) *MessageLatencies {
+	count := ms.latencyHist.TotalCount()
+	if count == 0 {
+		return nil
+	}
+
+	ml := &MessageLatencies{
+		Count:               uint64(count),
+		Average:             ms.total / time.Duration(count),
+		Fastest:             ms.fastest,
+		Slowest:             ms.slowest,
+		LatencyDistribution: latencies(ms.latencyHist, ms.fastest, ms.slowest),
+	}
+
+	if withHist {
+		ml.LatencyHistogram = ms.latencyHist.Export()
+	}
+
+	return ml
+}
@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+)
+
+func TestRPCMessages(t *testing.T) {
+	begin := time.Unix(1600000000, 0)
+
+	t.Run("unary", func(t *testing.T) {
+		m := &rpcMessages{}
+		m.begun(begin, false)
+		m.sentMessage(12)
+		m.receivedMessage(20, begin.Add(time.Millisecond))
+
+		res := &callResult{}
+		m.result(res)
+
+		assert.Equal(t, int64(12), res.reqSize)
+		assert.Equal(t, int64(20), res.respSize)
+		assert.Nil(t, res.stream)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		m := &rpcMessages{}
+		m.begun(begin, true)
+		m.sentMessage(12)
+		m.receivedMessage(20, begin.Add(10*time.Millisecond))
+		m.receivedMessage(21, begin.Add(15*time.Millisecond))
+		m.receivedMessage(22, begin.Add(25*time.Millisecond))
+
+		res := &callResult{}
+		m.result(res)
+
+		assert.Equal(t, int64(12), res.reqSize)
+		assert.Equal(t, int64(63), res.respSize)
+		assert.Equal(t, &streamResult{
+			sent:          1,
+			received:      3,
+			firstResponse: 10 * time.Millisecond,
+			gaps:          []time.Duration{5 * time.Millisecond, 10 * time.Millisecond},
+		}, res.stream)
+	})
+}
+
+func TestStreamStats(t *testing.T) {
+	ss := newStreamStats(3)
+
+	ss.record(&callResult{reqSize: 10, respSize: 30, stream: &streamResult{
+		sent: 1, received: 3, firstResponse: 4 * time.Millisecond,
+		gaps: []time.Duration{time.Millisecond, 3 * time.Millisecond},
+	}})
+	ss.record(&callResult{reqSize: 10, stream: &streamResult{sent: 1}})
+
+	sr := ss.report(2*time.Second, false)
+
+	assert.Equal(t, uint64(2), sr.Count)
+	assert.Equal(t, uint64(2), sr.MessagesSent)
+	assert.Equal(t, uint64(3), sr.MessagesReceived)
+	assert.Equal(t, int64(20), sr.BytesSent)
+	assert.Equal(t, int64(30), sr.BytesReceived)
+	assert.Equal(t, 1.0, sr.SentRate)
+	assert.Equal(t, 1.5, sr.ReceivedRate)
+
+	assert.Equal(t, uint64(1), sr.TimeToFirstResponse.Count)
+	assert.Equal(t, 4*time.Millisecond, sr.TimeToFirstResponse.Average)
+
+	assert.Equal(t, uint64(2), sr.InterMessageLatency.Count)
+	assert.Equal(t, 2*time.Millisecond, sr.InterMessageLatency.Average)
+	assert.Equal(t, time.Millisecond, sr.InterMessageLatency.Fastest)
+	assert.Equal(t, 3*time.Millisecond, sr.InterMessageLatency.Slowest)
+	assert.NotEmpty(t, sr.InterMessageLatency.LatencyDistribution)
+	assert.Nil(t, sr.InterMessageLatency.LatencyHistogram)
+}
+
+func TestRunStreamingStats(t *testing.T) {
+	gs, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	messages := len(gs.StreamData)
+
+	t.Run("server streaming", func(t *testing.T) {
+		report, err := Run(
+			"helloworld.Greeter.SayHellos",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(10),
+			WithConcurrency(2),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithLatencyHistogram(true),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		sr := report.Streaming
+		if !assert.NotNil(t, sr) {
+			return
+		}
+
+		assert.Equal(t, uint64(10), sr.Count)
+		assert.Equal(t, uint64(10), sr.MessagesSent)
+		assert.Equal(t, uint64(10*messages), sr.MessagesReceived)
+		assert.Greater(t, sr.BytesSent, int64(0))
+		assert.Greater(t, sr.BytesReceived, sr.BytesSent)
+		assert.Greater(t, sr.ReceivedRate, sr.SentRate)
+
+		assert.Equal(t, uint64(10), sr.TimeToFirstResponse.Count)
+		assert.NotZero(t, sr.TimeToFirstResponse.Average)
+		assert.LessOrEqual(t, sr.TimeToFirstResponse.Slowest, report.Slowest)
+
+		assert.Equal(t, uint64(10*(messages-1)), sr.InterMessageLatency.Count)
+		assert.NotEmpty(t, sr.InterMessageLatency.LatencyDistribution)
+		assert.NotNil(t, sr.InterMessageLatency.LatencyHistogram)
+
+		merged, err := MergeReports([]*Report{report, report})
+		assert.NoError(t, err)
+		assert.Equal(t, 2*sr.MessagesReceived, merged.Streaming.MessagesReceived)
+		assert.Equal(t, 2*sr.InterMessageLatency.Count, merged.Streaming.InterMessageLatency.Count)
+		assert.Equal(t, sr.InterMessageLatency.Slowest, merged.Streaming.InterMessageLatency.Slowest)
+	})
+
+	t.Run("unary", func(t *testing.T) {
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(5),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Nil(t, report.Streaming)
+	})
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// TimelineBucket holds the results received within a single window of the run timeline
+type TimelineBucket struct {
+	// Timestamp is the start time of the window
+	Timestamp time.Time `json:"timestamp"`
+
+	// Elapsed is the time from the start of the run to the start of the window
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Duration is the length of the window, the last window may be shorter than the interval
+	Duration time.Duration `json:"duration"`
+
+	Count      uint64  `json:"count"`
+	ErrorCount uint64  `json:"errorCount"`
+	Rps        float64 `json:"rps"`
+
+	// TargetRps is the rate of the load schedule at the start of the window, 0 if the run is not rate limited
+	TargetRps float64 `json:"targetRps"`
+
+	// Workers is the number of active workers at the end of the window
+	Workers int `json:"workers"`
+
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+
+	// LatencyDistribution is the 50th, 95th and 99th percentile latencies within the window
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+}
+
+// timelineSampler returns the target rate and the number of active workers at the elapsed duration
+type timelineSampler func(elapsed time.Duration) (float64, int)
+
+// timelineTracker splits the results of the run into fixed windows by the time they are received
+type timelineTracker struct {
+	start    time.Time
+	interval time.Duration
+	sample   timelineSampler
+
+	// the window in progress
+	begin          time.Time
+	targetRps      float64
+	count          uint64
+	errorCount     uint64
+	totalLatencies time.Duration
+	latencyHist    *hdrhistogram.Histogram
+	fastest        time.Duration
+	slowest        time.Duration
+
+	buckets []TimelineBucket
+}
+
+func newTimelineTracker(start time.Time, interval time.Duration, precision int, sample timelineSampler) *timelineTracker {
+	t := &timelineTracker{
+		start:       start,
+		interval:    interval,
+		sample:      sample,
+		begin:       start,
+		latencyHist: hdrhistogram.New(1, int64(maxTrackableLatency), precision),
+	}
+
+	t.targetRps, _ = sample(0)
+
+	return t
+}
+
+func (t *timelineTracker) record(res *callResult, errStr string, countLatency bool) {
+	t.count++
+
+	if errStr != "" {
+		t.errorCount++
+	}
+
+	if countLatency {
+		t.totalLatencies += res.duration
+
+		if t.latencyHist.TotalCount() == 0 || res.duration < t.fastest {
+			t.fastest = res.duration
+		}
+
+		if res.duration > t.slowest {
+			t.slowest = res.duration
+		}
+
+		recordHistValue(t.latencyHist, res.duration)
+	}
+}
+
+// close ends the window in progress at now and starts the next one
+func (t *timelineTracker) close(now time.Time) {
+	duration := now.Sub(t.begin)
+	if duration <= 0 {
+		return
+	}
+
+	_, workers := t.sample(now.Sub(t.start))
+
+	b := TimelineBucket{
+		Timestamp:  t.begin,
+		Elapsed:    t.begin.Sub(t.start),
+		Duration:   duration,
+		Count:      t.count,
+		ErrorCount: t.errorCount,
+		Rps:        float64(t.count) / duration.Seconds(),
+		TargetRps:  t.targetRps,
+		Workers:    workers,
+	}
+
+	if n := t.latencyHist.TotalCount(); n > 0 {
+		b.Average = t.totalLatencies / time.Duration(n)
+		b.Fastest = t.fastest
+		b.Slowest = t.slowest
+		b.LatencyDistribution = percentiles(t.latencyHist, progressPercentiles, t.fastest, t.slowest)
+	}
+
+	t.buckets = append(t.buckets, b)
+
+	t.begin = now
+	t.targetRps, _ = t.sample(now.Sub(t.start))
+	t.count = 0
+	t.errorCount = 0
+	t.totalLatencies = 0
+	t.fastest = 0
+	t.slowest = 0
+	t.latencyHist.Reset()
+}
+
+// report closes the last window at the end of the run and returns all the windows
+func (t *timelineTracker) report(total time.Duration) []TimelineBucket {
+	if end := t.start.Add(total); t.count > 0 || end.Sub(t.begin) >= t.interval/2 {
+		t.close(end)
+	}
+
+	return t.buckets
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bojand/ghz/internal"
+	"github.com/bojand/ghz/internal/helloworld"
+)
+
+func TestTimelineTracker(t *testing.T) {
+	start := time.Now()
+	workers := 2
+	tt := newTimelineTracker(start, time.Second, 3, func(elapsed time.Duration) (float64, int) {
+		return float64(10 + elapsed/time.Second), workers
+	})
+
+	for i := 1; i <= 4; i++ {
+		tt.record(&callResult{status: "OK", duration: time.Duration(i) * time.Millisecond}, "", true)
+	}
+	tt.record(&callResult{status: "Unavailable", err: errors.New("down"), duration: time.Second}, "down", false)
+
+	tt.close(start.Add(time.Second))
+
+	workers = 4
+	tt.close(start.Add(2 * time.Second))
+
+	tt.record(&callResult{status: "OK", duration: 10 * time.Millisecond}, "", true)
+
+	buckets := tt.report(2500 * time.Millisecond)
+	assert.Len(t, buckets, 3)
+
+	b := buckets[0]
+	assert.Equal(t, start, b.Timestamp)
+	assert.Zero(t, b.Elapsed)
+	assert.Equal(t, time.Second, b.Duration)
+	assert.Equal(t, uint64(5), b.Count)
+	assert.Equal(t, uint64(1), b.ErrorCount)
+	assert.Equal(t, 5.0, b.Rps)
+	assert.Equal(t, 10.0, b.TargetRps)
+	assert.Equal(t, 2, b.Workers)
+	assert.Equal(t, 2500*time.Microsecond, b.Average)
+	assert.Equal(t, time.Millisecond, b.Fastest)
+	assert.Equal(t, 4*time.Millisecond, b.Slowest)
+	assert.Len(t, b.LatencyDistribution, 3)
+	assert.Equal(t, 99, b.LatencyDistribution[2].Percentage)
+	assert.Equal(t, 4*time.Millisecond, b.LatencyDistribution[2].Latency)
+
+	b = buckets[1]
+	assert.Equal(t, time.Second, b.Elapsed)
+	assert.Zero(t, b.Count)
+	assert.Zero(t, b.Rps)
+	assert.Equal(t, 11.0, b.TargetRps)
+	assert.Equal(t, 4, b.Workers)
+	assert.Empty(t, b.LatencyDistribution)
+
+	b = buckets[2]
+	assert.Equal(t, 2*time.Second, b.Elapsed)
+	assert.Equal(t, 500*time.Millisecond, b.Duration)
+	assert.Equal(t, uint64(1), b.Count)
+	assert.Equal(t, 2.0, b.Rps)
+	assert.Equal(t, 12.0, b.TargetRps)
+}
+
+func TestRunTimeline(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := internal.StartServer(false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("step load", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithConcurrency(5),
+			WithRunDuration(2*time.Second),
+			WithLoadSchedule(ScheduleStep),
+			WithLoadStart(20),
+			WithLoadStep(20),
+			WithLoadStepDuration(time.Second),
+			WithTimelineInterval(500*time.Millisecond),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, 500*time.Millisecond, report.Options.TimelineInterval)
+
+		assert.True(t, len(report.Timeline) >= 3 && len(report.Timeline) <= 5, "timeline length %d", len(report.Timeline))
+
+		var count uint64
+		for i, b := range report.Timeline {
+			count += b.Count
+			assert.Equal(t, 5, b.Workers)
+			assert.NotZero(t, b.TargetRps)
+
+			if i > 0 {
+				assert.True(t, b.Elapsed > report.Timeline[i-1].Elapsed)
+			}
+		}
+
+		assert.Equal(t, report.Count, count)
+		assert.True(t, int(report.Count) <= gs.GetCount(callType))
+		assert.Equal(t, 20.0, report.Timeline[0].TargetRps)
+		assert.Equal(t, 40.0, report.Timeline[len(report.Timeline)-1].TargetRps)
+	})
+
+	t.Run("not included by default", func(t *testing.T) {
+		report, err := Run(
+			"helloworld.Greeter.SayHello",
+			internal.TestLocalhost,
+			WithProtoFile("../testdata/greeter.proto", []string{}),
+			WithTotalRequests(10),
+			WithConcurrency(2),
+			WithData(map[string]interface{}{"name": "bob"}),
+			WithInsecure(true),
+		)
+
+		assert.NoError(t, err)
+		assert.Empty(t, report.Timeline)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewConfig("helloworld.Greeter.SayHello", internal.TestLocalhost,
+			WithTimelineInterval(-time.Second),
+		)
+
+		assert.EqualError(t, err, "timeline interval cannot be negative")
+	})
+}
@@ -0,0 +1,275 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// responseValidation is a parsed response validation rule in the form "<path> <op> <value>"
+// evaluated against every response message received. Supported rules are:
+//
+//	token != ""                 expected field value, the value is a JSON literal or a bare string
+//	user.name == "bob"          nested field values using the response path syntax
+//	status == ACTIVE            enum values are compared by name
+//	items[0].quantity >= 1      numeric comparison using <, <=, > or >=
+//	token =~ ^[a-f0-9]{32}$     regular expression match using =~ or !~
+//	len(items) <= 10            length of a repeated field, map or string
+type responseValidation struct {
+	expr   string
+	path   *responsePath
+	length bool
+	op     string
+	value  interface{}
+	re     *regexp.Regexp
+}
+
+// validationOps are ordered so that the longer operators are matched first
+var validationOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+func parseResponseValidation(expr string) (*responseValidation, error) {
+	expr = strings.TrimSpace(expr)
+
+	opIdx, op := -1, ""
+	for _, o := range validationOps {
+		if i := strings.Index(expr, o); i > 0 && (opIdx < 0 || i < opIdx) {
+			opIdx, op = i, o
+		}
+	}
+
+	if opIdx < 0 {
+		return nil, fmt.Errorf("invalid validation %q: must be in the form \"<path> <op> <value>\"", expr)
+	}
+
+	left := strings.TrimSpace(expr[:opIdx])
+	right := strings.TrimSpace(expr[opIdx+len(op):])
+
+	v := &responseValidation{expr: expr, op: op}
+
+	if strings.HasPrefix(left, "len(") && strings.HasSuffix(left, ")") {
+		v.length = true
+		left = strings.TrimSpace(left[len("len(") : len(left)-1])
+	}
+
+	p, err := parseResponsePath(left)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation %q: %v", expr, err)
+	}
+	v.path = p
+
+	switch op {
+	case "=~", "!~":
+		if v.length {
+			return nil, fmt.Errorf("invalid validation %q: cannot match the length using %s", expr, op)
+		}
+
+		if v.re, err = regexp.Compile(right); err != nil {
+			return nil, fmt.Errorf("invalid validation %q: %v", expr, err)
+		}
+	case "<", "<=", ">", ">=":
+		n, err := strconv.ParseFloat(right, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validation %q: %q is not a number", expr, right)
+		}
+		v.value = n
+	default:
+		var lit interface{}
+		if err := json.Unmarshal([]byte(right), &lit); err != nil {
+			// a bare string
+			lit = right
+		}
+
+		if v.length {
+			n, ok := lit.(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid validation %q: %q is not a number", expr, right)
+			}
+			lit = n
+		}
+
+		v.value = lit
+	}
+
+	return v, nil
+}
+
+func parseResponseValidations(rules []string) ([]*responseValidation, error) {
+	var res []*responseValidation
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+
+		v, err := parseResponseValidation(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+// validationError is the error of a response failing validation.
+// The error message does not include the actual value so that
+// all the failures of a rule are counted together in the error distribution.
+type validationError struct {
+	rule   string
+	actual string
+}
+
+func (e *validationError) Error() string {
+	return "response validation failed: " + e.rule
+}
+
+func isValidationError(err error) bool {
+	var ve *validationError
+	return errors.As(err, &ve)
+}
+
+// validate validates the response message
+func (v *responseValidation) validate(msg *dynamic.Message) error {
+	val, err := v.path.value(msg)
+	if err != nil {
+		return &validationError{rule: v.expr, actual: err.Error()}
+	}
+
+	if v.length {
+		switch lv := val.(type) {
+		case []interface{}:
+			val = float64(len(lv))
+		case map[string]interface{}:
+			val = float64(len(lv))
+		case string:
+			val = float64(len(lv))
+		default:
+			return &validationError{rule: v.expr, actual: fmt.Sprintf("%v has no length", val)}
+		}
+	}
+
+	if !v.compare(val) {
+		return &validationError{rule: v.expr, actual: fmt.Sprint(val)}
+	}
+
+	return nil
+}
+
+func (v *responseValidation) compare(actual interface{}) bool {
+	switch v.op {
+	case "=~":
+		return v.re.MatchString(fmt.Sprint(actual))
+	case "!~":
+		return !v.re.MatchString(fmt.Sprint(actual))
+	case "==":
+		return valuesEqual(actual, v.value)
+	case "!=":
+		return !valuesEqual(actual, v.value)
+	}
+
+	a, ok := toFloat64(actual)
+	if !ok {
+		return false
+	}
+
+	expected := v.value.(float64)
+	switch v.op {
+	case "<":
+		return a < expected
+	case "<=":
+		return a <= expected
+	case ">":
+		return a > expected
+	case ">=":
+		return a >= expected
+	}
+
+	return false
+}
+
+func valuesEqual(actual, expected interface{}) bool {
+	switch ev := expected.(type) {
+	case nil:
+		return actual == nil
+	case float64:
+		a, ok := toFloat64(actual)
+		return ok && a == ev
+	case string:
+		return actual != nil && fmt.Sprint(actual) == ev
+	case bool:
+		a, ok := actual.(bool)
+		return ok && a == ev
+	}
+
+	// objects and arrays are compared by their JSON representation
+	a, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+
+	e, err := json.Marshal(expected)
+	return err == nil && string(a) == string(e)
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case string:
+		// 64 bit integers are represented as strings in JSON
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil && !math.IsNaN(f)
+	}
+
+	return 0, false
+}
+
+// rpcValidation holds the response validation state of a single RPC
+type rpcValidation struct {
+	validations []*responseValidation
+
+	lock sync.Mutex
+	err  error
+}
+
+// validate validates the received message. Only the first failure of the RPC is kept.
+func (rv *rpcValidation) validate(msg *dynamic.Message) {
+	rv.lock.Lock()
+	defer rv.lock.Unlock()
+
+	if rv.err != nil {
+		return
+	}
+
+	for _, v := range rv.validations {
+		if err := v.validate(msg); err != nil {
+			rv.err = err
+			return
+		}
+	}
+}
+
+// result returns the validation failure of the RPC if any
+func (rv *rpcValidation) result() error {
+	rv.lock.Lock()
+	defer rv.lock.Unlock()
+
+	return rv.err
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResponseValidation(t *testing.T) {
+	var tests = []struct {
+		in       string
+		op       string
+		length   bool
+		expected interface{}
+		err      string
+	}{
+		{`token != ""`, "!=", false, "", ""},
+		{`user.name == "bob"`, "==", false, "bob", ""},
+		{`status == ACTIVE`, "==", false, "ACTIVE", ""},
+		{`items[0].quantity >= 1`, ">=", false, float64(1), ""},
+		{`len(items) <= 10`, "<=", true, float64(10), ""},
+		{`len(items)==2`, "==", true, float64(2), ""},
+		{`token =~ ^[a-f0-9]+$`, "=~", false, nil, ""},
+		{`token`, "", false, nil, `invalid validation "token": must be in the form "<path> <op> <value>"`},
+		{`$ == 1`, "", false, nil, `invalid validation "$ == 1": invalid path "$": empty path`},
+		{`count > many`, "", false, nil, `invalid validation "count > many": "many" is not a number`},
+		{`len(items) == two`, "", false, nil, `invalid validation "len(items) == two": "two" is not a number`},
+		{`len(token) =~ 1`, "", false, nil, `invalid validation "len(token) =~ 1": cannot match the length using =~`},
+		{`token =~ [`, "", false, nil, "invalid validation \"token =~ [\": error parsing regexp: missing closing ]: `[`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := parseResponseValidation(tt.in)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.op, v.op)
+				assert.Equal(t, tt.length, v.length)
+				assert.Equal(t, tt.expected, v.value)
+			}
+		})
+	}
+}
+
+func TestResponseValidation_validate(t *testing.T) {
+	msg := newTestSession(t)
+
+	var tests = []struct {
+		in     string
+		passed bool
+	}{
+		{`token == abc123`, true},
+		{`token == "abc123"`, true},
+		{`token != ""`, true},
+		{`token != abc123`, false},
+		{`user.name == "bob"`, true},
+		{`user == {"name": "bob", "user_id": "u1"}`, true},
+		{`user == {"name": "alice", "user_id": "u1"}`, false},
+		{`status == ACTIVE`, true},
+		{`status == EXPIRED`, false},
+		{`items[0].quantity >= 2`, true},
+		{`items[1].quantity < 5`, false},
+		{`items[1].quantity == 5`, true},
+		{`expires > 1600000000`, true},
+		{`len(items) == 2`, true},
+		{`len(items) > 2`, false},
+		{`len(labels) == 1`, true},
+		{`len(token) == 6`, true},
+		{`len(items[0].quantity) == 1`, false},
+		{`token =~ ^[a-z0-9]+$`, true},
+		{`token !~ ^abc`, false},
+		{`tags == ["a", "b"]`, true},
+		{`items[5].id == i1`, false},
+		{`token > 1`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := parseResponseValidation(tt.in)
+			assert.NoError(t, err)
+
+			err = v.validate(msg)
+			if tt.passed {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, "response validation failed: "+tt.in)
+				assert.True(t, isValidationError(err))
+			}
+		})
+	}
+}
+
+func TestRPCValidation(t *testing.T) {
+	msg := newTestSession(t)
+
+	vs, err := parseResponseValidations([]string{`token != ""`, `status == EXPIRED`, `len(items) > 5`, ""})
+	assert.NoError(t, err)
+	assert.Len(t, vs, 3)
+
+	rv := &rpcValidation{validations: vs}
+	assert.NoError(t, rv.result())
+
+	rv.validate(msg)
+	rv.validate(msg)
+
+	// only the first failure is kept
+	assert.EqualError(t, rv.result(), "response validation failed: status == EXPIRED")
+}
@@ -15,20 +15,25 @@ import (
 	"go.uber.org/multierr"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // TickValue is the tick value
 type TickValue struct {
+	// instant is the intended start of the request on the load schedule
 	instant   time.Time
 	reqNumber uint64
 }
 
 // Worker is used for doing a single stream of requests in parallel
 type Worker struct {
-	stub grpcdynamic.Stub
-	mtd  *desc.MethodDescriptor
+	stub    grpcdynamic.Stub
+	calls   callSchedule
+	journey []*callTarget
+	results chan *callResult
 
 	config   *RunConfig
 	workerID string
@@ -36,9 +41,7 @@ type Worker struct {
 	stopCh   chan bool
 	ticks    <-chan TickValue
 
-	dataProvider     DataProviderFunc
-	metadataProvider MetadataProviderFunc
-	msgProvider      StreamMessageProviderFunc
+	msgProvider StreamMessageProviderFunc
 
 	streamRecv                    StreamRecvMsgInterceptFunc
 	streamInterceptorProviderFunc StreamInterceptorProviderFunc
@@ -80,20 +83,85 @@ func (w *Worker) Stop() {
 }
 
 func (w *Worker) makeRequest(tv TickValue) error {
+	if len(w.journey) > 0 {
+		return w.makeJourney(tv)
+	}
+
+	_, _, err := w.makeCall(tv, w.calls.next(tv.reqNumber), nil)
+
+	return err
+}
+
+// makeJourney makes the calls of the journey steps in order. The journey is aborted on the
+// first failed step and its result is reported along with the results of the step calls.
+func (w *Worker) makeJourney(tv TickValue) error {
+	start := time.Now()
+	js := newJourneyState()
+
+	var err, journeyErr error
+	for i, step := range w.journey {
+		stv := tv
+		if i > 0 {
+			// only the first step is held up by the load schedule
+			stv.instant = time.Time{}
+		}
+
+		res, callErr, stepErr := w.makeCall(stv, step, js)
+		if stepErr != nil {
+			err = stepErr
+			journeyErr = fmt.Errorf("%s: %w", step.name, stepErr)
+			break
+		}
+
+		if callErr != nil {
+			journeyErr = fmt.Errorf("%s: %w", step.name, callErr)
+			break
+		}
+
+		if recErr := js.record(step, res); recErr != nil {
+			journeyErr = fmt.Errorf("%s: %w", step.name, recErr)
+			break
+		}
+	}
+
+	end := time.Now()
+
+	w.results <- &callResult{
+		err:           journeyErr,
+		status:        status.Code(errors.Unwrap(journeyErr)).String(),
+		duration:      end.Sub(start),
+		timestamp:     end,
+		journey:       true,
+		journeyNumber: tv.reqNumber,
+	}
+
+	return err
+}
+
+// makeCall makes a single call to the target. The RPC or response validation error is reported
+// via the stats handler and returned as callErr along with the response. For streaming calls the response is the
+// last message received. The js journey state is nil if the call is not a journey step.
+func (w *Worker) makeCall(tv TickValue, target *callTarget, js *journeyState) (*dynamic.Message, error, error) {
 	reqNum := int64(tv.reqNumber)
 
-	ctd := newCallData(w.mtd, w.workerID, reqNum, !w.config.disableTemplateFuncs, !w.config.disableTemplateData, w.config.funcs)
+	mtd := target.mtd
+
+	ctd := newCallData(mtd, w.workerID, reqNum, !w.config.disableTemplateFuncs, !w.config.disableTemplateData, w.config.funcs)
+	if js != nil {
+		ctd.Vars = js.vars
+		ctd.Responses = js.responses
+	}
 
 	var streamInterceptor StreamInterceptor
-	if w.mtd.IsClientStreaming() || w.mtd.IsServerStreaming() {
+	if mtd.IsClientStreaming() || mtd.IsServerStreaming() {
 		if w.streamInterceptorProviderFunc != nil {
 			streamInterceptor = w.streamInterceptorProviderFunc(ctd)
 		}
 	}
 
-	reqMD, err := w.metadataProvider(ctd)
+	reqMD, err := target.metadataProvider(ctd)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	if w.config.enableCompression {
@@ -110,14 +178,38 @@ func (w *Worker) makeRequest(tv TickValue) error {
 	}
 	defer cancel()
 
+	ctx = withWorkerID(ctx, w.workerID)
+
+	// tag the call for the per call breakdown of the scenario
+	if w.config.scenario != nil {
+		ctx = withCallName(ctx, target.name)
+	}
+
+	// tag the journey steps so they are skipped along with their journey
+	if js != nil {
+		ctx = withJourney(ctx, tv.reqNumber)
+	}
+
+	// measure the corrected latency from the intended start via the stats handler
+	if !tv.instant.IsZero() {
+		ctx = withIntendedStart(ctx, tv.instant)
+	}
+
+	// validate the received messages via the stats handler
+	var rv *rpcValidation
+	if len(target.validations) > 0 {
+		rv = &rpcValidation{validations: target.validations}
+		ctx = withRPCValidation(ctx, rv)
+	}
+
 	// include the metadata
 	if reqMD != nil {
 		ctx = metadata.NewOutgoingContext(ctx, *reqMD)
 	}
 
-	inputs, err := w.dataProvider(ctd)
+	inputs, err := target.dataProvider(ctd)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	var msgProvider StreamMessageProviderFunc
@@ -125,18 +217,18 @@ func (w *Worker) makeRequest(tv TickValue) error {
 		msgProvider = w.msgProvider
 	} else if streamInterceptor != nil {
 		msgProvider = streamInterceptor.Send
-	} else if w.mtd.IsClientStreaming() {
+	} else if mtd.IsClientStreaming() {
 		if w.config.streamDynamicMessages {
-			mp, err := newDynamicMessageProvider(w.mtd, w.config.data, w.config.streamCallCount, !w.config.disableTemplateFuncs, !w.config.disableTemplateData)
+			mp, err := newDynamicMessageProvider(mtd, target.data, w.config.streamCallCount, !w.config.disableTemplateFuncs, !w.config.disableTemplateData)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 
 			msgProvider = mp.GetStreamMessage
 		} else {
 			mp, err := newStaticMessageProvider(w.config.streamCallCount, inputs)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 
 			msgProvider = mp.GetStreamMessage
@@ -144,40 +236,49 @@ func (w *Worker) makeRequest(tv TickValue) error {
 	}
 
 	if len(inputs) == 0 && msgProvider == nil {
-		return fmt.Errorf("no data provided for request")
+		return nil, nil, fmt.Errorf("no data provided for request")
 	}
 
 	var callType string
 	if w.config.hasLog {
 		callType = "unary"
-		if w.mtd.IsClientStreaming() && w.mtd.IsServerStreaming() {
+		if mtd.IsClientStreaming() && mtd.IsServerStreaming() {
 			callType = "bidi"
-		} else if w.mtd.IsServerStreaming() {
+		} else if mtd.IsServerStreaming() {
 			callType = "server-streaming"
-		} else if w.mtd.IsClientStreaming() {
+		} else if mtd.IsClientStreaming() {
 			callType = "client-streaming"
 		}
 
 		w.config.log.Debugw("Making request", "workerID", w.workerID,
-			"call type", callType, "call", w.mtd.GetFullyQualifiedName(),
+			"call type", callType, "call", mtd.GetFullyQualifiedName(),
 			"input", inputs, "metadata", reqMD)
 	}
 
+	var res proto.Message
+	var callErr error
+
 	// RPC errors are handled via stats handler
-	if w.mtd.IsClientStreaming() && w.mtd.IsServerStreaming() {
-		_ = w.makeBidiRequest(&ctx, ctd, msgProvider, streamInterceptor)
-	} else if w.mtd.IsClientStreaming() {
-		_ = w.makeClientStreamingRequest(&ctx, ctd, msgProvider)
-	} else if w.mtd.IsServerStreaming() {
-		_ = w.makeServerStreamingRequest(&ctx, inputs[0], streamInterceptor)
+	if mtd.IsClientStreaming() && mtd.IsServerStreaming() {
+		res, callErr = w.makeBidiRequest(&ctx, mtd, ctd, msgProvider, streamInterceptor)
+	} else if mtd.IsClientStreaming() {
+		res, callErr = w.makeClientStreamingRequest(&ctx, mtd, ctd, msgProvider)
+	} else if mtd.IsServerStreaming() {
+		res, callErr = w.makeServerStreamingRequest(&ctx, mtd, inputs[0], streamInterceptor)
 	} else {
-		_ = w.makeUnaryRequest(&ctx, reqMD, inputs[0])
+		res, callErr = w.makeUnaryRequest(&ctx, mtd, reqMD, inputs[0])
 	}
 
-	return err
+	if callErr == nil && rv != nil {
+		callErr = rv.result()
+	}
+
+	dm, _ := res.(*dynamic.Message)
+
+	return dm, callErr, err
 }
 
-func (w *Worker) makeUnaryRequest(ctx *context.Context, reqMD *metadata.MD, input *dynamic.Message) error {
+func (w *Worker) makeUnaryRequest(ctx *context.Context, mtd *desc.MethodDescriptor, reqMD *metadata.MD, input *dynamic.Message) (proto.Message, error) {
 	var res proto.Message
 	var resErr error
 	var callOptions = []grpc.CallOption{}
@@ -185,45 +286,47 @@ func (w *Worker) makeUnaryRequest(ctx *context.Context, reqMD *metadata.MD, inpu
 		callOptions = append(callOptions, grpc.UseCompressor(gzip.Name))
 	}
 
-	res, resErr = w.stub.InvokeRpc(*ctx, w.mtd, input, callOptions...)
+	res, resErr = w.stub.InvokeRpc(*ctx, mtd, input, callOptions...)
 
 	if w.config.hasLog {
 		inputData, _ := input.MarshalJSON()
 		resData, _ := json.Marshal(res)
 
 		w.config.log.Debugw("Received response", "workerID", w.workerID, "call type", "unary",
-			"call", w.mtd.GetFullyQualifiedName(),
+			"call", mtd.GetFullyQualifiedName(),
 			"input", string(inputData), "metadata", reqMD,
 			"response", string(resData), "error", resErr)
 	}
 
-	return resErr
+	return res, resErr
 }
 
-func (w *Worker) makeClientStreamingRequest(ctx *context.Context,
-	ctd *CallData, messageProvider StreamMessageProviderFunc) error {
+func (w *Worker) makeClientStreamingRequest(ctx *context.Context, mtd *desc.MethodDescriptor,
+	ctd *CallData, messageProvider StreamMessageProviderFunc) (proto.Message, error) {
 	var str *grpcdynamic.ClientStream
 	var callOptions = []grpc.CallOption{}
 	if w.config.enableCompression {
 		callOptions = append(callOptions, grpc.UseCompressor(gzip.Name))
 	}
-	str, err := w.stub.InvokeRpcClientStream(*ctx, w.mtd, callOptions...)
+	str, err := w.stub.InvokeRpcClientStream(*ctx, mtd, callOptions...)
 	if err != nil {
 		if w.config.hasLog {
 			w.config.log.Errorw("Invoke Client Streaming RPC call error: "+err.Error(), "workerID", w.workerID,
 				"call type", "client-streaming",
-				"call", w.mtd.GetFullyQualifiedName(), "error", err)
+				"call", mtd.GetFullyQualifiedName(), "error", err)
 		}
 
-		return err
+		return nil, err
 	}
 
+	var res proto.Message
+	var closeErr error
 	closeStream := func() {
-		res, closeErr := str.CloseAndReceive()
+		res, closeErr = str.CloseAndReceive()
 
 		if w.config.hasLog {
 			w.config.log.Debugw("Close and receive", "workerID", w.workerID, "call type", "client-streaming",
-				"call", w.mtd.GetFullyQualifiedName(),
+				"call", mtd.GetFullyQualifiedName(),
 				"response", res, "error", closeErr)
 		}
 	}
@@ -233,7 +336,7 @@ func (w *Worker) makeClientStreamingRequest(ctx *context.Context,
 
 		if w.config.hasLog {
 			w.config.log.Debugw("Send message", "workerID", w.workerID, "call type", "client-streaming",
-				"call", w.mtd.GetFullyQualifiedName(),
+				"call", mtd.GetFullyQualifiedName(),
 				"payload", payload, "error", err)
 		}
 
@@ -321,10 +424,10 @@ func (w *Worker) makeClientStreamingRequest(ctx *context.Context,
 	close(doneCh)
 	close(cancel)
 
-	return nil
+	return res, closeErr
 }
 
-func (w *Worker) makeServerStreamingRequest(ctx *context.Context, input *dynamic.Message, streamInterceptor StreamInterceptor) error {
+func (w *Worker) makeServerStreamingRequest(ctx *context.Context, mtd *desc.MethodDescriptor, input *dynamic.Message, streamInterceptor StreamInterceptor) (proto.Message, error) {
 	var callOptions = []grpc.CallOption{}
 	if w.config.enableCompression {
 		callOptions = append(callOptions, grpc.UseCompressor(gzip.Name))
@@ -333,17 +436,17 @@ func (w *Worker) makeServerStreamingRequest(ctx *context.Context, input *dynamic
 	callCtx, callCancel := context.WithCancel(*ctx)
 	defer callCancel()
 
-	str, err := w.stub.InvokeRpcServerStream(callCtx, w.mtd, input, callOptions...)
+	str, err := w.stub.InvokeRpcServerStream(callCtx, mtd, input, callOptions...)
 
 	if err != nil {
 		if w.config.hasLog {
 			w.config.log.Errorw("Invoke Server Streaming RPC call error: "+err.Error(), "workerID", w.workerID,
 				"call type", "server-streaming",
-				"call", w.mtd.GetFullyQualifiedName(),
+				"call", mtd.GetFullyQualifiedName(),
 				"input", input, "error", err)
 		}
 
-		return err
+		return nil, err
 	}
 
 	doneCh := make(chan struct{})
@@ -364,6 +467,7 @@ func (w *Worker) makeServerStreamingRequest(ctx *context.Context, input *dynamic
 		}()
 	}
 
+	var last proto.Message
 	interceptCanceled := false
 	counter := uint(0)
 	for err == nil {
@@ -376,10 +480,13 @@ func (w *Worker) makeServerStreamingRequest(ctx *context.Context, input *dynamic
 
 		var res proto.Message
 		res, err = str.RecvMsg()
+		if err == nil {
+			last = res
+		}
 
 		if w.config.hasLog {
 			w.config.log.Debugw("Receive message", "workerID", w.workerID, "call type", "server-streaming",
-				"call", w.mtd.GetFullyQualifiedName(),
+				"call", mtd.GetFullyQualifiedName(),
 				"response", res, "error", err)
 		}
 
@@ -433,27 +540,32 @@ func (w *Worker) makeServerStreamingRequest(ctx *context.Context, input *dynamic
 	close(doneCh)
 	close(cancel)
 
-	return err
+	// the stream was canceled by us rather than failed
+	if status.Code(err) == codes.Canceled && callCtx.Err() != nil && (*ctx).Err() == nil {
+		err = nil
+	}
+
+	return last, err
 }
 
-func (w *Worker) makeBidiRequest(ctx *context.Context,
-	ctd *CallData, messageProvider StreamMessageProviderFunc, streamInterceptor StreamInterceptor) error {
+func (w *Worker) makeBidiRequest(ctx *context.Context, mtd *desc.MethodDescriptor,
+	ctd *CallData, messageProvider StreamMessageProviderFunc, streamInterceptor StreamInterceptor) (proto.Message, error) {
 
 	var callOptions = []grpc.CallOption{}
 
 	if w.config.enableCompression {
 		callOptions = append(callOptions, grpc.UseCompressor(gzip.Name))
 	}
-	str, err := w.stub.InvokeRpcBidiStream(*ctx, w.mtd, callOptions...)
+	str, err := w.stub.InvokeRpcBidiStream(*ctx, mtd, callOptions...)
 
 	if err != nil {
 		if w.config.hasLog {
 			w.config.log.Errorw("Invoke Bidi RPC call error: "+err.Error(),
 				"workerID", w.workerID, "call type", "bidi",
-				"call", w.mtd.GetFullyQualifiedName(), "error", err)
+				"call", mtd.GetFullyQualifiedName(), "error", err)
 		}
 
-		return err
+		return nil, err
 	}
 
 	counter := uint(0)
@@ -466,7 +578,7 @@ func (w *Worker) makeBidiRequest(ctx *context.Context,
 
 		if w.config.hasLog {
 			w.config.log.Debugw("Close send", "workerID", w.workerID, "call type", "bidi",
-				"call", w.mtd.GetFullyQualifiedName(), "error", closeErr)
+				"call", mtd.GetFullyQualifiedName(), "error", closeErr)
 		}
 	}
 
@@ -489,6 +601,7 @@ func (w *Worker) makeBidiRequest(ctx *context.Context,
 	}
 
 	var recvErr error
+	var last proto.Message
 
 	go func() {
 		interceptCanceled := false
@@ -496,10 +609,13 @@ func (w *Worker) makeBidiRequest(ctx *context.Context,
 		for recvErr == nil {
 			var res proto.Message
 			res, recvErr = str.RecvMsg()
+			if recvErr == nil {
+				last = res
+			}
 
 			if w.config.hasLog {
 				w.config.log.Debugw("Receive message", "workerID", w.workerID, "call type", "bidi",
-					"call", w.mtd.GetFullyQualifiedName(),
+					"call", mtd.GetFullyQualifiedName(),
 					"response", res, "error", recvErr)
 			}
 
@@ -581,7 +697,7 @@ func (w *Worker) makeBidiRequest(ctx *context.Context,
 
 			if w.config.hasLog {
 				w.config.log.Debugw("Send message", "workerID", w.workerID, "call type", "bidi",
-					"call", w.mtd.GetFullyQualifiedName(),
+					"call", mtd.GetFullyQualifiedName(),
 					"payload", payload, "error", err)
 			}
 
@@ -632,9 +748,9 @@ func (w *Worker) makeBidiRequest(ctx *context.Context,
 	close(doneCh)
 	close(cancel)
 
-	if err == nil && recvErr != nil {
+	if err == nil && recvErr != nil && recvErr != io.EOF {
 		err = recvErr
 	}
 
-	return err
+	return last, err
 }
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+)
+
+// Access is the access level required by an API endpoint
+type Access int
+
+const (
+	// AccessRead is required to read projects and reports
+	AccessRead Access = iota
+
+	// AccessIngest is required to ingest reports into a project
+	AccessIngest
+
+	// AccessAdmin is required to manage projects, reports, webhooks and access tokens
+	AccessAdmin
+)
+
+// AuthDatabase interface for encapsulating database access.
+type AuthDatabase interface {
+	FindTokenByHash(string) (*model.Token, error)
+}
+
+// The Auth authenticates API requests using the admin credentials or an access token.
+// The admin credentials are sent using basic authentication and grant full access.
+// Access tokens are sent as bearer tokens: read tokens grant read access to everything,
+// and write tokens only grant ingesting reports into their project.
+type Auth struct {
+	DB AuthDatabase
+
+	Username string
+	Password string
+}
+
+// Require returns a middleware that only lets through requests authorized for the access level
+func (a *Auth) Require(access Access) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if a.isAdmin(ctx) {
+				return next(ctx)
+			}
+
+			token := bearerToken(ctx)
+			if token == "" {
+				// prompts the browser for the admin credentials
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="ghz"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+			}
+
+			t, err := a.DB.FindTokenByHash(model.HashToken(token))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid access token")
+			}
+
+			if !isAuthorized(t, access, ctx) {
+				return echo.NewHTTPError(http.StatusForbidden, "Access token is not authorized for this request")
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+func (a *Auth) isAdmin(ctx echo.Context) bool {
+	username, password, ok := ctx.Request().BasicAuth()
+	if !ok {
+		return false
+	}
+
+	validUsername := subtle.ConstantTimeCompare([]byte(username), []byte(a.Username)) == 1
+	validPassword := subtle.ConstantTimeCompare([]byte(password), []byte(a.Password)) == 1
+
+	return validUsername && validPassword
+}
+
+func isAuthorized(t *model.Token, access Access, ctx echo.Context) bool {
+	switch access {
+	case AccessRead:
+		return t.Scope == model.TokenScopeRead
+	case AccessIngest:
+		return t.Scope == model.TokenScopeWrite && t.ProjectID != nil &&
+			ctx.Param("pid") == strconv.FormatUint(uint64(*t.ProjectID), 10)
+	default:
+		return false
+	}
+}
+
+func bearerToken(ctx echo.Context) string {
+	const prefix = "Bearer "
+
+	header := ctx.Request().Header.Get(echo.HeaderAuthorization)
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(header[len(prefix):])
+}
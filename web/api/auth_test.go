@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/bojand/ghz/web/database"
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuth_Require(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	p := &model.Project{Name: "Auth project"}
+	assert.NoError(t, db.CreateProject(p))
+
+	newToken := func(scope model.TokenScope, projectID *uint) string {
+		tk := &model.Token{Scope: scope, ProjectID: projectID}
+		token, err := tk.GenerateToken()
+		assert.NoError(t, err)
+		assert.NoError(t, db.CreateToken(tk))
+		return token
+	}
+
+	readToken := newToken(model.TokenScopeRead, nil)
+	writeToken := newToken(model.TokenScopeWrite, &p.ID)
+
+	auth := &Auth{DB: db, Username: "admin", Password: "secret"}
+
+	pid := strconv.FormatUint(uint64(p.ID), 10)
+
+	var tests = []struct {
+		name     string
+		access   Access
+		pid      string
+		setAuth  func(*http.Request)
+		expected int
+	}{
+		{"no auth", AccessRead, "", func(*http.Request) {}, http.StatusUnauthorized},
+		{"admin read", AccessRead, "", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusOK},
+		{"admin ingest", AccessIngest, pid, func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusOK},
+		{"admin", AccessAdmin, "", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusOK},
+		{"wrong password", AccessRead, "", func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }, http.StatusUnauthorized},
+		{"wrong username", AccessRead, "", func(r *http.Request) { r.SetBasicAuth("root", "secret") }, http.StatusUnauthorized},
+		{"invalid token", AccessRead, "", bearer("ghz_invalid"), http.StatusUnauthorized},
+		{"read token read", AccessRead, "", bearer(readToken), http.StatusOK},
+		{"read token ingest", AccessIngest, pid, bearer(readToken), http.StatusForbidden},
+		{"read token admin", AccessAdmin, "", bearer(readToken), http.StatusForbidden},
+		{"write token ingest", AccessIngest, pid, bearer(writeToken), http.StatusOK},
+		{"write token ingest other project", AccessIngest, "1234", bearer(writeToken), http.StatusForbidden},
+		{"write token read", AccessRead, "", bearer(writeToken), http.StatusForbidden},
+		{"write token admin", AccessAdmin, "", bearer(writeToken), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setAuth(req)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.pid != "" {
+				c.SetParamNames("pid")
+				c.SetParamValues(tt.pid)
+			}
+
+			h := auth.Require(tt.access)(func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusOK)
+			})
+
+			err := h(c)
+			if tt.expected == http.StatusOK {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, rec.Code)
+				return
+			}
+
+			if assert.Error(t, err) {
+				httpError, ok := err.(*echo.HTTPError)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expected, httpError.Code)
+			}
+		})
+	}
+
+	t.Run("prompts for credentials", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := auth.Require(AccessRead)(func(ctx echo.Context) error { return nil })(c)
+		assert.Error(t, err)
+		assert.Equal(t, `Basic realm="ghz"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+	})
+}
+
+func bearer(token string) func(*http.Request) {
+	return func(r *http.Request) {
+		r.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+}
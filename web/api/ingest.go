@@ -1,23 +1,34 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/bojand/ghz/runner"
 	"github.com/bojand/ghz/web/model"
 	"github.com/labstack/echo"
 )
 
+// HeaderIdempotencyKey is the optional request header with the idempotency key of an ingest request.
+// Retrying an ingest request with the same key responds with the report created by the first request
+// instead of creating a duplicate.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed is the response header set when responding with the report created by
+// an earlier ingest request with the same idempotency key.
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
 // IngestDatabase interface for encapsulating database access.
 type IngestDatabase interface {
-	CreateProject(*model.Project) error
-	CreateReport(*model.Report) error
-	CreateHistogram(*model.Histogram) error
-	CreateOptions(*model.Options) error
 	FindProjectByID(uint) (*model.Project, error)
-	FindLatestReportForProject(uint) (*model.Report, error)
-	CreateDetailsBatch(uint, []*model.Detail) (uint, uint)
-	UpdateProjectStatus(uint, model.Status) error
+	FindReportByID(uint) (*model.Report, error)
+	GetOptionsForReport(uint) (*model.Options, error)
+	GetHistogramForReport(uint) (*model.Histogram, error)
+	FindIngestKey(string) (*model.IngestKey, error)
+	IngestReport(*model.Project, *model.Report, *model.Options, *model.Histogram, []*model.Detail, *model.IngestKey) error
 }
 
 // IngestResponse is the response to the ingest endpoint
@@ -47,9 +58,17 @@ type DetailsCreated struct {
 	Fail uint `json:"fail"`
 }
 
+// ReportNotifier is notified of the reports ingested into the projects.
+type ReportNotifier interface {
+	ReportIngested(p *model.Project, r *model.Report, previousStatus model.Status)
+}
+
 // The IngestAPI provides handlers for ingesting and processing reports.
 type IngestAPI struct {
 	DB IngestDatabase
+
+	// Notifier is optional
+	Notifier ReportNotifier
 }
 
 // IngestRequest is the raw report
@@ -63,13 +82,8 @@ func (api *IngestAPI) Ingest(ctx echo.Context) error {
 		return err
 	}
 
-	// Project
-	p := new(model.Project)
-	if err := api.DB.CreateProject(p); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-
-	return api.ingestToProject(p, ir, ctx)
+	// the project is created along with the report
+	return api.ingestToProject(new(model.Project), ir, ctx)
 }
 
 // IngestToProject ingests data into a specific project
@@ -91,61 +105,73 @@ func (api *IngestAPI) IngestToProject(ctx echo.Context) error {
 }
 
 func (api *IngestAPI) ingestToProject(p *model.Project, ir *IngestRequest, ctx echo.Context) error {
+	pid := p.ID
 
-	// first get latest (we'll need it later)
-	latest, _ := api.DB.FindLatestReportForProject(p.ID)
+	previousStatus := p.Status
+	if previousStatus == "" {
+		// new projects are created with the ok status
+		previousStatus = model.StatusOK
+	}
 
-	// Report
+	// Idempotency key
 
-	report := convertIngestToReport(p.ID, ir)
-	if err := api.DB.CreateReport(report); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	var key *model.IngestKey
+	if k := strings.TrimSpace(ctx.Request().Header.Get(HeaderIdempotencyKey)); k != "" {
+		hash, err := hashIngestRequest(ir)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		key = &model.IngestKey{Key: k, RequestHash: hash}
+
+		if existing, err := api.DB.FindIngestKey(k); err == nil {
+			return api.replayIngest(pid, key, existing, ctx)
+		}
 	}
 
+	// Report
+
+	report := convertIngestToReport(pid, ir)
+
 	// Options
 
-	o := &model.Options{
-		ReportID: report.ID,
-	}
+	o := new(model.Options)
 
 	opts := model.OptionsInfo(ir.Options)
 	o.Info = &opts
 
-	if err := api.DB.CreateOptions(o); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-
 	// Histogram
-	h := &model.Histogram{
-		ReportID: report.ID,
-	}
+
+	h := new(model.Histogram)
 
 	h.Buckets = make(model.BucketList, len(ir.Histogram))
 	for i := range ir.Histogram {
 		h.Buckets[i] = &ir.Histogram[i]
 	}
 
-	if err := api.DB.CreateHistogram(h); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-
 	// Details
 
 	details := make([]*model.Detail, len(ir.Details))
 	for i, v := range ir.Details {
-		det := model.Detail{ReportID: report.ID, ResultDetail: v}
+		det := model.Detail{ResultDetail: v}
 		details[i] = &det
 	}
 
-	created, errored := api.DB.CreateDetailsBatch(report.ID, details)
+	// Everything is created in a single transaction, which also updates the project status if needed
 
-	// Update project status if needed
-	if latest == nil || report.Date.After(latest.Date) {
-		if err := api.DB.UpdateProjectStatus(p.ID, report.Status); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := api.DB.IngestReport(p, report, o, h, details, key); err != nil {
+		// a concurrent request with the same idempotency key may have created the report first
+		if key != nil {
+			if existing, findErr := api.DB.FindIngestKey(key.Key); findErr == nil {
+				return api.replayIngest(pid, key, existing, ctx)
+			}
 		}
 
-		p.Status = report.Status
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if api.Notifier != nil {
+		api.Notifier.ReportIngested(p, report, previousStatus)
 	}
 
 	// Response
@@ -156,14 +182,67 @@ func (api *IngestAPI) ingestToProject(p *model.Project, ir *IngestRequest, ctx e
 		Options:   o,
 		Histogram: h,
 		Details: &DetailsCreated{
-			Success: created,
-			Fail:    errored,
+			Success: uint(len(details)),
 		},
 	}
 
 	return ctx.JSON(http.StatusCreated, rres)
 }
 
+// replayIngest responds with the report created by the earlier ingest request with the same idempotency key
+func (api *IngestAPI) replayIngest(pid uint, key, existing *model.IngestKey, ctx echo.Context) error {
+	if existing.RequestHash != key.RequestHash || (pid != 0 && existing.ProjectID != pid) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity,
+			"Idempotency key has already been used to ingest a different report")
+	}
+
+	p, err := api.DB.FindProjectByID(existing.ProjectID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	report, err := api.DB.FindReportByID(existing.ReportID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	o, err := api.DB.GetOptionsForReport(existing.ReportID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	h, err := api.DB.GetHistogramForReport(existing.ReportID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	rres := &IngestResponse{
+		Project:   p,
+		Report:    report,
+		Options:   o,
+		Histogram: h,
+		Details: &DetailsCreated{
+			Success: existing.Details,
+		},
+	}
+
+	ctx.Response().Header().Set(HeaderIdempotentReplayed, "true")
+
+	return ctx.JSON(http.StatusCreated, rres)
+}
+
+// hashIngestRequest returns the hash of the ingested report
+func hashIngestRequest(ir *IngestRequest) (string, error) {
+	b, err := json.Marshal(ir)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func convertIngestToReport(pid uint, ir *IngestRequest) *model.Report {
 	r := new(model.Report)
 	r.ProjectID = pid
@@ -262,3 +262,245 @@ func TestIngestAPI(t *testing.T) {
 		}
 	})
 }
+
+func TestIngestAPI_Thresholds(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	api := IngestAPI{DB: db}
+
+	errorRate := 0.1
+	p := &model.Project{
+		Name: "thresholds",
+		Thresholds: model.Thresholds{
+			ErrorRate:  &errorRate,
+			MinRPS:     1000,
+			Regression: 0.1,
+		},
+	}
+
+	if err := db.CreateProject(p); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	pid := strconv.FormatUint(uint64(p.ID), 10)
+
+	ingest := func(t *testing.T, file string) *IngestResponse {
+		dat, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/projects/"+pid+"/ingest", strings.NewReader(string(dat)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.SetParamNames("pid")
+		c.SetParamValues(pid)
+
+		r := new(IngestResponse)
+		if assert.NoError(t, api.IngestToProject(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(r))
+		}
+
+		return r
+	}
+
+	t.Run("within thresholds", func(t *testing.T) {
+		r := ingest(t, "../test/SayHello/report1.json")
+
+		assert.Equal(t, model.StatusOK, r.Report.Status)
+		assert.Empty(t, r.Report.Violations)
+		assert.Equal(t, model.StatusOK, r.Project.Status)
+	})
+
+	t.Run("errors within error rate", func(t *testing.T) {
+		r := ingest(t, "../test/SayHello/report2.json")
+
+		assert.NotEmpty(t, r.Report.ErrorDist)
+		assert.Equal(t, model.StatusOK, r.Report.Status)
+		assert.Empty(t, r.Report.Violations)
+		assert.Equal(t, model.StatusOK, r.Project.Status)
+	})
+
+	t.Run("violated", func(t *testing.T) {
+		r := ingest(t, "../test/SayHello/report3.json")
+
+		assert.Equal(t, model.StatusFail, r.Report.Status)
+		assert.Equal(t, model.StatusFail, r.Project.Status)
+
+		thresholds := make([]string, len(r.Report.Violations))
+		for i, v := range r.Report.Violations {
+			thresholds[i] = v.Threshold
+		}
+
+		assert.Contains(t, thresholds, "minRPS")
+		assert.Contains(t, thresholds, "regression.rps")
+		assert.Contains(t, thresholds, "regression.p95")
+		assert.NotContains(t, thresholds, "regression.average")
+
+		report, err := db.FindReportByID(r.Report.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusFail, report.Status)
+		assert.Equal(t, r.Report.Violations, report.Violations)
+	})
+}
+
+func TestIngestAPI_IdempotencyKey(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	api := IngestAPI{DB: db}
+
+	ingest := func(t *testing.T, file, key string) (*httptest.ResponseRecorder, error) {
+		dat, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(string(dat)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(HeaderIdempotencyKey, key)
+		rec := httptest.NewRecorder()
+
+		return rec, api.Ingest(e.NewContext(req, rec))
+	}
+
+	var first *IngestResponse
+
+	t.Run("first request", func(t *testing.T) {
+		rec, err := ingest(t, "../test/SayHello/report1.json", "ci-build-1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+
+			first = new(IngestResponse)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(first))
+			assert.NotZero(t, first.Report.ID)
+			assert.NotZero(t, first.Details.Success)
+			assert.Zero(t, first.Details.Fail)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		rec, err := ingest(t, "../test/SayHello/report1.json", "ci-build-1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+
+			r := new(IngestResponse)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(r))
+			assert.Equal(t, first.Project.ID, r.Project.ID)
+			assert.Equal(t, first.Report.ID, r.Report.ID)
+			assert.Equal(t, first.Options.ID, r.Options.ID)
+			assert.Equal(t, first.Histogram.ID, r.Histogram.ID)
+			assert.Equal(t, first.Details.Success, r.Details.Success)
+		}
+
+		count, err := db.CountReports()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+
+		count, err = db.CountProjects()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+	})
+
+	t.Run("different report", func(t *testing.T) {
+		_, err := ingest(t, "../test/SayHello/report2.json", "ci-build-1")
+		if assert.Error(t, err) {
+			httpError, ok := err.(*echo.HTTPError)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusUnprocessableEntity, httpError.Code)
+		}
+
+		count, err := db.CountReports()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+	})
+
+	t.Run("new key", func(t *testing.T) {
+		rec, err := ingest(t, "../test/SayHello/report1.json", "ci-build-2")
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+
+			r := new(IngestResponse)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(r))
+			assert.NotEqual(t, first.Report.ID, r.Report.ID)
+		}
+
+		count, err := db.CountReports()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), count)
+	})
+}
+
+type ingested struct {
+	project        *model.Project
+	report         *model.Report
+	previousStatus model.Status
+}
+
+type recordingNotifier struct {
+	ingested []ingested
+}
+
+func (n *recordingNotifier) ReportIngested(p *model.Project, r *model.Report, previousStatus model.Status) {
+	n.ingested = append(n.ingested, ingested{project: p, report: r, previousStatus: previousStatus})
+}
+
+func TestIngestAPI_Notifier(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	notifier := &recordingNotifier{}
+	api := IngestAPI{DB: db, Notifier: notifier}
+
+	ingest := func(t *testing.T, file, key string) {
+		dat, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(string(dat)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(HeaderIdempotencyKey, key)
+		rec := httptest.NewRecorder()
+
+		assert.NoError(t, api.Ingest(e.NewContext(req, rec)))
+	}
+
+	ingest(t, "../test/SayHello/report2.json", "key")
+
+	if assert.Len(t, notifier.ingested, 1) {
+		n := notifier.ingested[0]
+		assert.NotZero(t, n.report.ID)
+		assert.Equal(t, model.StatusFail, n.report.Status)
+		assert.Equal(t, model.StatusFail, n.project.Status)
+		assert.Equal(t, model.StatusOK, n.previousStatus)
+	}
+
+	// replayed requests are not notified
+	ingest(t, "../test/SayHello/report2.json", "key")
+	assert.Len(t, notifier.ingested, 1)
+}
@@ -24,6 +24,13 @@ type ProjectAPI struct {
 	DB ProjectDatabase
 }
 
+// projectUpdate is the body of a project update. The thresholds are only
+// updated if they are in the body.
+type projectUpdate struct {
+	model.Project
+	Thresholds *model.Thresholds `json:"thresholds"`
+}
+
 // ProjectList response
 type ProjectList struct {
 	Total uint             `json:"total"`
@@ -54,7 +61,7 @@ func (api *ProjectAPI) UpdateProject(ctx echo.Context) error {
 		return err
 	}
 
-	newVal := new(model.Project)
+	newVal := new(projectUpdate)
 
 	if err := api.bindAndValidate(ctx, newVal); err != nil {
 		return err
@@ -63,6 +70,10 @@ func (api *ProjectAPI) UpdateProject(ctx echo.Context) error {
 	project.Name = newVal.Name
 	project.Description = newVal.Description
 
+	if newVal.Thresholds != nil {
+		project.Thresholds = *newVal.Thresholds
+	}
+
 	err = api.DB.UpdateProject(project)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -180,7 +191,7 @@ func findProject(FindProjectByID func(id uint) (*model.Project, error), ctx echo
 	return project, err
 }
 
-func (api *ProjectAPI) bindAndValidate(ctx echo.Context, p *model.Project) error {
+func (api *ProjectAPI) bindAndValidate(ctx echo.Context, p interface{}) error {
 	if err := ctx.Bind(p); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
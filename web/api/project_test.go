@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
@@ -240,6 +241,108 @@ func TestProjectAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("UpdateProject thresholds", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/"+pid, strings.NewReader(`{"name":"Updated name","thresholds":{"percentiles":[{"percentage":95,"latency":50000000}],"errorRate":0.05,"minRPS":100}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.SetParamNames("pid")
+		c.SetParamValues(pid)
+
+		if assert.NoError(t, api.UpdateProject(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			p := new(model.Project)
+			err = json.NewDecoder(rec.Body).Decode(p)
+
+			assert.NoError(t, err)
+
+			assert.Equal(t, []model.PercentileThreshold{{Percentage: 95, Latency: 50 * time.Millisecond}}, p.Thresholds.Percentiles)
+			assert.Equal(t, 0.05, *p.Thresholds.ErrorRate)
+			assert.Equal(t, 100.0, p.Thresholds.MinRPS)
+
+			found, err := db.FindProjectByID(p.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, p.Thresholds, found.Thresholds)
+		}
+	})
+
+	t.Run("UpdateProject invalid thresholds", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/"+pid, strings.NewReader(`{"name":"Updated name","thresholds":{"errorRate":2}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.SetParamNames("pid")
+		c.SetParamValues(pid)
+
+		err := api.UpdateProject(c)
+		if assert.Error(t, err) {
+			httpError, ok := err.(*echo.HTTPError)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusBadRequest, httpError.Code)
+			assert.Equal(t, "Threshold error rate must be between 0 and 1", httpError.Message)
+		}
+	})
+
+	t.Run("UpdateProject without thresholds", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/"+pid, strings.NewReader(`{"name":"Renamed","description":"Renamed desc"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.SetParamNames("pid")
+		c.SetParamValues(pid)
+
+		if assert.NoError(t, api.UpdateProject(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			p := new(model.Project)
+			err = json.NewDecoder(rec.Body).Decode(p)
+
+			assert.NoError(t, err)
+
+			assert.Equal(t, "Renamed", p.Name)
+			assert.Equal(t, "Renamed desc", p.Description)
+
+			// the thresholds set before are kept
+			assert.Equal(t, []model.PercentileThreshold{{Percentage: 95, Latency: 50 * time.Millisecond}}, p.Thresholds.Percentiles)
+			assert.Equal(t, 0.05, *p.Thresholds.ErrorRate)
+			assert.Equal(t, 100.0, p.Thresholds.MinRPS)
+
+			found, err := db.FindProjectByID(p.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, p.Thresholds, found.Thresholds)
+		}
+	})
+
+	t.Run("UpdateProject clear thresholds", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/"+pid, strings.NewReader(`{"name":"Renamed","thresholds":{}}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.SetParamNames("pid")
+		c.SetParamValues(pid)
+
+		if assert.NoError(t, api.UpdateProject(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			found, err := db.FindProjectByID(projectID)
+			assert.NoError(t, err)
+			assert.Equal(t, model.Thresholds{}, found.Thresholds)
+		}
+	})
+
 	t.Run("ListProjects", func(t *testing.T) {
 		e := echo.New()
 		req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader(`{}`))
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+)
+
+// TokenDatabase interface for encapsulating database access.
+type TokenDatabase interface {
+	FindProjectByID(uint) (*model.Project, error)
+	CreateToken(*model.Token) error
+	FindTokenByID(uint) (*model.Token, error)
+	ListTokens() ([]*model.Token, error)
+	DeleteToken(*model.Token) error
+}
+
+// The TokenAPI provides handlers for managing access tokens.
+type TokenAPI struct {
+	DB TokenDatabase
+}
+
+// TokenRequest is the request to create an access token
+type TokenRequest struct {
+	Name      string           `json:"name"`
+	Scope     model.TokenScope `json:"scope"`
+	ProjectID *uint            `json:"projectID"`
+}
+
+// TokenCreated is the response to creating an access token. The token is only ever returned here.
+type TokenCreated struct {
+	*model.Token
+
+	// Secret is the access token to authenticate the requests with
+	Secret string `json:"token"`
+}
+
+// TokenList response
+type TokenList struct {
+	Total uint           `json:"total"`
+	Data  []*model.Token `json:"data"`
+}
+
+// CreateToken creates an access token
+func (api *TokenAPI) CreateToken(ctx echo.Context) error {
+	tr := new(TokenRequest)
+
+	if err := ctx.Bind(tr); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if tr.ProjectID != nil {
+		if _, err := api.DB.FindProjectByID(*tr.ProjectID); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown project")
+		}
+	}
+
+	t := &model.Token{
+		Name:      tr.Name,
+		Scope:     tr.Scope,
+		ProjectID: tr.ProjectID,
+	}
+
+	token, err := t.GenerateToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := api.DB.CreateToken(t); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return ctx.JSON(http.StatusCreated, &TokenCreated{Token: t, Secret: token})
+}
+
+// ListTokens lists the access tokens
+func (api *TokenAPI) ListTokens(ctx echo.Context) error {
+	tokens, err := api.DB.ListTokens()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, &TokenList{Total: uint(len(tokens)), Data: tokens})
+}
+
+// DeleteToken deletes an access token
+func (api *TokenAPI) DeleteToken(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("tid"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	t, err := api.DB.FindTokenByID(uint(id))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	if err := api.DB.DeleteToken(t); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, t)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bojand/ghz/web/database"
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAPI(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	api := TokenAPI{DB: db}
+
+	p := &model.Project{Name: "Token project"}
+	assert.NoError(t, db.CreateProject(p))
+
+	var tid string
+
+	t.Run("CreateToken", func(t *testing.T) {
+		e := echo.New()
+		body := `{"name":"CI","scope":"write","projectID":` + strconv.FormatUint(uint64(p.ID), 10) + `}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, api.CreateToken(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.NotContains(t, rec.Body.String(), `"hash"`)
+
+			tc := new(TokenCreated)
+			err = json.NewDecoder(rec.Body).Decode(tc)
+			assert.NoError(t, err)
+
+			assert.NotZero(t, tc.ID)
+			assert.Equal(t, "CI", tc.Name)
+			assert.Equal(t, model.TokenScopeWrite, tc.Scope)
+			assert.Equal(t, p.ID, *tc.ProjectID)
+			assert.True(t, strings.HasPrefix(tc.Secret, tc.Hint))
+
+			found, err := db.FindTokenByHash(model.HashToken(tc.Secret))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ID, found.ID)
+
+			tid = strconv.FormatUint(uint64(tc.ID), 10)
+		}
+	})
+
+	t.Run("CreateToken invalid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"name":"no project","scope":"write"}`,
+			`{"name":"unknown project","scope":"write","projectID":1234}`,
+			`{"name":"invalid scope","scope":"admin"}`,
+		} {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := api.CreateToken(c)
+			if assert.Error(t, err, body) {
+				httpError, ok := err.(*echo.HTTPError)
+				assert.True(t, ok)
+				assert.Equal(t, http.StatusBadRequest, httpError.Code)
+			}
+		}
+	})
+
+	t.Run("ListTokens", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, api.ListTokens(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), `"token"`)
+
+			tl := new(TokenList)
+			err = json.NewDecoder(rec.Body).Decode(tl)
+			assert.NoError(t, err)
+			assert.Equal(t, uint(1), tl.Total)
+			assert.Len(t, tl.Data, 1)
+			assert.Empty(t, tl.Data[0].Hash)
+		}
+	})
+
+	t.Run("DeleteToken", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/"+tid, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("tid")
+		c.SetParamValues(tid)
+
+		if assert.NoError(t, api.DeleteToken(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		tokens, err := db.ListTokens()
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("DeleteToken 404 for unknown", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodDelete, "/"+tid, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("tid")
+		c.SetParamValues(tid)
+
+		err := api.DeleteToken(c)
+		if assert.Error(t, err) {
+			httpError, ok := err.(*echo.HTTPError)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusNotFound, httpError.Code)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+)
+
+// dateLayout is the layout of the dates of the trend date range, besides RFC 3339
+const dateLayout = "2006-01-02"
+
+// TrendDatabase interface for encapsulating database access.
+type TrendDatabase interface {
+	FindProjectByID(uint) (*model.Project, error)
+	ListReportsForProjectByDate(pid uint, from, to time.Time) ([]*model.Report, error)
+}
+
+// The TrendAPI provides handlers for the trends of the reports of projects.
+type TrendAPI struct {
+	DB TrendDatabase
+}
+
+// GetTrend gets the trend of the reports of a project, filtered by the date range
+// and tags in the query parameters
+func (api *TrendAPI) GetTrend(ctx echo.Context) error {
+	project, err := findProject(api.DB.FindProjectByID, ctx)
+	if err != nil {
+		return err
+	}
+
+	from, err := parseTrendDate(ctx.QueryParam("from"), false)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid from date: "+err.Error())
+	}
+
+	to, err := parseTrendDate(ctx.QueryParam("to"), true)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid to date: "+err.Error())
+	}
+
+	tags, err := parseTrendTags(ctx.QueryParams()["tag"])
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	options, err := parseTrendOptions(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	reports, err := api.DB.ListReportsForProjectByDate(project.ID, from, to)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	filtered := make([]*model.Report, 0, len(reports))
+	for _, r := range reports {
+		if r.HasTags(tags) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, model.NewTrend(filtered, options))
+}
+
+// parseTrendDate parses an RFC 3339 time or a date. The end of the range is exclusive,
+// so a date ending the range includes the whole day.
+func parseTrendDate(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+// parseTrendTags parses the tags in the key:value format
+func parseTrendTags(values []string) (map[string]string, error) {
+	tags := make(map[string]string, len(values))
+
+	for _, v := range values {
+		parts := strings.SplitN(v, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid tag %q, must be key:value", v)
+		}
+
+		tags[parts[0]] = parts[1]
+	}
+
+	return tags, nil
+}
+
+func parseTrendOptions(ctx echo.Context) (model.TrendOptions, error) {
+	options := model.TrendOptions{
+		Interval: model.TrendInterval(strings.ToLower(ctx.QueryParam("interval"))),
+	}
+
+	if window := ctx.QueryParam("window"); window != "" {
+		w, err := strconv.Atoi(window)
+		if err != nil {
+			return options, fmt.Errorf("Invalid window %q", window)
+		}
+
+		options.Window = w
+	}
+
+	if threshold := ctx.QueryParam("threshold"); threshold != "" {
+		t, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			return options, fmt.Errorf("Invalid threshold %q", threshold)
+		}
+
+		options.Threshold = t
+	}
+
+	return options, options.Validate()
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bojand/ghz/web/database"
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrendAPI(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	api := TrendAPI{DB: db}
+
+	p := &model.Project{Name: "Trend project"}
+	assert.NoError(t, db.CreateProject(p))
+
+	for d := 1; d <= 6; d++ {
+		env := "staging"
+		if d%2 == 0 {
+			env = "prod"
+		}
+
+		average := 10 * time.Millisecond
+		if d == 6 {
+			average = 20 * time.Millisecond
+		}
+
+		assert.NoError(t, db.CreateReport(&model.Report{
+			ProjectID: p.ID,
+			Date:      time.Date(2020, 3, d, 12, 0, 0, 0, time.UTC),
+			Count:     100,
+			Average:   average,
+			Rps:       1000,
+			Tags:      model.StringStringMap{"env": env},
+		}))
+	}
+
+	pid := strconv.FormatUint(uint64(p.ID), 10)
+
+	getTrend := func(pid string, query url.Values) (*model.Trend, error) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("pid")
+		c.SetParamValues(pid)
+
+		if err := api.GetTrend(c); err != nil {
+			return nil, err
+		}
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		trend := new(model.Trend)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(trend))
+
+		return trend, nil
+	}
+
+	t.Run("all reports", func(t *testing.T) {
+		trend, err := getTrend(pid, url.Values{})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.TrendIntervalDay, trend.Interval)
+			assert.Equal(t, uint(6), trend.Reports)
+			assert.Len(t, trend.Buckets, 6)
+
+			if assert.Len(t, trend.ChangePoints, 1) {
+				cp := trend.ChangePoints[0]
+				assert.Equal(t, "average", cp.Metric)
+				assert.Equal(t, time.Date(2020, 3, 6, 0, 0, 0, 0, time.UTC), cp.Start.UTC())
+				assert.Equal(t, 1.0, cp.Change)
+				assert.True(t, cp.Regression)
+			}
+		}
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		trend, err := getTrend(pid, url.Values{"from": {"2020-03-02"}, "to": {"2020-03-04"}})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint(3), trend.Reports)
+			if assert.Len(t, trend.Buckets, 3) {
+				assert.Equal(t, time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC), trend.Buckets[0].Start.UTC())
+				assert.Equal(t, time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC), trend.Buckets[2].Start.UTC())
+			}
+		}
+	})
+
+	t.Run("RFC 3339 date range", func(t *testing.T) {
+		trend, err := getTrend(pid, url.Values{"from": {"2020-03-05T00:00:00Z"}, "to": {"2020-03-06T12:00:00Z"}})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint(1), trend.Reports)
+		}
+	})
+
+	t.Run("tags and interval", func(t *testing.T) {
+		trend, err := getTrend(pid, url.Values{"tag": {"env:staging"}, "interval": {"week"}})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.TrendIntervalWeek, trend.Interval)
+			assert.Equal(t, uint(3), trend.Reports)
+			if assert.Len(t, trend.Buckets, 2) {
+				assert.Equal(t, uint(1), trend.Buckets[0].Reports)
+				assert.Equal(t, uint(2), trend.Buckets[1].Reports)
+			}
+		}
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		trend, err := getTrend(pid, url.Values{"threshold": {"1.5"}})
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, trend.ChangePoints)
+		}
+	})
+
+	var errorTests = []struct {
+		name     string
+		pid      string
+		query    url.Values
+		code     int
+		expected string
+	}{
+		{"unknown project", "1234", url.Values{}, http.StatusNotFound, "record not found"},
+		{"invalid from", pid, url.Values{"from": {"yesterday"}}, http.StatusBadRequest, ""},
+		{"invalid to", pid, url.Values{"to": {"2020-13-01"}}, http.StatusBadRequest, ""},
+		{"invalid tag", pid, url.Values{"tag": {"env"}}, http.StatusBadRequest, `Invalid tag "env", must be key:value`},
+		{"invalid interval", pid, url.Values{"interval": {"year"}}, http.StatusBadRequest, "Trend interval must be hour, day, week or month"},
+		{"invalid window", pid, url.Values{"window": {"a"}}, http.StatusBadRequest, `Invalid window "a"`},
+		{"invalid threshold", pid, url.Values{"threshold": {"-1"}}, http.StatusBadRequest, "Trend threshold must be a positive number"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := getTrend(tt.pid, tt.query)
+
+			if assert.Error(t, err) {
+				httpError, ok := err.(*echo.HTTPError)
+				assert.True(t, ok)
+				assert.Equal(t, tt.code, httpError.Code)
+				if tt.expected != "" {
+					assert.Equal(t, tt.expected, httpError.Message)
+				}
+			}
+		})
+	}
+}
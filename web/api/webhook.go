@@ -0,0 +1,184 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+)
+
+// maxDeliveries is the number of latest deliveries listed for a webhook
+const maxDeliveries = 100
+
+// WebhookDatabase interface for encapsulating database access.
+type WebhookDatabase interface {
+	FindProjectByID(uint) (*model.Project, error)
+	CreateWebhook(*model.Webhook) error
+	FindWebhookByID(uint) (*model.Webhook, error)
+	UpdateWebhook(*model.Webhook) error
+	DeleteWebhook(*model.Webhook) error
+	ListWebhooksForProject(uint) ([]*model.Webhook, error)
+	ListDeliveriesForWebhook(wid, limit uint) ([]*model.WebhookDelivery, error)
+}
+
+// The WebhookAPI provides handlers for managing the webhooks of projects.
+type WebhookAPI struct {
+	DB WebhookDatabase
+}
+
+// WebhookRequest is the request to create or update a webhook
+type WebhookRequest struct {
+	URL      string              `json:"url"`
+	Format   model.WebhookFormat `json:"format"`
+	Template string              `json:"template"`
+	Events   []string            `json:"events"`
+
+	// Secret is the key the request body is signed with. When updating a webhook the
+	// secret is kept if not set, and removed if set to an empty string.
+	Secret *string `json:"secret"`
+}
+
+// WebhookList response
+type WebhookList struct {
+	Total uint             `json:"total"`
+	Data  []*model.Webhook `json:"data"`
+}
+
+// DeliveryList response
+type DeliveryList struct {
+	Total uint                     `json:"total"`
+	Data  []*model.WebhookDelivery `json:"data"`
+}
+
+// CreateWebhook creates a webhook for a project
+func (api *WebhookAPI) CreateWebhook(ctx echo.Context) error {
+	project, err := findProject(api.DB.FindProjectByID, ctx)
+	if err != nil {
+		return err
+	}
+
+	wr := new(WebhookRequest)
+	if err := ctx.Bind(wr); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	w := &model.Webhook{ProjectID: project.ID}
+	wr.apply(w)
+
+	if err := api.DB.CreateWebhook(w); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return ctx.JSON(http.StatusCreated, w)
+}
+
+// UpdateWebhook updates a webhook
+func (api *WebhookAPI) UpdateWebhook(ctx echo.Context) error {
+	w, err := api.findWebhook(ctx)
+	if err != nil {
+		return err
+	}
+
+	wr := new(WebhookRequest)
+	if err := ctx.Bind(wr); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	wr.apply(w)
+
+	if err := api.DB.UpdateWebhook(w); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, w)
+}
+
+// GetWebhook gets a webhook
+func (api *WebhookAPI) GetWebhook(ctx echo.Context) error {
+	w, err := api.findWebhook(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, w)
+}
+
+// ListWebhooks lists the webhooks of a project
+func (api *WebhookAPI) ListWebhooks(ctx echo.Context) error {
+	project, err := findProject(api.DB.FindProjectByID, ctx)
+	if err != nil {
+		return err
+	}
+
+	hooks, err := api.DB.ListWebhooksForProject(project.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, &WebhookList{Total: uint(len(hooks)), Data: hooks})
+}
+
+// DeleteWebhook deletes a webhook
+func (api *WebhookAPI) DeleteWebhook(ctx echo.Context) error {
+	w, err := api.findWebhook(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := api.DB.DeleteWebhook(w); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, w)
+}
+
+// ListDeliveries lists the latest deliveries of a webhook
+func (api *WebhookAPI) ListDeliveries(ctx echo.Context) error {
+	w, err := api.findWebhook(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := api.DB.ListDeliveriesForWebhook(w.ID, maxDeliveries)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, &DeliveryList{Total: uint(len(deliveries)), Data: deliveries})
+}
+
+// findWebhook finds the webhook of the project in the request path
+func (api *WebhookAPI) findWebhook(ctx echo.Context) (*model.Webhook, error) {
+	project, err := findProject(api.DB.FindProjectByID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("wid"), 10, 32)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	w, err := api.DB.FindWebhookByID(uint(id))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+
+	if w.ProjectID != project.ID {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Webhook not found")
+	}
+
+	return w, nil
+}
+
+func (wr *WebhookRequest) apply(w *model.Webhook) {
+	w.URL = wr.URL
+	w.Format = wr.Format
+	w.Template = wr.Template
+	w.Events = wr.Events
+
+	if wr.Secret != nil {
+		w.Secret = *wr.Secret
+	}
+}
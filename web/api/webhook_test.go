@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bojand/ghz/web/database"
+	"github.com/bojand/ghz/web/model"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookAPI(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	api := WebhookAPI{DB: db}
+
+	p := &model.Project{Name: "Webhook project"}
+	assert.NoError(t, db.CreateProject(p))
+
+	p2 := &model.Project{Name: "Other project"}
+	assert.NoError(t, db.CreateProject(p2))
+
+	pid := strconv.FormatUint(uint64(p.ID), 10)
+	var wid string
+
+	newContext := func(method, body string, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		names := []string{"pid", "wid"}
+		c.SetParamNames(names[:len(params)]...)
+		c.SetParamValues(params...)
+
+		return c, rec
+	}
+
+	t.Run("CreateWebhook", func(t *testing.T) {
+		c, rec := newContext(http.MethodPost, `{"url":"https://example.com/hook","format":"slack","events":["status"],"secret":"s3cret"}`, pid)
+
+		if assert.NoError(t, api.CreateWebhook(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "s3cret")
+
+			w := new(model.Webhook)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(w))
+			assert.NotZero(t, w.ID)
+			assert.Equal(t, p.ID, w.ProjectID)
+			assert.Equal(t, model.WebhookFormatSlack, w.Format)
+			assert.Equal(t, model.StringList{"status"}, w.Events)
+
+			found, err := db.FindWebhookByID(w.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "s3cret", found.Secret)
+
+			wid = strconv.FormatUint(uint64(w.ID), 10)
+		}
+	})
+
+	t.Run("CreateWebhook invalid", func(t *testing.T) {
+		c, _ := newContext(http.MethodPost, `{"url":"example.com","events":["status"]}`, pid)
+
+		err := api.CreateWebhook(c)
+		if assert.Error(t, err) {
+			httpError, ok := err.(*echo.HTTPError)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusBadRequest, httpError.Code)
+			assert.Equal(t, "Webhook URL must be an absolute http or https URL", httpError.Message)
+		}
+	})
+
+	t.Run("UpdateWebhook", func(t *testing.T) {
+		c, rec := newContext(http.MethodPut, `{"url":"https://example.com/hook2","events":["report","status"]}`, pid, wid)
+
+		if assert.NoError(t, api.UpdateWebhook(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			w := new(model.Webhook)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(w))
+			assert.Equal(t, "https://example.com/hook2", w.URL)
+			assert.Equal(t, model.WebhookFormatJSON, w.Format)
+			assert.Equal(t, model.StringList{"report", "status"}, w.Events)
+
+			// the secret is kept when not set
+			found, err := db.FindWebhookByID(w.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "s3cret", found.Secret)
+		}
+	})
+
+	t.Run("GetWebhook", func(t *testing.T) {
+		c, rec := newContext(http.MethodGet, "", pid, wid)
+
+		if assert.NoError(t, api.GetWebhook(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			w := new(model.Webhook)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(w))
+			assert.Equal(t, "https://example.com/hook2", w.URL)
+		}
+	})
+
+	t.Run("GetWebhook 404 for other project", func(t *testing.T) {
+		c, _ := newContext(http.MethodGet, "", strconv.FormatUint(uint64(p2.ID), 10), wid)
+
+		err := api.GetWebhook(c)
+		if assert.Error(t, err) {
+			httpError, ok := err.(*echo.HTTPError)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusNotFound, httpError.Code)
+		}
+	})
+
+	t.Run("ListWebhooks", func(t *testing.T) {
+		c, rec := newContext(http.MethodGet, "", pid)
+
+		if assert.NoError(t, api.ListWebhooks(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			wl := new(WebhookList)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(wl))
+			assert.Equal(t, uint(1), wl.Total)
+			assert.Len(t, wl.Data, 1)
+		}
+	})
+
+	t.Run("ListDeliveries", func(t *testing.T) {
+		id, _ := strconv.ParseUint(wid, 10, 32)
+		assert.NoError(t, db.CreateWebhookDelivery(&model.WebhookDelivery{
+			WebhookID:  uint(id),
+			Event:      model.WebhookEventStatus,
+			Attempts:   1,
+			StatusCode: 200,
+			Success:    true,
+		}))
+
+		c, rec := newContext(http.MethodGet, "", pid, wid)
+
+		if assert.NoError(t, api.ListDeliveries(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			dl := new(DeliveryList)
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(dl))
+			assert.Equal(t, uint(1), dl.Total)
+			if assert.Len(t, dl.Data, 1) {
+				assert.True(t, dl.Data[0].Success)
+				assert.Equal(t, 200, dl.Data[0].StatusCode)
+			}
+		}
+	})
+
+	t.Run("DeleteWebhook", func(t *testing.T) {
+		c, rec := newContext(http.MethodDelete, "", pid, wid)
+
+		if assert.NoError(t, api.DeleteWebhook(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		hooks, err := db.ListWebhooksForProject(p.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, hooks)
+	})
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/jinzhu/configor"
@@ -11,6 +12,27 @@ type Config struct {
 	Server   Server
 	Database Database
 	Log      Log
+	Auth     Auth
+	CORS     CORS
+}
+
+// Auth settings
+type Auth struct {
+	// Enabled requires the API requests to be authenticated using the admin credentials or an access token
+	Enabled bool
+	Admin   Admin
+}
+
+// Admin credentials, used by the UI and for full access to the API
+type Admin struct {
+	Username string
+	Password string
+}
+
+// CORS settings
+type CORS struct {
+	// Origins allowed to make cross-origin requests, all origins are allowed if empty
+	Origins []string
 }
 
 // Log settings
@@ -44,5 +66,9 @@ func Read(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if config.Auth.Enabled && (config.Auth.Admin.Username == "" || config.Auth.Admin.Password == "") {
+		return nil, errors.New("auth requires the admin username and password")
+	}
+
 	return &config, nil
 }
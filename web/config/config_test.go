@@ -42,6 +42,14 @@ func TestConfig_Read(t *testing.T) {
 				Server:   Server{Port: 4321},
 				Database: Database{Type: "postgres", Connection: "host=dbhost user=dbuser dbname=ghz sslmode=disable password=dbpwd"},
 				Log:      Log{Level: "warn", Path: "/tmp/ghz.log"}}},
+		{"config4.toml",
+			"../test/config4.toml",
+			&Config{
+				Server:   Server{Port: 3000},
+				Database: Database{Type: "sqlite3", Connection: "data/ghz.db"},
+				Log:      Log{Level: "info"},
+				Auth:     Auth{Enabled: true, Admin: Admin{Username: "admin", Password: "secret"}},
+				CORS:     CORS{Origins: []string{"https://ghz.example.com", "http://localhost:3000"}}}},
 	}
 
 	for _, tt := range tests {
@@ -51,4 +59,10 @@ func TestConfig_Read(t *testing.T) {
 			assert.Equal(t, tt.expected, actual)
 		})
 	}
+
+	t.Run("auth without password", func(t *testing.T) {
+		actual, err := Read("../test/config5.toml")
+		assert.EqualError(t, err, "auth requires the admin username and password")
+		assert.Nil(t, actual)
+	})
 }
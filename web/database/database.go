@@ -44,6 +44,10 @@ func New(dialect, connection string, log bool) (*Database, error) {
 		new(model.Options),
 		new(model.Detail),
 		new(model.Histogram),
+		new(model.IngestKey),
+		new(model.Token),
+		new(model.Webhook),
+		new(model.WebhookDelivery),
 	)
 
 	return &Database{DB: db}, nil
@@ -65,6 +69,14 @@ type Database struct {
 	DB *gorm.DB
 }
 
+// Transaction runs fn in a single database transaction, which is committed if fn
+// returns nil and rolled back otherwise.
+func (d *Database) Transaction(fn func(tx *Database) error) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&Database{DB: tx})
+	})
+}
+
 // Close closes the gorm database connection.
 func (d *Database) Close() error {
 	return d.DB.Close()
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"github.com/bojand/ghz/web/model"
+)
+
+// IngestReport creates the report along with its options, histogram and details in a single
+// transaction, so a failure at any step leaves nothing behind. The project is created too if it
+// does not exist yet. The report status is evaluated against the project thresholds, and the
+// project status is updated if the report is the latest of the project. If the idempotency key
+// is not nil it is created for the report within the same transaction.
+func (d *Database) IngestReport(p *model.Project, r *model.Report, o *model.Options, h *model.Histogram,
+	details []*model.Detail, key *model.IngestKey) error {
+	return d.Transaction(func(tx *Database) error {
+		if p.ID == 0 {
+			if err := tx.CreateProject(p); err != nil {
+				return err
+			}
+		}
+
+		latest, err := tx.FindLatestReportForProject(p.ID)
+		if err != nil {
+			return err
+		}
+
+		r.ProjectID = p.ID
+		p.EvaluateReport(r, latest)
+
+		if err := tx.CreateReport(r); err != nil {
+			return err
+		}
+
+		o.ReportID = r.ID
+		if err := tx.CreateOptions(o); err != nil {
+			return err
+		}
+
+		h.ReportID = r.ID
+		if err := tx.CreateHistogram(h); err != nil {
+			return err
+		}
+
+		for _, detail := range details {
+			detail.ReportID = r.ID
+			if err := tx.createDetail(detail); err != nil {
+				return err
+			}
+		}
+
+		if latest == nil || r.Date.After(latest.Date) {
+			if err := tx.UpdateProjectStatus(p.ID, r.Status); err != nil {
+				return err
+			}
+
+			p.Status = r.Status
+		}
+
+		if key != nil {
+			key.ProjectID = p.ID
+			key.ReportID = r.ID
+			key.Details = uint(len(details))
+
+			if err := tx.DB.Create(key).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// FindIngestKey gets the ingest idempotency key
+func (d *Database) FindIngestKey(key string) (*model.IngestKey, error) {
+	k := new(model.IngestKey)
+	err := d.DB.Where("idempotency_key = ?", key).First(k).Error
+	if err != nil {
+		k = nil
+	}
+	return k, err
+}
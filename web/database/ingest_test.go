@@ -0,0 +1,127 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bojand/ghz/runner"
+	"github.com/bojand/ghz/web/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_IngestReport(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	newIngest := func(date time.Time) (*model.Report, *model.Options, *model.Histogram, []*model.Detail) {
+		r := &model.Report{
+			Name:      "Test report",
+			Date:      date,
+			Count:     2,
+			Average:   10 * time.Millisecond,
+			Status:    model.StatusFail,
+			ErrorDist: map[string]int{"rpc error: code = Internal desc = Internal error.": 1},
+		}
+
+		o := &model.Options{Info: &model.OptionsInfo{Call: "helloworld.Greeter.SayHello"}}
+		h := &model.Histogram{Buckets: model.BucketList{{Mark: 0.01, Count: 2}}}
+
+		details := []*model.Detail{
+			{ResultDetail: runner.ResultDetail{Timestamp: date, Latency: time.Millisecond, Status: "OK"}},
+			{ResultDetail: runner.ResultDetail{Timestamp: date, Latency: time.Millisecond, Status: "Internal", Error: "Internal error."}},
+		}
+
+		return r, o, h, details
+	}
+
+	t.Run("new project", func(t *testing.T) {
+		p := &model.Project{Name: "Ingest project"}
+		r, o, h, details := newIngest(time.Date(2018, 12, 1, 8, 0, 0, 0, time.UTC))
+		key := &model.IngestKey{Key: "key1", RequestHash: "hash1"}
+
+		err := db.IngestReport(p, r, o, h, details, key)
+		assert.NoError(t, err)
+
+		assert.NotZero(t, p.ID)
+		assert.NotZero(t, r.ID)
+		assert.Equal(t, p.ID, r.ProjectID)
+		assert.Equal(t, model.StatusFail, r.Status)
+		assert.Equal(t, model.StatusFail, p.Status)
+		assert.Equal(t, r.ID, o.ReportID)
+		assert.Equal(t, r.ID, h.ReportID)
+
+		found, err := db.FindProjectByID(p.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusFail, found.Status)
+
+		dets, err := db.ListAllDetailsForReport(r.ID)
+		assert.NoError(t, err)
+		assert.Len(t, dets, 2)
+
+		k, err := db.FindIngestKey("key1")
+		assert.NoError(t, err)
+		assert.Equal(t, p.ID, k.ProjectID)
+		assert.Equal(t, r.ID, k.ReportID)
+		assert.Equal(t, uint(2), k.Details)
+		assert.Equal(t, "hash1", k.RequestHash)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		nProjects, err := db.CountProjects()
+		assert.NoError(t, err)
+
+		nReports, err := db.CountReports()
+		assert.NoError(t, err)
+
+		p := &model.Project{Name: "Rolled back project"}
+		r, o, h, details := newIngest(time.Date(2018, 12, 2, 8, 0, 0, 0, time.UTC))
+
+		// the key is created last and the duplicate fails the whole ingest
+		err = db.IngestReport(p, r, o, h, details, &model.IngestKey{Key: "key1", RequestHash: "hash2"})
+		assert.Error(t, err)
+
+		count, err := db.CountProjects()
+		assert.NoError(t, err)
+		assert.Equal(t, nProjects, count)
+
+		count, err = db.CountReports()
+		assert.NoError(t, err)
+		assert.Equal(t, nReports, count)
+
+		dets, err := db.ListAllDetailsForReport(r.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, dets)
+
+		_, err = db.GetOptionsForReport(r.ID)
+		assert.Error(t, err)
+
+		k, err := db.FindIngestKey("key1")
+		assert.NoError(t, err)
+		assert.Equal(t, "hash1", k.RequestHash)
+	})
+
+	t.Run("earlier report keeps project status", func(t *testing.T) {
+		p := &model.Project{Name: "Ingest project 2"}
+		r, o, h, details := newIngest(time.Date(2018, 12, 3, 8, 0, 0, 0, time.UTC))
+		assert.NoError(t, db.IngestReport(p, r, o, h, details, nil))
+		assert.Equal(t, model.StatusFail, p.Status)
+
+		r, o, h, details = newIngest(time.Date(2018, 12, 2, 8, 0, 0, 0, time.UTC))
+		r.ErrorDist = nil
+		r.Status = model.StatusOK
+		assert.NoError(t, db.IngestReport(p, r, o, h, details, nil))
+		assert.Equal(t, model.StatusOK, r.Status)
+
+		found, err := db.FindProjectByID(p.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusFail, found.Status)
+	})
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/bojand/ghz/web/model"
 )
@@ -115,6 +116,26 @@ func (d *Database) FindLatestReportForProject(pid uint) (*model.Report, error) {
 	return list[0], nil
 }
 
+// ListReportsForProjectByDate lists the reports of a project dated from the start time, inclusive,
+// to the end time, exclusive, oldest first. Zero times leave the range open.
+func (d *Database) ListReportsForProjectByDate(pid uint, from, to time.Time) ([]*model.Report, error) {
+	query := d.DB.Where("project_id = ?", pid)
+
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+
+	if !to.IsZero() {
+		query = query.Where("date < ?", to)
+	}
+
+	s := make([]*model.Report, 0)
+
+	err := query.Order("date asc").Find(&s).Error
+
+	return s, err
+}
+
 // ListReports lists reports using sorting
 func (d *Database) ListReports(limit, page uint, sortField, order string) ([]*model.Report, error) {
 	return d.listReports(false, 0, limit, page, sortField, order)
@@ -487,6 +487,41 @@ func TestDatabase_Report(t *testing.T) {
 		assert.Equal(t, uint(1), list[0].ID)
 	})
 
+	t.Run("ListReportsForProjectByDate", func(t *testing.T) {
+		list, err := db.ListReportsForProjectByDate(pid2, time.Time{}, time.Time{})
+
+		assert.NoError(t, err)
+		assert.Len(t, list, 2)
+
+		assert.Equal(t, rid2, list[0].ID)
+		assert.Equal(t, rid3, list[1].ID)
+	})
+
+	t.Run("ListReportsForProjectByDate from", func(t *testing.T) {
+		list, err := db.ListReportsForProjectByDate(pid2, time.Date(2018, 12, 3, 10, 0, 0, 0, time.UTC), time.Time{})
+
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		assert.Equal(t, rid3, list[0].ID)
+	})
+
+	t.Run("ListReportsForProjectByDate to", func(t *testing.T) {
+		list, err := db.ListReportsForProjectByDate(pid2, time.Time{}, time.Date(2018, 12, 3, 10, 0, 0, 0, time.UTC))
+
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		assert.Equal(t, rid2, list[0].ID)
+	})
+
+	t.Run("ListReportsForProjectByDate unknown", func(t *testing.T) {
+		list, err := db.ListReportsForProjectByDate(112311, time.Time{}, time.Time{})
+
+		assert.NoError(t, err)
+		assert.Len(t, list, 0)
+	})
+
 	t.Run("FindLatestReportForProject", func(t *testing.T) {
 		r, err := db.FindLatestReportForProject(pid2)
 
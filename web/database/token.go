@@ -0,0 +1,42 @@
+package database
+
+import (
+	"github.com/bojand/ghz/web/model"
+)
+
+// CreateToken creates a new access token
+func (d *Database) CreateToken(t *model.Token) error {
+	return d.DB.Create(t).Error
+}
+
+// FindTokenByID gets the access token by id
+func (d *Database) FindTokenByID(id uint) (*model.Token, error) {
+	t := new(model.Token)
+	err := d.DB.First(t, id).Error
+	if err != nil {
+		t = nil
+	}
+	return t, err
+}
+
+// FindTokenByHash gets the access token by the hash of the token
+func (d *Database) FindTokenByHash(hash string) (*model.Token, error) {
+	t := new(model.Token)
+	err := d.DB.Where("hash = ?", hash).First(t).Error
+	if err != nil {
+		t = nil
+	}
+	return t, err
+}
+
+// ListTokens lists all the access tokens
+func (d *Database) ListTokens() ([]*model.Token, error) {
+	s := make([]*model.Token, 0)
+	err := d.DB.Order("id asc").Find(&s).Error
+	return s, err
+}
+
+// DeleteToken deletes an existing access token
+func (d *Database) DeleteToken(t *model.Token) error {
+	return d.DB.Delete(t).Error
+}
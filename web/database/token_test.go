@@ -0,0 +1,80 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bojand/ghz/web/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_Token(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	p := &model.Project{Name: "Token project"}
+	assert.NoError(t, db.CreateProject(p))
+
+	read := &model.Token{Name: "read", Scope: model.TokenScopeRead}
+	readToken, err := read.GenerateToken()
+	assert.NoError(t, err)
+
+	write := &model.Token{Name: "write", Scope: model.TokenScopeWrite, ProjectID: &p.ID}
+	_, err = write.GenerateToken()
+	assert.NoError(t, err)
+
+	t.Run("CreateToken", func(t *testing.T) {
+		assert.NoError(t, db.CreateToken(read))
+		assert.NoError(t, db.CreateToken(write))
+		assert.NotZero(t, read.ID)
+		assert.NotZero(t, write.ID)
+	})
+
+	t.Run("FindTokenByHash", func(t *testing.T) {
+		found, err := db.FindTokenByHash(model.HashToken(readToken))
+		assert.NoError(t, err)
+		assert.Equal(t, read.ID, found.ID)
+		assert.Equal(t, model.TokenScopeRead, found.Scope)
+		assert.Nil(t, found.ProjectID)
+
+		found, err = db.FindTokenByHash(model.HashToken("ghz_unknown"))
+		assert.Error(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("FindTokenByID", func(t *testing.T) {
+		found, err := db.FindTokenByID(write.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "write", found.Name)
+		assert.Equal(t, p.ID, *found.ProjectID)
+	})
+
+	t.Run("ListTokens", func(t *testing.T) {
+		tokens, err := db.ListTokens()
+		assert.NoError(t, err)
+		assert.Len(t, tokens, 2)
+		assert.Equal(t, read.ID, tokens[0].ID)
+		assert.Equal(t, write.ID, tokens[1].ID)
+	})
+
+	t.Run("DeleteToken", func(t *testing.T) {
+		assert.NoError(t, db.DeleteToken(read))
+
+		_, err := db.FindTokenByID(read.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("delete project deletes its tokens", func(t *testing.T) {
+		assert.NoError(t, db.DeleteProject(p))
+
+		_, err := db.FindTokenByID(write.ID)
+		assert.Error(t, err)
+	})
+}
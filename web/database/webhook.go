@@ -0,0 +1,54 @@
+package database
+
+import (
+	"github.com/bojand/ghz/web/model"
+)
+
+// CreateWebhook creates a new webhook
+func (d *Database) CreateWebhook(w *model.Webhook) error {
+	return d.DB.Create(w).Error
+}
+
+// FindWebhookByID gets the webhook by id
+func (d *Database) FindWebhookByID(id uint) (*model.Webhook, error) {
+	w := new(model.Webhook)
+	err := d.DB.First(w, id).Error
+	if err != nil {
+		w = nil
+	}
+	return w, err
+}
+
+// UpdateWebhook updates a webhook
+func (d *Database) UpdateWebhook(w *model.Webhook) error {
+	return d.DB.Save(w).Error
+}
+
+// DeleteWebhook deletes an existing webhook
+func (d *Database) DeleteWebhook(w *model.Webhook) error {
+	return d.DB.Delete(w).Error
+}
+
+// ListWebhooksForProject lists the webhooks of the project
+func (d *Database) ListWebhooksForProject(pid uint) ([]*model.Webhook, error) {
+	s := make([]*model.Webhook, 0)
+	err := d.DB.Where("project_id = ?", pid).Order("id asc").Find(&s).Error
+	return s, err
+}
+
+// CreateWebhookDelivery creates a new webhook delivery log
+func (d *Database) CreateWebhookDelivery(wd *model.WebhookDelivery) error {
+	return d.DB.Create(wd).Error
+}
+
+// UpdateWebhookDelivery updates a webhook delivery log
+func (d *Database) UpdateWebhookDelivery(wd *model.WebhookDelivery) error {
+	return d.DB.Save(wd).Error
+}
+
+// ListDeliveriesForWebhook lists the latest deliveries of the webhook
+func (d *Database) ListDeliveriesForWebhook(wid, limit uint) ([]*model.WebhookDelivery, error) {
+	s := make([]*model.WebhookDelivery, 0)
+	err := d.DB.Where("webhook_id = ?", wid).Order("id desc").Limit(limit).Find(&s).Error
+	return s, err
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bojand/ghz/web/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_Webhook(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	p := &model.Project{Name: "Webhook project"}
+	assert.NoError(t, db.CreateProject(p))
+
+	p2 := &model.Project{Name: "Webhook project 2"}
+	assert.NoError(t, db.CreateProject(p2))
+
+	w := &model.Webhook{ProjectID: p.ID, URL: "https://example.com/hook", Events: model.StringList{model.WebhookEventReport}}
+	w2 := &model.Webhook{ProjectID: p2.ID, URL: "https://example.com/hook2", Events: model.StringList{model.WebhookEventStatus}}
+
+	t.Run("CreateWebhook", func(t *testing.T) {
+		assert.NoError(t, db.CreateWebhook(w))
+		assert.NoError(t, db.CreateWebhook(w2))
+		assert.NotZero(t, w.ID)
+		assert.Equal(t, model.WebhookFormatJSON, w.Format)
+	})
+
+	t.Run("UpdateWebhook", func(t *testing.T) {
+		w.Format = model.WebhookFormatSlack
+		assert.NoError(t, db.UpdateWebhook(w))
+
+		found, err := db.FindWebhookByID(w.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.WebhookFormatSlack, found.Format)
+		assert.Equal(t, model.StringList{model.WebhookEventReport}, found.Events)
+	})
+
+	t.Run("ListWebhooksForProject", func(t *testing.T) {
+		hooks, err := db.ListWebhooksForProject(p.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, hooks, 1) {
+			assert.Equal(t, w.ID, hooks[0].ID)
+		}
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, db.CreateWebhookDelivery(&model.WebhookDelivery{
+				WebhookID: w.ID,
+				Event:     model.WebhookEventReport,
+				Attempts:  i + 1,
+			}))
+		}
+
+		assert.NoError(t, db.CreateWebhookDelivery(&model.WebhookDelivery{WebhookID: w2.ID, Event: model.WebhookEventStatus}))
+
+		ds, err := db.ListDeliveriesForWebhook(w.ID, 2)
+		assert.NoError(t, err)
+		if assert.Len(t, ds, 2) {
+			assert.Equal(t, 3, ds[0].Attempts)
+			assert.Equal(t, 2, ds[1].Attempts)
+		}
+	})
+
+	t.Run("DeleteWebhook", func(t *testing.T) {
+		assert.NoError(t, db.DeleteWebhook(w))
+
+		_, err := db.FindWebhookByID(w.ID)
+		assert.Error(t, err)
+
+		ds, err := db.ListDeliveriesForWebhook(w.ID, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ds)
+	})
+
+	t.Run("delete project deletes its webhooks", func(t *testing.T) {
+		assert.NoError(t, db.DeleteProject(p2))
+
+		_, err := db.FindWebhookByID(w2.ID)
+		assert.Error(t, err)
+	})
+}
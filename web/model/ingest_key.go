@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// IngestKey is the idempotency key of an ingest request, mapping the key to the report created by the request
+type IngestKey struct {
+	Model
+
+	Key string `json:"key" gorm:"column:idempotency_key;unique_index;not null"`
+
+	// RequestHash is the hash of the ingested report, used to detect the key being reused for a different report
+	RequestHash string `json:"requestHash" gorm:"not null"`
+
+	ProjectID uint `json:"projectID" gorm:"type:integer REFERENCES projects(id) ON DELETE CASCADE;not null"`
+	ReportID  uint `json:"reportID" gorm:"type:integer REFERENCES reports(id) ON DELETE CASCADE;not null"`
+
+	// Details is the number of details created by the request
+	Details uint `json:"details"`
+}
+
+// BeforeSave is called by GORM before save
+func (k *IngestKey) BeforeSave(scope *gorm.Scope) error {
+	k.Key = strings.TrimSpace(k.Key)
+
+	if k.Key == "" {
+		return errors.New("Ingest key cannot be empty")
+	}
+
+	if k.ReportID == 0 {
+		return errors.New("Ingest key must belong to a report")
+	}
+
+	return nil
+}
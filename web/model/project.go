@@ -13,6 +13,9 @@ type Project struct {
 	Name        string `json:"name" gorm:"not null"`
 	Description string `json:"description"`
 	Status      Status `json:"status" gorm:"not null"`
+
+	// Thresholds are evaluated against the reports ingested into the project to set their status
+	Thresholds Thresholds `json:"thresholds" gorm:"type:TEXT"`
 }
 
 // BeforeCreate is a GORM hook called when a model is created
@@ -42,5 +45,30 @@ func (p *Project) BeforeSave() error {
 	p.Name = strings.TrimSpace(p.Name)
 	p.Description = strings.TrimSpace(p.Description)
 
-	return nil
+	return p.Thresholds.Validate()
+}
+
+// EvaluateReport sets the violated thresholds and the status of the report ingested into the project.
+// The latest report of the project is used for the regression threshold if it precedes the report.
+func (p *Project) EvaluateReport(r *Report, latest *Report) {
+	var previous *Report
+	if latest != nil && latest.Date.Before(r.Date) {
+		previous = latest
+	}
+
+	r.Violations = p.Thresholds.Evaluate(r, previous)
+
+	// without an error rate threshold any error fails the report
+	if p.Thresholds.ErrorRate == nil && len(r.ErrorDist) > 0 {
+		r.Violations = append(r.Violations, &Violation{
+			Threshold: ThresholdErrorRate,
+			Limit:     0,
+			Actual:    r.ErrorRate(),
+		})
+	}
+
+	r.Status = StatusOK
+	if len(r.Violations) > 0 {
+		r.Status = StatusFail
+	}
 }
@@ -222,3 +222,48 @@ func TestProject_Save(t *testing.T) {
 		assert.NotZero(t, p2.UpdatedAt)
 	})
 }
+
+func TestProject_EvaluateReport(t *testing.T) {
+	report := func() *Report {
+		return &Report{
+			Count:     100,
+			Rps:       500,
+			Status:    StatusFail,
+			ErrorDist: map[string]int{"rpc error": 5},
+		}
+	}
+
+	t.Run("errors without error rate threshold", func(t *testing.T) {
+		p := &Project{Thresholds: Thresholds{MinRPS: 100}}
+		r := report()
+
+		p.EvaluateReport(r, nil)
+
+		assert.Equal(t, StatusFail, r.Status)
+		assert.Equal(t, ViolationList{
+			{Threshold: "errorRate", Limit: 0, Actual: 0.05},
+		}, r.Violations)
+	})
+
+	t.Run("errors within error rate threshold", func(t *testing.T) {
+		errorRate := 0.1
+		p := &Project{Thresholds: Thresholds{ErrorRate: &errorRate}}
+		r := report()
+
+		p.EvaluateReport(r, nil)
+
+		assert.Equal(t, StatusOK, r.Status)
+		assert.Empty(t, r.Violations)
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		p := &Project{}
+		r := report()
+		r.ErrorDist = nil
+
+		p.EvaluateReport(r, nil)
+
+		assert.Equal(t, StatusOK, r.Status)
+		assert.Empty(t, r.Violations)
+	})
+}
@@ -133,6 +133,34 @@ type Report struct {
 	LatencyDistribution LatencyDistributionList `json:"latencyDistribution" gorm:"type:TEXT"`
 
 	Tags StringStringMap `json:"tags,omitempty" gorm:"type:TEXT"`
+
+	// Violations are the project thresholds violated by the report
+	Violations ViolationList `json:"violations,omitempty" gorm:"type:TEXT"`
+}
+
+// ErrorRate returns the ratio of errored calls to all calls of the report
+func (r *Report) ErrorRate() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+
+	errCount := 0
+	for _, v := range r.ErrorDist {
+		errCount += v
+	}
+
+	return float64(errCount) / float64(r.Count)
+}
+
+// HasTags returns whether the report has all the tags with the same values
+func (r *Report) HasTags(tags map[string]string) bool {
+	for k, v := range tags {
+		if rv, ok := r.Tags[k]; !ok || rv != v {
+			return false
+		}
+	}
+
+	return true
 }
 
 // BeforeSave is called by GORM before save
@@ -221,3 +221,14 @@ func TestReport(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestReport_HasTags(t *testing.T) {
+	r := &Report{Tags: StringStringMap{"env": "staging", "build": "123"}}
+
+	assert.True(t, r.HasTags(nil))
+	assert.True(t, r.HasTags(map[string]string{"env": "staging"}))
+	assert.True(t, r.HasTags(map[string]string{"env": "staging", "build": "123"}))
+	assert.False(t, r.HasTags(map[string]string{"env": "prod"}))
+	assert.False(t, r.HasTags(map[string]string{"env": "staging", "region": "eu"}))
+	assert.False(t, (&Report{}).HasTags(map[string]string{"env": "staging"}))
+}
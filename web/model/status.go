@@ -19,9 +19,9 @@ func StatusFromString(str string) Status {
 }
 
 const (
-	// StatusOK means the latest run in test was within the threshold
+	// StatusOK means the latest run in test was within the project thresholds
 	StatusOK = Status("ok")
 
-	// StatusFail means the latest run in test was not within the threshold
+	// StatusFail means the latest run in test was not within the project thresholds
 	StatusFail = Status("fail")
 )
@@ -0,0 +1,244 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Threshold names of the violations
+const (
+	ThresholdAverage    = "average"
+	ThresholdErrorRate  = "errorRate"
+	ThresholdMinRPS     = "minRPS"
+	ThresholdRegression = "regression"
+)
+
+// PercentileThreshold is the maximum latency of a percentile
+type PercentileThreshold struct {
+	Percentage int           `json:"percentage"`
+	Latency    time.Duration `json:"latency"`
+}
+
+// Thresholds are the limits the reports of a project are evaluated against when ingested.
+// The zero value of a threshold means it is not set.
+type Thresholds struct {
+	// Percentiles are the maximum latencies of the latency distribution percentiles
+	Percentiles []PercentileThreshold `json:"percentiles,omitempty"`
+
+	// Average is the maximum average latency
+	Average time.Duration `json:"average,omitempty"`
+
+	// ErrorRate is the maximum ratio of errored calls to all calls, from 0 to 1.
+	// If not set any error fails the report.
+	ErrorRate *float64 `json:"errorRate,omitempty"`
+
+	// MinRPS is the minimum requests per second
+	MinRPS float64 `json:"minRPS,omitempty"`
+
+	// Regression is the maximum relative increase of the average and percentile latencies,
+	// and relative decrease of the requests per second, versus the previous report of the project.
+	// For example 0.1 fails a report more than 10% slower than the previous one.
+	Regression float64 `json:"regression,omitempty"`
+}
+
+// Value converts struct to a database value
+func (t Thresholds) Value() (driver.Value, error) {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(v), nil
+}
+
+// Scan converts database value to a struct
+func (t *Thresholds) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+
+	var sourceStr string
+	sourceByte, ok := src.([]byte)
+	if !ok {
+		sourceStr, ok = src.(string)
+		if !ok {
+			return errors.New("type assertion from string / byte")
+		}
+		sourceByte = []byte(sourceStr)
+	}
+
+	return json.Unmarshal(sourceByte, t)
+}
+
+// Validate checks the thresholds are within the valid ranges
+func (t *Thresholds) Validate() error {
+	for _, p := range t.Percentiles {
+		if p.Percentage <= 0 || p.Percentage > 100 {
+			return fmt.Errorf("Invalid threshold percentile %d", p.Percentage)
+		}
+
+		if p.Latency <= 0 {
+			return fmt.Errorf("Threshold latency of percentile %d must be positive", p.Percentage)
+		}
+	}
+
+	if t.Average < 0 {
+		return errors.New("Threshold average cannot be negative")
+	}
+
+	if t.ErrorRate != nil && (*t.ErrorRate < 0 || *t.ErrorRate > 1) {
+		return errors.New("Threshold error rate must be between 0 and 1")
+	}
+
+	if t.MinRPS < 0 {
+		return errors.New("Threshold minimum RPS cannot be negative")
+	}
+
+	if t.Regression < 0 {
+		return errors.New("Threshold regression cannot be negative")
+	}
+
+	return nil
+}
+
+// Evaluate returns the thresholds violated by the report. The previous report is used for
+// the regression threshold and may be nil.
+func (t *Thresholds) Evaluate(r *Report, previous *Report) ViolationList {
+	var violations ViolationList
+
+	for _, p := range t.Percentiles {
+		if l, ok := percentileLatency(r, p.Percentage); ok && l > p.Latency {
+			violations = append(violations, &Violation{
+				Threshold: percentileName(p.Percentage),
+				Limit:     float64(p.Latency),
+				Actual:    float64(l),
+			})
+		}
+	}
+
+	if t.Average > 0 && r.Average > t.Average {
+		violations = append(violations, &Violation{
+			Threshold: ThresholdAverage,
+			Limit:     float64(t.Average),
+			Actual:    float64(r.Average),
+		})
+	}
+
+	errorRate := r.ErrorRate()
+	if t.ErrorRate != nil && errorRate > *t.ErrorRate {
+		violations = append(violations, &Violation{
+			Threshold: ThresholdErrorRate,
+			Limit:     *t.ErrorRate,
+			Actual:    errorRate,
+		})
+	}
+
+	if t.MinRPS > 0 && r.Rps < t.MinRPS {
+		violations = append(violations, &Violation{
+			Threshold: ThresholdMinRPS,
+			Limit:     t.MinRPS,
+			Actual:    r.Rps,
+		})
+	}
+
+	if t.Regression > 0 && previous != nil {
+		violations = append(violations, t.evaluateRegression(r, previous)...)
+	}
+
+	return violations
+}
+
+// evaluateRegression returns the regressions of the report versus the previous report
+func (t *Thresholds) evaluateRegression(r *Report, previous *Report) ViolationList {
+	var violations ViolationList
+
+	slower := func(name string, current, prev time.Duration) {
+		if prev <= 0 {
+			return
+		}
+
+		if change := float64(current-prev) / float64(prev); change > t.Regression {
+			violations = append(violations, &Violation{
+				Threshold: ThresholdRegression + "." + name,
+				Limit:     t.Regression,
+				Actual:    change,
+			})
+		}
+	}
+
+	slower(ThresholdAverage, r.Average, previous.Average)
+
+	for _, ld := range r.LatencyDistribution {
+		if prev, ok := percentileLatency(previous, ld.Percentage); ok {
+			slower(percentileName(ld.Percentage), ld.Latency, prev)
+		}
+	}
+
+	if previous.Rps > 0 {
+		if change := (previous.Rps - r.Rps) / previous.Rps; change > t.Regression {
+			violations = append(violations, &Violation{
+				Threshold: ThresholdRegression + ".rps",
+				Limit:     t.Regression,
+				Actual:    change,
+			})
+		}
+	}
+
+	return violations
+}
+
+func percentileLatency(r *Report, percentage int) (time.Duration, bool) {
+	for _, ld := range r.LatencyDistribution {
+		if ld != nil && ld.Percentage == percentage {
+			return ld.Latency, true
+		}
+	}
+
+	return 0, false
+}
+
+func percentileName(percentage int) string {
+	return "p" + strconv.Itoa(percentage)
+}
+
+// Violation is a threshold violated by a report. Latencies are in nanoseconds, and the limit
+// and actual value of a regression are the relative changes.
+type Violation struct {
+	Threshold string  `json:"threshold"`
+	Limit     float64 `json:"limit"`
+	Actual    float64 `json:"actual"`
+}
+
+// ViolationList is a slice of Violation pointers
+type ViolationList []*Violation
+
+// Value converts struct to a database value
+func (vl ViolationList) Value() (driver.Value, error) {
+	v, err := json.Marshal(vl)
+	if err != nil {
+		return nil, err
+	}
+	return string(v), nil
+}
+
+// Scan converts database value to a struct
+func (vl *ViolationList) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+
+	var sourceStr string
+	sourceByte, ok := src.([]byte)
+	if !ok {
+		sourceStr, ok = src.(string)
+		if !ok {
+			return errors.New("type assertion from string / byte")
+		}
+		sourceByte = []byte(sourceStr)
+	}
+
+	return json.Unmarshal(sourceByte, vl)
+}
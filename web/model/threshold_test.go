@@ -0,0 +1,134 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholds_Validate(t *testing.T) {
+	negative := -0.1
+	over := 1.5
+
+	var tests = []struct {
+		name       string
+		thresholds Thresholds
+		expected   string
+	}{
+		{"empty", Thresholds{}, ""},
+		{"valid", Thresholds{
+			Percentiles: []PercentileThreshold{{Percentage: 95, Latency: time.Millisecond}},
+			Average:     time.Millisecond,
+			MinRPS:      100,
+			Regression:  0.1,
+		}, ""},
+		{"invalid percentile", Thresholds{Percentiles: []PercentileThreshold{{Percentage: 101, Latency: time.Millisecond}}}, "Invalid threshold percentile 101"},
+		{"invalid percentile latency", Thresholds{Percentiles: []PercentileThreshold{{Percentage: 95}}}, "Threshold latency of percentile 95 must be positive"},
+		{"negative average", Thresholds{Average: -1}, "Threshold average cannot be negative"},
+		{"negative error rate", Thresholds{ErrorRate: &negative}, "Threshold error rate must be between 0 and 1"},
+		{"error rate over 1", Thresholds{ErrorRate: &over}, "Threshold error rate must be between 0 and 1"},
+		{"negative min rps", Thresholds{MinRPS: -1}, "Threshold minimum RPS cannot be negative"},
+		{"negative regression", Thresholds{Regression: -1}, "Threshold regression cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.thresholds.Validate()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestThresholds_Evaluate(t *testing.T) {
+	report := &Report{
+		Count:     100,
+		Average:   10 * time.Millisecond,
+		Rps:       200,
+		ErrorDist: StringIntMap{"rpc error: code = Unavailable desc = down": 5},
+		LatencyDistribution: LatencyDistributionList{
+			{Percentage: 50, Latency: 9 * time.Millisecond},
+			{Percentage: 95, Latency: 20 * time.Millisecond},
+		},
+	}
+
+	previous := &Report{
+		Average: 8 * time.Millisecond,
+		Rps:     250,
+		LatencyDistribution: LatencyDistributionList{
+			{Percentage: 50, Latency: 9 * time.Millisecond},
+			{Percentage: 95, Latency: 16 * time.Millisecond},
+		},
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		th := Thresholds{}
+		assert.Empty(t, th.Evaluate(report, previous))
+	})
+
+	t.Run("within", func(t *testing.T) {
+		errorRate := 0.1
+		th := Thresholds{
+			Percentiles: []PercentileThreshold{{Percentage: 95, Latency: 20 * time.Millisecond}, {Percentage: 99, Latency: time.Millisecond}},
+			Average:     10 * time.Millisecond,
+			ErrorRate:   &errorRate,
+			MinRPS:      200,
+			Regression:  0.3,
+		}
+		assert.Empty(t, th.Evaluate(report, previous))
+	})
+
+	t.Run("violated", func(t *testing.T) {
+		errorRate := 0.01
+		th := Thresholds{
+			Percentiles: []PercentileThreshold{{Percentage: 50, Latency: 10 * time.Millisecond}, {Percentage: 95, Latency: 15 * time.Millisecond}},
+			Average:     5 * time.Millisecond,
+			ErrorRate:   &errorRate,
+			MinRPS:      300,
+			Regression:  0.1,
+		}
+
+		assert.Equal(t, ViolationList{
+			{Threshold: "p95", Limit: float64(15 * time.Millisecond), Actual: float64(20 * time.Millisecond)},
+			{Threshold: "average", Limit: float64(5 * time.Millisecond), Actual: float64(10 * time.Millisecond)},
+			{Threshold: "errorRate", Limit: 0.01, Actual: 0.05},
+			{Threshold: "minRPS", Limit: 300, Actual: 200},
+			{Threshold: "regression.average", Limit: 0.1, Actual: 0.25},
+			{Threshold: "regression.p95", Limit: 0.1, Actual: 0.25},
+			{Threshold: "regression.rps", Limit: 0.1, Actual: 0.2},
+		}, th.Evaluate(report, previous))
+	})
+
+	t.Run("regression without previous", func(t *testing.T) {
+		th := Thresholds{Regression: 0.01}
+		assert.Empty(t, th.Evaluate(report, nil))
+	})
+}
+
+func TestReport_ErrorRate(t *testing.T) {
+	assert.Equal(t, 0.0, (&Report{}).ErrorRate())
+	assert.Equal(t, 0.0, (&Report{Count: 10}).ErrorRate())
+	assert.Equal(t, 0.3, (&Report{Count: 10, ErrorDist: StringIntMap{"a": 1, "b": 2}}).ErrorRate())
+}
+
+func TestThresholds_Scan(t *testing.T) {
+	th := Thresholds{
+		Percentiles: []PercentileThreshold{{Percentage: 99, Latency: time.Second}},
+		MinRPS:      10,
+	}
+
+	v, err := th.Value()
+	assert.NoError(t, err)
+
+	scanned := Thresholds{}
+	assert.NoError(t, scanned.Scan(v))
+	assert.Equal(t, th, scanned)
+
+	empty := Thresholds{}
+	assert.NoError(t, empty.Scan(nil))
+	assert.Equal(t, Thresholds{}, empty)
+}
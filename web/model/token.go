@@ -0,0 +1,86 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// TokenScope is the access granted by an access token
+type TokenScope string
+
+const (
+	// TokenScopeRead grants read only access to all the projects and reports
+	TokenScopeRead = TokenScope("read")
+
+	// TokenScopeWrite grants ingesting reports into the project of the token
+	TokenScopeWrite = TokenScope("write")
+)
+
+// tokenPrefix is the prefix of the generated access tokens
+const tokenPrefix = "ghz_"
+
+// Token represents an API access token. Only the hash of the token is stored.
+type Token struct {
+	Model
+
+	Name  string     `json:"name"`
+	Scope TokenScope `json:"scope" gorm:"not null"`
+
+	// ProjectID is the project a write token can ingest reports into
+	ProjectID *uint    `json:"projectID,omitempty" gorm:"type:integer REFERENCES projects(id) ON DELETE CASCADE"`
+	Project   *Project `json:"-"`
+
+	// Hint is the start of the token, to tell the tokens apart
+	Hint string `json:"hint"`
+	Hash string `json:"-" gorm:"unique_index;not null"`
+}
+
+// BeforeSave is called by GORM before save
+func (t *Token) BeforeSave(scope *gorm.Scope) error {
+	t.Name = strings.TrimSpace(t.Name)
+
+	switch t.Scope {
+	case TokenScopeRead:
+		if t.ProjectID != nil {
+			return errors.New("Read token cannot belong to a project")
+		}
+	case TokenScopeWrite:
+		if t.ProjectID == nil || *t.ProjectID == 0 {
+			return errors.New("Write token must belong to a project")
+		}
+	default:
+		return errors.New("Token scope must be read or write")
+	}
+
+	if t.Hash == "" {
+		return errors.New("Token hash cannot be empty")
+	}
+
+	return nil
+}
+
+// GenerateToken generates a new random access token and sets the hash and hint of the token to it
+func (t *Token) GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	token := tokenPrefix + hex.EncodeToString(b)
+
+	t.Hash = HashToken(token)
+	t.Hint = token[:len(tokenPrefix)+6]
+
+	return token, nil
+}
+
+// HashToken returns the hash of the access token stored in the database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken_GenerateToken(t *testing.T) {
+	tk := new(Token)
+
+	token, err := tk.GenerateToken()
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, "ghz_"))
+	assert.Len(t, token, 68)
+	assert.Equal(t, HashToken(token), tk.Hash)
+	assert.NotEqual(t, token, tk.Hash)
+	assert.True(t, strings.HasPrefix(token, tk.Hint))
+
+	other, err := new(Token).GenerateToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestToken_BeforeSave(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := gorm.Open("sqlite3", dbName)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	db.AutoMigrate(&Project{}, &Token{})
+	db.Exec("PRAGMA foreign_keys = ON;")
+
+	p := Project{Name: "Token project"}
+	assert.NoError(t, db.Create(&p).Error)
+
+	var tests = []struct {
+		name      string
+		scope     TokenScope
+		projectID *uint
+		expected  string
+	}{
+		{"read", TokenScopeRead, nil, ""},
+		{"write", TokenScopeWrite, &p.ID, ""},
+		{"read with project", TokenScopeRead, &p.ID, "Read token cannot belong to a project"},
+		{"write without project", TokenScopeWrite, nil, "Write token must belong to a project"},
+		{"invalid scope", TokenScope("admin"), nil, "Token scope must be read or write"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk := &Token{Name: " " + tt.name + " ", Scope: tt.scope, ProjectID: tt.projectID}
+			_, err := tk.GenerateToken()
+			assert.NoError(t, err)
+
+			err = db.Create(tk).Error
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				assert.NotZero(t, tk.ID)
+				assert.Equal(t, tt.name, tk.Name)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// TrendInterval is the period of time the reports of a trend are aggregated by
+type TrendInterval string
+
+const (
+	// TrendIntervalHour aggregates the reports by hour
+	TrendIntervalHour = TrendInterval("hour")
+
+	// TrendIntervalDay aggregates the reports by day
+	TrendIntervalDay = TrendInterval("day")
+
+	// TrendIntervalWeek aggregates the reports by week, starting on Monday
+	TrendIntervalWeek = TrendInterval("week")
+
+	// TrendIntervalMonth aggregates the reports by month
+	TrendIntervalMonth = TrendInterval("month")
+)
+
+// TrendMetricRPS is the change point metric name of the requests per second
+const TrendMetricRPS = "rps"
+
+// trendPercentiles are the latency percentiles aggregated by trends
+var trendPercentiles = []int{50, 95, 99}
+
+// changeSigmas is the number of standard deviations from the baseline mean a value must
+// differ by to be a change point, so changes within the usual noise of the baseline are ignored
+const changeSigmas = 2
+
+// minBaseline is the minimum number of buckets a value is compared with to detect a change
+const minBaseline = 2
+
+// TrendOptions are the options of a trend. The zero value of an option means the default.
+type TrendOptions struct {
+	// Interval is the period of the buckets. Default is day.
+	Interval TrendInterval `json:"interval"`
+
+	// Window is the maximum number of preceding buckets a bucket is compared with to
+	// detect a change. Default is 5.
+	Window int `json:"window"`
+
+	// Threshold is the minimum relative change from the preceding buckets to detect
+	// a change, for example 0.1 for 10%. Default is 0.1.
+	Threshold float64 `json:"threshold"`
+}
+
+// Validate validates the options
+func (o *TrendOptions) Validate() error {
+	switch o.Interval {
+	case "", TrendIntervalHour, TrendIntervalDay, TrendIntervalWeek, TrendIntervalMonth:
+	default:
+		return errors.New("Trend interval must be hour, day, week or month")
+	}
+
+	if o.Window < 0 {
+		return errors.New("Trend window must be a positive number")
+	}
+
+	if o.Threshold < 0 || math.IsNaN(o.Threshold) || math.IsInf(o.Threshold, 0) {
+		return errors.New("Trend threshold must be a positive number")
+	}
+
+	return nil
+}
+
+func (o TrendOptions) withDefaults() TrendOptions {
+	if o.Interval == "" {
+		o.Interval = TrendIntervalDay
+	}
+
+	if o.Window == 0 {
+		o.Window = 5
+	}
+
+	if o.Threshold == 0 {
+		o.Threshold = 0.1
+	}
+
+	return o
+}
+
+// TrendBucket is the aggregate of the reports within an interval. Latencies are averages
+// of the latencies of the reports weighted by their count.
+type TrendBucket struct {
+	// Start is the start of the interval, in UTC
+	Start time.Time `json:"start"`
+
+	// Reports is the number of reports in the interval
+	Reports uint `json:"reports"`
+
+	// Count is the total number of calls of the reports
+	Count uint64 `json:"count"`
+
+	Average time.Duration `json:"average"`
+	P50     time.Duration `json:"p50"`
+	P95     time.Duration `json:"p95"`
+	P99     time.Duration `json:"p99"`
+
+	// Rps is the average requests per second of the reports
+	Rps float64 `json:"rps"`
+
+	// ErrorRate is the ratio of errored calls to all calls of the reports
+	ErrorRate float64 `json:"errorRate"`
+}
+
+// ChangePoint is a bucket where a metric changed significantly from the preceding buckets.
+// Latencies are in nanoseconds.
+type ChangePoint struct {
+	// Metric is the name of the metric: average, p50, p95, p99, rps or errorRate
+	Metric string `json:"metric"`
+
+	// Start is the start of the bucket
+	Start time.Time `json:"start"`
+
+	// Baseline is the mean of the metric in the preceding buckets
+	Baseline float64 `json:"baseline"`
+
+	// Value is the value of the metric in the bucket
+	Value float64 `json:"value"`
+
+	// Change is the change relative to the baseline. It is 1 or -1 if the baseline is zero.
+	Change float64 `json:"change"`
+
+	// Regression is whether the change is for the worse
+	Regression bool `json:"regression"`
+}
+
+// Trend is the aggregate of the reports of a project over time
+type Trend struct {
+	Interval TrendInterval `json:"interval"`
+
+	// Reports is the number of reports aggregated
+	Reports uint `json:"reports"`
+
+	// Buckets are the intervals with at least one report, oldest first
+	Buckets []*TrendBucket `json:"buckets"`
+
+	// ChangePoints are the significant changes of the metrics, oldest first
+	ChangePoints []*ChangePoint `json:"changePoints"`
+}
+
+// NewTrend aggregates the reports by interval and detects the change points
+func NewTrend(reports []*Report, options TrendOptions) *Trend {
+	options = options.withDefaults()
+
+	sorted := make([]*Report, len(reports))
+	copy(sorted, reports)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	trend := &Trend{
+		Interval:     options.Interval,
+		Reports:      uint(len(sorted)),
+		Buckets:      make([]*TrendBucket, 0),
+		ChangePoints: make([]*ChangePoint, 0),
+	}
+
+	for i := 0; i < len(sorted); {
+		start := options.Interval.truncate(sorted[i].Date)
+
+		j := i + 1
+		for j < len(sorted) && options.Interval.truncate(sorted[j].Date).Equal(start) {
+			j++
+		}
+
+		trend.Buckets = append(trend.Buckets, newTrendBucket(start, sorted[i:j]))
+
+		i = j
+	}
+
+	trend.detectChanges(options)
+
+	return trend
+}
+
+// truncate returns the start of the interval of the time, in UTC
+func (i TrendInterval) truncate(t time.Time) time.Time {
+	t = t.UTC()
+
+	switch i {
+	case TrendIntervalHour:
+		return t.Truncate(time.Hour)
+	case TrendIntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case TrendIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func newTrendBucket(start time.Time, reports []*Report) *TrendBucket {
+	b := &TrendBucket{Start: start, Reports: uint(len(reports))}
+
+	var average, rps, weights float64
+	var errCount uint64
+	percentiles := make([]weightedMean, len(trendPercentiles))
+
+	for _, r := range reports {
+		// reports without calls still count, so the bucket latencies are defined
+		weight := math.Max(float64(r.Count), 1)
+
+		b.Count += r.Count
+		average += weight * float64(r.Average)
+		weights += weight
+		rps += r.Rps
+
+		for _, v := range r.ErrorDist {
+			errCount += uint64(v)
+		}
+
+		for i, percentage := range trendPercentiles {
+			if latency, ok := percentileLatency(r, percentage); ok {
+				percentiles[i].add(float64(latency), weight)
+			}
+		}
+	}
+
+	b.Average = time.Duration(average / weights)
+	b.P50 = time.Duration(percentiles[0].mean())
+	b.P95 = time.Duration(percentiles[1].mean())
+	b.P99 = time.Duration(percentiles[2].mean())
+	b.Rps = rps / float64(len(reports))
+
+	if b.Count > 0 {
+		b.ErrorRate = float64(errCount) / float64(b.Count)
+	}
+
+	return b
+}
+
+type weightedMean struct {
+	sum    float64
+	weight float64
+}
+
+func (m *weightedMean) add(value, weight float64) {
+	m.sum += value * weight
+	m.weight += weight
+}
+
+func (m *weightedMean) mean() float64 {
+	if m.weight == 0 {
+		return 0
+	}
+
+	return m.sum / m.weight
+}
+
+// detectChanges compares the metrics of each bucket with the mean of the preceding buckets,
+// and restarts the baseline at each change point so a lasting change is only reported once
+func (t *Trend) detectChanges(options TrendOptions) {
+	metrics := []struct {
+		name string
+
+		// latency metrics are missing from buckets without latency distributions
+		latency bool
+
+		// higherIsWorse is whether an increase of the metric is a regression
+		higherIsWorse bool
+
+		value func(b *TrendBucket) float64
+	}{
+		{ThresholdAverage, true, true, func(b *TrendBucket) float64 { return float64(b.Average) }},
+		{percentileName(50), true, true, func(b *TrendBucket) float64 { return float64(b.P50) }},
+		{percentileName(95), true, true, func(b *TrendBucket) float64 { return float64(b.P95) }},
+		{percentileName(99), true, true, func(b *TrendBucket) float64 { return float64(b.P99) }},
+		{TrendMetricRPS, false, false, func(b *TrendBucket) float64 { return b.Rps }},
+		{ThresholdErrorRate, false, true, func(b *TrendBucket) float64 { return b.ErrorRate }},
+	}
+
+	for _, metric := range metrics {
+		buckets := make([]*TrendBucket, 0, len(t.Buckets))
+		values := make([]float64, 0, len(t.Buckets))
+
+		for _, b := range t.Buckets {
+			value := metric.value(b)
+			if metric.latency && value == 0 {
+				continue
+			}
+
+			buckets = append(buckets, b)
+			values = append(values, value)
+		}
+
+		baselineStart := 0
+
+		for i, value := range values {
+			from := baselineStart
+			if i-options.Window > from {
+				from = i - options.Window
+			}
+
+			if i-from < minBaseline && i-from < options.Window {
+				continue
+			}
+
+			mean, stddev := meanStddev(values[from:i])
+			diff := value - mean
+
+			if diff == 0 || math.Abs(diff) < changeSigmas*stddev {
+				continue
+			}
+
+			change := math.Copysign(1, diff)
+			if mean != 0 {
+				change = diff / math.Abs(mean)
+			}
+
+			if math.Abs(change) < options.Threshold {
+				continue
+			}
+
+			t.ChangePoints = append(t.ChangePoints, &ChangePoint{
+				Metric:     metric.name,
+				Start:      buckets[i].Start,
+				Baseline:   mean,
+				Value:      value,
+				Change:     change,
+				Regression: (diff > 0) == metric.higherIsWorse,
+			})
+
+			baselineStart = i
+		}
+	}
+
+	sort.SliceStable(t.ChangePoints, func(i, j int) bool {
+		return t.ChangePoints[i].Start.Before(t.ChangePoints[j].Start)
+	})
+}
+
+func meanStddev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
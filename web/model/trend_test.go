@@ -0,0 +1,201 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func trendReport(date time.Time, count uint64, average time.Duration, rps float64, errors int) *Report {
+	r := &Report{
+		Date:    date,
+		Count:   count,
+		Average: average,
+		Rps:     rps,
+		LatencyDistribution: LatencyDistributionList{
+			{Percentage: 50, Latency: average},
+			{Percentage: 95, Latency: 2 * average},
+			{Percentage: 99, Latency: 3 * average},
+		},
+	}
+
+	if errors > 0 {
+		r.ErrorDist = StringIntMap{"rpc error: code = Unavailable desc = down": errors}
+	}
+
+	return r
+}
+
+func day(d int) time.Time {
+	return time.Date(2020, 3, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestTrendOptions_Validate(t *testing.T) {
+	var tests = []struct {
+		name     string
+		options  TrendOptions
+		expected string
+	}{
+		{"empty", TrendOptions{}, ""},
+		{"valid", TrendOptions{Interval: TrendIntervalWeek, Window: 3, Threshold: 0.2}, ""},
+		{"invalid interval", TrendOptions{Interval: "year"}, "Trend interval must be hour, day, week or month"},
+		{"negative window", TrendOptions{Window: -1}, "Trend window must be a positive number"},
+		{"negative threshold", TrendOptions{Threshold: -0.1}, "Trend threshold must be a positive number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewTrend_Buckets(t *testing.T) {
+	reports := []*Report{
+		trendReport(day(2).Add(time.Hour), 300, 20*time.Millisecond, 2000, 15),
+		trendReport(day(2), 100, 10*time.Millisecond, 1000, 5),
+		trendReport(day(3), 200, 10*time.Millisecond, 1500, 0),
+	}
+
+	// without latency distribution the report only counts for the average
+	reports = append(reports, &Report{Date: day(3), Count: 200, Average: 20 * time.Millisecond, Rps: 500})
+
+	trend := NewTrend(reports, TrendOptions{})
+
+	assert.Equal(t, TrendIntervalDay, trend.Interval)
+	assert.Equal(t, uint(4), trend.Reports)
+	assert.Empty(t, trend.ChangePoints)
+
+	if assert.Len(t, trend.Buckets, 2) {
+		b := trend.Buckets[0]
+		assert.Equal(t, time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC), b.Start)
+		assert.Equal(t, uint(2), b.Reports)
+		assert.Equal(t, uint64(400), b.Count)
+		assert.Equal(t, 17500*time.Microsecond, b.Average)
+		assert.Equal(t, 17500*time.Microsecond, b.P50)
+		assert.Equal(t, 35*time.Millisecond, b.P95)
+		assert.Equal(t, 52500*time.Microsecond, b.P99)
+		assert.Equal(t, 1500.0, b.Rps)
+		assert.Equal(t, 0.05, b.ErrorRate)
+
+		b = trend.Buckets[1]
+		assert.Equal(t, time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC), b.Start)
+		assert.Equal(t, uint(2), b.Reports)
+		assert.Equal(t, uint64(400), b.Count)
+		assert.Equal(t, 15*time.Millisecond, b.Average)
+		assert.Equal(t, 10*time.Millisecond, b.P50)
+		assert.Equal(t, 20*time.Millisecond, b.P95)
+		assert.Equal(t, 30*time.Millisecond, b.P99)
+		assert.Equal(t, 1000.0, b.Rps)
+		assert.Zero(t, b.ErrorRate)
+	}
+}
+
+func TestNewTrend_Intervals(t *testing.T) {
+	// Wednesday, in UTC-5
+	date := time.Date(2020, 3, 4, 22, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	var tests = []struct {
+		interval TrendInterval
+		expected time.Time
+	}{
+		{TrendIntervalHour, time.Date(2020, 3, 5, 3, 0, 0, 0, time.UTC)},
+		{TrendIntervalDay, time.Date(2020, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{TrendIntervalWeek, time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{TrendIntervalMonth, time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.interval), func(t *testing.T) {
+			trend := NewTrend([]*Report{trendReport(date, 100, time.Millisecond, 100, 0)}, TrendOptions{Interval: tt.interval})
+
+			assert.Equal(t, tt.interval, trend.Interval)
+			if assert.Len(t, trend.Buckets, 1) {
+				assert.Equal(t, tt.expected, trend.Buckets[0].Start)
+			}
+		})
+	}
+}
+
+func TestNewTrend_ChangePoints(t *testing.T) {
+	reports := []*Report{
+		trendReport(day(1), 100, 10*time.Millisecond, 1000, 0),
+		trendReport(day(2), 100, 10*time.Millisecond, 1000, 0),
+		trendReport(day(3), 100, 10*time.Millisecond, 1000, 0),
+		trendReport(day(4), 100, 10*time.Millisecond, 1000, 0),
+		trendReport(day(5), 100, 10*time.Millisecond, 1000, 0),
+		// below the threshold
+		trendReport(day(6), 100, 10500*time.Microsecond, 1000, 0),
+		// slower, and reported only once
+		trendReport(day(7), 100, 15*time.Millisecond, 800, 0),
+		trendReport(day(8), 100, 15*time.Millisecond, 800, 0),
+		// errors, from a zero baseline
+		trendReport(day(9), 100, 15*time.Millisecond, 800, 10),
+		// faster
+		trendReport(day(10), 100, 15*time.Millisecond, 1200, 0),
+		// no latency distribution
+		{Date: day(11), Count: 100, Average: 15 * time.Millisecond, Rps: 1200},
+	}
+
+	trend := NewTrend(reports, TrendOptions{})
+
+	assert.Len(t, trend.Buckets, 11)
+
+	expected := []struct {
+		metric     string
+		day        int
+		baseline   float64
+		value      float64
+		change     float64
+		regression bool
+	}{
+		{"average", 7, float64(10100 * time.Microsecond), float64(15 * time.Millisecond), 0.4851, true},
+		{"p50", 7, float64(10100 * time.Microsecond), float64(15 * time.Millisecond), 0.4851, true},
+		{"p95", 7, float64(20200 * time.Microsecond), float64(30 * time.Millisecond), 0.4851, true},
+		{"p99", 7, float64(30300 * time.Microsecond), float64(45 * time.Millisecond), 0.4851, true},
+		{"rps", 7, 1000, 800, -0.2, true},
+		{"errorRate", 9, 0, 0.1, 1, true},
+		{"rps", 10, 800, 1200, 0.5, false},
+	}
+
+	if assert.Len(t, trend.ChangePoints, len(expected)) {
+		for i, e := range expected {
+			cp := trend.ChangePoints[i]
+			assert.Equal(t, e.metric, cp.Metric)
+			assert.Equal(t, time.Date(2020, 3, e.day, 0, 0, 0, 0, time.UTC), cp.Start, e.metric)
+			assert.InDelta(t, e.baseline, cp.Baseline, 1, e.metric)
+			assert.InDelta(t, e.value, cp.Value, 1, e.metric)
+			assert.InDelta(t, e.change, cp.Change, 0.0001, e.metric)
+			assert.Equal(t, e.regression, cp.Regression, e.metric)
+		}
+	}
+
+	t.Run("threshold", func(t *testing.T) {
+		trend := NewTrend(reports, TrendOptions{Threshold: 0.6})
+
+		if assert.Len(t, trend.ChangePoints, 1) {
+			assert.Equal(t, "errorRate", trend.ChangePoints[0].Metric)
+		}
+	})
+
+	t.Run("noise", func(t *testing.T) {
+		noisy := []*Report{
+			trendReport(day(1), 100, 10*time.Millisecond, 1000, 0),
+			trendReport(day(2), 100, 14*time.Millisecond, 1000, 0),
+			trendReport(day(3), 100, 9*time.Millisecond, 1000, 0),
+			trendReport(day(4), 100, 15*time.Millisecond, 1000, 0),
+			trendReport(day(5), 100, 11*time.Millisecond, 1000, 0),
+			trendReport(day(6), 100, 14*time.Millisecond, 1000, 0),
+		}
+
+		trend := NewTrend(noisy, TrendOptions{})
+
+		assert.Empty(t, trend.ChangePoints)
+	})
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WebhookFormat is the format of the webhook request body
+type WebhookFormat string
+
+const (
+	// WebhookFormatJSON posts the event as JSON
+	WebhookFormatJSON = WebhookFormat("json")
+
+	// WebhookFormatSlack posts a Slack compatible message
+	WebhookFormatSlack = WebhookFormat("slack")
+
+	// WebhookFormatTemplate posts the body rendered using the webhook template
+	WebhookFormatTemplate = WebhookFormat("template")
+)
+
+const (
+	// WebhookEventReport is fired when a report is ingested into the project
+	WebhookEventReport = "report"
+
+	// WebhookEventStatus is fired when the status of the project changes
+	WebhookEventStatus = "status"
+)
+
+// StringList is a slice of strings
+type StringList []string
+
+// Value converts struct to a database value
+func (sl StringList) Value() (driver.Value, error) {
+	v, err := json.Marshal(sl)
+	if err != nil {
+		return nil, err
+	}
+	return string(v), nil
+}
+
+// Scan converts database value to a struct
+func (sl *StringList) Scan(src interface{}) error {
+	var sourceStr string
+	sourceByte, ok := src.([]byte)
+	if !ok {
+		sourceStr, ok = src.(string)
+		if !ok {
+			return errors.New("type assertion from string / byte")
+		}
+		sourceByte = []byte(sourceStr)
+	}
+
+	return json.Unmarshal(sourceByte, sl)
+}
+
+// Webhook represents an outbound webhook of a project
+type Webhook struct {
+	Model
+
+	ProjectID uint     `json:"projectID" gorm:"type:integer REFERENCES projects(id) ON DELETE CASCADE;not null"`
+	Project   *Project `json:"-"`
+
+	URL    string        `json:"url" gorm:"not null"`
+	Format WebhookFormat `json:"format" gorm:"not null"`
+
+	// Template is the Go template of the request body of the template format
+	Template string `json:"template,omitempty" gorm:"type:TEXT"`
+
+	// Events are the events the webhook is fired for
+	Events StringList `json:"events" gorm:"type:TEXT"`
+
+	// Secret is the key the request body is signed with, not returned by the API
+	Secret string `json:"-"`
+}
+
+// BeforeSave is called by GORM before save
+func (w *Webhook) BeforeSave(scope *gorm.Scope) error {
+	if w.ProjectID == 0 && w.Project == nil {
+		return errors.New("Webhook must belong to a project")
+	}
+
+	w.URL = strings.TrimSpace(w.URL)
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Webhook URL must be an absolute http or https URL")
+	}
+
+	if string(w.Format) == "" {
+		w.Format = WebhookFormatJSON
+	}
+
+	switch w.Format {
+	case WebhookFormatJSON, WebhookFormatSlack:
+	case WebhookFormatTemplate:
+		if _, err := template.New("webhook").Parse(w.Template); err != nil {
+			return fmt.Errorf("Invalid webhook template: %v", err)
+		}
+	default:
+		return errors.New("Webhook format must be json, slack or template")
+	}
+
+	if len(w.Events) == 0 {
+		return errors.New("Webhook must have at least one event")
+	}
+
+	for _, e := range w.Events {
+		if e != WebhookEventReport && e != WebhookEventStatus {
+			return fmt.Errorf("Invalid webhook event %q", e)
+		}
+	}
+
+	return nil
+}
+
+// HasEvent returns whether the webhook is fired for the event
+func (w *Webhook) HasEvent(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is the log of a webhook request, including its retries
+type WebhookDelivery struct {
+	Model
+
+	WebhookID uint     `json:"webhookID" gorm:"type:integer REFERENCES webhooks(id) ON DELETE CASCADE;not null"`
+	Webhook   *Webhook `json:"-"`
+
+	ReportID uint   `json:"reportID"`
+	Event    string `json:"event"`
+
+	// Attempts is the number of requests made
+	Attempts int `json:"attempts"`
+
+	// StatusCode is the response status code of the last attempt, zero if there was no response
+	StatusCode int `json:"statusCode"`
+
+	// Error of the last attempt, empty if the delivery succeeded
+	Error   string `json:"error,omitempty"`
+	Success bool   `json:"success"`
+
+	Payload string `json:"payload" gorm:"type:TEXT"`
+}
+
+// BeforeSave is called by GORM before save
+func (d *WebhookDelivery) BeforeSave(scope *gorm.Scope) error {
+	if d.WebhookID == 0 && d.Webhook == nil {
+		return errors.New("Webhook delivery must belong to a webhook")
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_BeforeSave(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := gorm.Open("sqlite3", dbName)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	db.AutoMigrate(&Project{}, &Webhook{})
+	db.Exec("PRAGMA foreign_keys = ON;")
+
+	p := Project{Name: "Webhook project"}
+	assert.NoError(t, db.Create(&p).Error)
+
+	var tests = []struct {
+		name     string
+		webhook  Webhook
+		expected string
+	}{
+		{"default format", Webhook{URL: " https://example.com/hook ", Events: StringList{WebhookEventReport}}, ""},
+		{"slack", Webhook{URL: "https://hooks.slack.com/services/T0/B0/X", Format: WebhookFormatSlack, Events: StringList{WebhookEventStatus}}, ""},
+		{"template", Webhook{URL: "http://localhost:8080", Format: WebhookFormatTemplate, Template: `{"name":"{{ .Project.Name }}"}`, Events: StringList{WebhookEventReport, WebhookEventStatus}}, ""},
+		{"relative url", Webhook{URL: "/hook", Events: StringList{WebhookEventReport}}, "Webhook URL must be an absolute http or https URL"},
+		{"invalid scheme", Webhook{URL: "ftp://example.com", Events: StringList{WebhookEventReport}}, "Webhook URL must be an absolute http or https URL"},
+		{"invalid format", Webhook{URL: "https://example.com", Format: WebhookFormat("xml"), Events: StringList{WebhookEventReport}}, "Webhook format must be json, slack or template"},
+		{"invalid template", Webhook{URL: "https://example.com", Format: WebhookFormatTemplate, Template: "{{ .Project", Events: StringList{WebhookEventReport}}, "Invalid webhook template: template: webhook:1: unclosed action"},
+		{"no events", Webhook{URL: "https://example.com"}, "Webhook must have at least one event"},
+		{"invalid event", Webhook{URL: "https://example.com", Events: StringList{"deleted"}}, `Invalid webhook event "deleted"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.webhook
+			w.ProjectID = p.ID
+
+			err := db.Create(&w).Error
+			if tt.expected != "" {
+				assert.EqualError(t, err, tt.expected)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotZero(t, w.ID)
+
+			w2 := new(Webhook)
+			assert.NoError(t, db.First(w2, w.ID).Error)
+			assert.Equal(t, tt.webhook.Events, w2.Events)
+			assert.NotEmpty(t, w2.Format)
+			assert.Equal(t, w.URL, w2.URL)
+		})
+	}
+
+	t.Run("no project", func(t *testing.T) {
+		err := db.Create(&Webhook{URL: "https://example.com", Events: StringList{WebhookEventReport}}).Error
+		assert.EqualError(t, err, "Webhook must belong to a project")
+	})
+}
+
+func TestWebhook_HasEvent(t *testing.T) {
+	w := Webhook{Events: StringList{WebhookEventStatus}}
+	assert.True(t, w.HasEvent(WebhookEventStatus))
+	assert.False(t, w.HasEvent(WebhookEventReport))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/bojand/ghz/web/api"
 	"github.com/bojand/ghz/web/config"
 	"github.com/bojand/ghz/web/database"
+	"github.com/bojand/ghz/web/webhook"
 	"github.com/rakyll/statik/fs"
 
 	"github.com/go-playground/validator"
@@ -42,12 +43,19 @@ func New(db *database.Database, appInfo *api.ApplicationInfo, conf *config.Confi
 		},
 	}))
 
-	s.Use(middleware.CORS())
+	s.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:  conf.CORS.Origins,
+		ExposeHeaders: []string{api.HeaderIdempotentReplayed},
+	}))
 
 	s.Use(middleware.RequestID())
 	s.Use(middleware.Logger())
 	s.Use(middleware.Recover())
 
+	// Auth
+
+	read, ingest, admin := authMiddleware(db, conf)
+
 	// API
 
 	apiRoot := s.Group("/api")
@@ -58,42 +66,67 @@ func New(db *database.Database, appInfo *api.ApplicationInfo, conf *config.Confi
 
 	projectAPI := api.ProjectAPI{DB: db}
 
-	projectGroup.GET("/", projectAPI.ListProjects).Name = "ghz api: list projects"
-	projectGroup.POST("/", projectAPI.CreateProject).Name = "ghz api: create project"
-	projectGroup.GET("/:pid/", projectAPI.GetProject).Name = "ghz api: get project"
-	projectGroup.PUT("/:pid/", projectAPI.UpdateProject).Name = "ghz api: update project"
-	projectGroup.DELETE("/:pid/", projectAPI.DeleteProject).Name = "ghz api: delete project"
+	projectGroup.GET("/", projectAPI.ListProjects, read).Name = "ghz api: list projects"
+	projectGroup.POST("/", projectAPI.CreateProject, admin).Name = "ghz api: create project"
+	projectGroup.GET("/:pid/", projectAPI.GetProject, read).Name = "ghz api: get project"
+	projectGroup.PUT("/:pid/", projectAPI.UpdateProject, admin).Name = "ghz api: update project"
+	projectGroup.DELETE("/:pid/", projectAPI.DeleteProject, admin).Name = "ghz api: delete project"
 
 	// Reports by Project
 
 	reportAPI := api.ReportAPI{DB: db}
-	projectGroup.GET("/:pid/reports/", reportAPI.ListReportsForProject).Name = "ghz api: list reports for project"
+	projectGroup.GET("/:pid/reports/", reportAPI.ListReportsForProject, read).Name = "ghz api: list reports for project"
+
+	// Trends by Project
+
+	trendAPI := api.TrendAPI{DB: db}
+	projectGroup.GET("/:pid/trends/", trendAPI.GetTrend, read).Name = "ghz api: get trend for project"
 
 	// Reports
 
 	reportGroup := apiRoot.Group("/reports")
-	reportGroup.GET("/", reportAPI.ListReportsAll).Name = "ghz api: list all reports"
-	reportGroup.GET("/:rid/", reportAPI.GetReport).Name = "ghz api: get report"
-	reportGroup.DELETE("/:rid/", reportAPI.DeleteReport).Name = "ghz api: delete report"
-	reportGroup.GET("/:rid/previous/", reportAPI.GetPreviousReport).Name = "ghz api: get previous report"
-	reportGroup.POST("/bulk_delete/", reportAPI.DeleteReportBulk).Name = "ghz api: delete bulk report"
+	reportGroup.GET("/", reportAPI.ListReportsAll, read).Name = "ghz api: list all reports"
+	reportGroup.GET("/:rid/", reportAPI.GetReport, read).Name = "ghz api: get report"
+	reportGroup.DELETE("/:rid/", reportAPI.DeleteReport, admin).Name = "ghz api: delete report"
+	reportGroup.GET("/:rid/previous/", reportAPI.GetPreviousReport, read).Name = "ghz api: get previous report"
+	reportGroup.POST("/bulk_delete/", reportAPI.DeleteReportBulk, admin).Name = "ghz api: delete bulk report"
 
 	optionsAPI := api.OptionsAPI{DB: db}
-	reportGroup.GET("/:rid/options/", optionsAPI.GetOptions).Name = "ghz api: get options"
+	reportGroup.GET("/:rid/options/", optionsAPI.GetOptions, read).Name = "ghz api: get options"
 
 	histogramAPI := api.HistogramAPI{DB: db}
-	reportGroup.GET("/:rid/histogram/", histogramAPI.GetHistogram).Name = "ghz api: get histogram"
+	reportGroup.GET("/:rid/histogram/", histogramAPI.GetHistogram, read).Name = "ghz api: get histogram"
 
 	exportAPI := api.ExportAPI{DB: db}
-	reportGroup.GET("/:rid/export/", exportAPI.GetExport).Name = "ghz api: get export"
+	reportGroup.GET("/:rid/export/", exportAPI.GetExport, read).Name = "ghz api: get export"
 
 	// Ingest
 
-	ingestAPI := api.IngestAPI{DB: db}
-	apiRoot.POST("/ingest/", ingestAPI.Ingest).Name = "ghz api: ingest"
+	ingestAPI := api.IngestAPI{DB: db, Notifier: webhook.New(db)}
+	apiRoot.POST("/ingest/", ingestAPI.Ingest, admin).Name = "ghz api: ingest"
 
 	// Ingest to project
-	projectGroup.POST("/:pid/ingest/", ingestAPI.IngestToProject).Name = "ghz api: ingest to project"
+	projectGroup.POST("/:pid/ingest/", ingestAPI.IngestToProject, ingest).Name = "ghz api: ingest to project"
+
+	// Webhooks by Project
+
+	webhookAPI := api.WebhookAPI{DB: db}
+	projectGroup.GET("/:pid/webhooks/", webhookAPI.ListWebhooks, admin).Name = "ghz api: list webhooks for project"
+	projectGroup.POST("/:pid/webhooks/", webhookAPI.CreateWebhook, admin).Name = "ghz api: create webhook"
+	projectGroup.GET("/:pid/webhooks/:wid/", webhookAPI.GetWebhook, admin).Name = "ghz api: get webhook"
+	projectGroup.PUT("/:pid/webhooks/:wid/", webhookAPI.UpdateWebhook, admin).Name = "ghz api: update webhook"
+	projectGroup.DELETE("/:pid/webhooks/:wid/", webhookAPI.DeleteWebhook, admin).Name = "ghz api: delete webhook"
+	projectGroup.GET("/:pid/webhooks/:wid/deliveries/", webhookAPI.ListDeliveries, admin).Name = "ghz api: list webhook deliveries"
+
+	// Tokens
+
+	tokenGroup := apiRoot.Group("/tokens")
+
+	tokenAPI := api.TokenAPI{DB: db}
+
+	tokenGroup.GET("/", tokenAPI.ListTokens, admin).Name = "ghz api: list tokens"
+	tokenGroup.POST("/", tokenAPI.CreateToken, admin).Name = "ghz api: create token"
+	tokenGroup.DELETE("/:tid/", tokenAPI.DeleteToken, admin).Name = "ghz api: delete token"
 
 	// Info
 
@@ -136,11 +169,31 @@ func New(db *database.Database, appInfo *api.ApplicationInfo, conf *config.Confi
 		// otherwise serve the index file
 		// React router will handle the path from there on
 		return ctx.HTML(200, string(indexFile))
-	})
+	}, admin)
 
 	return s, nil
 }
 
+// authMiddleware returns the middlewares requiring read, ingest and admin access to the API.
+// If auth is not enabled the requests are let through.
+func authMiddleware(db *database.Database, conf *config.Config) (read, ingest, admin echo.MiddlewareFunc) {
+	if !conf.Auth.Enabled {
+		noAuth := func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+
+		return noAuth, noAuth, noAuth
+	}
+
+	auth := &api.Auth{
+		DB:       db,
+		Username: conf.Auth.Admin.Username,
+		Password: conf.Auth.Admin.Password,
+	}
+
+	return auth.Require(api.AccessRead), auth.Require(api.AccessIngest), auth.Require(api.AccessAdmin)
+}
+
 // CustomValidator is our validator for the API
 type CustomValidator struct {
 	validator *validator.Validate
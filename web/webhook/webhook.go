@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/bojand/ghz/web/model"
+)
+
+const (
+	// HeaderEvent is the request header with the event the webhook is fired for
+	HeaderEvent = "X-Ghz-Event"
+
+	// HeaderSignature is the request header with the HMAC SHA256 signature of the request body,
+	// computed using the webhook secret and sent as "sha256=<hex digest>"
+	HeaderSignature = "X-Ghz-Signature"
+)
+
+// Database interface for encapsulating database access.
+type Database interface {
+	ListWebhooksForProject(uint) ([]*model.Webhook, error)
+	CreateWebhookDelivery(*model.WebhookDelivery) error
+	UpdateWebhookDelivery(*model.WebhookDelivery) error
+}
+
+// Event is the payload of the json format, and the data of the template format
+type Event struct {
+	Event          string         `json:"event"`
+	Project        *model.Project `json:"project"`
+	Report         *model.Report  `json:"report"`
+	PreviousStatus model.Status   `json:"previousStatus"`
+}
+
+// Notifier fires the webhooks of the projects when reports are ingested. The requests are sent
+// in the background and retried, and each delivery is logged in the database.
+type Notifier struct {
+	DB     Database
+	Client *http.Client
+
+	// Attempts is the maximum number of requests made for a delivery
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled for each further retry
+	Backoff time.Duration
+
+	wg sync.WaitGroup
+}
+
+// New creates a new notifier with the default settings
+func New(db Database) *Notifier {
+	return &Notifier{
+		DB:       db,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Attempts: 3,
+		Backoff:  time.Second,
+	}
+}
+
+// ReportIngested fires the webhooks of the project for the ingested report, and for the
+// project status change if the status differs from the previous one.
+func (n *Notifier) ReportIngested(p *model.Project, r *model.Report, previousStatus model.Status) {
+	hooks, err := n.DB.ListWebhooksForProject(p.ID)
+	if err != nil || len(hooks) == 0 {
+		return
+	}
+
+	events := []string{model.WebhookEventReport}
+	if p.Status != previousStatus {
+		events = append(events, model.WebhookEventStatus)
+	}
+
+	for _, event := range events {
+		ev := &Event{
+			Event:          event,
+			Project:        p,
+			Report:         r,
+			PreviousStatus: previousStatus,
+		}
+
+		for _, hook := range hooks {
+			if !hook.HasEvent(event) {
+				continue
+			}
+
+			delivery := &model.WebhookDelivery{
+				WebhookID: hook.ID,
+				ReportID:  r.ID,
+				Event:     event,
+			}
+
+			// the payload is rendered right away as the project and report may change afterwards
+			body, err := render(hook, ev)
+			if err != nil {
+				delivery.Error = err.Error()
+				_ = n.DB.CreateWebhookDelivery(delivery)
+				continue
+			}
+
+			delivery.Payload = string(body)
+
+			n.wg.Add(1)
+			go func(hook *model.Webhook) {
+				defer n.wg.Done()
+
+				n.deliver(hook, delivery, body)
+			}(hook)
+		}
+	}
+}
+
+// Wait waits for the pending deliveries to finish
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// deliver sends the webhook request, retrying on errors. The delivery is logged before the
+// first attempt and updated after each attempt, so pending deliveries are logged as well.
+func (n *Notifier) deliver(hook *model.Webhook, delivery *model.WebhookDelivery, body []byte) {
+	_ = n.DB.CreateWebhookDelivery(delivery)
+
+	backoff := n.Backoff
+
+	for attempt := 1; attempt <= n.Attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		delivery.Attempts = attempt
+
+		retry, err := n.send(hook, delivery, body)
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+		} else {
+			delivery.Error = err.Error()
+		}
+
+		_ = n.DB.UpdateWebhookDelivery(delivery)
+
+		if err == nil || !retry {
+			break
+		}
+	}
+}
+
+// send makes a single webhook request, and returns whether it can be retried if it failed
+func (n *Notifier) send(hook *model.Webhook, delivery *model.WebhookDelivery, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ghz-web")
+	req.Header.Set(HeaderEvent, delivery.Event)
+
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, body))
+	}
+
+	res, err := n.Client.Do(req)
+	if err != nil {
+		delivery.StatusCode = 0
+		return true, err
+	}
+
+	defer res.Body.Close()
+
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	delivery.StatusCode = res.StatusCode
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+
+	return retry, fmt.Errorf("unexpected response status %s", res.Status)
+}
+
+// Sign returns the hex encoded HMAC SHA256 signature of the body using the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// render renders the request body of the webhook for the event
+func render(hook *model.Webhook, ev *Event) ([]byte, error) {
+	switch hook.Format {
+	case model.WebhookFormatSlack:
+		return json.Marshal(map[string]string{"text": slackText(ev)})
+	case model.WebhookFormatTemplate:
+		tmpl, err := template.New("webhook").Parse(hook.Template)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, ev); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return json.Marshal(ev)
+	}
+}
+
+// slackText returns the text of the Slack message for the event
+func slackText(ev *Event) string {
+	p, r := ev.Project, ev.Report
+
+	lines := make([]string, 0, 3)
+
+	if ev.Event == model.WebhookEventStatus {
+		lines = append(lines, fmt.Sprintf("Project *%s* status changed from %s to *%s*",
+			p.Name, strings.ToUpper(string(ev.PreviousStatus)), strings.ToUpper(string(p.Status))))
+	} else {
+		lines = append(lines, fmt.Sprintf("Report *%s* ingested into project *%s* with status *%s*",
+			reportName(r), p.Name, strings.ToUpper(string(r.Status))))
+	}
+
+	lines = append(lines, fmt.Sprintf("Count: %d, Average: %v, Slowest: %v, RPS: %.2f",
+		r.Count, r.Average, r.Slowest, r.Rps))
+
+	if len(r.Violations) > 0 {
+		thresholds := make([]string, len(r.Violations))
+		for i, v := range r.Violations {
+			thresholds[i] = v.Threshold
+		}
+
+		lines = append(lines, "Violated thresholds: "+strings.Join(thresholds, ", "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func reportName(r *model.Report) string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	return fmt.Sprintf("#%d", r.ID)
+}
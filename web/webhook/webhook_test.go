@@ -0,0 +1,332 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bojand/ghz/web/database"
+	"github.com/bojand/ghz/web/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const dbName = "../test/webhook_test.db"
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver records the webhook requests and responds with the status codes in order
+type receiver struct {
+	mu       sync.Mutex
+	requests []request
+	statuses []int
+
+	// onRequest is called for each request before responding
+	onRequest func()
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	status := http.StatusOK
+	if n := len(rc.requests); n < len(rc.statuses) {
+		status = rc.statuses[n]
+	}
+
+	rc.requests = append(rc.requests, request{header: r.Header, body: body})
+
+	if rc.onRequest != nil {
+		rc.onRequest()
+	}
+
+	w.WriteHeader(status)
+}
+
+func TestNotifier(t *testing.T) {
+	os.Remove(dbName)
+
+	defer os.Remove(dbName)
+
+	db, err := database.New("sqlite3", dbName, false)
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	defer db.Close()
+
+	p := &model.Project{Name: "Webhook project", Status: model.StatusFail}
+	assert.NoError(t, db.CreateProject(p))
+
+	r := &model.Report{
+		ProjectID: p.ID,
+		Name:      "Greeter SayHello",
+		Count:     200,
+		Average:   10 * time.Millisecond,
+		Rps:       1000,
+		Status:    model.StatusFail,
+		Violations: model.ViolationList{
+			{Threshold: "p95", Limit: float64(50 * time.Millisecond), Actual: float64(60 * time.Millisecond)},
+		},
+	}
+	assert.NoError(t, db.CreateReport(r))
+
+	newNotifier := func() *Notifier {
+		n := New(db)
+		n.Backoff = time.Millisecond
+		return n
+	}
+
+	newWebhook := func(rc *receiver, w *model.Webhook) (*model.Webhook, func()) {
+		server := httptest.NewServer(rc)
+
+		w.ProjectID = p.ID
+		w.URL = server.URL
+		assert.NoError(t, db.CreateWebhook(w))
+
+		return w, func() {
+			server.Close()
+			assert.NoError(t, db.DeleteWebhook(w))
+		}
+	}
+
+	deliveries := func(w *model.Webhook) []*model.WebhookDelivery {
+		ds, err := db.ListDeliveriesForWebhook(w.ID, 10)
+		assert.NoError(t, err)
+		return ds
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rc := &receiver{}
+		w, done := newWebhook(rc, &model.Webhook{
+			Format: model.WebhookFormatJSON,
+			Events: model.StringList{model.WebhookEventReport, model.WebhookEventStatus},
+			Secret: "secret",
+		})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusOK)
+		n.Wait()
+
+		if !assert.Len(t, rc.requests, 2) {
+			return
+		}
+
+		events := map[string]*Event{}
+		for _, req := range rc.requests {
+			assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+			assert.Equal(t, "sha256="+Sign("secret", req.body), req.header.Get(HeaderSignature))
+
+			ev := new(Event)
+			assert.NoError(t, json.Unmarshal(req.body, ev))
+			assert.Equal(t, req.header.Get(HeaderEvent), ev.Event)
+
+			events[ev.Event] = ev
+		}
+
+		if assert.Contains(t, events, model.WebhookEventStatus) {
+			ev := events[model.WebhookEventStatus]
+			assert.Equal(t, p.ID, ev.Project.ID)
+			assert.Equal(t, model.StatusFail, ev.Project.Status)
+			assert.Equal(t, model.StatusOK, ev.PreviousStatus)
+			assert.Equal(t, r.ID, ev.Report.ID)
+		}
+
+		assert.Contains(t, events, model.WebhookEventReport)
+
+		ds := deliveries(w)
+		if assert.Len(t, ds, 2) {
+			for _, d := range ds {
+				assert.True(t, d.Success)
+				assert.Equal(t, 1, d.Attempts)
+				assert.Equal(t, http.StatusOK, d.StatusCode)
+				assert.Equal(t, r.ID, d.ReportID)
+				assert.Empty(t, d.Error)
+				assert.NotEmpty(t, d.Payload)
+			}
+		}
+	})
+
+	t.Run("status unchanged", func(t *testing.T) {
+		rc := &receiver{}
+		_, done := newWebhook(rc, &model.Webhook{Events: model.StringList{model.WebhookEventStatus}})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusFail)
+		n.Wait()
+
+		assert.Empty(t, rc.requests)
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		rc := &receiver{}
+		_, done := newWebhook(rc, &model.Webhook{
+			Format: model.WebhookFormatSlack,
+			Events: model.StringList{model.WebhookEventStatus},
+		})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusOK)
+		n.Wait()
+
+		if assert.Len(t, rc.requests, 1) {
+			assert.Empty(t, rc.requests[0].header.Get(HeaderSignature))
+
+			msg := map[string]string{}
+			assert.NoError(t, json.Unmarshal(rc.requests[0].body, &msg))
+			assert.Equal(t, "Project *Webhook project* status changed from OK to *FAIL*\n"+
+				"Count: 200, Average: 10ms, Slowest: 0s, RPS: 1000.00\n"+
+				"Violated thresholds: p95", msg["text"])
+		}
+	})
+
+	t.Run("template", func(t *testing.T) {
+		rc := &receiver{}
+		_, done := newWebhook(rc, &model.Webhook{
+			Format:   model.WebhookFormatTemplate,
+			Template: `{"summary":"{{ .Project.Name }} {{ .Event }} {{ .Report.Status }}"}`,
+			Events:   model.StringList{model.WebhookEventReport},
+		})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusOK)
+		n.Wait()
+
+		if assert.Len(t, rc.requests, 1) {
+			assert.Equal(t, `{"summary":"Webhook project report fail"}`, string(rc.requests[0].body))
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+		w, done := newWebhook(rc, &model.Webhook{Events: model.StringList{model.WebhookEventReport}})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusFail)
+		n.Wait()
+
+		assert.Len(t, rc.requests, 3)
+
+		ds := deliveries(w)
+		if assert.Len(t, ds, 1) {
+			assert.True(t, ds[0].Success)
+			assert.Equal(t, 3, ds[0].Attempts)
+			assert.Equal(t, http.StatusOK, ds[0].StatusCode)
+			assert.Empty(t, ds[0].Error)
+		}
+	})
+
+	t.Run("logged before each attempt", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+		w, done := newWebhook(rc, &model.Webhook{Events: model.StringList{model.WebhookEventReport}})
+		defer done()
+
+		var pending []model.WebhookDelivery
+		rc.onRequest = func() {
+			for _, d := range deliveries(w) {
+				pending = append(pending, *d)
+			}
+		}
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusFail)
+		n.Wait()
+
+		if assert.Len(t, pending, 2) {
+			assert.Equal(t, 0, pending[0].Attempts)
+			assert.False(t, pending[0].Success)
+			assert.NotEmpty(t, pending[0].Payload)
+
+			assert.Equal(t, pending[0].ID, pending[1].ID)
+			assert.Equal(t, 1, pending[1].Attempts)
+			assert.Equal(t, http.StatusServiceUnavailable, pending[1].StatusCode)
+			assert.Equal(t, "unexpected response status 503 Service Unavailable", pending[1].Error)
+		}
+
+		ds := deliveries(w)
+		if assert.Len(t, ds, 1) {
+			assert.True(t, ds[0].Success)
+			assert.Equal(t, 2, ds[0].Attempts)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}}
+		w, done := newWebhook(rc, &model.Webhook{Events: model.StringList{model.WebhookEventReport}})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusFail)
+		n.Wait()
+
+		assert.Len(t, rc.requests, 3)
+
+		ds := deliveries(w)
+		if assert.Len(t, ds, 1) {
+			assert.False(t, ds[0].Success)
+			assert.Equal(t, 3, ds[0].Attempts)
+			assert.Equal(t, http.StatusInternalServerError, ds[0].StatusCode)
+			assert.Equal(t, "unexpected response status 500 Internal Server Error", ds[0].Error)
+		}
+	})
+
+	t.Run("client error is not retried", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusBadRequest}}
+		w, done := newWebhook(rc, &model.Webhook{Events: model.StringList{model.WebhookEventReport}})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusFail)
+		n.Wait()
+
+		assert.Len(t, rc.requests, 1)
+
+		ds := deliveries(w)
+		if assert.Len(t, ds, 1) {
+			assert.False(t, ds[0].Success)
+			assert.Equal(t, 1, ds[0].Attempts)
+			assert.Equal(t, http.StatusBadRequest, ds[0].StatusCode)
+		}
+	})
+
+	t.Run("template error", func(t *testing.T) {
+		rc := &receiver{}
+		w, done := newWebhook(rc, &model.Webhook{
+			Format:   model.WebhookFormatTemplate,
+			Template: `{{ .Unknown }}`,
+			Events:   model.StringList{model.WebhookEventReport},
+		})
+		defer done()
+
+		n := newNotifier()
+		n.ReportIngested(p, r, model.StatusFail)
+		n.Wait()
+
+		assert.Empty(t, rc.requests)
+
+		ds := deliveries(w)
+		if assert.Len(t, ds, 1) {
+			assert.False(t, ds[0].Success)
+			assert.Zero(t, ds[0].Attempts)
+			assert.Contains(t, ds[0].Error, "can't evaluate field Unknown")
+		}
+	})
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		Sign("key", []byte("The quick brown fox jumps over the lazy dog")))
+}